@@ -0,0 +1,56 @@
+// Package buildinfo holds the version metadata embedded at build time with ldflags:
+//
+//	PKG=github.com/ilya-pishchalnikov/grafana-provisioner/buildinfo
+//	go build -ldflags "-X $PKG.Version=v1.2.3 -X $PKG.Commit=$(git rev-parse HEAD) -X $PKG.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package buildinfo
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Name of the tool used in the User-Agent and in reports
+const Name = "grafana-provisioner"
+
+// Set with -ldflags "-X ..." at build time
+var (
+	Version = "dev"
+	Commit  = "unknown"
+	Date    = "unknown"
+)
+
+func init() {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	// `go install ...@v1.2.3` records the module version, builds from a checkout a pseudo-version or (devel)
+	if Version == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		Version = info.Main.Version
+	}
+
+	// Plain `go build` from a git checkout still records the revision
+	if Commit != "unknown" {
+		return
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			Commit = setting.Value
+		case "vcs.time":
+			if Date == "unknown" {
+				Date = setting.Value
+			}
+		}
+	}
+}
+
+// UserAgent returns the User-Agent of the tool, e.g. "grafana-provisioner/v1.2.3"
+func UserAgent() string {
+	return Name + "/" + Version
+}
+
+// String returns the full version line printed by the version command
+func String() string {
+	return fmt.Sprintf("%s %s (commit %s, built %s)", Name, Version, Commit, Date)
+}
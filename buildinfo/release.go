@@ -0,0 +1,86 @@
+package buildinfo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LatestReleaseURL is the GitHub releases API endpoint of the latest published release
+const LatestReleaseURL = "https://api.github.com/repos/ilya-pishchalnikov/grafana-provisioner/releases/latest"
+
+// Release is a published release of the tool
+type Release struct {
+	Tag         string    `json:"tag_name"`
+	URL         string    `json:"html_url"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// LatestRelease fetches the latest published release from the GitHub releases API
+func LatestRelease(url string) (*Release, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create release request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", UserAgent())
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the latest release: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch the latest release: %s", resp.Status)
+	}
+
+	var release Release
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to decode the latest release: %w", err)
+	}
+	return &release, nil
+}
+
+// IsNewer reports whether the release tag is a newer semver version than the current one. Development builds
+// and versions that aren't `vX.Y.Z` are never older.
+func IsNewer(tag, current string) bool {
+	latest, ok := parseSemver(tag)
+	if !ok {
+		return false
+	}
+	running, ok := parseSemver(current)
+	if !ok {
+		return false
+	}
+	for i := range latest {
+		if latest[i] != running[i] {
+			return latest[i] > running[i]
+		}
+	}
+	return false
+}
+
+// parseSemver parses the major, minor and patch numbers of `vX.Y.Z`, ignoring pre-release and build suffixes
+func parseSemver(version string) ([3]int, bool) {
+	numbers := [3]int{}
+	version = strings.TrimPrefix(version, "v")
+	if end := strings.IndexAny(version, "-+"); end >= 0 {
+		version = version[:end]
+	}
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return numbers, false
+	}
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return numbers, false
+		}
+		numbers[i] = number
+	}
+	return numbers, true
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/config"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var ageRecipients []string
+
+var encryptCmd = &cobra.Command{
+	Use:   "encrypt [value]",
+	Short: "Encrypt a config value with age for use with the !age tag",
+	Long: `Encrypts the value (or stdin) to the given age recipients and prints it ready to paste
+into config.yaml, e.g. under 'token:'. The loader decrypts it with AGE_IDENTITY or AGE_IDENTITY_FILE.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runEncrypt,
+}
+
+var decryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Decrypt an armored age value read from stdin",
+	Args:  cobra.NoArgs,
+	RunE:  runDecrypt,
+}
+
+func init() {
+	encryptCmd.Flags().StringSliceVarP(&ageRecipients, "recipient", "r", nil, "age public key (age1...) to encrypt to, repeatable (env AGE_RECIPIENT)")
+	rootCmd.AddCommand(encryptCmd)
+	rootCmd.AddCommand(decryptCmd)
+}
+
+// runEncrypt prints the value encrypted as a YAML block for the !age tag
+func runEncrypt(cmd *cobra.Command, args []string) error {
+	recipients := ageRecipients
+	if len(recipients) == 0 && os.Getenv("AGE_RECIPIENT") != "" {
+		recipients = strings.Split(os.Getenv("AGE_RECIPIENT"), ",")
+	}
+	if len(recipients) == 0 {
+		return fmt.Errorf("no age recipient given, use --recipient or AGE_RECIPIENT")
+	}
+
+	var plaintext string
+	if len(args) == 1 {
+		plaintext = args[0]
+	} else {
+		data, err := io.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return fmt.Errorf("failed to read value from stdin: %w", err)
+		}
+		plaintext = strings.TrimRight(string(data), "\n")
+	}
+
+	armored, err := config.EncryptValue(plaintext, recipients)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "%s |\n", config.AgeTag)
+	for _, line := range strings.Split(strings.TrimRight(armored, "\n"), "\n") {
+		fmt.Fprintf(cmd.OutOrStdout(), "    %s\n", line)
+	}
+	return nil
+}
+
+// runDecrypt prints the plaintext of an armored value
+func runDecrypt(cmd *cobra.Command, args []string) error {
+	data, err := io.ReadAll(cmd.InOrStdin())
+	if err != nil {
+		return fmt.Errorf("failed to read value from stdin: %w", err)
+	}
+
+	identities, err := config.LoadAgeIdentities()
+	if err != nil {
+		return err
+	}
+
+	// Accept the value as printed by encrypt, with the tag and the indentation
+	armored := strings.TrimPrefix(strings.TrimSpace(string(data)), config.AgeTag+" |")
+	lines := []string{}
+	for _, line := range strings.Split(armored, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			lines = append(lines, trimmed)
+		}
+	}
+
+	plaintext, err := config.DecryptValue(strings.Join(lines, "\n"), identities)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt value: %w", err)
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), plaintext)
+	return nil
+}
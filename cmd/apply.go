@@ -0,0 +1,278 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/grafana"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var refsFile string
+
+// changelogFile keeps the report of the last run to print the changelog of the next one against
+var changelogFile string
+
+// configGlob selects many configs provisioned concurrently, parallel at a time
+var (
+	configGlob string
+	parallel   int
+)
+
+// pauseAlerts pauses the managed alert rules for the duration of the run
+var pauseAlerts bool
+
+// silenceAlerts silences the alerts of the managed alert rules for the run and silenceGrace after it
+var (
+	silenceAlerts bool
+	silenceGrace  time.Duration
+)
+
+// liveTail polls the Grafana health and admin stats during the run, attached to server errors
+var liveTail time.Duration
+
+// overrideWindow lets apply run outside the configured change window
+var overrideWindow bool
+
+// groupBy is the resource label the summary of the run is grouped by
+var groupBy string
+
+// dryRun plans the changes of the run and prints them instead of making them
+var dryRun bool
+
+// strictTokenScope fails the run when the token has more permissions than the config needs
+var strictTokenScope bool
+
+// updateSecrets sends the configured secrets of every matched data source, they can't be read back to compare
+var updateSecrets bool
+
+// dumpDir receives the payloads of dashboard imports rejected by Grafana
+var dumpDir string
+
+// summary prints the table of the changed resources at the end of the run
+var summary bool
+
+// shard applies one of several disjoint parts of the config, e.g. 2/5, for parallel CI jobs
+var (
+	shard       string
+	parsedShard *grafana.Shard
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Provision all configured resources into Grafana",
+	RunE:  runApply,
+}
+
+func init() {
+	for _, command := range []*cobra.Command{rootCmd, applyCmd} {
+		command.Flags().StringVar(&dumpDir, "dump-failed-imports", "", "write the payload of dashboard imports rejected by Grafana into this directory")
+		command.Flags().StringVar(&configGlob, "config-glob", "", "provision every config matching the glob (e.g. 'tenants/*/config.yaml') instead of --config")
+		command.Flags().IntVar(&parallel, "parallel", 4, "number of configs provisioned at once with --config-glob")
+		command.Flags().DurationVar(&liveTail, "live-tail", 0, "poll the Grafana health and admin stats at this interval (e.g. 2s) and attach them to server errors")
+		command.Flags().BoolVar(&overrideWindow, "override-window", false, "run outside the configured change_window")
+		command.Flags().BoolVar(&dryRun, "dry-run", false, "print the changes the run would make without changing Grafana (overrides dry_run)")
+		command.Flags().BoolVar(&strictTokenScope, "strict", false, "fail instead of warning when the token has more permissions than the config needs")
+		command.Flags().BoolVar(&silenceAlerts, "silence-alerts", false, "silence the alerts of the managed alert rules while provisioning and for --silence-grace after")
+		command.Flags().DurationVar(&silenceGrace, "silence-grace", 5*time.Minute, "time the silence of --silence-alerts lasts after the run")
+		command.Flags().BoolVar(&pauseAlerts, "pause-alerts", false, "pause the managed alert rules while provisioning and resume them afterwards")
+		command.Flags().BoolVar(&updateSecrets, "update-secrets", false, "update the passwords and other secrets of the existing data sources, e.g. after a rotation")
+		command.Flags().BoolVar(&summary, "summary", true, "print a table of the resources the run changed, colored on a terminal")
+		command.Flags().StringVar(&shard, "shard", "", "only apply the folders, dashboards and alert rule groups of one of several disjoint shards of the config, e.g. 2/5 for the second of five parallel jobs")
+		command.Flags().StringVar(&groupBy, "group-by", "", "log a summary of the run per value of this resource label, e.g. team")
+		command.Flags().StringVar(&refsFile, "refs-file", "", "write the data source UID and dashboard URL reference map to this file (overrides refs_file)")
+		command.Flags().StringVar(&changelogFile, "changelog-file", "", "print the changes since the run report saved in this file, then save this run's report there (overrides changelog_file)")
+	}
+	rootCmd.AddCommand(applyCmd)
+}
+
+// runApply runs the full provisioning workflow
+func runApply(cmd *cobra.Command, args []string) error {
+	ctx, stop := interruptContext()
+	defer stop()
+
+	if shard != "" {
+		var err error
+		parsedShard, err = grafana.ParseShard(shard)
+		if err != nil {
+			return err
+		}
+	}
+
+	if configGlob != "" {
+		return runBatch(ctx, configGlob, parallel)
+	}
+
+	appConfig, log, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	// Each instance is provisioned like a config of --config-glob
+	if len(appConfig.Instances) > 0 {
+		return runInstances(ctx, appConfig, log)
+	}
+
+	provisionerConfig, _, err := buildProvisionerConfig(appConfig, log)
+	if err != nil {
+		return err
+	}
+
+	provisionerConfig.DumpDir = dumpDir
+	provisionerConfig.PauseAlerts = pauseAlerts
+	provisionerConfig.SilenceAlerts = silenceAlerts
+	provisionerConfig.SilenceGrace = silenceGrace
+	provisionerConfig.StrictTokenScope = strictTokenScope
+	provisionerConfig.UpdateSecrets = updateSecrets
+	provisionerConfig.DryRun = dryRun || appConfig.DryRun
+	provisionerConfig.Shard = parsedShard
+
+	// Used by dashboards with 'on_conflict: prompt'
+	reader := bufio.NewReader(os.Stdin)
+	provisionerConfig.ConfirmConflict = func(dashboard string, liveVersion int) bool {
+		return confirm(reader, fmt.Sprintf("Dashboard '%s' was changed in Grafana (live version %d). Overwrite the live changes?", dashboard, liveVersion))
+	}
+
+	report, err := grafana.RunProvisioning(ctx, provisionerConfig, log)
+	if err != nil {
+		// What was changed before the failure
+		if summary && report != nil && len(report.Resources) > 0 && !provisionerConfig.DryRun {
+			printSummary(report)
+		}
+		return fmt.Errorf("grafana provisioning failed: %w", err)
+	}
+
+	if provisionerConfig.DryRun {
+		printPlan(report)
+		log.Info("Dry run finished, Grafana was not changed.")
+		return nil
+	}
+
+	if refsFile == "" {
+		refsFile = appConfig.RefsFile
+	}
+	if refsFile != "" {
+		refsFile = shardPath(refsFile, parsedShard)
+		if err := writeReferences(refsFile, report.References()); err != nil {
+			return err
+		}
+		log.Info("Reference map written", "file", refsFile)
+	}
+
+	if changelogFile == "" {
+		changelogFile = appConfig.ChangelogFile
+	}
+	if changelogFile != "" {
+		changelogFile = shardPath(changelogFile, parsedShard)
+		previous, changelog, err := updateChangelog(changelogFile, report)
+		if err != nil {
+			return err
+		}
+		if previous != nil {
+			printChangelog(previous, changelog)
+		} else {
+			log.Info("No previous run report, the changelog starts with the next run", "file", changelogFile)
+		}
+	}
+
+	if groupBy != "" {
+		logReportByLabel(report, groupBy, log)
+	}
+
+	if broken := report.Broken(); len(broken) > 0 {
+		log.Warn("Some resources were provisioned but are broken", "count", len(broken))
+	}
+	if summary {
+		printSummary(report)
+	}
+
+	log.Info("Application finished successfully.")
+	return nil
+}
+
+// shardPath inserts the shard into the file name of a run artifact, e.g. refs.shard-2-of-5.json, so the jobs of
+// the shards don't overwrite each other's files
+func shardPath(path string, shard *grafana.Shard) string {
+	if shard == nil {
+		return path
+	}
+	extension := filepath.Ext(path)
+	return fmt.Sprintf("%s.shard-%d-of-%d%s", strings.TrimSuffix(path, extension), shard.Index, shard.Count, extension)
+}
+
+// interruptContext returns a context canceled on SIGINT or SIGTERM, aborting the requests in flight. A second
+// signal terminates the process as usual.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
+
+// logReportByLabel logs one line per value of the label with the resource counts by action
+func logReportByLabel(report *grafana.Report, key string, log *slog.Logger) {
+	counts := report.CountsByLabel(strings.ToLower(key))
+	values := []string{}
+	for value := range counts {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+
+	for _, value := range values {
+		attrs := []interface{}{"label", key, "value", value}
+		if value == "" {
+			attrs[3] = "(none)"
+		}
+		for _, action := range []string{grafana.ActionCreated, grafana.ActionUpdated, grafana.ActionUnchanged, grafana.ActionProvisioned, grafana.ActionSkipped, grafana.ActionDeleted} {
+			attrs = append(attrs, action, counts[value][action])
+		}
+		log.Info("Resources by label", attrs...)
+	}
+}
+
+// printPlan prints the changes planned by a dry run, one per line, their totals and the dashboards per folder
+func printPlan(report *grafana.Report) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprint(writer, "OPERATION\tKIND\tNAME\tDETAIL\n")
+	counts := map[string]int{}
+	for _, change := range report.Plan {
+		counts[change.Operation]++
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", change.Operation, change.Kind, change.Name, change.Detail)
+	}
+	writer.Flush()
+
+	unchanged := 0
+	for _, resource := range report.Resources {
+		if resource.Action == grafana.ActionUnchanged {
+			unchanged++
+		}
+	}
+	fmt.Printf("Plan: %d to create, %d to update, %d to delete, %d unchanged\n",
+		counts[grafana.PlanCreate], counts[grafana.PlanUpdate], counts[grafana.PlanDelete], unchanged)
+
+	if len(report.FolderStats) == 0 {
+		return
+	}
+	fmt.Println()
+	writer = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprint(writer, "FOLDER\tDASHBOARDS\tCONFIGURED\t\n")
+	for _, stat := range report.FolderStats {
+		warning := ""
+		if stat.OverLimit {
+			warning = "over folder_capacity, consider splitting"
+		}
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%s\n", stat.Folder, stat.Dashboards, stat.Configured, warning)
+	}
+	writer.Flush()
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/config"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/grafana"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// tenantResult is the outcome of provisioning one config of a batch
+type tenantResult struct {
+	Config   string
+	Report   *grafana.Report
+	Err      error
+	Duration time.Duration
+}
+
+// runBatch provisions every config matching the pattern, at most parallel at a time, and prints the aggregate report
+func runBatch(ctx context.Context, pattern string, parallel int) error {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid config glob '%s': %w", pattern, err)
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no configs match '%s'", pattern)
+	}
+	sort.Strings(paths)
+	return runTenants(ctx, paths, parallel, applyTenant)
+}
+
+// runTenants applies every named tenant, at most parallel at a time, and prints the aggregate report
+func runTenants(ctx context.Context, names []string, parallel int, apply func(ctx context.Context, name string) (*grafana.Report, error)) error {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	results := make([]tenantResult, len(names))
+	slots := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			started := time.Now()
+			report, err := apply(ctx, name)
+			results[i] = tenantResult{Config: name, Report: report, Err: err, Duration: time.Since(started)}
+		}()
+	}
+	wg.Wait()
+
+	failed := printBatchReport(results)
+
+	// The plans of dry runs follow the report, one per config
+	for _, result := range results {
+		if result.Report != nil && (dryRun || len(result.Report.Plan) > 0) {
+			fmt.Printf("\n%s:\n", result.Config)
+			printPlan(result.Report)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("provisioning failed for %d of %d configs", failed, len(results))
+	}
+	return nil
+}
+
+// applyTenant provisions a single config of the batch with its own logger tagged with the config path
+func applyTenant(ctx context.Context, path string) (*grafana.Report, error) {
+	appConfig, log, err := loadConfigFrom(path)
+	if err != nil {
+		return nil, err
+	}
+	return applyConfig(ctx, appConfig, path, log.With("tenant", path))
+}
+
+// applyConfig provisions a single config of a batch or instance of a config, failed import payloads are
+// dumped into a subdirectory named after the tenant
+func applyConfig(ctx context.Context, appConfig *config.AppConfig, tenant string, log *slog.Logger) (*grafana.Report, error) {
+	provisionerConfig, closeConnection, err := buildProvisionerConfig(appConfig, log)
+	if err != nil {
+		return nil, err
+	}
+	defer closeConnection()
+
+	// Prompts can't be answered for concurrent runs, conflicting dashboards are kept
+	provisionerConfig.PauseAlerts = pauseAlerts
+	provisionerConfig.SilenceAlerts = silenceAlerts
+	provisionerConfig.SilenceGrace = silenceGrace
+	provisionerConfig.StrictTokenScope = strictTokenScope
+	provisionerConfig.UpdateSecrets = updateSecrets
+	provisionerConfig.DryRun = dryRun || appConfig.DryRun
+	provisionerConfig.Shard = parsedShard
+	if dumpDir != "" {
+		provisionerConfig.DumpDir = filepath.Join(dumpDir, unsafePathChars.ReplaceAllString(tenant, "_"))
+	}
+
+	report, err := grafana.RunProvisioning(ctx, provisionerConfig, log)
+	if err != nil {
+		log.Error("Tenant provisioning failed", "error", err)
+		return report, err
+	}
+
+	if provisionerConfig.DryRun {
+		log.Info("Tenant dry run finished")
+		return report, nil
+	}
+	if appConfig.RefsFile != "" {
+		if err := writeReferences(appConfig.RefsFile, report.References()); err != nil {
+			return report, err
+		}
+	}
+	// Printed lines of concurrent configs would interleave, the changelog is logged
+	if appConfig.ChangelogFile != "" {
+		_, changelog, err := updateChangelog(appConfig.ChangelogFile, report)
+		if err != nil {
+			return report, err
+		}
+		for _, entry := range changelog {
+			log.Info("Changed since the previous run", "change", entry.Change, "kind", entry.Kind, "name", entry.Name, "detail", entry.Detail)
+		}
+	}
+
+	log.Info("Tenant provisioned successfully")
+	return report, nil
+}
+
+// printBatchReport prints one line per config with the resource counts by action and returns the number of failures
+func printBatchReport(results []tenantResult) int {
+	actions := []string{grafana.ActionCreated, grafana.ActionUpdated, grafana.ActionUnchanged, grafana.ActionProvisioned, grafana.ActionSkipped, grafana.ActionDeleted}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprint(writer, "CONFIG\tSTATUS")
+	for _, action := range actions {
+		fmt.Fprintf(writer, "\t%s", action)
+	}
+	fmt.Fprint(writer, "\tDURATION\tERROR\n")
+
+	failed := 0
+	totals := map[string]int{}
+	for _, result := range results {
+		counts := map[string]int{}
+		if result.Report != nil {
+			for _, resource := range result.Report.Resources {
+				counts[resource.Action]++
+				totals[resource.Action]++
+			}
+		}
+
+		status, message := "ok", ""
+		if result.Report != nil && len(result.Report.Broken()) > 0 {
+			status = "broken"
+		}
+		if result.Err != nil {
+			status, message = "failed", result.Err.Error()
+			failed++
+		}
+
+		fmt.Fprintf(writer, "%s\t%s", result.Config, status)
+		for _, action := range actions {
+			fmt.Fprintf(writer, "\t%d", counts[action])
+		}
+		fmt.Fprintf(writer, "\t%s\t%s\n", result.Duration.Round(time.Millisecond), message)
+	}
+
+	fmt.Fprintf(writer, "TOTAL\t%d/%d ok", len(results)-failed, len(results))
+	for _, action := range actions {
+		fmt.Fprintf(writer, "\t%d", totals[action])
+	}
+	fmt.Fprint(writer, "\t\t\n")
+	writer.Flush()
+
+	return failed
+}
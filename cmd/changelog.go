@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/grafana"
+	"io/fs"
+	"os"
+	"text/tabwriter"
+)
+
+// updateChangelog compares the report with the previous run's report saved in the file, then saves the report
+// there for the next run. Returns a nil previous report when the file doesn't exist yet.
+func updateChangelog(path string, report *grafana.Report) (*grafana.Report, []grafana.ChangelogEntry, error) {
+	var previous *grafana.Report
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, nil, fmt.Errorf("failed to read previous run report '%s': %w", path, err)
+	default:
+		previous = &grafana.Report{}
+		if err := json.Unmarshal(data, previous); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode previous run report '%s': %w", path, err)
+		}
+	}
+
+	data, err = json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal run report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return nil, nil, fmt.Errorf("failed to write run report '%s': %w", path, err)
+	}
+
+	if previous == nil {
+		return nil, nil, nil
+	}
+	return previous, grafana.Changelog(previous, report), nil
+}
+
+// printChangelog prints the resources changed since the previous run, one per line, and their totals
+func printChangelog(previous *grafana.Report, entries []grafana.ChangelogEntry) {
+	since := previous.FinishedAt.Format("2006-01-02 15:04:05 MST")
+	if len(entries) == 0 {
+		fmt.Printf("Changelog: no changes since the run of %s\n", since)
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprint(writer, "CHANGE\tKIND\tNAME\tDETAIL\n")
+	counts := map[string]int{}
+	for _, entry := range entries {
+		counts[entry.Change]++
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", entry.Change, entry.Kind, entry.Name, entry.Detail)
+	}
+	writer.Flush()
+	fmt.Printf("Changelog: %d added, %d removed, %d changed since the run of %s\n",
+		counts[grafana.ChangeAdded], counts[grafana.ChangeRemoved], counts[grafana.ChangeChanged], since)
+}
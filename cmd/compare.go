@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/config"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/grafana"
+	"log/slog"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// Targets of compare besides the names of the instances
+const (
+	compareTargetGrafana = "grafana" // The top-level grafana connection
+	compareTargetConfig  = "config"  // The config itself
+)
+
+var (
+	compareA string
+	compareB string
+)
+
+var compareCmd = &cobra.Command{
+	Use:   "compare --a prod --b staging",
+	Short: "Compare the managed resources of two Grafana targets, or of a target and the config (read-only)",
+	Long: `Takes a snapshot of the folders, data sources and dashboards the config manages on both targets and
+reports the resources missing from one of them and the settings that differ, for environment parity
+audits. A target is the name of an instance of 'instances', 'grafana' for the top-level connection or
+'config' for the config itself. Nothing is provisioned. Exits non-zero when the targets diverge.`,
+	Args: cobra.NoArgs,
+	RunE: runCompare,
+}
+
+func init() {
+	compareCmd.Flags().StringVar(&compareA, "a", "", "first target: an instance name, grafana or config")
+	compareCmd.Flags().StringVar(&compareB, "b", "", "second target: an instance name, grafana or config")
+	compareCmd.MarkFlagRequired("a")
+	compareCmd.MarkFlagRequired("b")
+	rootCmd.AddCommand(compareCmd)
+}
+
+// runCompare prints the divergences of the two targets
+func runCompare(cmd *cobra.Command, args []string) error {
+	appConfig, log, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	// The config side compares the resources of the instance it is paired with
+	configA, configB := appConfig, appConfig
+	if compareA != compareTargetConfig {
+		if configA, err = compareTargetAppConfig(appConfig, compareA); err != nil {
+			return err
+		}
+	}
+	if compareB != compareTargetConfig {
+		if configB, err = compareTargetAppConfig(appConfig, compareB); err != nil {
+			return err
+		}
+	}
+	if compareA == compareTargetConfig {
+		configA = configB
+	}
+	if compareB == compareTargetConfig {
+		configB = configA
+	}
+
+	snapshotA, err := takeSnapshot(configA, compareA, log)
+	if err != nil {
+		return err
+	}
+	snapshotB, err := takeSnapshot(configB, compareB, log)
+	if err != nil {
+		return err
+	}
+
+	divergences := grafana.CompareSnapshots(snapshotA, snapshotB)
+	if len(divergences) == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s and %s match: %d resources compared\n", compareA, compareB, len(snapshotA.Resources))
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+	fmt.Fprintf(writer, "KIND\tNAME\tFIELD\t%s\t%s\n", compareA, compareB)
+	for _, divergence := range divergences {
+		field, a, b := divergence.Field, divergence.A, divergence.B
+		if field == "" {
+			field = "(resource)"
+		}
+		if a == "" {
+			a = "missing"
+		}
+		if b == "" {
+			b = "missing"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", divergence.Kind, divergence.Name, field, a, b)
+	}
+	writer.Flush()
+	return fmt.Errorf("%s and %s diverge in %d places", compareA, compareB, len(divergences))
+}
+
+// compareTargetAppConfig returns the config of the instance or of the top-level grafana connection
+func compareTargetAppConfig(appConfig *config.AppConfig, target string) (*config.AppConfig, error) {
+	if target == compareTargetGrafana {
+		return appConfig, nil
+	}
+	for _, instance := range appConfig.Instances {
+		if instance.Name == target {
+			return instanceConfig(appConfig, instance), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown compare target '%s', use the name of an instance, %s or %s", target, compareTargetGrafana, compareTargetConfig)
+}
+
+// takeSnapshot reads the snapshot of the target, the config one without contacting Grafana
+func takeSnapshot(appConfig *config.AppConfig, target string, log *slog.Logger) (*grafana.Snapshot, error) {
+	if target == compareTargetConfig {
+		provisionerConfig, err := selectProvisionerConfig(appConfig, log)
+		if err != nil {
+			return nil, err
+		}
+		return grafana.ConfigSnapshot(provisionerConfig, log)
+	}
+
+	provisionerConfig, closeConnection, err := buildProvisionerConfig(appConfig, log)
+	if err != nil {
+		return nil, err
+	}
+	defer closeConnection()
+	if provisionerConfig.Grafana.URL == "" {
+		return nil, fmt.Errorf("compare target '%s' has no grafana.url", target)
+	}
+
+	snapshot, err := grafana.LiveSnapshot(grafana.NewClient(provisionerConfig.Grafana, log.With("target", target)), provisionerConfig, target, log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to take the snapshot of '%s': %w", target, err)
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,657 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/config"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/grafana"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/presets"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// toProvisionerConfig converts config types to grafana provisioner types
+func toProvisionerConfig(appConfig *config.AppConfig) (grafana.Config, error) {
+	dataSources := []grafana.DataSource{}
+
+	for _, dataSourceConfig := range appConfig.DataSources {
+		match := dataSourceConfig.Match
+		if match == "" {
+			match = appConfig.DataSourceMatch
+		}
+
+		dataSource := grafana.DataSource{
+			Name:                 dataSourceConfig.Name,
+			UID:                  dataSourceConfig.UID,
+			Match:                match,
+			Type:                 grafana.DataSourceTypePostgres,
+			URL:                  dataSourceConfig.Host + ":" + strconv.Itoa(dataSourceConfig.Port),
+			Database:             dataSourceConfig.DbName,
+			User:                 dataSourceConfig.User,
+			Password:             dataSourceConfig.Password,
+			SSLMode:              dataSourceConfig.SslMode,
+			IsDefault:            false,
+			ForwardOAuthIdentity: dataSourceConfig.ForwardOAuthIdentity,
+			KeepCookies:          dataSourceConfig.KeepCookies,
+			BasicAuth:            dataSourceConfig.BasicAuth,
+			Labels:               dataSourceConfig.Labels,
+			TestQuery:            dataSourceConfig.TestQuery,
+			Org:                  dataSourceConfig.Org,
+		}
+
+		switch dataSourceConfig.Type {
+		case "", "postgres", grafana.DataSourceTypePostgres:
+			// The PostgreSQL settings above
+		case "prometheus":
+			dataSource.Type = grafana.DataSourceTypePrometheus
+			dataSource.URL = dataSourceConfig.URL
+			dataSource.Prometheus = &grafana.PrometheusSettings{
+				ScrapeInterval: dataSourceConfig.ScrapeInterval,
+				QueryTimeout:   dataSourceConfig.QueryTimeout,
+				HTTPMethod:     dataSourceConfig.HTTPMethod,
+			}
+			for _, exemplar := range dataSourceConfig.Exemplars {
+				dataSource.Prometheus.Exemplars = append(dataSource.Prometheus.Exemplars, grafana.PrometheusExemplar{
+					Name:          exemplar.Name,
+					DataSourceUID: exemplar.DataSourceUID,
+					URL:           exemplar.URL,
+				})
+			}
+		default:
+			// Any other plugin is configured through url, the credentials, json_data and secure_json_data
+			dataSource.Type = dataSourceConfig.Type
+			dataSource.URL = dataSourceConfig.URL
+			dataSource.SSLMode = ""
+		}
+
+		for _, headerConfig := range dataSourceConfig.Headers {
+			dataSource.Headers = append(dataSource.Headers, grafana.DataSourceHeader{Name: headerConfig.Name, Value: headerConfig.Value})
+		}
+		if dataSourceConfig.JSONData != "" {
+			if err := json.Unmarshal([]byte(dataSourceConfig.JSONData), &dataSource.JSONData); err != nil {
+				return grafana.Config{}, fmt.Errorf("invalid json_data of data source '%s': %w", dataSourceConfig.Name, err)
+			}
+		}
+		if dataSourceConfig.SecureJSONData != "" {
+			if err := json.Unmarshal([]byte(dataSourceConfig.SecureJSONData), &dataSource.SecureJSONData); err != nil {
+				return grafana.Config{}, fmt.Errorf("invalid secure_json_data of data source '%s', expected an object of strings: %w", dataSourceConfig.Name, err)
+			}
+		}
+
+		dataSources = append(dataSources, dataSource)
+	}
+
+	dashboards := []grafana.Dashboard{}
+
+	for _, dashboardConfig := range appConfig.Dashboards {
+		// Convert imports
+		dashboardImports := []grafana.DashboardImport{}
+		for _, importConfig := range dashboardConfig.Imports {
+			dashboardImports = append(dashboardImports, grafana.DashboardImport{
+				Name:       importConfig.Name,
+				DataSource: importConfig.DataSource,
+			})
+		}
+
+		assertions := []grafana.PanelAssertion{}
+		for _, assertionConfig := range dashboardConfig.Assertions {
+			assertions = append(assertions, grafana.PanelAssertion{
+				Panel:    assertionConfig.Panel,
+				RefID:    assertionConfig.RefID,
+				NonEmpty: assertionConfig.NonEmpty,
+				Min:      assertionConfig.Min,
+				Max:      assertionConfig.Max,
+				From:     assertionConfig.From,
+			})
+		}
+
+		dashboardPermissions, err := toPermissions(dashboardConfig.Permissions)
+		if err != nil {
+			return grafana.Config{}, fmt.Errorf("invalid permissions of dashboard '%s': %w", dashboardConfig.Name, err)
+		}
+
+		dashboard := grafana.Dashboard{
+			Name:         dashboardConfig.Name,
+			Folder:       dashboardConfig.Folder,
+			File:         dashboardConfig.File,
+			GnetID:       dashboardConfig.GnetID,
+			Revision:     dashboardConfig.Revision,
+			Mode:         dashboardConfig.Mode,
+			Overwrite:    dashboardConfig.Overwrite == nil || *dashboardConfig.Overwrite,
+			OnConflict:   dashboardConfig.OnConflict,
+			UIDCollision: dashboardConfig.UIDCollision,
+			Imports:      dashboardImports,
+			Assertions:   assertions,
+			Labels:       dashboardConfig.Labels,
+			Permissions:  dashboardPermissions,
+
+			RequiresDataSourceType: dashboardConfig.RequiresDataSourceType,
+			MigrateGraphPanels:     appConfig.MigrateGraphPanels,
+			Org:                    dashboardConfig.Org,
+		}
+		if dashboardConfig.MigrateGraphPanels != nil {
+			dashboard.MigrateGraphPanels = *dashboardConfig.MigrateGraphPanels
+		}
+
+		// A glob or directory in file configures one dashboard per JSON file with the same settings
+		files, err := expandDashboardFiles(dashboardConfig)
+		if err != nil {
+			return grafana.Config{}, err
+		}
+		for _, file := range files {
+			// Names derived from the dashboard titles are normalized like the configured ones
+			dashboard.Name = config.NormalizeName(file.Name, appConfig.Names.Case)
+			dashboard.File = file.File
+			dashboards = append(dashboards, dashboard)
+		}
+	}
+
+	folders := []grafana.Folder{}
+
+	for _, folderConfig := range appConfig.Folders {
+		folderPermissions, err := toPermissions(folderConfig.Permissions)
+		if err != nil {
+			return grafana.Config{}, fmt.Errorf("invalid permissions of folder '%s': %w", folderConfig.Name, err)
+		}
+		folder := grafana.Folder{
+			Name:           folderConfig.Name,
+			OwnerTeam:      folderConfig.OwnerTeam,
+			ServiceAccount: folderConfig.ServiceAccount,
+			Permissions:    folderPermissions,
+			Labels:         folderConfig.Labels,
+		}
+		folders = append(folders, folder)
+	}
+
+	// Expand presets into their folders and grafana.com dashboards
+	for _, presetConfig := range appConfig.Presets {
+		presetFolder, presetDashboards, err := expandPreset(presetConfig)
+		if err != nil {
+			return grafana.Config{}, err
+		}
+
+		if !hasFolder(folders, presetFolder) && !strings.EqualFold(presetFolder, "General") {
+			folders = append(folders, grafana.Folder{Name: presetFolder})
+		}
+		dashboards = append(dashboards, presetDashboards...)
+	}
+
+	orgs := []grafana.Org{}
+
+	for _, orgConfig := range appConfig.Orgs {
+		aliases := map[string]string{}
+		for _, alias := range orgConfig.DataSourceAliases {
+			aliases[alias.DataSource] = alias.Name
+		}
+		orgs = append(orgs, grafana.Org{
+			Name:              orgConfig.Name,
+			Role:              orgConfig.Role,
+			Dashboards:        orgConfig.Dashboards,
+			DataSourceAliases: aliases,
+		})
+	}
+
+	teams := []grafana.Team{}
+
+	for _, teamConfig := range appConfig.Teams {
+		teams = append(teams, grafana.Team{
+			Name:    teamConfig.Name,
+			Email:   teamConfig.Email,
+			Groups:  teamConfig.Groups,
+			Members: teamConfig.Members,
+		})
+	}
+
+	annotations := []grafana.Annotation{}
+
+	for _, annotationConfig := range appConfig.Annotations {
+		annotations = append(annotations, grafana.Annotation{
+			Name:       annotationConfig.Name,
+			DataSource: annotationConfig.DataSource,
+			Query:      annotationConfig.Query,
+			IconColor:  annotationConfig.IconColor,
+			Hide:       annotationConfig.Hide,
+			Dashboards: annotationConfig.Dashboards,
+		})
+	}
+
+	rulers := []grafana.Ruler{}
+
+	for _, rulerConfig := range appConfig.Alerting.Rulers {
+		rulers = append(rulers, grafana.Ruler{
+			Name:   rulerConfig.Name,
+			Type:   rulerConfig.Type,
+			URL:    rulerConfig.URL,
+			Tenant: rulerConfig.Tenant,
+			Token:  rulerConfig.Token,
+		})
+	}
+
+	alertRuleGroups := []grafana.AlertRuleGroup{}
+
+	for _, groupConfig := range appConfig.Alerting.RuleGroups {
+		alertRuleGroups = append(alertRuleGroups, toAlertRuleGroup(groupConfig))
+	}
+
+	contactPoints := []grafana.ContactPoint{}
+
+	for _, contactPointConfig := range appConfig.Alerting.ContactPoints {
+		contactPoints = append(contactPoints, grafana.ContactPoint{
+			UID:                   contactPointConfig.UID,
+			Name:                  contactPointConfig.Name,
+			Type:                  contactPointConfig.Type,
+			Settings:              contactPointConfig.Settings,
+			DisableResolveMessage: contactPointConfig.DisableResolveMessage,
+		})
+	}
+
+	var notificationPolicies *grafana.NotificationPolicies
+	if policiesConfig := appConfig.Alerting.Policies; policiesConfig != nil {
+		if policiesConfig.Receiver != "" && policiesConfig.Strategy != grafana.PolicyStrategyReplace {
+			return grafana.Config{}, fmt.Errorf("alerting.policies.receiver can only be set with the replace strategy")
+		}
+		routes, err := toPolicyRoutes(policiesConfig.Routes)
+		if err != nil {
+			return grafana.Config{}, err
+		}
+		notificationPolicies = &grafana.NotificationPolicies{
+			Strategy: policiesConfig.Strategy,
+			Receiver: policiesConfig.Receiver,
+			Routes:   routes,
+		}
+	}
+
+	var secretSink grafana.SecretSink
+	if appConfig.SecretsSink.Type != "" {
+		sink, err := grafana.NewSecretSink(appConfig.SecretsSink.Type, appConfig.SecretsSink.Path)
+		if err != nil {
+			return grafana.Config{}, err
+		}
+		secretSink = sink
+	}
+
+	var values map[string]interface{}
+	valuesPath := valuesFile
+	if valuesPath == "" {
+		valuesPath = appConfig.ValuesFile
+	}
+	if valuesPath != "" {
+		loaded, err := config.LoadValues(valuesPath)
+		if err != nil {
+			return grafana.Config{}, err
+		}
+		values = loaded
+	}
+
+	valueSources := []grafana.ValueSource{}
+	for _, source := range appConfig.ValueSources {
+		valueSources = append(valueSources, grafana.ValueSource{
+			Name:     source.Name,
+			Type:     source.Type,
+			URL:      source.URL,
+			Prefix:   source.Prefix,
+			Token:    source.Token,
+			CacheTTL: source.CacheTTL.Duration,
+		})
+	}
+
+	var changeWindow *grafana.ChangeWindow
+	if appConfig.ChangeWindow.Schedule != "" {
+		window, err := grafana.ParseChangeWindow(appConfig.ChangeWindow.Schedule, appConfig.ChangeWindow.Timezone)
+		if err != nil {
+			return grafana.Config{}, err
+		}
+		window.Override = overrideWindow
+		changeWindow = window
+	}
+
+	if err := grafana.CheckSlugCollisions(folders, dashboards); err != nil {
+		return grafana.Config{}, err
+	}
+
+	// Fails on unreadable certificates before connecting rather than on every request
+	tlsParams := grafana.TLSParams{
+		CAFile:             appConfig.Grafana.CAFile,
+		CertFile:           appConfig.Grafana.CertFile,
+		KeyFile:            appConfig.Grafana.KeyFile,
+		InsecureSkipVerify: appConfig.Grafana.InsecureSkipVerify,
+	}
+	if _, err := tlsParams.Config(); err != nil {
+		return grafana.Config{}, fmt.Errorf("invalid grafana TLS settings: %w", err)
+	}
+
+	var git *grafana.GitMetadata
+	if appConfig.GitMetadata {
+		git = grafana.DetectGitMetadata(".")
+	}
+
+	return grafana.Config{
+		Grafana: grafana.ClientParams{
+			URL:        appConfig.Grafana.URL,
+			Token:      appConfig.Grafana.Token,
+			APIKey:     appConfig.Grafana.APIKey,
+			Username:   appConfig.Grafana.Username,
+			Password:   appConfig.Grafana.Password,
+			Timeout:    appConfig.Grafana.Timeout.Duration,
+			Retries:    appConfig.Grafana.Retries,
+			RetryDelay: appConfig.Grafana.RetryDelay.Duration,
+			API:        appConfig.Grafana.API,
+			Org:        appConfig.Grafana.Org,
+			UserAgent:  appConfig.Grafana.UserAgent,
+
+			DialTimeout:           appConfig.Grafana.DialTimeout.Duration,
+			TLSTimeout:            appConfig.Grafana.TLSTimeout.Duration,
+			ResponseHeaderTimeout: appConfig.Grafana.HeaderTimeout.Duration,
+			TLS:                   tlsParams,
+			Inject:                failureInjection,
+			LiveTail:              liveTail,
+			ConsistencyWait:       appConfig.Grafana.ConsistencyWait.Duration,
+		},
+		Dashboards:           dashboards,
+		DataSources:          dataSources,
+		Orgs:                 orgs,
+		Teams:                teams,
+		Folders:              folders,
+		Annotations:          annotations,
+		Rulers:               rulers,
+		AlertRuleGroups:      alertRuleGroups,
+		ContactPoints:        contactPoints,
+		NotificationPolicies: notificationPolicies,
+		Safety: grafana.SafetyLimits{
+			MaxDeletes:          appConfig.Safety.MaxDeletes,
+			MaxOverwritePercent: appConfig.Safety.MaxOverwritePercent,
+			AllowMassChange:     allowMassChange,
+		},
+		FolderCapacity: grafana.FolderCapacity{
+			MaxDashboards: appConfig.FolderCapacity.MaxDashboards,
+			Enforce:       appConfig.FolderCapacity.Enforce,
+		},
+		ChangeWindow:    changeWindow,
+		SecretSink:      secretSink,
+		Values:          values,
+		ValueSources:    valueSources,
+		Prune:           appConfig.Prune,
+		PruneTag:        appConfig.PruneTag,
+		FileProvisioned: appConfig.FileProvisioned,
+		Git:             git,
+		StatusDashboard: grafana.StatusDashboard{
+			Enabled: appConfig.Status.Enabled,
+			Title:   appConfig.Status.Title,
+			Folder:  appConfig.Status.Folder,
+		},
+		Metrics: grafana.MetricsPush{
+			Pushgateway: appConfig.MetricsPush.Pushgateway,
+			RemoteWrite: appConfig.MetricsPush.RemoteWrite,
+			Job:         appConfig.MetricsPush.Job,
+			Username:    appConfig.MetricsPush.Username,
+			Password:    appConfig.MetricsPush.Password,
+			Labels:      appConfig.MetricsPush.Labels,
+		},
+		FoldersMapping: nil, // Will be populated in grafana.RunProvisioning
+	}, nil
+}
+
+// expandPreset returns the folder and dashboards of a built-in preset wired to the configured data source
+func expandPreset(presetConfig config.PresetConfig) (string, []grafana.Dashboard, error) {
+	preset, ok := presets.Get(presetConfig.Name)
+	if !ok {
+		return "", nil, fmt.Errorf("unknown preset '%s', available presets: %s", presetConfig.Name, strings.Join(presets.Names(), ", "))
+	}
+
+	folder := preset.Folder
+	if presetConfig.Folder != "" {
+		folder = presetConfig.Folder
+	}
+
+	dashboards := []grafana.Dashboard{}
+	for _, presetDashboard := range preset.Dashboards {
+		imports := []grafana.DashboardImport{}
+		for _, input := range presetDashboard.Inputs {
+			imports = append(imports, grafana.DashboardImport{
+				Name:       input,
+				DataSource: presetConfig.DataSource,
+			})
+		}
+
+		dashboards = append(dashboards, grafana.Dashboard{
+			Name:      presetDashboard.Name,
+			Folder:    folder,
+			GnetID:    presetDashboard.GnetID,
+			Revision:  presetDashboard.Revision,
+			Overwrite: true,
+			Imports:   imports,
+		})
+	}
+
+	return folder, dashboards, nil
+}
+
+// dashboardFile is a dashboard JSON file matched by the file of a dashboard config and its dashboard name
+type dashboardFile struct {
+	Name string
+	File string
+}
+
+// expandDashboardFiles resolves the file of a dashboard config: a glob like `dashboards/*.json` or a directory
+// matches its JSON files. Dashboards without a name are named after the title of their JSON or, without one,
+// their file name. The name can't be set for a glob or directory.
+func expandDashboardFiles(dashboardConfig config.Dashboard) ([]dashboardFile, error) {
+	pattern := dashboardConfig.File
+	if pattern == "" || dashboardConfig.GnetID != 0 {
+		return []dashboardFile{{Name: dashboardConfig.Name, File: pattern}}, nil
+	}
+
+	if !strings.ContainsAny(pattern, "*?[") {
+		if info, err := os.Stat(pattern); err != nil || !info.IsDir() {
+			if dashboardConfig.Name != "" {
+				return []dashboardFile{{Name: dashboardConfig.Name, File: pattern}}, nil
+			}
+			name, err := dashboardFileName(pattern)
+			if err != nil {
+				return nil, err
+			}
+			return []dashboardFile{{Name: name, File: pattern}}, nil
+		}
+		pattern = filepath.Join(pattern, "*.json")
+	}
+
+	if dashboardConfig.Name != "" {
+		return nil, fmt.Errorf("dashboard '%s': name can't be set when file '%s' is a glob or directory, the names come from the dashboard titles", dashboardConfig.Name, dashboardConfig.File)
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dashboard file pattern '%s': %w", dashboardConfig.File, err)
+	}
+	files := []dashboardFile{}
+	for _, match := range matches {
+		if info, err := os.Stat(match); err != nil || info.IsDir() {
+			continue
+		}
+		name, err := dashboardFileName(match)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, dashboardFile{Name: name, File: match})
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("dashboard file pattern '%s' matches no files", dashboardConfig.File)
+	}
+	return files, nil
+}
+
+// dashboardFileName returns the title of the dashboard JSON file, or its file name without the extension
+// if it has none
+func dashboardFileName(file string) (string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read dashboard file %s: %w", file, err)
+	}
+	var dashboardJSON struct {
+		Title string `json:"title"`
+	}
+	if err := json.Unmarshal(data, &dashboardJSON); err != nil {
+		return "", fmt.Errorf("failed to parse dashboard file %s: %w", file, err)
+	}
+	if title := strings.TrimSpace(dashboardJSON.Title); title != "" {
+		return title, nil
+	}
+	return strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)), nil
+}
+
+// hasFolder reports whether the folder list already contains the named folder
+func hasFolder(folders []grafana.Folder, name string) bool {
+	for _, folder := range folders {
+		if folder.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// toAlertRuleGroup converts a rule group config with all of its rules
+func toAlertRuleGroup(groupConfig config.AlertRuleGroupConfig) grafana.AlertRuleGroup {
+	rules := []grafana.AlertRule{}
+
+	for _, ruleConfig := range groupConfig.Rules {
+		queries := []grafana.AlertQuery{}
+		for _, queryConfig := range ruleConfig.Queries {
+			queries = append(queries, grafana.AlertQuery{
+				RefID:        queryConfig.RefID,
+				DataSource:   queryConfig.DataSource,
+				Expr:         queryConfig.Expr,
+				RelativeTime: queryConfig.RelativeTime.Duration,
+			})
+		}
+
+		expressions := []grafana.AlertExpression{}
+		for _, expressionConfig := range ruleConfig.Expressions {
+			expressions = append(expressions, grafana.AlertExpression{
+				RefID:      expressionConfig.RefID,
+				Type:       expressionConfig.Type,
+				Expression: expressionConfig.Expression,
+				Reducer:    expressionConfig.Reducer,
+			})
+		}
+
+		rules = append(rules, grafana.AlertRule{
+			UID:         ruleConfig.UID,
+			Title:       ruleConfig.Title,
+			Record:      ruleConfig.Record,
+			Expr:        ruleConfig.Expr,
+			Condition:   ruleConfig.Condition,
+			For:         ruleConfig.For,
+			Labels:      ruleConfig.Labels,
+			Annotations: ruleConfig.Annotations,
+			Queries:     queries,
+			Expressions: expressions,
+		})
+	}
+
+	return grafana.AlertRuleGroup{
+		Name:      groupConfig.Name,
+		Folder:    groupConfig.Folder,
+		Interval:  groupConfig.Interval.Duration,
+		Ruler:     groupConfig.Ruler,
+		Namespace: groupConfig.Namespace,
+		Rules:     rules,
+	}
+}
+
+// toSSHTunnel converts the ssh_tunnel block
+func toSSHTunnel(tunnel config.SSHTunnel) grafana.SSHTunnel {
+	return grafana.SSHTunnel{
+		Host:                  tunnel.Host,
+		User:                  tunnel.User,
+		Key:                   tunnel.Key,
+		KeyFile:               tunnel.KeyFile,
+		Passphrase:            tunnel.Passphrase,
+		KnownHostsFile:        tunnel.KnownHostsFile,
+		InsecureIgnoreHostKey: tunnel.InsecureIgnoreHostKey,
+		Remote:                tunnel.Remote,
+		Timeout:               tunnel.Timeout.Duration,
+	}
+}
+
+// toKubeTarget converts the kube block
+func toKubeTarget(kube config.KubeConfig) grafana.KubeTarget {
+	return grafana.KubeTarget{
+		Kubeconfig: kube.Kubeconfig,
+		Context:    kube.Context,
+		Namespace:  kube.Namespace,
+		Service:    kube.Service,
+		Port:       kube.Port,
+	}
+}
+
+// toPolicyRoutes converts the configured notification policies, nested ones included
+func toPolicyRoutes(routeConfigs []config.RouteConfig) ([]grafana.PolicyRoute, error) {
+	routes := []grafana.PolicyRoute{}
+	for _, routeConfig := range routeConfigs {
+		matchers := [][]string{}
+		for _, matcher := range routeConfig.Matchers {
+			parsed, err := grafana.ParseRouteMatcher(matcher)
+			if err != nil {
+				return nil, fmt.Errorf("invalid notification policy: %w", err)
+			}
+			matchers = append(matchers, parsed)
+		}
+
+		nested, err := toPolicyRoutes(routeConfig.Routes)
+		if err != nil {
+			return nil, err
+		}
+
+		routes = append(routes, grafana.PolicyRoute{
+			Receiver:          routeConfig.Receiver,
+			Matchers:          matchers,
+			GroupBy:           routeConfig.GroupBy,
+			Continue:          routeConfig.Continue,
+			GroupWait:         routeConfig.GroupWait,
+			GroupInterval:     routeConfig.GroupInterval,
+			RepeatInterval:    routeConfig.RepeatInterval,
+			MuteTimeIntervals: routeConfig.MuteTimeIntervals,
+			Routes:            nested,
+		})
+	}
+	return routes, nil
+}
+
+// toPermissions converts the configured folder or dashboard permissions, nil stays nil to keep the live ones
+func toPermissions(permissionConfigs []config.PermissionConfig) ([]grafana.Permission, error) {
+	if permissionConfigs == nil {
+		return nil, nil
+	}
+
+	permissions := []grafana.Permission{}
+	for _, permissionConfig := range permissionConfigs {
+		set := 0
+		for _, grantee := range []string{permissionConfig.Team, permissionConfig.User, permissionConfig.Role} {
+			if grantee != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return nil, fmt.Errorf("each permission needs exactly one of team, user or role")
+		}
+		switch permissionConfig.Role {
+		case "", "Viewer", "Editor", "Admin":
+		default:
+			return nil, fmt.Errorf("unknown role '%s', use Viewer, Editor or Admin", permissionConfig.Role)
+		}
+		switch permissionConfig.Permission {
+		case grafana.PermissionLevelView, grafana.PermissionLevelEdit, grafana.PermissionLevelAdmin:
+		default:
+			return nil, fmt.Errorf("unknown permission '%s', use view, edit or admin", permissionConfig.Permission)
+		}
+
+		permissions = append(permissions, grafana.Permission{
+			Team:  permissionConfig.Team,
+			User:  permissionConfig.User,
+			Role:  permissionConfig.Role,
+			Level: permissionConfig.Permission,
+		})
+	}
+	return permissions, nil
+}
@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/config"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/grafana"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	daemonInterval time.Duration
+	daemonPIDFile  string
+	daemonListen   string
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Provision the config repeatedly, as a long-running service",
+	Long: `Runs apply at start and then every --interval, reloading the config before each run so
+changes are picked up without a restart. A failed run is logged and retried at the next interval.
+
+SIGTERM or SIGINT (Ctrl+C, or the stop of a Windows service wrapper) lets the run in flight
+complete and then exits, a second signal exits at once. Under systemd with Type=notify the
+daemon reports READY=1 after the first run and STOPPING=1 on shutdown.
+
+With --listen, /healthz answers 200 while the daemon is up (liveness) and /readyz answers 200
+once the last run succeeded and 503 otherwise (readiness), both with the state of the last run.
+GET /config/effective returns the config of the last run as loaded, with the environment
+variables and age values expanded and the secrets redacted.`,
+	Args: cobra.NoArgs,
+	RunE: runDaemon,
+}
+
+func init() {
+	daemonCmd.Flags().DurationVar(&daemonInterval, "interval", 5*time.Minute, "time between the end of a run and the start of the next")
+	daemonCmd.Flags().StringVar(&daemonPIDFile, "pid-file", "", "write the process ID to this file, removed on exit")
+	daemonCmd.Flags().StringVar(&daemonListen, "listen", "", "serve the /healthz, /readyz and /config/effective endpoints on this address, e.g. :8080")
+	rootCmd.AddCommand(daemonCmd)
+}
+
+// daemonState is the state of the provisioning runs served by the health endpoints
+type daemonState struct {
+	mu        sync.Mutex
+	Running   bool      `json:"running"`
+	Runs      int       `json:"runs"`
+	LastRun   time.Time `json:"last_run,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+	Succeeded bool      `json:"succeeded"`
+	ShutDown  bool      `json:"shutting_down"`
+
+	config *config.AppConfig // Config of the last run, as loaded
+}
+
+// write serves the state as JSON with the given status code
+func (state *daemonState) write(w http.ResponseWriter, ready bool) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	status := http.StatusOK
+	if ready && (!state.Succeeded || state.ShutDown) {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(state)
+}
+
+// writeConfig serves the config the daemon operates on as JSON, secrets redacted
+func (state *daemonState) writeConfig(w http.ResponseWriter) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(config.Redact(state.config))
+}
+
+// runDaemon provisions the config every interval until it is signalled to stop
+func runDaemon(cmd *cobra.Command, args []string) error {
+	if daemonInterval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	// The logger of the config is needed before the first run, to report the startup
+	appConfig, log, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if daemonPIDFile != "" {
+		if err := os.WriteFile(daemonPIDFile, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644); err != nil {
+			return fmt.Errorf("failed to write PID file '%s': %w", daemonPIDFile, err)
+		}
+		defer os.Remove(daemonPIDFile)
+	}
+
+	state := &daemonState{config: appConfig}
+	if daemonListen != "" {
+		server, err := startHealthServer(daemonListen, state, log)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			server.Shutdown(ctx)
+		}()
+	}
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	log.Info("Daemon started", "interval", daemonInterval, "pid", os.Getpid())
+	ready := false
+	for {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- runDaemonOnce(ctx, state) }()
+
+		select {
+		case err = <-done:
+		case received := <-signals:
+			log.Info("Shutting down after the run in flight", "signal", received.String())
+			stopDaemon(state, log)
+			select {
+			case err = <-done:
+				cancel()
+				logDaemonRun(err, log)
+			case received = <-signals:
+				// Cancels the requests in flight, the run returns promptly
+				cancel()
+				<-done
+				return fmt.Errorf("run interrupted by a second %s signal", received.String())
+			}
+			log.Info("Daemon stopped")
+			return nil
+		}
+		cancel()
+		logDaemonRun(err, log)
+
+		if !ready {
+			ready = true
+			notifySystemd("READY=1", log)
+		}
+
+		select {
+		case <-time.After(daemonInterval):
+		case received := <-signals:
+			log.Info("Shutting down", "signal", received.String())
+			stopDaemon(state, log)
+			log.Info("Daemon stopped")
+			return nil
+		}
+	}
+}
+
+// runDaemonOnce reloads the config and provisions it, recording the outcome in the state
+func runDaemonOnce(ctx context.Context, state *daemonState) error {
+	state.mu.Lock()
+	state.Running = true
+	state.mu.Unlock()
+
+	appConfig, err := provisionOnce(ctx)
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if appConfig != nil {
+		state.config = appConfig
+	}
+	state.Running = false
+	state.Runs++
+	state.LastRun = time.Now()
+	state.Succeeded = err == nil
+	state.LastError = ""
+	if err != nil {
+		state.LastError = err.Error()
+	}
+	return err
+}
+
+// provisionOnce runs the provisioning of the config as loaded now, the connections to Grafana are closed afterwards.
+// Returns the loaded config, nil if it failed to load.
+func provisionOnce(ctx context.Context) (*config.AppConfig, error) {
+	appConfig, log, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	provisionerConfig, closeConnection, err := buildProvisionerConfig(appConfig, log)
+	if err != nil {
+		return appConfig, err
+	}
+	defer closeConnection()
+
+	provisionerConfig.DryRun = appConfig.DryRun
+	if _, err := grafana.RunProvisioning(ctx, provisionerConfig, log); err != nil {
+		return appConfig, fmt.Errorf("grafana provisioning failed: %w", err)
+	}
+	return appConfig, nil
+}
+
+// logDaemonRun logs the outcome of a run, failed runs don't stop the daemon
+func logDaemonRun(err error, log *slog.Logger) {
+	if err != nil {
+		log.Error("Provisioning run failed, retrying at the next interval", "error", err, "interval", daemonInterval)
+		return
+	}
+	log.Info("Provisioning run finished", "next_run", time.Now().Add(daemonInterval).Format(time.RFC3339))
+}
+
+// stopDaemon marks the daemon as shutting down, so it reports not ready
+func stopDaemon(state *daemonState, log *slog.Logger) {
+	state.mu.Lock()
+	state.ShutDown = true
+	state.mu.Unlock()
+	notifySystemd("STOPPING=1", log)
+}
+
+// startHealthServer serves the liveness and readiness endpoints in the background
+func startHealthServer(address string, state *daemonState, log *slog.Logger) (*http.Server, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on '%s': %w", address, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) { state.write(w, false) })
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) { state.write(w, true) })
+	mux.HandleFunc("GET /config/effective", func(w http.ResponseWriter, r *http.Request) { state.writeConfig(w) })
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("Health endpoints stopped", "error", err)
+		}
+	}()
+	log.Info("Serving health endpoints", "address", listener.Addr().String())
+	return server, nil
+}
+
+// notifySystemd sends the state to the systemd notification socket, if the service runs with Type=notify
+func notifySystemd(message string, log *slog.Logger) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return
+	}
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		log.Warn("Failed to notify systemd", "state", message, "error", err)
+		return
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(message)); err != nil {
+		log.Warn("Failed to notify systemd", "state", message, "error", err)
+	}
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/grafana"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	dedupeYes             bool
+	dedupeIncludeUntagged bool
+)
+
+var dedupeCmd = &cobra.Command{
+	Use:   "dedupe",
+	Short: "Find duplicate dashboards and data sources and delete obsolete copies",
+	Long: `Finds dashboards sharing the same title across folders and data sources with a '_N'
+suffix left by earlier runs. Dashboard copies outside the configured folder and suffixed data
+sources that don't match the config are offered for deletion if they carry the prune tag. Untagged
+copies may have been created by hand and are only offered with --include-untagged.`,
+	RunE: runDedupe,
+}
+
+func init() {
+	dedupeCmd.Flags().BoolVarP(&dedupeYes, "yes", "y", false, "delete obsolete copies without asking")
+	dedupeCmd.Flags().BoolVar(&dedupeIncludeUntagged, "include-untagged", false, "also delete dashboard and data source copies without the prune tag")
+	rootCmd.AddCommand(dedupeCmd)
+}
+
+// runDedupe reports duplicate resources and deletes the obsolete copies
+func runDedupe(cmd *cobra.Command, args []string) error {
+	_, provisionerConfig, log, err := loadProvisionerConfig()
+	if err != nil {
+		return err
+	}
+	client := grafana.NewClient(provisionerConfig.Grafana, log)
+
+	groups, err := grafana.FindDuplicates(client, provisionerConfig, dedupeIncludeUntagged, log)
+	if err != nil {
+		return fmt.Errorf("duplicate search failed: %w", err)
+	}
+
+	if len(groups) == 0 {
+		fmt.Println("No duplicates found.")
+		return nil
+	}
+
+	// Refuse to start if the number of deletions would exceed the safety limit
+	obsolete := 0
+	for _, group := range groups {
+		for _, duplicate := range group.Copies {
+			if duplicate.Obsolete {
+				obsolete++
+			}
+		}
+	}
+	if err := provisionerConfig.Safety.CheckDeletes(obsolete); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	deleted := 0
+
+	for _, group := range groups {
+		fmt.Printf("%s '%s' has %d copies:\n", group.Kind, group.Name, len(group.Copies))
+		for _, duplicate := range group.Copies {
+			fmt.Printf("  - %s\n", describeDuplicate(duplicate))
+		}
+
+		for _, duplicate := range group.Copies {
+			if !duplicate.Obsolete {
+				continue
+			}
+
+			if !dedupeYes && !confirm(reader, fmt.Sprintf("Delete %s '%s' (uid %s)?", group.Kind, duplicate.Name, duplicate.UID)) {
+				continue
+			}
+
+			if err := grafana.DeleteDuplicate(client, group.Kind, duplicate); err != nil {
+				return fmt.Errorf("failed to delete %s '%s': %w", group.Kind, duplicate.Name, err)
+			}
+			deleted++
+		}
+	}
+
+	fmt.Printf("Deleted %d obsolete copies.\n", deleted)
+	return nil
+}
+
+// describeDuplicate renders a single duplicate copy for the report
+func describeDuplicate(duplicate grafana.DuplicateCopy) string {
+	description := fmt.Sprintf("%s (uid %s)", duplicate.Name, duplicate.UID)
+	if duplicate.Folder != "" {
+		description += fmt.Sprintf(" in folder '%s'", duplicate.Folder)
+	}
+
+	switch {
+	case duplicate.Managed:
+		description += " [managed]"
+	case duplicate.Obsolete:
+		description += " [obsolete]"
+	default:
+		description += " [unmanaged]"
+	}
+
+	return description
+}
+
+// confirm asks a yes/no question on stdout and reads the answer from the reader
+func confirm(reader *bufio.Reader, question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+	answer, err := reader.ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/buildinfo"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/grafana"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	docsFormat string
+	docsOutput string
+)
+
+var docsCmd = &cobra.Command{
+	Use:   "docs",
+	Short: "Render a Markdown or HTML catalog of the configured resources",
+	Long: `Renders a catalog of every folder, dashboard (description, owner team, tags, links and
+data sources from its JSON), data source and alert rule of the config, so the config doubles
+as a self-updating observability catalog. Grafana is not contacted.`,
+	Args: cobra.NoArgs,
+	RunE: runDocs,
+}
+
+func init() {
+	docsCmd.Flags().StringVar(&docsFormat, "format", "markdown", "catalog format: markdown or html")
+	docsCmd.Flags().StringVarP(&docsOutput, "output", "o", "", "write the catalog to this file instead of stdout")
+	rootCmd.AddCommand(docsCmd)
+}
+
+// catalogFuncs are shared by the Markdown and HTML templates
+var catalogFuncs = map[string]interface{}{
+	"join": strings.Join,
+	"labels": func(labels map[string]string) string {
+		pairs := []string{}
+		for key, value := range labels {
+			pairs = append(pairs, key+"="+value)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ", ")
+	},
+	"cell": func(value string) string {
+		return strings.ReplaceAll(strings.ReplaceAll(value, "|", "\\|"), "\n", " ")
+	},
+}
+
+// markdownCatalog renders the catalog as Markdown
+const markdownCatalog = `# Observability Catalog
+
+Generated by {{.Generator}} from the provisioner config.
+{{range .Catalog.Folders}}
+## 📁 {{.Name}}
+{{if .OwnerTeam}}
+Owner team: **{{.OwnerTeam}}**
+{{end}}{{range .Dashboards}}
+### {{.Name}}
+{{if .Description}}
+{{.Description}}
+{{end}}
+- Source: {{.Source}}
+{{- if .Owner}}
+- Owner team: {{.Owner}}
+{{- end}}
+{{- if .Tags}}
+- Tags: {{join .Tags ", "}}
+{{- end}}
+{{- if .DataSources}}
+- Data sources: {{join .DataSources ", "}}
+{{- end}}
+{{- range .Links}}
+- Link: {{if .URL}}[{{.Title}}]({{.URL}}){{else}}{{.Title}} (dashboards tagged {{join .Tags ", "}}){{end}}
+{{- end}}
+{{end}}{{range .RuleGroups}}
+### 🔔 Rule group {{.Name}}
+
+| Rule | For | Labels | Summary |
+| :--- | :--- | :--- | :--- |
+{{range .Rules}}| {{cell .Title}} | {{.For}} | {{cell (labels .Labels)}} | {{cell (index .Annotations "summary")}} |
+{{end}}{{end}}{{end}}
+{{- if .Catalog.RulerGroups}}
+## 🔔 Ruler Rule Groups
+{{range .Catalog.RulerGroups}}
+### {{.Name}} ({{.Ruler}})
+
+| Rule | Expression | For | Labels |
+| :--- | :--- | :--- | :--- |
+{{range .Rules}}| {{cell (or .Record .Title)}} | ` + "`{{cell .Expr}}`" + ` | {{.For}} | {{cell (labels .Labels)}} |
+{{end}}{{end}}{{end}}
+## 🔌 Data Sources
+
+| Name | Type | URL | Database | User |
+| :--- | :--- | :--- | :--- | :--- |
+{{range .Catalog.DataSources}}| {{cell .Name}} | {{.Type}} | {{cell .URL}} | {{cell .Database}} | {{cell .User}} |
+{{end}}`
+
+// htmlCatalog renders the catalog as a standalone HTML page
+const htmlCatalog = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Observability Catalog</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 60em; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+.tag { background: #eee; border-radius: 0.3em; padding: 0 0.4em; }
+</style>
+</head>
+<body>
+<h1>Observability Catalog</h1>
+<p>Generated by {{.Generator}} from the provisioner config.</p>
+{{range .Catalog.Folders}}
+<h2>📁 {{.Name}}</h2>
+{{if .OwnerTeam}}<p>Owner team: <strong>{{.OwnerTeam}}</strong></p>{{end}}
+{{range .Dashboards}}
+<h3>{{.Name}}</h3>
+{{if .Description}}<p>{{.Description}}</p>{{end}}
+<ul>
+<li>Source: {{.Source}}</li>
+{{if .Owner}}<li>Owner team: {{.Owner}}</li>{{end}}
+{{if .Tags}}<li>Tags: {{range .Tags}}<span class="tag">{{.}}</span> {{end}}</li>{{end}}
+{{if .DataSources}}<li>Data sources: {{join .DataSources ", "}}</li>{{end}}
+{{range .Links}}<li>Link: {{if .URL}}<a href="{{.URL}}">{{.Title}}</a>{{else}}{{.Title}} (dashboards tagged {{join .Tags ", "}}){{end}}</li>{{end}}
+</ul>
+{{end}}
+{{range .RuleGroups}}
+<h3>🔔 Rule group {{.Name}}</h3>
+<table>
+<tr><th>Rule</th><th>For</th><th>Labels</th><th>Summary</th></tr>
+{{range .Rules}}<tr><td>{{.Title}}</td><td>{{.For}}</td><td>{{labels .Labels}}</td><td>{{index .Annotations "summary"}}</td></tr>
+{{end}}</table>
+{{end}}
+{{end}}
+{{if .Catalog.RulerGroups}}
+<h2>🔔 Ruler Rule Groups</h2>
+{{range .Catalog.RulerGroups}}
+<h3>{{.Name}} ({{.Ruler}})</h3>
+<table>
+<tr><th>Rule</th><th>Expression</th><th>For</th><th>Labels</th></tr>
+{{range .Rules}}<tr><td>{{or .Record .Title}}</td><td><code>{{.Expr}}</code></td><td>{{.For}}</td><td>{{labels .Labels}}</td></tr>
+{{end}}</table>
+{{end}}
+{{end}}
+<h2>🔌 Data Sources</h2>
+<table>
+<tr><th>Name</th><th>Type</th><th>URL</th><th>Database</th><th>User</th></tr>
+{{range .Catalog.DataSources}}<tr><td>{{.Name}}</td><td>{{.Type}}</td><td>{{.URL}}</td><td>{{.Database}}</td><td>{{.User}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`
+
+// runDocs renders the catalog of the config
+func runDocs(cmd *cobra.Command, args []string) error {
+	appConfig, log, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	// The catalog only needs the config, no connection to Grafana is opened
+	provisionerConfig, err := selectProvisionerConfig(appConfig, log)
+	if err != nil {
+		return err
+	}
+
+	catalog, err := grafana.BuildCatalog(provisionerConfig, log)
+	if err != nil {
+		return fmt.Errorf("failed to build the catalog: %w", err)
+	}
+
+	var out io.Writer = os.Stdout
+	if docsOutput != "" {
+		file, err := os.Create(docsOutput)
+		if err != nil {
+			return fmt.Errorf("failed to create catalog file '%s': %w", docsOutput, err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	data := map[string]interface{}{
+		"Generator": buildinfo.UserAgent(),
+		"Catalog":   catalog,
+	}
+
+	var renderErr error
+	switch docsFormat {
+	case "markdown":
+		renderErr = template.Must(template.New("catalog").Funcs(catalogFuncs).Parse(markdownCatalog)).Execute(out, data)
+	case "html":
+		renderErr = htmltemplate.Must(htmltemplate.New("catalog").Funcs(catalogFuncs).Parse(htmlCatalog)).Execute(out, data)
+	default:
+		return fmt.Errorf("unsupported catalog format '%s', use markdown or html", docsFormat)
+	}
+	if renderErr != nil {
+		return fmt.Errorf("failed to render the catalog: %w", renderErr)
+	}
+
+	log.Info("Catalog rendered", "format", docsFormat, "folders", len(catalog.Folders), "datasources", len(catalog.DataSources))
+	return nil
+}
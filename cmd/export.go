@@ -0,0 +1,433 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/grafana"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	exportDir        string
+	exportExternal   bool
+	exportAlertRules bool
+	exportMinify     bool
+	exportConfig     bool
+	exportBootstrap  bool
+)
+
+// unsafePathChars matches characters replaced in exported file and folder names
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// unsafeEnvChars matches characters replaced in the environment placeholders of exported secrets
+var unsafeEnvChars = regexp.MustCompile(`[^A-Z0-9]+`)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export all dashboards of the instance as JSON files",
+	Long: `Exports every dashboard to <dir>/<folder>/<title>.json. With --share-externally the
+data source references are converted to __inputs, so the files can be imported into other
+instances and provisioned again with the printed 'imports' mappings. With --alert-rules the
+Grafana-managed rule groups are also written to <dir>/alert-rules.yaml in the config format,
+keeping the rule UIDs so applying them to another instance updates the same rules. With
+--config-entries the folders and dashboards entries provisioning the exported files are written to
+<dir>/dashboards.yaml, to merge into a config. With --bootstrap a complete <dir>/config.yaml is
+written with the connection, data sources, folders and dashboards of the instance, to start
+provisioning an instance configured by hand.`,
+	Args: cobra.NoArgs,
+	RunE: runExport,
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportDir, "dir", "d", "export", "directory to write the dashboards to")
+	exportCmd.Flags().BoolVar(&exportExternal, "share-externally", false, "convert data source references to __inputs")
+	exportCmd.Flags().BoolVar(&exportMinify, "minify", false, "write the dashboards without indentation")
+	exportCmd.Flags().BoolVar(&exportConfig, "config-entries", false, "also write the folders and dashboards config entries of the exported files to dashboards.yaml")
+	exportCmd.Flags().BoolVar(&exportBootstrap, "bootstrap", false, "also write a complete config.yaml with the data sources, folders and dashboards of the instance")
+	exportCmd.Flags().BoolVar(&exportAlertRules, "alert-rules", false, "also export the Grafana-managed alert rules to alert-rules.yaml")
+	rootCmd.AddCommand(exportCmd)
+}
+
+// runExport writes the live dashboards to files
+func runExport(cmd *cobra.Command, args []string) error {
+	_, provisionerConfig, log, err := loadProvisionerConfig()
+	if err != nil {
+		return err
+	}
+	client := grafana.NewClient(provisionerConfig.Grafana, log)
+
+	searchResults, err := client.SearchDashboards()
+	if err != nil {
+		return fmt.Errorf("failed to list dashboards: %w", err)
+	}
+
+	dataSources, err := client.GetDataSources()
+	if err != nil {
+		return fmt.Errorf("failed to list data sources: %w", err)
+	}
+
+	exported := 0
+	folders := []string{}
+	entries := []newDashboardEntry{}
+	for _, result := range searchResults {
+		if result.Type != "dash-db" {
+			continue
+		}
+
+		dashboard, err := grafana.ExportDashboard(client, result.UID, exportExternal, dataSources)
+		if err != nil {
+			return err
+		}
+
+		path, err := writeExportedDashboard(exportDir, result.Title, dashboard)
+		if err != nil {
+			return err
+		}
+		exported++
+
+		fmt.Fprintf(cmd.OutOrStdout(), "%s\n", path)
+		for _, dashboardImport := range dashboard.Imports {
+			fmt.Fprintf(cmd.OutOrStdout(), "    imports: name: %s, datasource: %s\n", dashboardImport.Name, dashboardImport.DataSource)
+		}
+
+		entry := newDashboardEntry{Name: result.Title, File: filepath.ToSlash(path), Folder: dashboard.FolderTitle, Imports: []newDashboardInput{}}
+		for _, dashboardImport := range dashboard.Imports {
+			entry.Imports = append(entry.Imports, newDashboardInput{Name: dashboardImport.Name, DataSource: dashboardImport.DataSource})
+		}
+		if entry.Folder == "" {
+			entry.Folder = "General"
+		} else if !slices.Contains(folders, entry.Folder) {
+			folders = append(folders, entry.Folder)
+		}
+		entries = append(entries, entry)
+	}
+
+	log.Info("Dashboards exported", "count", exported, "dir", exportDir)
+
+	if exportConfig {
+		path, err := writeExportedConfig(exportDir, folders, entries)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s\n", path)
+		log.Info("Config entries exported", "folders", len(folders), "dashboards", len(entries), "path", path)
+	}
+
+	if exportBootstrap {
+		path, secrets, err := writeBootstrapConfig(exportDir, provisionerConfig.Grafana, dataSources, folders, entries)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s\n", path)
+		for _, secret := range secrets {
+			fmt.Fprintf(cmd.OutOrStdout(), "    set %s\n", secret)
+		}
+		log.Info("Config exported", "datasources", len(dataSources), "folders", len(folders), "dashboards", len(entries), "path", path)
+	}
+
+	if exportAlertRules {
+		groups, issues, err := grafana.ExportAlertRuleGroups(client, dataSources)
+		if err != nil {
+			return err
+		}
+		for _, issue := range issues {
+			log.Warn("Alert rule not exported", "issue", issue.String())
+		}
+
+		path, err := writeExportedRuleGroups(exportDir, groups)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s\n", path)
+		log.Info("Alert rule groups exported", "count", len(groups), "skipped_rules", len(issues), "path", path)
+	}
+	return nil
+}
+
+// writeExportedDashboard writes the dashboard to <dir>/<folder>/<title>.json
+func writeExportedDashboard(dir string, title string, dashboard *grafana.ExportedDashboard) (string, error) {
+	folder := dashboard.FolderTitle
+	if folder == "" {
+		folder = "General"
+	}
+
+	folderDir := filepath.Join(dir, unsafePathChars.ReplaceAllString(folder, "_"))
+	if err := os.MkdirAll(folderDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	data, err := grafana.MarshalDashboard(dashboard.Dashboard, exportMinify)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal dashboard '%s': %w", title, err)
+	}
+
+	path := filepath.Join(folderDir, unsafePathChars.ReplaceAllString(title, "_")+".json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write dashboard '%s': %w", title, err)
+	}
+	return path, nil
+}
+
+// exportedFolder mirrors the config entry of an exported folder
+type exportedFolder struct {
+	Name string `yaml:"name"`
+}
+
+// writeExportedConfig writes the folders and dashboards config entries of the exported dashboards to
+// <dir>/dashboards.yaml. Without --share-externally the imports are empty, the files keep the live data source UIDs.
+func writeExportedConfig(dir string, folders []string, entries []newDashboardEntry) (string, error) {
+	exportedFolders := []exportedFolder{}
+	for _, folder := range folders {
+		exportedFolders = append(exportedFolders, exportedFolder{Name: folder})
+	}
+
+	data, err := yaml.Marshal(map[string]interface{}{"folders": exportedFolders, "dashboards": entries})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal config entries: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create export directory: %w", err)
+	}
+	path := filepath.Join(dir, "dashboards.yaml")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write config entries: %w", err)
+	}
+	return path, nil
+}
+
+// exportedConfig mirrors a bootstrapped config, in the order of the config reference
+type exportedConfig struct {
+	Log         map[string]string    `yaml:"log"`
+	Grafana     exportedGrafana      `yaml:"grafana"`
+	DataSources []exportedDataSource `yaml:"datasources"`
+	Folders     []exportedFolder     `yaml:"folders"`
+	Dashboards  []newDashboardEntry  `yaml:"dashboards"`
+}
+
+// exportedGrafana mirrors the grafana config section of a bootstrapped config
+type exportedGrafana struct {
+	URL        string `yaml:"url"`
+	Token      string `yaml:"token"`
+	Timeout    string `yaml:"timeout"`
+	Retries    int    `yaml:"retries"`
+	RetryDelay string `yaml:"retry-delay"`
+}
+
+// exportedDataSource mirrors the config entry of an exported data source
+type exportedDataSource struct {
+	Name           string `yaml:"name"`
+	UID            string `yaml:"uid"`
+	Type           string `yaml:"type"`
+	Host           string `yaml:"host,omitempty"`
+	Port           int    `yaml:"port,omitempty"`
+	URL            string `yaml:"url,omitempty"`
+	User           string `yaml:"user,omitempty"`
+	Password       string `yaml:"password,omitempty"`
+	DbName         string `yaml:"dbname,omitempty"`
+	SslMode        string `yaml:"sslmode,omitempty"`
+	ScrapeInterval string `yaml:"scrape_interval,omitempty"`
+	QueryTimeout   string `yaml:"query_timeout,omitempty"`
+	HTTPMethod     string `yaml:"http_method,omitempty"`
+	JSONData       string `yaml:"json_data,omitempty"`
+}
+
+// writeBootstrapConfig writes a complete config provisioning the exported instance to <dir>/config.yaml. Secrets
+// can't be read back: the token and the PostgreSQL passwords become environment placeholders, returned to be set.
+func writeBootstrapConfig(dir string, params grafana.ClientParams, dataSources []grafana.DataSource, folders []string, entries []newDashboardEntry) (string, []string, error) {
+	secrets := []string{"GF_ADMIN_TOKEN"}
+	exportedDataSources := []exportedDataSource{}
+	for _, dataSource := range dataSources {
+		exported, secret := exportDataSource(dataSource)
+		if secret != "" {
+			secrets = append(secrets, secret)
+		}
+		exportedDataSources = append(exportedDataSources, exported)
+	}
+
+	exportedFolders := []exportedFolder{}
+	for _, folder := range folders {
+		exportedFolders = append(exportedFolders, exportedFolder{Name: folder})
+	}
+
+	data, err := yaml.Marshal(exportedConfig{
+		Log: map[string]string{"level": "info", "format": "text"},
+		Grafana: exportedGrafana{
+			URL:        params.URL,
+			Token:      "${GF_ADMIN_TOKEN}",
+			Timeout:    params.Timeout.String(),
+			Retries:    params.Retries,
+			RetryDelay: params.RetryDelay.String(),
+		},
+		DataSources: exportedDataSources,
+		Folders:     exportedFolders,
+		Dashboards:  entries,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", nil, fmt.Errorf("failed to write config: %w", err)
+	}
+	return path, secrets, nil
+}
+
+// exportDataSource converts a live data source into its config entry, keeping the UID so the exported dashboards
+// still resolve. Returns the environment variable of the PostgreSQL password, empty for other types.
+func exportDataSource(dataSource grafana.DataSource) (exportedDataSource, string) {
+	exported := exportedDataSource{Name: dataSource.Name, UID: dataSource.UID, Type: dataSource.Type, User: dataSource.User}
+	jsonData := map[string]interface{}{}
+	for key, value := range dataSource.JSONData {
+		jsonData[key] = value
+	}
+
+	secret := ""
+	switch dataSource.Type {
+	case grafana.DataSourceTypePostgres, "postgres":
+		exported.Type = "postgres"
+		exported.Host, exported.Port = dataSource.URL, 5432
+		if host, port, err := net.SplitHostPort(dataSource.URL); err == nil {
+			exported.Host = host
+			if number, err := strconv.Atoi(port); err == nil {
+				exported.Port = number
+			}
+		}
+		exported.DbName = dataSource.Database
+		if database, ok := jsonData["database"].(string); ok && database != "" {
+			exported.DbName = database
+		}
+		exported.SslMode = "disable"
+		if sslMode, ok := jsonData["sslmode"].(string); ok && sslMode != "" {
+			exported.SslMode = sslMode
+		}
+		secret = "DS_" + strings.Trim(unsafeEnvChars.ReplaceAllString(strings.ToUpper(dataSource.Name), "_"), "_") + "_PASSWORD"
+		exported.Password = "${" + secret + "}"
+		// Set by the provisioner from the settings above
+		for _, key := range []string{"database", "sslmode", "postgresVersion", "timescaledb"} {
+			delete(jsonData, key)
+		}
+	case grafana.DataSourceTypePrometheus:
+		exported.URL = dataSource.URL
+		exported.ScrapeInterval, _ = jsonData["timeInterval"].(string)
+		exported.QueryTimeout, _ = jsonData["queryTimeout"].(string)
+		exported.HTTPMethod, _ = jsonData["httpMethod"].(string)
+		for _, key := range []string{"timeInterval", "queryTimeout", "httpMethod"} {
+			delete(jsonData, key)
+		}
+	default:
+		exported.URL = dataSource.URL
+		exported.DbName = dataSource.Database
+	}
+
+	if len(jsonData) > 0 {
+		if data, err := json.Marshal(jsonData); err == nil {
+			exported.JSONData = string(data)
+		}
+	}
+	return exported, secret
+}
+
+// exportedRuleGroup mirrors the alerting.rule_groups config block of an exported rule group
+type exportedRuleGroup struct {
+	Name     string         `yaml:"name"`
+	Folder   string         `yaml:"folder"`
+	Interval string         `yaml:"interval,omitempty"`
+	Rules    []exportedRule `yaml:"rules"`
+}
+
+// exportedRule mirrors the config of a Grafana-managed rule
+type exportedRule struct {
+	UID         string               `yaml:"uid"`
+	Title       string               `yaml:"title"`
+	Condition   string               `yaml:"condition"`
+	For         string               `yaml:"for,omitempty"`
+	Labels      map[string]string    `yaml:"labels,omitempty"`
+	Annotations map[string]string    `yaml:"annotations,omitempty"`
+	Queries     []exportedQuery      `yaml:"queries"`
+	Expressions []exportedExpression `yaml:"expressions,omitempty"`
+}
+
+// exportedQuery mirrors the config of a rule query
+type exportedQuery struct {
+	RefID        string `yaml:"ref_id"`
+	DataSource   string `yaml:"datasource"`
+	Expr         string `yaml:"expr"`
+	RelativeTime string `yaml:"relative_time,omitempty"`
+}
+
+// exportedExpression mirrors the config of a rule expression
+type exportedExpression struct {
+	RefID      string `yaml:"ref_id"`
+	Type       string `yaml:"type"`
+	Expression string `yaml:"expression"`
+	Reducer    string `yaml:"reducer,omitempty"`
+}
+
+// writeExportedRuleGroups writes the rule groups to <dir>/alert-rules.yaml as an alerting config block
+func writeExportedRuleGroups(dir string, groups []grafana.AlertRuleGroup) (string, error) {
+	exported := []exportedRuleGroup{}
+	for _, group := range groups {
+		exportedGroup := exportedRuleGroup{Name: group.Name, Folder: group.Folder, Rules: []exportedRule{}}
+		if group.Interval > 0 {
+			exportedGroup.Interval = group.Interval.String()
+		}
+
+		for _, rule := range group.Rules {
+			exportedRule := exportedRule{
+				UID:         rule.UID,
+				Title:       rule.Title,
+				Condition:   rule.Condition,
+				For:         rule.For,
+				Labels:      rule.Labels,
+				Annotations: rule.Annotations,
+			}
+			for _, query := range rule.Queries {
+				exportedQuery := exportedQuery{RefID: query.RefID, DataSource: query.DataSource, Expr: query.Expr}
+				if query.RelativeTime > 0 {
+					exportedQuery.RelativeTime = query.RelativeTime.String()
+				}
+				exportedRule.Queries = append(exportedRule.Queries, exportedQuery)
+			}
+			for _, expression := range rule.Expressions {
+				exportedRule.Expressions = append(exportedRule.Expressions, exportedExpression{
+					RefID:      expression.RefID,
+					Type:       expression.Type,
+					Expression: expression.Expression,
+					Reducer:    expression.Reducer,
+				})
+			}
+			exportedGroup.Rules = append(exportedGroup.Rules, exportedRule)
+		}
+		exported = append(exported, exportedGroup)
+	}
+
+	data, err := yaml.Marshal(map[string]interface{}{
+		"alerting": map[string]interface{}{"rule_groups": exported},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal alert rule groups: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create export directory: %w", err)
+	}
+	path := filepath.Join(dir, "alert-rules.yaml")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write alert rule groups: %w", err)
+	}
+	return path, nil
+}
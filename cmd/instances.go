@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/config"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/grafana"
+	"log/slog"
+	"path/filepath"
+	"strings"
+)
+
+// runInstances provisions every Grafana instance of the config like the configs of --config-glob,
+// instance_parallelism at a time, and prints the aggregate report
+func runInstances(ctx context.Context, appConfig *config.AppConfig, log *slog.Logger) error {
+	instances := map[string]config.InstanceConfig{}
+	names := []string{}
+	for _, instance := range appConfig.Instances {
+		instances[instance.Name] = instance
+		names = append(names, instance.Name)
+	}
+
+	return runTenants(ctx, names, appConfig.InstanceParallelism, func(ctx context.Context, name string) (*grafana.Report, error) {
+		instanceLog := log.With("instance", name)
+		report, err := applyConfig(ctx, instanceConfig(appConfig, instances[name]), name, instanceLog)
+		if err != nil {
+			return report, fmt.Errorf("instance '%s': %w", name, err)
+		}
+		return report, nil
+	})
+}
+
+// instanceConfig returns the config of the instance: the shared settings with its URL, token and org, and the
+// shared resources followed by its own. The refs_file and changelog_file get the instance name before the
+// extension, e.g. refs.prod.json.
+func instanceConfig(appConfig *config.AppConfig, instance config.InstanceConfig) *config.AppConfig {
+	instanceConfig := *appConfig
+	instanceConfig.Instances = nil
+	if instance.URL != "" {
+		instanceConfig.Grafana.URL = instance.URL
+	}
+	if instance.Token != "" {
+		// The token replaces the shared credentials of any kind
+		instanceConfig.Grafana.Token = instance.Token
+		instanceConfig.Grafana.APIKey, instanceConfig.Grafana.Username, instanceConfig.Grafana.Password = "", "", ""
+	}
+	if instance.Org != "" {
+		instanceConfig.Grafana.Org = instance.Org
+	}
+
+	if instance.Shared != nil && !*instance.Shared {
+		instanceConfig.DataSources = nil
+		instanceConfig.Folders = nil
+		instanceConfig.Dashboards = nil
+	}
+	instanceConfig.DataSources = append(append([]config.DataSource{}, instanceConfig.DataSources...), instance.DataSources...)
+	instanceConfig.Dashboards = append(append([]config.Dashboard{}, instanceConfig.Dashboards...), instance.Dashboards...)
+	instanceConfig.Folders = append([]config.FolderConfig{}, instanceConfig.Folders...)
+	for _, folder := range instance.Folders {
+		if !hasFolderConfig(instanceConfig.Folders, folder.Name) {
+			instanceConfig.Folders = append(instanceConfig.Folders, folder)
+		}
+	}
+
+	instanceConfig.RefsFile = instanceFile(appConfig.RefsFile, instance.Name)
+	instanceConfig.ChangelogFile = instanceFile(appConfig.ChangelogFile, instance.Name)
+	return &instanceConfig
+}
+
+// hasFolderConfig reports whether the folder with the name is in the list
+func hasFolderConfig(folders []config.FolderConfig, name string) bool {
+	for _, folder := range folders {
+		if folder.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// instanceFile inserts the instance name before the extension of the file, empty paths stay empty
+func instanceFile(path string, instance string) string {
+	if path == "" {
+		return ""
+	}
+	extension := filepath.Ext(path)
+	return strings.TrimSuffix(path, extension) + "." + unsafePathChars.ReplaceAllString(instance, "_") + extension
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Defaults of the log sampling
+const (
+	defaultLogSamplingBurst    = 5
+	defaultLogSamplingInterval = time.Minute
+)
+
+// logOutput is the log destination shared by every logger of the process
+var logOutput io.Writer = &syncWriter{out: os.Stderr}
+
+// syncWriter serializes the writes of the log handlers, each record is written in one piece
+type syncWriter struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func (writer *syncWriter) Write(p []byte) (int, error) {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	return writer.out.Write(p)
+}
+
+// samplingHandler logs at most burst warnings with the same message per interval and drops the others.
+// The last warning logged before dropping says so, the first one logged after carries the number of dropped
+// repetitions.
+type samplingHandler struct {
+	next     slog.Handler
+	burst    int
+	interval time.Duration
+	state    *samplingState // Shared by the handlers derived with WithAttrs and WithGroup
+}
+
+// samplingState counts the warnings of the current interval by message
+type samplingState struct {
+	mu      sync.Mutex
+	windows map[string]*samplingWindow
+}
+
+type samplingWindow struct {
+	started    time.Time
+	logged     int
+	suppressed int
+}
+
+func newSamplingHandler(next slog.Handler, burst int, interval time.Duration) *samplingHandler {
+	return &samplingHandler{
+		next:     next,
+		burst:    burst,
+		interval: interval,
+		state:    &samplingState{windows: map[string]*samplingWindow{}},
+	}
+}
+
+func (handler *samplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return handler.next.Enabled(ctx, level)
+}
+
+func (handler *samplingHandler) Handle(ctx context.Context, record slog.Record) error {
+	// Only warnings are sampled, errors and progress messages are always logged
+	if record.Level != slog.LevelWarn {
+		return handler.next.Handle(ctx, record)
+	}
+
+	suppressed, last, ok := handler.admit(record.Message, record.Time)
+	if !ok {
+		return nil
+	}
+	if suppressed > 0 || last {
+		record = record.Clone()
+	}
+	if suppressed > 0 {
+		record.AddAttrs(slog.Int("suppressed_repeats", suppressed))
+	}
+	if last {
+		record.AddAttrs(slog.Duration("repeats_sampled_for", handler.interval))
+	}
+	return handler.next.Handle(ctx, record)
+}
+
+// admit reports whether the warning is logged, how many repetitions were dropped before it and whether the
+// following ones are dropped
+func (handler *samplingHandler) admit(message string, at time.Time) (int, bool, bool) {
+	state := handler.state
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	suppressed := 0
+	window, ok := state.windows[message]
+	if !ok || at.Sub(window.started) >= handler.interval {
+		if ok {
+			suppressed = window.suppressed
+		}
+		window = &samplingWindow{started: at}
+		state.windows[message] = window
+	}
+
+	if window.logged >= handler.burst {
+		window.suppressed++
+		return 0, false, false
+	}
+	window.logged++
+	return suppressed, window.logged == handler.burst, true
+}
+
+func (handler *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	derived := *handler
+	derived.next = handler.next.WithAttrs(attrs)
+	return &derived
+}
+
+func (handler *samplingHandler) WithGroup(name string) slog.Handler {
+	derived := *handler
+	derived.next = handler.next.WithGroup(name)
+	return &derived
+}
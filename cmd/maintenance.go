@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/grafana"
+
+	"github.com/spf13/cobra"
+)
+
+var maintenanceCmd = &cobra.Command{
+	Use:   "maintenance",
+	Short: "Pause or resume the managed alert rules around a maintenance window",
+	Long: `Pauses or resumes the evaluation of all rules in the Grafana-managed rule groups of the
+config, to avoid alert storms during large changes. Rule groups of Mimir/Loki rulers are not affected.`,
+}
+
+var maintenancePauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Pause the managed alert rules",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMaintenance(true)
+	},
+}
+
+var maintenanceResumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Resume the managed alert rules",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMaintenance(false)
+	},
+}
+
+func init() {
+	maintenanceCmd.AddCommand(maintenancePauseCmd, maintenanceResumeCmd)
+	rootCmd.AddCommand(maintenanceCmd)
+}
+
+// runMaintenance pauses or resumes the managed alert rules
+func runMaintenance(paused bool) error {
+	_, provisionerConfig, log, err := loadProvisionerConfig()
+	if err != nil {
+		return err
+	}
+	client := grafana.NewClient(provisionerConfig.Grafana, log)
+
+	changed, err := grafana.PauseAlerts(client, provisionerConfig, paused, log)
+	if err != nil {
+		return fmt.Errorf("failed to update alert rules: %w", err)
+	}
+
+	action := "Resumed"
+	if paused {
+		action = "Paused"
+	}
+	fmt.Printf("%s %d alert rules.\n", action, len(changed))
+	return nil
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/grafana"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	migrateFromURL   string
+	migrateFromToken string
+	migrateOutput    string
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Migrate resources of older Grafana versions into the config",
+}
+
+var migrateChannelsCmd = &cobra.Command{
+	Use:   "notification-channels",
+	Short: "Convert the legacy notification channels of an old instance into contact point config entries",
+	Long: `Reads the legacy alerting notification channels (/api/alert-notifications) of the instance at
+--from-url and writes them as an alerting.contact_points config block to --output, to provision
+them on a new instance with unified alerting. Names, UIDs, types and settings are kept. Secure
+settings can't be read back and become ${CONTACT_<NAME>_<SETTING>} environment placeholders.
+What can't be carried over is printed, e.g. the default channel and reminders. The config is
+only read for logging, the token defaults to $LEGACY_GRAFANA_TOKEN.`,
+	Args: cobra.NoArgs,
+	RunE: runMigrateChannels,
+}
+
+func init() {
+	migrateChannelsCmd.Flags().StringVar(&migrateFromURL, "from-url", "", "URL of the Grafana instance with the legacy channels (required)")
+	migrateChannelsCmd.Flags().StringVar(&migrateFromToken, "from-token", os.Getenv("LEGACY_GRAFANA_TOKEN"), "token of the instance with the legacy channels (env LEGACY_GRAFANA_TOKEN)")
+	migrateChannelsCmd.Flags().StringVarP(&migrateOutput, "output", "o", "contact-points.yaml", "file to write the contact points config block to")
+	migrateChannelsCmd.MarkFlagRequired("from-url")
+	migrateCmd.AddCommand(migrateChannelsCmd)
+	rootCmd.AddCommand(migrateCmd)
+}
+
+// exportedContactPoint mirrors the alerting.contact_points config entry of a migrated channel
+type exportedContactPoint struct {
+	UID                   string                 `yaml:"uid,omitempty"`
+	Name                  string                 `yaml:"name"`
+	Type                  string                 `yaml:"type"`
+	Settings              map[string]interface{} `yaml:"settings"`
+	DisableResolveMessage bool                   `yaml:"disable_resolve_message,omitempty"`
+}
+
+// runMigrateChannels writes the contact points converted from the legacy channels
+func runMigrateChannels(cmd *cobra.Command, args []string) error {
+	_, log, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	client := grafana.NewClient(grafana.ClientParams{URL: migrateFromURL, Token: migrateFromToken, Timeout: 30 * time.Second, Retries: 3, RetryDelay: 2 * time.Second}, log)
+
+	channels, err := client.GetLegacyNotificationChannels()
+	if err != nil {
+		return err
+	}
+	contactPoints, notes := grafana.MigrateNotificationChannels(channels)
+
+	exported := []exportedContactPoint{}
+	for _, contactPoint := range contactPoints {
+		exported = append(exported, exportedContactPoint{
+			UID:                   contactPoint.UID,
+			Name:                  contactPoint.Name,
+			Type:                  contactPoint.Type,
+			Settings:              contactPoint.Settings,
+			DisableResolveMessage: contactPoint.DisableResolveMessage,
+		})
+	}
+	data, err := yaml.Marshal(map[string]interface{}{
+		"alerting": map[string]interface{}{"contact_points": exported},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal contact points: %w", err)
+	}
+	if err := os.WriteFile(migrateOutput, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write contact points: %w", err)
+	}
+
+	for _, note := range notes {
+		fmt.Fprintf(cmd.OutOrStdout(), "  - %s\n", note)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%d of %d channels written to %s\n", len(contactPoints), len(channels), migrateOutput)
+	return nil
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/config"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/grafana"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	newDashboardName       string
+	newDashboardFolder     string
+	newDashboardDataSource string
+	newDashboardFile       string
+)
+
+var newCmd = &cobra.Command{
+	Use:   "new",
+	Short: "Scaffold new resources and add them to the config",
+}
+
+var newDashboardCmd = &cobra.Command{
+	Use:   "dashboard",
+	Short: "Create a dashboard JSON file wired to a data source and add it to the config",
+	Long: `Writes a minimal dashboard with one time series panel to --file (dashboards/<name>.json by
+default). The panel queries the data source through a ${DS_NAME} input, and the dashboard entry
+with the matching 'imports' mapping is appended to the config file. The config file is rewritten
+with 4-space indentation, its comments are kept. Grafana is not contacted.`,
+	Args: cobra.NoArgs,
+	RunE: runNewDashboard,
+}
+
+// newDashboardEntry is the config entry of a scaffolded dashboard
+type newDashboardEntry struct {
+	Name    string              `yaml:"name"`
+	File    string              `yaml:"file"`
+	Folder  string              `yaml:"folder,omitempty"`
+	Imports []newDashboardInput `yaml:"imports"`
+}
+
+// newDashboardInput is an imports mapping of a scaffolded dashboard
+type newDashboardInput struct {
+	Name       string `yaml:"name"`
+	DataSource string `yaml:"datasource"`
+}
+
+func init() {
+	newDashboardCmd.Flags().StringVar(&newDashboardName, "name", "", "dashboard title and config name (required)")
+	newDashboardCmd.Flags().StringVar(&newDashboardFolder, "folder", "", "folder of the dashboard, one of the configured folders; General if empty")
+	newDashboardCmd.Flags().StringVar(&newDashboardDataSource, "datasource", "", "configured data source the panel queries (required)")
+	newDashboardCmd.Flags().StringVar(&newDashboardFile, "file", "", "path of the dashboard JSON file, defaults to dashboards/<name>.json")
+	newDashboardCmd.MarkFlagRequired("name")
+	newDashboardCmd.MarkFlagRequired("datasource")
+	newCmd.AddCommand(newDashboardCmd)
+	rootCmd.AddCommand(newCmd)
+}
+
+// runNewDashboard writes the dashboard file and appends its entry to the config
+func runNewDashboard(cmd *cobra.Command, args []string) error {
+	appConfig, log, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	provisionerConfig, err := toProvisionerConfig(appConfig)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	for _, dashboard := range provisionerConfig.Dashboards {
+		if dashboard.Name == newDashboardName {
+			return fmt.Errorf("dashboard '%s' is already configured", newDashboardName)
+		}
+	}
+
+	if newDashboardFolder != "" && !strings.EqualFold(newDashboardFolder, "General") {
+		found := false
+		for _, folder := range provisionerConfig.Folders {
+			found = found || folder.Name == newDashboardFolder
+		}
+		if !found {
+			return fmt.Errorf("folder '%s' is not in the 'folders' list of the config, add it first", newDashboardFolder)
+		}
+	}
+
+	var dataSource *grafana.DataSource
+	names := []string{}
+	for i, configured := range provisionerConfig.DataSources {
+		names = append(names, configured.Name)
+		if configured.Name == newDashboardDataSource {
+			dataSource = &provisionerConfig.DataSources[i]
+		}
+	}
+	if dataSource == nil {
+		return fmt.Errorf("data source '%s' is not configured, use one of: %s", newDashboardDataSource, strings.Join(names, ", "))
+	}
+
+	file := newDashboardFile
+	if file == "" {
+		file = filepath.Join("dashboards", unsafePathChars.ReplaceAllString(newDashboardName, "_")+".json")
+	}
+	if _, err := os.Stat(file); err == nil {
+		return fmt.Errorf("dashboard file '%s' already exists", file)
+	}
+
+	dashboard, dashboardImport := grafana.ScaffoldDashboard(newDashboardName, *dataSource)
+	data, err := grafana.MarshalDashboard(dashboard, false)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dashboard '%s': %w", newDashboardName, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for '%s': %w", file, err)
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return fmt.Errorf("failed to write dashboard file '%s': %w", file, err)
+	}
+	log.Info("Dashboard file written", "file", file, "input", dashboardImport.Name, "datasource", dataSource.Name)
+
+	entry := newDashboardEntry{
+		Name:    newDashboardName,
+		File:    filepath.ToSlash(file),
+		Folder:  newDashboardFolder,
+		Imports: []newDashboardInput{{Name: dashboardImport.Name, DataSource: dashboardImport.DataSource}},
+	}
+	if err := config.AppendListEntry(configPath, "dashboards", entry); err != nil {
+		return err
+	}
+	log.Info("Dashboard added to the config", "config", configPath, "dashboard", newDashboardName)
+	return nil
+}
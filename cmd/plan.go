@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Print the changes apply would make, without changing Grafana",
+	Long: `Same as 'apply --dry-run': reads Grafana and prints every create, update and delete the
+run would make, with the totals.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun = true
+		return runApply(cmd, args)
+	},
+}
+
+func init() {
+	planCmd.Flags().StringVar(&configGlob, "config-glob", "", "plan every config matching the glob (e.g. 'tenants/*/config.yaml') instead of --config")
+	planCmd.Flags().IntVar(&parallel, "parallel", 4, "number of configs planned at once with --config-glob")
+	planCmd.Flags().BoolVar(&overrideWindow, "override-window", false, "plan outside the configured change_window")
+	planCmd.Flags().BoolVar(&strictTokenScope, "strict", false, "fail instead of warning when the token has more permissions than the config needs")
+	planCmd.Flags().StringVar(&shard, "shard", "", "plan one of several disjoint shards of the config, e.g. 2/5")
+	rootCmd.AddCommand(planCmd)
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/grafana"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var probeCmd = &cobra.Command{
+	Use:   "probe",
+	Short: "Report the capabilities of the Grafana instance and the config parts it doesn't support",
+	Long: `Reports the version, edition (OSS, Enterprise or Cloud), enabled features and installed plugins
+of the Grafana instance and lists the parts of the config it can't provision. Exits non-zero when any are found.`,
+	Args: cobra.NoArgs,
+	RunE: runProbe,
+}
+
+func init() {
+	rootCmd.AddCommand(probeCmd)
+}
+
+// runProbe prints the probe report of the configured Grafana instance
+func runProbe(cmd *cobra.Command, args []string) error {
+	_, provisionerConfig, log, err := loadProvisionerConfig()
+	if err != nil {
+		return err
+	}
+	client := grafana.NewClient(provisionerConfig.Grafana, log)
+
+	report, err := grafana.Probe(client, provisionerConfig, log)
+	if err != nil {
+		return fmt.Errorf("probe failed: %w", err)
+	}
+
+	fmt.Printf("Grafana %s (%s)\n", report.Version, report.Edition)
+	fmt.Printf("Token: %s in org '%s' (role %s, server admin %t)\n", report.Token.Login, report.Token.OrgName, report.Token.Role, report.Token.IsGrafanaAdmin)
+
+	features := []string{}
+	for feature := range report.Features {
+		features = append(features, feature)
+	}
+	sort.Strings(features)
+	fmt.Println("\nFeatures:")
+	for _, feature := range features {
+		state := "disabled"
+		if report.Features[feature] {
+			state = "enabled"
+		}
+		fmt.Printf("  %-20s %s\n", feature, state)
+	}
+
+	fmt.Println("\nPlugins:")
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, plugin := range report.Plugins {
+		fmt.Fprintf(writer, "  %s\t%s\t%s\n", plugin.ID, plugin.Type, plugin.Info.Version)
+	}
+	writer.Flush()
+
+	if len(report.Unsupported) == 0 {
+		fmt.Println("\nThe config is fully supported.")
+		return nil
+	}
+
+	fmt.Println("\nUnsupported:")
+	for _, item := range report.Unsupported {
+		fmt.Printf("  - %s\n", item)
+	}
+	return fmt.Errorf("%d parts of the config are not supported by the instance", len(report.Unsupported))
+}
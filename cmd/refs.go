@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/grafana"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// writeReferences writes the reference map as JSON or YAML depending on the file extension
+func writeReferences(path string, references grafana.ReferenceMap) error {
+	var data []byte
+	var err error
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		data, err = yaml.Marshal(references)
+	case ".json":
+		data, err = json.MarshalIndent(references, "", "  ")
+		data = append(data, '\n')
+	default:
+		return fmt.Errorf("unsupported reference map file extension '%s', use .json, .yaml or .yml", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal reference map: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write reference map file '%s': %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/buildinfo"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/config"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/grafana"
+	"log/slog"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// configPath is the path to the configuration file, shared by all subcommands
+var configPath string
+
+// allowMassChange lets a run exceed the configured safety limits
+var allowMassChange bool
+
+// failureInjection simulates Grafana API failures for testing the retry behavior, hidden from the help
+var failureInjection grafana.FailureInjection
+
+// valuesFile overrides the values_file of the config
+var valuesFile string
+
+// resourceSelector narrows every command to the resources with matching labels
+var resourceSelector string
+
+var rootCmd = &cobra.Command{
+	Use:   "grafana-provisioner",
+	Short: "Provision Grafana data sources, folders and dashboards from config",
+	// Running without a subcommand keeps the original single-shot behavior
+	RunE:          runApply,
+	Version:       buildinfo.Version,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	// --version prints the same line as the version command
+	rootCmd.SetVersionTemplate(buildinfo.String() + "\n")
+
+	defaultConfigPath := os.Getenv("CONFIG_PATH")
+	if defaultConfigPath == "" {
+		defaultConfigPath = "config.yaml"
+	}
+
+	rootCmd.PersistentFlags().BoolVar(&allowMassChange, "allow-mass-change", false, "allow exceeding the safety limits on deletes and overwrites")
+	rootCmd.PersistentFlags().StringVar(&valuesFile, "values", "", "per-environment values file substituted into dashboard placeholders (overrides values_file)")
+	rootCmd.PersistentFlags().StringVar(&resourceSelector, "select", "", "only handle the data sources, folders and dashboards with matching labels, e.g. 'team=payments,tier!=dev'")
+	rootCmd.PersistentFlags().Float64Var(&failureInjection.ErrorRate, "inject-error-rate", 0, "share of API requests failing with a simulated 503 (0-1)")
+	rootCmd.PersistentFlags().Float64Var(&failureInjection.TimeoutRate, "inject-timeout-rate", 0, "share of API requests failing with a simulated timeout (0-1)")
+	rootCmd.PersistentFlags().MarkHidden("inject-error-rate")
+	rootCmd.PersistentFlags().MarkHidden("inject-timeout-rate")
+	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", defaultConfigPath, "path to the configuration file (env CONFIG_PATH)")
+}
+
+// Execute runs the command line interface
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		slog.Error("FATAL: Command failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+// loadConfig loads the configuration file and initializes the logger from it
+func loadConfig() (*config.AppConfig, *slog.Logger, error) {
+	appConfig, log, err := loadConfigFrom(configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	slog.SetDefault(log)
+	log.Info("Provisioner logger started")
+
+	return appConfig, log, nil
+}
+
+// loadConfigFrom loads the given configuration file and creates the logger described by it
+func loadConfigFrom(path string) (*config.AppConfig, *slog.Logger, error) {
+	// 1. Load configuration
+	appConfig, err := config.Load(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	// 2. Initialize logger (using slog)
+	log, err := newLogger(appConfig.Log)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return appConfig, log, nil
+}
+
+// loadProvisionerConfig loads the configuration file and converts it to the grafana provisioner config.
+// A tunnel or port-forward to Grafana stays open until the process exits.
+func loadProvisionerConfig() (*config.AppConfig, grafana.Config, *slog.Logger, error) {
+	appConfig, log, err := loadConfig()
+	if err != nil {
+		return nil, grafana.Config{}, nil, err
+	}
+
+	provisionerConfig, _, err := buildProvisionerConfig(appConfig, log)
+	if err != nil {
+		return nil, grafana.Config{}, nil, err
+	}
+	return appConfig, provisionerConfig, log, nil
+}
+
+// buildProvisionerConfig converts the loaded configuration and opens the connections it needs to reach Grafana.
+// The returned function closes them.
+func buildProvisionerConfig(appConfig *config.AppConfig, log *slog.Logger) (grafana.Config, func() error, error) {
+	closeConnection := func() error { return nil }
+
+	provisionerConfig, err := selectProvisionerConfig(appConfig, log)
+	if err != nil {
+		return grafana.Config{}, nil, err
+	}
+	if appConfig.SSHTunnel.Host != "" && appConfig.Kube.Service != "" {
+		return grafana.Config{}, nil, fmt.Errorf("invalid configuration: 'ssh_tunnel' and 'kube' can't be used together")
+	}
+
+	// Reach a Grafana on a private network through the bastion host
+	if tunnel := appConfig.SSHTunnel; tunnel.Host != "" {
+		dial, closeTunnel, err := grafana.OpenSSHTunnel(toSSHTunnel(tunnel), provisionerConfig.Grafana.URL, log)
+		if err != nil {
+			return grafana.Config{}, nil, err
+		}
+		provisionerConfig.Grafana.DialContext = dial
+		closeConnection = closeTunnel
+	}
+
+	// Reach a cluster-internal Grafana through a port-forward to its service
+	if kube := appConfig.Kube; kube.Service != "" {
+		dial, closeForward, err := grafana.OpenKubePortForward(toKubeTarget(kube), provisionerConfig.Grafana.URL, log)
+		if err != nil {
+			return grafana.Config{}, nil, err
+		}
+		provisionerConfig.Grafana.DialContext = dial
+		closeConnection = closeForward
+	}
+
+	return provisionerConfig, closeConnection, nil
+}
+
+// selectProvisionerConfig converts the loaded configuration and narrows it to the resources matched by --select
+func selectProvisionerConfig(appConfig *config.AppConfig, log *slog.Logger) (grafana.Config, error) {
+	provisionerConfig, err := toProvisionerConfig(appConfig)
+	if err != nil {
+		return grafana.Config{}, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	selector, err := grafana.ParseSelector(resourceSelector)
+	if err != nil {
+		return grafana.Config{}, err
+	}
+	return grafana.SelectResources(provisionerConfig, selector, log), nil
+}
+
+// newLogger creates the slog logger described by the log config section
+func newLogger(logConfig config.LogConfig) (*slog.Logger, error) {
+	logLevel := new(slog.LevelVar)
+	if err := logLevel.UnmarshalText([]byte(logConfig.Level)); err != nil {
+		return nil, fmt.Errorf("invalid log level in config '%s': %w", logConfig.Level, err)
+	}
+
+	handlerOptions := &slog.HandlerOptions{
+		Level: logLevel,
+	}
+
+	// All loggers share one synchronized output, so concurrent runs of a batch don't interleave their lines
+	var handler slog.Handler = slog.NewTextHandler(logOutput, handlerOptions)
+	if logConfig.Format == "json" {
+		handler = slog.NewJSONHandler(logOutput, handlerOptions)
+	}
+
+	burst, interval := logConfig.Sampling.Burst, logConfig.Sampling.Interval.Duration
+	if burst == 0 {
+		burst = defaultLogSamplingBurst
+	}
+	if interval <= 0 {
+		interval = defaultLogSamplingInterval
+	}
+	if burst > 0 {
+		handler = newSamplingHandler(handler, burst, interval)
+	}
+
+	return slog.New(handler), nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/grafana"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// actionColors are the ANSI colors of the summary rows by action. All codes have the same length, so the
+// columns stay aligned with colors.
+var actionColors = map[string]string{
+	grafana.ActionCreated:     "\x1b[32m",
+	grafana.ActionUpdated:     "\x1b[33m",
+	grafana.ActionDeleted:     "\x1b[31m",
+	grafana.ActionProvisioned: "\x1b[36m",
+	grafana.ActionSkipped:     "\x1b[90m",
+}
+
+// ANSI codes of the summary header and of the end of a colored row
+const (
+	colorBold  = "\x1b[01m"
+	colorReset = "\x1b[0m"
+)
+
+// printSummary prints a table of the resources the run changed, with their action, duration and URL, and the
+// totals by action. Unchanged resources are only counted. Rows are colored by action when stdout is a terminal
+// and NO_COLOR is not set.
+func printSummary(report *grafana.Report) {
+	writeSummary(os.Stdout, report, colorOutput(os.Stdout))
+}
+
+// writeSummary writes the summary table, colored or not
+func writeSummary(out io.Writer, report *grafana.Report, color bool) {
+	paint := func(code string, row string) string {
+		if !color {
+			return row
+		}
+		return code + row + colorReset
+	}
+
+	counts := map[string]int{}
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, paint(colorBold, "KIND\tNAME\tACTION\tDURATION\tURL"))
+	for _, resource := range report.Resources {
+		counts[resource.Action]++
+		if resource.Action == grafana.ActionUnchanged {
+			continue
+		}
+		code, known := actionColors[resource.Action]
+		if !known {
+			code = colorBold
+		}
+		row := fmt.Sprintf("%s\t%s\t%s\t%s\t%s", resource.Kind, resource.Name, resource.Action, resource.Duration.Round(time.Millisecond), resource.URL)
+		fmt.Fprintln(writer, paint(code, row))
+	}
+	writer.Flush()
+
+	shard := ""
+	if report.Shard != "" {
+		shard = " (shard " + report.Shard + ")"
+	}
+	fmt.Fprintf(out, "Summary: %d created, %d updated, %d deleted, %d provisioned, %d skipped, %d unchanged in %s%s\n",
+		counts[grafana.ActionCreated], counts[grafana.ActionUpdated], counts[grafana.ActionDeleted], counts[grafana.ActionProvisioned],
+		counts[grafana.ActionSkipped], counts[grafana.ActionUnchanged], report.FinishedAt.Sub(report.StartedAt).Round(time.Millisecond), shard)
+}
+
+// colorOutput reports whether the file is a terminal and colors aren't disabled with NO_COLOR
+func colorOutput(file *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
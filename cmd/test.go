@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/grafana"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	testFormat string
+	testOutput string
+)
+
+// errPanelAssertionsFailed is returned when at least one panel assertion fails, so CI jobs fail
+var errPanelAssertionsFailed = errors.New("dashboard panel assertions failed")
+
+var testCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Run the panel queries of the dashboards and check the configured assertions",
+	Long: `Runs the queries of the panels listed in the dashboards' assertions through
+/api/ds/query and checks that they return data in the expected range, catching dashboards that
+render but show no data after an environment change. Exits non-zero on failures.`,
+	Args: cobra.NoArgs,
+	RunE: runTest,
+}
+
+func init() {
+	testCmd.Flags().StringVar(&testFormat, "format", "text", "report format: text or junit")
+	testCmd.Flags().StringVarP(&testOutput, "output", "o", "", "write the report to this file instead of stdout")
+	rootCmd.AddCommand(testCmd)
+}
+
+// runTest checks the panel assertions and writes the report
+func runTest(cmd *cobra.Command, args []string) error {
+	_, provisionerConfig, log, err := loadProvisionerConfig()
+	if err != nil {
+		return err
+	}
+	client := grafana.NewClient(provisionerConfig.Grafana, log)
+
+	checks, err := grafana.TestDashboards(client, provisionerConfig, log)
+	if err != nil {
+		return fmt.Errorf("dashboard test failed: %w", err)
+	}
+
+	var out io.Writer = os.Stdout
+	if testOutput != "" {
+		file, err := os.Create(testOutput)
+		if err != nil {
+			return fmt.Errorf("failed to create report file '%s': %w", testOutput, err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	switch testFormat {
+	case "text":
+		err = writeTextChecks(out, checks)
+	case "junit":
+		err = writeJUnitChecks(out, "test", checks)
+	default:
+		return fmt.Errorf("unsupported report format '%s', use text or junit", testFormat)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	for _, check := range checks {
+		if !check.Passed {
+			return errPanelAssertionsFailed
+		}
+	}
+
+	log.Info("All panel assertions passed", "checks", len(checks))
+	return nil
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/grafana"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	backtestWindow time.Duration
+	backtestStep   time.Duration
+	backtestGroup  string
+)
+
+// errAlertBacktestFailed is returned when a rule couldn't be backtested, so CI jobs fail
+var errAlertBacktestFailed = errors.New("alert rule backtest failed")
+
+var testAlertsCmd = &cobra.Command{
+	Use:   "test-alerts",
+	Short: "Backtest the alert rules over historical data",
+	Long: `Evaluates the configured alert rules at every step of a past window, Grafana-managed rules
+through /api/v1/eval and the rules of Mimir and Loki rulers with range queries, and reports how
+many times each would have fired. Nothing is provisioned, so thresholds and 'for' durations can be
+tuned before apply. Exits non-zero if a rule can't be evaluated.`,
+	Args: cobra.NoArgs,
+	RunE: runTestAlerts,
+}
+
+func init() {
+	testAlertsCmd.Flags().DurationVar(&backtestWindow, "window", 24*time.Hour, "historical window the rules are evaluated over, ending now")
+	testAlertsCmd.Flags().DurationVar(&backtestStep, "step", 0, "time between the evaluations, defaults to the interval of each rule group")
+	testAlertsCmd.Flags().StringVar(&backtestGroup, "group", "", "only backtest the rule group with this name")
+	rootCmd.AddCommand(testAlertsCmd)
+}
+
+// runTestAlerts backtests the alert rules and prints the firings of each
+func runTestAlerts(cmd *cobra.Command, args []string) error {
+	ctx, stop := interruptContext()
+	defer stop()
+
+	_, provisionerConfig, log, err := loadProvisionerConfig()
+	if err != nil {
+		return err
+	}
+	client := grafana.NewClient(provisionerConfig.Grafana, log).WithContext(ctx)
+
+	now := time.Now()
+	options := grafana.BacktestOptions{From: now.Add(-backtestWindow), To: now, Step: backtestStep, Group: backtestGroup}
+	results, err := grafana.BacktestAlertRules(client, provisionerConfig, options, log)
+	if err != nil {
+		return fmt.Errorf("alert rule backtest failed: %w", err)
+	}
+	if len(results) == 0 {
+		log.Warn("No alert rules to backtest")
+		return nil
+	}
+
+	writeBacktest(os.Stdout, results, backtestWindow)
+	for _, result := range results {
+		if result.Error != "" {
+			return errAlertBacktestFailed
+		}
+	}
+	return nil
+}
+
+// writeBacktest writes the firings of each rule over the window, and the errors of the rules that failed
+func writeBacktest(out io.Writer, results []grafana.BacktestResult, window time.Duration) {
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprint(writer, "GROUP\tRULE\tEVALUATIONS\tFIRINGS\tSERIES\tFIRING TIME\tFIRST FIRING\n")
+	for _, result := range results {
+		if result.Error != "" {
+			fmt.Fprintf(writer, "%s\t%s\t-\t-\t-\t-\terror: %s\n", result.Group, result.Rule, result.Error)
+			continue
+		}
+		first := "-"
+		if !result.FirstFiring.IsZero() {
+			first = result.FirstFiring.Format(time.RFC3339)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%d\t%d\t%d\t%s\t%s\n", result.Group, result.Rule, result.Evaluations, result.Firings,
+			result.Series, result.FiringTime.Round(time.Second), first)
+	}
+	writer.Flush()
+
+	firing := 0
+	for _, result := range results {
+		if result.Firings > 0 {
+			firing++
+		}
+	}
+	fmt.Fprintf(out, "Backtest: %d of %d rules would have fired in the last %s\n", firing, len(results), window)
+}
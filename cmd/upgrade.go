@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/grafana"
+
+	"github.com/spf13/cobra"
+)
+
+var upgradeTargetVersion string
+
+var checkUpgradeCmd = &cobra.Command{
+	Use:   "check-upgrade",
+	Short: "Check the managed dashboards and the alerting for compatibility with a new Grafana version",
+	Long: `Analyzes the live versions of the managed dashboards, or their files when they don't exist yet,
+and the installed plugins before a Grafana upgrade: deprecated panel types (graph to timeseries),
+AngularJS plugins and legacy dashboard alerts and notification channels. Prints a migration report
+and exits non-zero when something breaks on the target version.`,
+	Args: cobra.NoArgs,
+	RunE: runCheckUpgrade,
+}
+
+func init() {
+	checkUpgradeCmd.Flags().StringVar(&upgradeTargetVersion, "target-version", "", "Grafana version to upgrade to, e.g. 11.x")
+	checkUpgradeCmd.MarkFlagRequired("target-version")
+	rootCmd.AddCommand(checkUpgradeCmd)
+}
+
+// runCheckUpgrade prints the migration report for the target version
+func runCheckUpgrade(cmd *cobra.Command, args []string) error {
+	_, provisionerConfig, log, err := loadProvisionerConfig()
+	if err != nil {
+		return err
+	}
+	client := grafana.NewClient(provisionerConfig.Grafana, log)
+
+	report, err := grafana.CheckUpgrade(client, provisionerConfig, upgradeTargetVersion, log)
+	if err != nil {
+		return fmt.Errorf("upgrade check failed: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Upgrade of Grafana %s to %s\n", report.CurrentVersion, report.TargetVersion)
+	errorCount := 0
+	for _, issue := range report.Issues {
+		if issue.Severity == grafana.SeverityError {
+			errorCount++
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), issue.String())
+	}
+	if errorCount > 0 {
+		return fmt.Errorf("%d issues break on Grafana %s, migrate them before the upgrade", errorCount, report.TargetVersion)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Nothing breaks on Grafana %s.\n", report.TargetVersion)
+	return nil
+}
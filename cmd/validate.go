@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/grafana"
+
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the config without contacting Grafana",
+	Long: `Checks the config offline, e.g. in a pre-merge pipeline stage: the config schema, unique
+names, dashboards in configured folders, dashboard files that parse with their values placeholders,
+'__inputs' and links covered by the config, and references to data sources, teams and rulers.
+References to resources that may already exist in Grafana are warnings. Exits non-zero on errors.`,
+	Args: cobra.NoArgs,
+	RunE: runValidate,
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
+
+// runValidate prints the problems found in the config
+func runValidate(cmd *cobra.Command, args []string) error {
+	appConfig, log, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	provisionerConfig, err := selectProvisionerConfig(appConfig, log)
+	if err != nil {
+		return err
+	}
+
+	errorCount := 0
+	for _, issue := range grafana.ValidateConfig(provisionerConfig) {
+		if issue.Severity == grafana.SeverityError {
+			errorCount++
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), issue.String())
+	}
+	if errorCount > 0 {
+		return fmt.Errorf("%d errors found in the config", errorCount)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Config '%s' is valid.\n", configPath)
+	return nil
+}
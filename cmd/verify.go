@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/buildinfo"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/grafana"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyFormat string
+	verifyOutput string
+)
+
+// errVerificationFailed is returned when at least one check fails, so CI jobs fail
+var errVerificationFailed = errors.New("live instance does not match config")
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Assert that the live Grafana instance matches the config (read-only)",
+	Long: `Checks every configured folder, data source, dashboard and alert rule group against the
+live instance without changing anything, and writes a compliance report. Exits non-zero on failures.`,
+	RunE: runVerify,
+}
+
+func init() {
+	verifyCmd.Flags().StringVar(&verifyFormat, "format", "text", "report format: text, junit or sarif")
+	verifyCmd.Flags().StringVarP(&verifyOutput, "output", "o", "", "write the report to this file instead of stdout")
+	rootCmd.AddCommand(verifyCmd)
+}
+
+// runVerify runs the verification and writes the report
+func runVerify(cmd *cobra.Command, args []string) error {
+	_, provisionerConfig, log, err := loadProvisionerConfig()
+	if err != nil {
+		return err
+	}
+	client := grafana.NewClient(provisionerConfig.Grafana, log)
+
+	checks, err := grafana.Verify(client, provisionerConfig, log)
+	if err != nil {
+		return fmt.Errorf("verification failed: %w", err)
+	}
+
+	var out io.Writer = os.Stdout
+	if verifyOutput != "" {
+		file, err := os.Create(verifyOutput)
+		if err != nil {
+			return fmt.Errorf("failed to create report file '%s': %w", verifyOutput, err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	switch verifyFormat {
+	case "text":
+		err = writeTextChecks(out, checks)
+	case "junit":
+		err = writeJUnitChecks(out, "verify", checks)
+	case "sarif":
+		err = writeSARIFChecks(out, checks)
+	default:
+		return fmt.Errorf("unsupported report format '%s', use text, junit or sarif", verifyFormat)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	for _, check := range checks {
+		if !check.Passed && !check.Skipped {
+			return errVerificationFailed
+		}
+	}
+
+	log.Info("Live instance matches config", "checks", len(checks))
+	return nil
+}
+
+// writeTextChecks writes one line per check
+func writeTextChecks(out io.Writer, checks []grafana.Check) error {
+	for _, check := range checks {
+		status := "PASS"
+		if check.Skipped {
+			status = "SKIP"
+		} else if !check.Passed {
+			status = "FAIL"
+		}
+		if _, err := fmt.Fprintf(out, "%s  %s '%s': %s\n", status, check.Kind, check.Name, check.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// JUnit XML report structures
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+// writeJUnitChecks writes the checks of the command as a JUnit XML report with one test suite per resource kind
+func writeJUnitChecks(out io.Writer, command string, checks []grafana.Check) error {
+	report := junitTestSuites{Name: buildinfo.UserAgent() + " " + command}
+	suiteIndex := map[string]int{}
+
+	for _, check := range checks {
+		index, ok := suiteIndex[check.Kind]
+		if !ok {
+			index = len(report.Suites)
+			suiteIndex[check.Kind] = index
+			report.Suites = append(report.Suites, junitTestSuite{Name: check.Kind})
+		}
+		suite := &report.Suites[index]
+
+		testCase := junitTestCase{Name: check.Name, ClassName: check.Kind}
+		switch {
+		case check.Skipped:
+			testCase.Skipped = &junitMessage{Message: check.Message}
+			suite.Skipped++
+		case !check.Passed:
+			testCase.Failure = &junitMessage{Message: check.Message}
+			suite.Failures++
+			report.Failures++
+		default:
+			testCase.SystemOut = check.Message
+		}
+
+		suite.Cases = append(suite.Cases, testCase)
+		suite.Tests++
+		report.Tests++
+	}
+
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(out, "\n")
+	return err
+}
+
+// SARIF 2.1.0 report structures (only the subset used here)
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	Kind               string `json:"kind"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+// writeSARIFChecks writes failed checks as SARIF results, one rule per resource kind
+func writeSARIFChecks(out io.Writer, checks []grafana.Check) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           buildinfo.Name,
+			Version:        buildinfo.Version,
+			InformationURI: "https://github.com/ilya-pishchalnikov/grafana-provisioner",
+		}},
+		Results: []sarifResult{},
+	}
+	rules := map[string]bool{}
+
+	for _, check := range checks {
+		ruleID := check.Kind + "-drift"
+		if !rules[ruleID] {
+			rules[ruleID] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+				ID:               ruleID,
+				ShortDescription: sarifMessage{Text: fmt.Sprintf("Configured %s does not match the live Grafana instance", check.Kind)},
+			})
+		}
+
+		if check.Passed || check.Skipped {
+			continue
+		}
+
+		run.Results = append(run.Results, sarifResult{
+			RuleID:  ruleID,
+			Level:   "error",
+			Message: sarifMessage{Text: fmt.Sprintf("%s '%s': %s", check.Kind, check.Name, check.Message)},
+			Locations: []sarifLocation{{LogicalLocations: []sarifLogicalLocation{{
+				Name:               check.Name,
+				Kind:               "resource",
+				FullyQualifiedName: check.Kind + "/" + check.Name,
+			}}}},
+		})
+	}
+
+	report := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/buildinfo"
+
+	"github.com/spf13/cobra"
+)
+
+// checkUpdate compares the version with the latest release on GitHub, opt-in as it calls out to the internet
+var checkUpdate bool
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the version, git commit and build date",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(cmd.OutOrStdout(), buildinfo.String())
+		if !checkUpdate {
+			return nil
+		}
+
+		release, err := buildinfo.LatestRelease(buildinfo.LatestReleaseURL)
+		if err != nil {
+			return err
+		}
+		switch {
+		case buildinfo.IsNewer(release.Tag, buildinfo.Version):
+			fmt.Fprintf(cmd.OutOrStdout(), "A newer release is available: %s (published %s), see %s\n",
+				release.Tag, release.PublishedAt.Format("2006-01-02"), release.URL)
+		case release.Tag == buildinfo.Version:
+			fmt.Fprintln(cmd.OutOrStdout(), "This is the latest release.")
+		default:
+			fmt.Fprintf(cmd.OutOrStdout(), "The latest release is %s, see %s\n", release.Tag, release.URL)
+		}
+		return nil
+	},
+}
+
+func init() {
+	versionCmd.Flags().BoolVar(&checkUpdate, "check", false, "check GitHub for a newer release")
+	rootCmd.AddCommand(versionCmd)
+}
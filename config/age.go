@@ -0,0 +1,147 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"gopkg.in/yaml.v3"
+)
+
+// AgeTag marks config values encrypted with age, e.g. `token: !age |` followed by an armored block
+const AgeTag = "!age"
+
+// Environment variables holding the age identity used to decrypt config values
+const (
+	AgeIdentityEnv     = "AGE_IDENTITY"      // The AGE-SECRET-KEY-... itself
+	AgeIdentityFileEnv = "AGE_IDENTITY_FILE" // Path to an age identity file (age-keygen output)
+)
+
+// decryptAgeValues replaces the `!age` tagged scalars of the YAML document with their plaintext.
+// Documents without tagged values are returned unchanged and need no identity.
+func decryptAgeValues(content []byte) ([]byte, error) {
+	if !bytes.Contains(content, []byte(AgeTag)) {
+		return content, nil
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration: %w", err)
+	}
+
+	var identities []age.Identity
+	found := false
+	var walkErr error
+	walkYAML(&document, func(node *yaml.Node) {
+		if walkErr != nil || node.Kind != yaml.ScalarNode || node.Tag != AgeTag {
+			return
+		}
+		found = true
+
+		if identities == nil {
+			identities, walkErr = LoadAgeIdentities()
+			if walkErr != nil {
+				return
+			}
+		}
+
+		plaintext, err := DecryptValue(node.Value, identities)
+		if err != nil {
+			walkErr = fmt.Errorf("failed to decrypt value at line %d: %w", node.Line, err)
+			return
+		}
+		node.Tag = "!!str"
+		node.Style = yaml.DoubleQuotedStyle
+		node.Value = plaintext
+	})
+	if walkErr != nil {
+		return nil, walkErr
+	}
+	if !found {
+		return content, nil
+	}
+
+	return yaml.Marshal(&document)
+}
+
+// walkYAML calls visit for the node and all its descendants
+func walkYAML(node *yaml.Node, visit func(*yaml.Node)) {
+	visit(node)
+	for _, child := range node.Content {
+		walkYAML(child, visit)
+	}
+}
+
+// LoadAgeIdentities reads the age identities from AGE_IDENTITY or the file in AGE_IDENTITY_FILE
+func LoadAgeIdentities() ([]age.Identity, error) {
+	if key := os.Getenv(AgeIdentityEnv); key != "" {
+		identities, err := age.ParseIdentities(strings.NewReader(key))
+		if err != nil {
+			return nil, fmt.Errorf("invalid age identity in %s: %w", AgeIdentityEnv, err)
+		}
+		return identities, nil
+	}
+
+	if path := os.Getenv(AgeIdentityFileEnv); path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open age identity file: %w", err)
+		}
+		defer file.Close()
+
+		identities, err := age.ParseIdentities(file)
+		if err != nil {
+			return nil, fmt.Errorf("invalid age identity file '%s': %w", path, err)
+		}
+		return identities, nil
+	}
+
+	return nil, fmt.Errorf("config contains %s values but neither %s nor %s is set", AgeTag, AgeIdentityEnv, AgeIdentityFileEnv)
+}
+
+// EncryptValue encrypts the plaintext to the recipients (age1... public keys) as an armored block
+func EncryptValue(plaintext string, recipients []string) (string, error) {
+	parsed := []age.Recipient{}
+	for _, recipient := range recipients {
+		ageRecipient, err := age.ParseX25519Recipient(recipient)
+		if err != nil {
+			return "", fmt.Errorf("invalid age recipient '%s': %w", recipient, err)
+		}
+		parsed = append(parsed, ageRecipient)
+	}
+
+	var out bytes.Buffer
+	armorWriter := armor.NewWriter(&out)
+	writer, err := age.Encrypt(armorWriter, parsed...)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt value: %w", err)
+	}
+	if _, err := io.WriteString(writer, plaintext); err != nil {
+		return "", fmt.Errorf("failed to encrypt value: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("failed to encrypt value: %w", err)
+	}
+	if err := armorWriter.Close(); err != nil {
+		return "", fmt.Errorf("failed to armor encrypted value: %w", err)
+	}
+	return out.String(), nil
+}
+
+// DecryptValue decrypts an armored age block with one of the identities
+func DecryptValue(armored string, identities []age.Identity) (string, error) {
+	reader, err := age.Decrypt(armor.NewReader(strings.NewReader(strings.TrimSpace(armored)+"\n")), identities...)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
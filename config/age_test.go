@@ -0,0 +1,45 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func TestLoadKeepsDollarInAgeSecret(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(AgeIdentityEnv, identity.String())
+	t.Setenv("ecret", "expanded")
+
+	const secret = "pa$word-$ecret-${ecret}"
+	encrypted, err := EncryptValue(secret, []string{identity.Recipient().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(writeTestConfig(t, "  token: !age |\n    "+strings.ReplaceAll(strings.TrimSpace(encrypted), "\n", "\n    ")+"\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Grafana.Token != secret {
+		t.Errorf("token = %q, want %q", cfg.Grafana.Token, secret)
+	}
+}
+
+// writeTestConfig writes a minimal valid config with the extra lines of the grafana section and returns its path
+func writeTestConfig(t *testing.T, grafana string) string {
+	t.Helper()
+	content := "log:\n  level: info\n  format: text\n" +
+		"grafana:\n  url: http://grafana:3000\n  timeout: 5s\n  retries: 1\n  retry-delay: 1s\n" + grafana
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// Environment variables of the AWS region, the same the AWS CLI and SDKs use
+const (
+	AWSRegionEnv        = "AWS_REGION"
+	AWSDefaultRegionEnv = "AWS_DEFAULT_REGION"
+)
+
+// awsTimeout bounds loading the AWS config and reading a secret, including the lookup of the credentials
+const awsTimeout = 30 * time.Second
+
+// awsService is an AWS API holding secrets
+type awsService struct {
+	scheme string // Scheme of the secret references
+	name   string
+	read   func(ctx context.Context, cfg aws.Config, region string, id string) (string, error)
+}
+
+// secretsManager reads the secret strings of AWS Secrets Manager
+var secretsManager = awsService{
+	scheme: "awssm",
+	name:   "secretsmanager",
+	read: func(ctx context.Context, cfg aws.Config, region string, id string) (string, error) {
+		client := secretsmanager.NewFromConfig(cfg, func(options *secretsmanager.Options) { options.Region = region })
+		output, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(id)})
+		if err != nil {
+			return "", err
+		}
+		if output.SecretString != nil {
+			return *output.SecretString, nil
+		}
+		return string(output.SecretBinary), nil
+	},
+}
+
+// parameterStore reads the parameters of the SSM Parameter Store, SecureString ones decrypted
+var parameterStore = awsService{
+	scheme: "ssm",
+	name:   "ssm",
+	read: func(ctx context.Context, cfg aws.Config, region string, id string) (string, error) {
+		client := ssm.NewFromConfig(cfg, func(options *ssm.Options) { options.Region = region })
+		output, err := client.GetParameter(ctx, &ssm.GetParameterInput{Name: aws.String(id), WithDecryption: aws.Bool(true)})
+		if err != nil {
+			return "", err
+		}
+		return aws.ToString(output.Parameter.Value), nil
+	},
+}
+
+// awsProvider resolves the `awssm://<secret>[#key]` and `ssm://<parameter>[#key]` references. A key selects
+// the key of a secret holding a JSON object, e.g. the username and password of an RDS secret.
+type awsProvider struct {
+	service awsService
+	config  aws.Config
+	secrets map[string]string // Read secrets by ID, each is read once per load
+}
+
+// newAWSProvider returns the constructor of the provider of an AWS service. The region, the credentials and the
+// endpoints come from the default config of the AWS SDK, i.e. the environment, the shared config and credentials
+// files with AWS_PROFILE, web identity tokens, container credentials and the EC2 instance role.
+func newAWSProvider(service awsService) func() (SecretProvider, error) {
+	return func() (SecretProvider, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), awsTimeout)
+		defer cancel()
+
+		cfg, err := awsconfig.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the AWS config: %w", err)
+		}
+		return &awsProvider{
+			service: service,
+			config:  cfg,
+			secrets: map[string]string{},
+		}, nil
+	}
+}
+
+// Resolve returns the secret of a `//<id>[#key]` reference. An ARN as the ID also sets the region.
+func (provider *awsProvider) Resolve(reference string) (string, error) {
+	id, key, _ := strings.Cut(strings.TrimPrefix(reference, "//"), "#")
+	if id == "" {
+		return "", fmt.Errorf("%s references must have the form %s://<name>[#key]", provider.service.name, provider.service.scheme)
+	}
+
+	secret, read := provider.secrets[id]
+	if !read {
+		var err error
+		secret, err = provider.read(id)
+		if err != nil {
+			return "", err
+		}
+		provider.secrets[id] = secret
+	}
+	if key == "" {
+		return secret, nil
+	}
+
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &object); err != nil {
+		return "", fmt.Errorf("secret '%s' is not a JSON object, it has no key '%s'", id, key)
+	}
+	value, found := object[key]
+	if !found {
+		return "", fmt.Errorf("secret '%s' has no key '%s'", id, key)
+	}
+	text, isString := value.(string)
+	if !isString {
+		return "", fmt.Errorf("key '%s' of secret '%s' is not a string", key, id)
+	}
+	return text, nil
+}
+
+// read calls the service for the secret with the ID
+func (provider *awsProvider) read(id string) (string, error) {
+	region := provider.config.Region
+	if strings.HasPrefix(id, "arn:") {
+		if parts := strings.Split(id, ":"); len(parts) > 3 && parts[3] != "" {
+			region = parts[3]
+		}
+	}
+	if region == "" {
+		return "", fmt.Errorf("config contains %s:// references but no AWS region is set, neither in %s, %s nor the AWS profile",
+			provider.service.scheme, AWSRegionEnv, AWSDefaultRegionEnv)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), awsTimeout)
+	defer cancel()
+
+	secret, err := provider.service.read(ctx, provider.config, region, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret '%s': %w", id, err)
+	}
+	return secret, nil
+}
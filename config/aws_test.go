@@ -0,0 +1,107 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// awsTestServer serves GetSecretValue and GetParameter of the JSON protocol and records the Authorization headers
+func awsTestServer(t *testing.T, authorizations *[]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*authorizations = append(*authorizations, r.Header.Get("Authorization"))
+		var request map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&request)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+
+		switch r.Header.Get("X-Amz-Target") {
+		case "secretsmanager.GetSecretValue":
+			if request["SecretId"] != "prod/grafana/db" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`))
+				return
+			}
+			w.Write([]byte(`{"Name":"prod/grafana/db","SecretString":"{\"username\":\"grafana\",\"password\":\"db$ecret\"}"}`))
+		case "AmazonSSM.GetParameter":
+			if request["WithDecryption"] != true {
+				t.Errorf("GetParameter without decryption: %v", request)
+			}
+			w.Write([]byte(`{"Parameter":{"Name":"/prod/grafana/token","Type":"SecureString","Value":"glsa_token"}}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// isolateAWS points the AWS SDK at the test server, away from the credentials and profiles of the environment
+func isolateAWS(t *testing.T, endpoint string) {
+	t.Helper()
+	dir := t.TempDir()
+	for _, env := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_PROFILE", "AWS_REGION",
+		"AWS_DEFAULT_REGION", "AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_ROLE_ARN", "AWS_CONTAINER_CREDENTIALS_RELATIVE_URI",
+		"AWS_CONTAINER_CREDENTIALS_FULL_URI"} {
+		t.Setenv(env, "")
+		os.Unsetenv(env)
+	}
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	t.Setenv("AWS_ENDPOINT_URL", endpoint)
+}
+
+func TestResolveAWSReferencesWithProfile(t *testing.T) {
+	authorizations := []string{}
+	isolateAWS(t, awsTestServer(t, &authorizations).URL)
+	dir := filepath.Dir(os.Getenv("AWS_CONFIG_FILE"))
+	os.WriteFile(filepath.Join(dir, "config"), []byte("[profile dev]\nregion = eu-west-1\n"), 0o600)
+	os.WriteFile(filepath.Join(dir, "credentials"), []byte("[dev]\naws_access_key_id = AKIDDEV\naws_secret_access_key = secret\n"), 0o600)
+	t.Setenv("AWS_PROFILE", "dev")
+
+	content := "grafana:\n  token: ssm:///prod/grafana/token\n" +
+		"datasources:\n  - user: awssm://prod/grafana/db#username\n    password: awssm://prod/grafana/db#password\n"
+	resolved, err := resolveSecretReferences([]byte(content))
+	if err != nil {
+		t.Fatalf("resolveSecretReferences() error = %v", err)
+	}
+	for _, want := range []string{`token: "glsa_token"`, `user: "grafana"`, `password: "db$ecret"`} {
+		if !strings.Contains(string(resolved), want) {
+			t.Errorf("resolved config misses %q:\n%s", want, resolved)
+		}
+	}
+
+	// The secret is read once for both keys, signed with the credentials and the region of the profile
+	if len(authorizations) != 2 {
+		t.Fatalf("AWS was called %d times, want 2", len(authorizations))
+	}
+	for _, authorization := range authorizations {
+		if !strings.Contains(authorization, "Credential=AKIDDEV/") || !strings.Contains(authorization, "/eu-west-1/") {
+			t.Errorf("Authorization = %q, want the key and region of the dev profile", authorization)
+		}
+	}
+}
+
+func TestResolveAWSReferenceRegionOfARN(t *testing.T) {
+	authorizations := []string{}
+	isolateAWS(t, awsTestServer(t, &authorizations).URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDENV")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	if _, err := resolveSecretReferences([]byte("token: awssm://prod/grafana/db\n")); err == nil || !strings.Contains(err.Error(), "no AWS region") {
+		t.Errorf("resolveSecretReferences() without a region error = %v, want a missing region", err)
+	}
+
+	_, err := resolveSecretReferences([]byte("token: awssm://arn:aws:secretsmanager:us-east-2:123456789012:secret:prod/grafana/other\n"))
+	if err == nil || !strings.Contains(err.Error(), "ResourceNotFoundException") {
+		t.Errorf("resolveSecretReferences() error = %v, want the error of Secrets Manager", err)
+	}
+	if len(authorizations) != 1 || !strings.Contains(authorizations[0], "Credential=AKIDENV/") || !strings.Contains(authorizations[0], "/us-east-2/secretsmanager/") {
+		t.Errorf("Authorization = %v, want the environment key and the region of the ARN", authorizations)
+	}
+}
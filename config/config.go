@@ -20,23 +20,118 @@ type Duration struct {
 
 // AppConfig is the root structure containing all application configuration
 type AppConfig struct {
-	Log         LogConfig      `mapstructure:"log"`
-	Grafana     GrafanaConfig  `mapstructure:"grafana" validate:"required"`
-	Folders     []FolderConfig `mapstructure:"folders"`
-	DataSources []DataSource   `mapstructure:"datasources"`
-	Dashboards  []Dashboard    `mapstructure:"dashboards"`
+	Log             LogConfig      `mapstructure:"log"`
+	Grafana         GrafanaConfig  `mapstructure:"grafana" validate:"required"`
+	Orgs            []OrgConfig    `mapstructure:"orgs" validate:"dive"`
+	Teams           []TeamConfig   `mapstructure:"teams" validate:"dive"`
+	Folders         []FolderConfig `mapstructure:"folders"`
+	DataSources     []DataSource   `mapstructure:"datasources" validate:"dive"`
+	Dashboards      []Dashboard    `mapstructure:"dashboards"`
+	Annotations     []Annotation   `mapstructure:"annotations"`
+	Alerting        AlertingConfig `mapstructure:"alerting"`
+	Presets         []PresetConfig `mapstructure:"presets" validate:"dive"`
+	Safety          SafetyConfig   `mapstructure:"safety"`
+	FolderCapacity  FolderCapacity `mapstructure:"folder_capacity"`
+	ChangeWindow    ChangeWindow   `mapstructure:"change_window"`
+	Status          StatusConfig   `mapstructure:"status_dashboard"`
+	MetricsPush     MetricsPush    `mapstructure:"metrics_push"`
+	SecretsSink     SecretsSink    `mapstructure:"secrets_sink"`
+	DataSourceMatch string         `mapstructure:"datasource_match" validate:"omitempty,oneof=name uid type+url+database type+url+database+user"` // Identity key of existing data sources, see datasources[*].match
+	SSHTunnel       SSHTunnel      `mapstructure:"ssh_tunnel"`
+	Kube            KubeConfig     `mapstructure:"kube"`
+	GitMetadata     bool           `mapstructure:"git_metadata"` // Tag dashboards with the commit, branch and repo of the working directory
+	ValuesFile      string         `mapstructure:"values_file"` // Per-environment values substituted into dashboard placeholders
+	SecretsFile     string         `mapstructure:"secrets_file"` // YAML merged over the config, e.g. a SOPS-encrypted secrets.enc.yaml
+	ValueSources    []ValueSource  `mapstructure:"value_sources" validate:"dive"` // External stores of dashboard values, e.g. Consul
+	RefsFile        string         `mapstructure:"refs_file"` // Reference map artifact written after apply (.json, .yaml or .yml)
+	ChangelogFile   string         `mapstructure:"changelog_file"` // Report of the last apply, the next one prints its changelog against it
+	DryRun          bool           `mapstructure:"dry_run"` // Plan the changes of apply without making them, see --dry-run
+	Prune           bool           `mapstructure:"prune"` // Delete the tagged dashboards, data sources and folders removed from the config
+	PruneTag        string         `mapstructure:"prune_tag"` // Tag marking the resources owned by pruning runs
+	FileProvisioned string         `mapstructure:"file_provisioned" validate:"omitempty,oneof=fail skip"` // Policy for resources of Grafana's file provisioning
+
+	MigrateGraphPanels bool       `mapstructure:"migrate_graph_panels"` // Convert graph panels to timeseries panels on import
+	Names              NameConfig `mapstructure:"names"`                // Normalization of the dashboard, data source and folder names
+
+	Instances           []InstanceConfig `mapstructure:"instances" validate:"unique=Name,dive"` // Grafana instances apply provisions instead of grafana.url
+	InstanceParallelism int              `mapstructure:"instance_parallelism" validate:"gte=0"` // Instances provisioned at once, defaults to 1
+}
+
+// InstanceConfig defines a Grafana instance provisioned by apply, e.g. staging or prod, with the shared
+// resources of the config and its own
+type InstanceConfig struct {
+	Name         string         `mapstructure:"name" validate:"required"`
+	URL          string         `mapstructure:"url"`           // Defaults to grafana.url
+	Token        string         `mapstructure:"token"`         // Defaults to grafana.token
+	TokenCommand []string       `mapstructure:"token_command"` // Credential helper printing the token
+	Org          string         `mapstructure:"org"`           // Defaults to grafana.org
+	Shared       *bool          `mapstructure:"shared"`        // Provision the top-level resources too, defaults to true
+	DataSources  []DataSource   `mapstructure:"datasources" validate:"dive"`
+	Folders      []FolderConfig `mapstructure:"folders"`
+	Dashboards   []Dashboard    `mapstructure:"dashboards"`
+}
+
+// ValueSource defines an external store of the values substituted into `${values.NAME.KEY}` placeholders
+type ValueSource struct {
+	Name     string   `mapstructure:"name" validate:"required,excludes=."`
+	Type     string   `mapstructure:"type" validate:"required,oneof=consul etcd http"`
+	URL      string   `mapstructure:"url" validate:"required,url"`
+	Prefix   string   `mapstructure:"prefix"`    // Consul or etcd key prefix
+	Token    string   `mapstructure:"token"`     // Consul ACL token, etcd auth token or bearer token
+	CacheTTL Duration `mapstructure:"cache_ttl"` // Defaults to 1m
 }
 
 // LogConfig defines logging parameters
 type LogConfig struct {
-	Level  string `mapstructure:"level" validate:"oneof=debug info warn error"`  // debug, info, warn, error
-	Format string `mapstructure:"format" validate:"oneof=debug json text"` // json, text
-	File   string `mapstructure:"file"`
+	Level    string      `mapstructure:"level" validate:"oneof=debug info warn error"`  // debug, info, warn, error
+	Format   string      `mapstructure:"format" validate:"oneof=debug json text"` // json, text
+	File     string      `mapstructure:"file"`
+	Sampling LogSampling `mapstructure:"sampling"`
+}
+
+// LogSampling limits how often the same warning is logged, e.g. retry warnings of a flapping Grafana
+type LogSampling struct {
+	Burst    int      `mapstructure:"burst"`    // Identical warnings logged per interval, defaults to 5, negative logs all of them
+	Interval Duration `mapstructure:"interval"` // Defaults to 1m
+}
+
+// OrgConfig defines an organization created when missing (requires server admin credentials)
+type OrgConfig struct {
+	Name              string            `mapstructure:"name" validate:"required"`
+	Role              string            `mapstructure:"role" validate:"omitempty,oneof=Viewer Editor Admin"` // Role of the provisioning user in the org
+	Dashboards        bool              `mapstructure:"dashboards"` // Import the configured dashboards into the org too
+	DataSourceAliases []DataSourceAlias `mapstructure:"datasource_aliases" validate:"dive"`
+}
+
+// DataSourceAlias maps a data source name used in the dashboard imports to the data source of an org
+type DataSourceAlias struct {
+	DataSource string `mapstructure:"datasource" validate:"required"` // The data source name used in imports and annotations
+	Name       string `mapstructure:"name" validate:"required"`       // The org-local data source name
+}
+
+// TeamConfig defines a team, its members and the external groups synced into it (Enterprise team sync)
+type TeamConfig struct {
+	Name    string   `mapstructure:"name" validate:"required"`
+	Email   string   `mapstructure:"email" validate:"omitempty,email"`
+	Groups  []string `mapstructure:"groups"` // LDAP group DNs or OAuth group names
+	Members []string `mapstructure:"members"` // Logins or emails of the org users in the team
 }
 
 // DbConnectionConfig defines grafana folder parameters
 type FolderConfig struct {
-	Name string `mapstructure:"name" validate:"required"`
+	Name           string             `mapstructure:"name" validate:"required"`
+	OwnerTeam      string             `mapstructure:"owner_team"`      // Team with Edit, everyone else gets View
+	ServiceAccount bool               `mapstructure:"service_account"` // Service account limited to the folder, token written to secrets_sink
+	Permissions    []PermissionConfig `mapstructure:"permissions"`     // Replace the Grafana default permissions of the folder
+	Labels         map[string]string  `mapstructure:"labels"`          // Inherited by the dashboards of the folder, keys are lowercased
+}
+
+// PermissionConfig grants a team, a user or a role access to a folder or dashboard
+type PermissionConfig struct {
+	Team       string `mapstructure:"team"`
+	User       string `mapstructure:"user"`       // Login or email of an org user
+	Role       string `mapstructure:"role"`       // Viewer, Editor or Admin
+	Permission string `mapstructure:"permission"` // view, edit or admin
 }
 
 // Import defines a single variable mapping for data source injection in config package.
@@ -47,31 +142,285 @@ type Import struct {
 
 // Dashboard defines parameters of grafana dashboard
 type Dashboard struct {
+	Name         string `mapstructure:"name" validate:"required_without=File"` // Derived from the dashboard JSON when empty
+	Folder       string `mapstructure:"folder"`
+	File         string `mapstructure:"file" validate:"required_without=GnetID"` // JSON file, glob or directory of JSON files
+	GnetID       int    `mapstructure:"gnet_id"`  // grafana.com dashboard ID, used instead of file
+	Revision     int    `mapstructure:"revision"` // grafana.com revision, 0 means the latest one
+	Mode         string `mapstructure:"mode" validate:"omitempty,oneof=import db"` // import (default) or db for /api/dashboards/db
+	Overwrite    *bool  `mapstructure:"overwrite"` // Defaults to true
+	OnConflict   string `mapstructure:"on_conflict" validate:"omitempty,oneof=fail merge prompt"` // Version conflict policy when not overwriting
+	UIDCollision string `mapstructure:"uid_collision" validate:"omitempty,oneof=regenerate fail adopt"` // Policy when the JSON UID belongs to another dashboard
+	Imports      []Import `mapstructure:"imports" validate:"required"`
+	Assertions   []PanelAssertion `mapstructure:"assertions" validate:"dive"` // Expected panel query results checked by the test command
+	Labels       map[string]string `mapstructure:"labels"` // Freeform metadata for --select and the report grouping, keys are lowercased
+	Permissions  []PermissionConfig `mapstructure:"permissions"` // Replace the permissions inherited from the folder
+
+	RequiresDataSourceType string `mapstructure:"requires_datasource_type"` // Plugin type the imported data sources must have, e.g. prometheus
+	MigrateGraphPanels     *bool  `mapstructure:"migrate_graph_panels"`     // Defaults to the top-level migrate_graph_panels
+	Org                    string `mapstructure:"org"`                      // Organization to import into, defaults to grafana.org
+}
+
+// PanelAssertion defines the expected query results of a dashboard panel
+type PanelAssertion struct {
+	Panel    string   `mapstructure:"panel" validate:"required"` // The panel title
+	RefID    string   `mapstructure:"ref_id"`                    // Query of the panel, empty means all of them
+	NonEmpty bool     `mapstructure:"non_empty"`
+	Min      *float64 `mapstructure:"min"`
+	Max      *float64 `mapstructure:"max"`
+	From     string   `mapstructure:"from"` // Start of the queried range, defaults to now-1h
+}
+
+// KubeConfig defines the Kubernetes service of a cluster-internal Grafana reached through a port-forward
+type KubeConfig struct {
+	Kubeconfig string `mapstructure:"kubeconfig"`
+	Context    string `mapstructure:"context"`
+	Namespace  string `mapstructure:"namespace"`
+	Service    string `mapstructure:"service"` // Empty to connect directly
+	Port       int    `mapstructure:"port" validate:"omitempty,min=1,max=65535"`
+}
+
+// SSHTunnel defines the bastion host the Grafana API is reached through
+type SSHTunnel struct {
+	Host                  string   `mapstructure:"host"` // Bastion host[:port], empty to connect directly
+	User                  string   `mapstructure:"user" validate:"required_with=Host"`
+	Key                   string   `mapstructure:"key"` // PEM private key
+	KeyFile               string   `mapstructure:"key_file"`
+	Passphrase            string   `mapstructure:"passphrase"`
+	KnownHostsFile        string   `mapstructure:"known_hosts"`
+	InsecureIgnoreHostKey bool     `mapstructure:"insecure_ignore_host_key"`
+	Remote                string   `mapstructure:"remote" validate:"omitempty,hostname_port"` // Grafana address as seen from the bastion
+	Timeout               Duration `mapstructure:"timeout"`
+}
+
+// SecretsSink defines where generated secrets (folder service account tokens) are written
+type SecretsSink struct {
+	Type string `mapstructure:"type" validate:"omitempty,oneof=dir env-file"`
+	Path string `mapstructure:"path" validate:"required_with=Type"`
+}
+
+// StatusConfig defines the "Provisioning Status" dashboard updated at the end of each run
+type StatusConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Title   string `mapstructure:"title"`
+	Folder  string `mapstructure:"folder"` // Must be defined in folders, empty for General
+}
+
+// MetricsPush defines where the run metrics are pushed, to a Pushgateway and/or a remote-write endpoint
+type MetricsPush struct {
+	Pushgateway string            `mapstructure:"pushgateway" validate:"omitempty,url"`
+	RemoteWrite string            `mapstructure:"remote_write" validate:"omitempty,url"` // e.g. Grafana Cloud's https://.../api/prom/push
+	Job         string            `mapstructure:"job"`                                   // Defaults to grafana-provisioner
+	Username    string            `mapstructure:"username"`
+	Password    string            `mapstructure:"password"`
+	Labels      map[string]string `mapstructure:"labels"` // Added to every series, keys are lowercased
+}
+
+// SafetyConfig defines per-run guardrails against mass changes
+type SafetyConfig struct {
+	MaxDeletes          int `mapstructure:"max_deletes" validate:"gte=0"`                   // 0 means unlimited
+	MaxOverwritePercent int `mapstructure:"max_overwrite_percent" validate:"gte=0,lte=100"` // 0 means unlimited
+}
+
+// FolderCapacity is the usability guideline on the number of dashboards in a folder
+type FolderCapacity struct {
+	MaxDashboards int  `mapstructure:"max_dashboards" validate:"gte=0"` // 0 disables the warnings
+	Enforce       bool `mapstructure:"enforce"`                         // Fail plan, apply and validate instead of warning
+}
+
+// ChangeWindow restricts apply to the minutes matching a cron-like schedule
+type ChangeWindow struct {
+	Schedule string `mapstructure:"schedule"` // minute hour day-of-month month day-of-week, e.g. "* 9-16 * * mon-thu"
+	Timezone string `mapstructure:"timezone"` // IANA time zone, defaults to UTC
+}
+
+// PresetConfig enables a built-in bundle of grafana.com dashboards
+type PresetConfig struct {
 	Name       string `mapstructure:"name" validate:"required"`
-	Folder     string `mapstructure:"folder"`
-	File       string `mapstructure:"file" validate:"required"`
-	DataSource string `mapstructure:"datasource"`
-	Imports    []Import `mapstructure:"imports" validate:"required"`
+	DataSource string `mapstructure:"datasource" validate:"required"` // The data source name wired into the dashboards
+	Folder     string `mapstructure:"folder"`                         // Overrides the preset default folder
+}
+
+// Annotation defines an org-level annotation query injected into dashboards
+type Annotation struct {
+	Name       string   `mapstructure:"name" validate:"required"`
+	DataSource string   `mapstructure:"datasource" validate:"required"` // The data source name
+	Query      string   `mapstructure:"query" validate:"required"`      // Raw SQL or LogQL/PromQL expression
+	IconColor  string   `mapstructure:"icon_color"`
+	Hide       bool     `mapstructure:"hide"`
+	Dashboards []string `mapstructure:"dashboards"` // Dashboard names to inject into, empty means all
+}
+
+// AlertingConfig defines alert and recording rule provisioning
+type AlertingConfig struct {
+	Rulers        []RulerConfig          `mapstructure:"rulers" validate:"dive"`
+	RuleGroups    []AlertRuleGroupConfig `mapstructure:"rule_groups" validate:"dive"`
+	ContactPoints []ContactPointConfig   `mapstructure:"contact_points" validate:"dive"`
+	Policies      *PoliciesConfig        `mapstructure:"policies"` // Notification policy routes, omit to leave the tree untouched
+}
+
+// ContactPointConfig defines a unified alerting contact point with a single integration
+type ContactPointConfig struct {
+	UID                   string                 `mapstructure:"uid"`
+	Name                  string                 `mapstructure:"name" validate:"required"`
+	Type                  string                 `mapstructure:"type" validate:"required"` // e.g. email, slack, pagerduty, webhook
+	Settings              map[string]interface{} `mapstructure:"settings"`                  // Integration settings, e.g. addresses or url
+	DisableResolveMessage bool                   `mapstructure:"disable_resolve_message"`
+}
+
+// PoliciesConfig defines the provisioner-managed routes of the notification policy tree
+type PoliciesConfig struct {
+	Strategy string        `mapstructure:"strategy" validate:"omitempty,oneof=merge replace"` // merge (default) keeps the manually managed routes
+	Receiver string        `mapstructure:"receiver"`                                          // Root contact point, replace strategy only
+	Routes   []RouteConfig `mapstructure:"routes" validate:"dive"`
+}
+
+// RouteConfig defines a notification policy
+type RouteConfig struct {
+	Receiver          string        `mapstructure:"receiver"` // Contact point, empty inherits the parent's
+	Matchers          []string      `mapstructure:"matchers"` // e.g. team=payments, severity=~critical|warning
+	GroupBy           []string      `mapstructure:"group_by"`
+	Continue          bool          `mapstructure:"continue"`
+	GroupWait         string        `mapstructure:"group_wait"`
+	GroupInterval     string        `mapstructure:"group_interval"`
+	RepeatInterval    string        `mapstructure:"repeat_interval"`
+	MuteTimeIntervals []string      `mapstructure:"mute_time_intervals"`
+	Routes            []RouteConfig `mapstructure:"routes" validate:"dive"` // Nested policies
+}
+
+// RulerConfig defines a Cortex-compatible ruler (Mimir or Loki) rule groups can target
+type RulerConfig struct {
+	Name   string `mapstructure:"name" validate:"required"`
+	Type   string `mapstructure:"type" validate:"oneof=mimir loki"`
+	URL    string `mapstructure:"url" validate:"required"`
+	Tenant string `mapstructure:"tenant"` // Sent as X-Scope-OrgID
+	Token  string `mapstructure:"token"`
+}
+
+// AlertRuleGroupConfig defines a group of rules evaluated together
+type AlertRuleGroupConfig struct {
+	Name      string            `mapstructure:"name" validate:"required"`
+	Folder    string            `mapstructure:"folder"`    // Grafana folder of Grafana-managed rules
+	Interval  Duration          `mapstructure:"interval"`  // Evaluation interval
+	Ruler     string            `mapstructure:"ruler"`     // Name of the ruler, empty means Grafana-managed alerting
+	Namespace string            `mapstructure:"namespace"` // Ruler namespace, defaults to the folder
+	Rules     []AlertRuleConfig `mapstructure:"rules" validate:"required,dive"`
+}
+
+// AlertRuleConfig defines a single alert or recording rule
+type AlertRuleConfig struct {
+	UID         string                  `mapstructure:"uid"`                       // Grafana-managed only, derived from the names by default
+	Title       string                  `mapstructure:"title" validate:"required"` // Alert name
+	Record      string                  `mapstructure:"record"`                    // Recording rule metric name, ruler targets only
+	Expr        string                  `mapstructure:"expr"`                      // Rule expression, ruler targets only
+	Condition   string                  `mapstructure:"condition"`                 // RefID of the condition, Grafana-managed only
+	For         string                  `mapstructure:"for"`
+	Labels      map[string]string       `mapstructure:"labels"`
+	Annotations map[string]string       `mapstructure:"annotations"`
+	Queries     []AlertQueryConfig      `mapstructure:"queries" validate:"dive"`
+	Expressions []AlertExpressionConfig `mapstructure:"expressions" validate:"dive"`
+}
+
+// AlertQueryConfig defines a data source query of a Grafana-managed rule
+type AlertQueryConfig struct {
+	RefID        string   `mapstructure:"ref_id" validate:"required"`
+	DataSource   string   `mapstructure:"datasource" validate:"required"` // The data source name
+	Expr         string   `mapstructure:"expr" validate:"required"`       // Raw SQL or PromQL/LogQL expression
+	RelativeTime Duration `mapstructure:"relative_time"`                  // Query time range, defaults to 10m
+}
+
+// AlertExpressionConfig defines a server-side expression of a Grafana-managed rule
+type AlertExpressionConfig struct {
+	RefID      string `mapstructure:"ref_id" validate:"required"`
+	Type       string `mapstructure:"type" validate:"oneof=math reduce"`
+	Expression string `mapstructure:"expression" validate:"required"` // Math expression or the input RefID for reduce
+	Reducer    string `mapstructure:"reducer"`                        // last, mean, max, ... for reduce
 }
 
 // Datasource defines parameters of grafana datasource
 type DataSource struct {
 	Name      string `mapstructure:"name" validate:"required"`
-    Host     string `mapstructure:"host" validate:"required"`
-    Port     int    `mapstructure:"port" validate:"required,min=1,max=65535"`
-    User     string `mapstructure:"user" validate:"required"`
-    Password string `mapstructure:"password" validate:"required"`
-    DbName   string `mapstructure:"dbname" validate:"required"`
-    SslMode  string `mapstructure:"sslmode" validate:"oneof=disable require verify-ca verify-full"`
+	UID       string `mapstructure:"uid" validate:"required_if=Match uid"` // UID set on creation
+	Match     string `mapstructure:"match" validate:"omitempty,oneof=name uid type+url+database type+url+database+user"` // Overrides datasource_match
+	Type      string `mapstructure:"type"` // postgres (default), prometheus or any plugin ID, e.g. loki, tempo, elasticsearch
+
+	// PostgreSQL connection, user and password are the basic auth of the HTTP-based types and the login of other SQL types
+    Host     string `mapstructure:"host" validate:"required_without=Type,required_if=Type postgres,required_if=Type grafana-postgresql-datasource"`
+    Port     int    `mapstructure:"port" validate:"required_without=Type,required_if=Type postgres,required_if=Type grafana-postgresql-datasource,omitempty,min=1,max=65535"`
+    User     string `mapstructure:"user" validate:"required_without=Type,required_if=Type postgres,required_if=Type grafana-postgresql-datasource"`
+    Password string `mapstructure:"password" validate:"required_without=Type,required_if=Type postgres,required_if=Type grafana-postgresql-datasource"`
+    DbName   string `mapstructure:"dbname" validate:"required_without=Type,required_if=Type postgres,required_if=Type grafana-postgresql-datasource"` // Also the database or index of other types
+    SslMode  string `mapstructure:"sslmode" validate:"required_without=Type,required_if=Type postgres,required_if=Type grafana-postgresql-datasource,omitempty,oneof=disable require verify-ca verify-full"`
+    BasicAuth bool  `mapstructure:"basic_auth"` // Send user and password as basic auth, implied by the HTTP-based types
+
+	PasswordCommand []string `mapstructure:"password_command"` // Credential helper printing the password instead
+
+	// Prometheus settings, url is also the URL of the other HTTP-based types
+	URL            string               `mapstructure:"url" validate:"required_if=Type prometheus,omitempty,url"`
+	ScrapeInterval string               `mapstructure:"scrape_interval"`                                 // Scrape interval of the targets, e.g. 15s
+	QueryTimeout   string               `mapstructure:"query_timeout"`                                   // e.g. 60s
+	HTTPMethod     string               `mapstructure:"http_method" validate:"omitempty,oneof=GET POST"` // Method of the queries, defaults to POST in Grafana
+	Exemplars      []PrometheusExemplar `mapstructure:"exemplars" validate:"dive"`                       // Links from exemplars to traces
+
+	TestQuery string `mapstructure:"test_query"` // SQL, PromQL or LogQL run after provisioning, e.g. SELECT 1 or up
+
+	// HTTP settings, applied when the data source is created
+	Headers              []DataSourceHeader `mapstructure:"headers" validate:"dive"`  // Custom HTTP headers sent with every query, e.g. X-Scope-OrgID
+	ForwardOAuthIdentity bool               `mapstructure:"forward_oauth_identity"` // Forward the user's OAuth identity to the data source
+	KeepCookies          []string           `mapstructure:"keep_cookies"`           // Browser cookies forwarded to the data source
+	JSONData             string             `mapstructure:"json_data" validate:"omitempty,json"`        // JSON object merged into jsonData, a string to keep the key case
+	SecureJSONData       string             `mapstructure:"secure_json_data" validate:"omitempty,json"` // JSON object of strings merged into secureJsonData
+
+	Labels map[string]string `mapstructure:"labels"` // Freeform metadata for --select and the report grouping, keys are lowercased
+	Org    string            `mapstructure:"org"`    // Organization to create the data source in, defaults to grafana.org
+}
+
+// PrometheusExemplar links the trace ID label of exemplars to a tracing data source or an external URL
+type PrometheusExemplar struct {
+	Name          string `mapstructure:"name" validate:"required"`                       // Label holding the trace ID, e.g. trace_id
+	DataSourceUID string `mapstructure:"datasource_uid" validate:"required_without=URL"` // UID of the tracing data source, e.g. Tempo
+	URL           string `mapstructure:"url"`                                            // External link, ${__value.raw} is the trace ID
+}
+
+// DataSourceHeader defines a custom HTTP header of a data source, the value is stored encrypted in secureJsonData
+type DataSourceHeader struct {
+	Name  string `mapstructure:"name" validate:"required"`
+	Value string `mapstructure:"value" validate:"required"`
 }
 
 // GrafanaConfig defines parameters for Grafana API client and provisioning
 type GrafanaConfig struct {
-	URL            string        `mapstructure:"url" validate:"required"`
-	Token          string        `mapstructure:"token" validate:"required"`
-	Timeout        Duration      `mapstructure:"timeout" validate:"gt=0"`
-	Retries        int           `mapstructure:"retries" validate:"gt=0"`
-	RetryDelay     Duration      `mapstructure:"retry-delay" validate:"gt=0"`
+	URL             string   `mapstructure:"url"`   // Required without instances, see resolveCredentials
+	Token           string   `mapstructure:"token"` // Service account token, or another credential, see resolveCredentials
+	Timeout         Duration `mapstructure:"timeout" validate:"gt=0"`                        // Overall request timeout
+	DialTimeout     Duration `mapstructure:"dial-timeout"`
+	TLSTimeout      Duration `mapstructure:"tls-timeout"`
+	HeaderTimeout   Duration `mapstructure:"response-header-timeout"`
+	Retries         int      `mapstructure:"retries" validate:"gt=0"`
+	RetryDelay      Duration `mapstructure:"retry-delay" validate:"gt=0"`
+	API             string   `mapstructure:"api" validate:"omitempty,oneof=auto legacy k8s"` // Dashboard and folder API backend
+	Org             string   `mapstructure:"org"`                                            // Organization to provision into
+	UserAgent       string   `mapstructure:"user-agent"`                                     // User-Agent of all API requests
+	ConsistencyWait Duration `mapstructure:"consistency-wait"`                               // Polling for created resources until lookups find them
+
+	// Credentials where service account tokens aren't available, and files holding them, e.g. mounted secrets
+	APIKey       string `mapstructure:"api-key"`  // Legacy API key
+	Username     string `mapstructure:"username"` // Basic auth of a Grafana user
+	Password     string `mapstructure:"password"`
+	TokenFile    string `mapstructure:"token-file"`
+	APIKeyFile   string `mapstructure:"api-key-file"`
+	PasswordFile string `mapstructure:"password-file"`
+
+	// Credential helpers printing the credential, e.g. of a corporate credential broker
+	TokenCommand    []string `mapstructure:"token-command"`
+	APIKeyCommand   []string `mapstructure:"api-key-command"`
+	PasswordCommand []string `mapstructure:"password-command"`
+
+	// TLS of a Grafana behind an internal PKI
+	CAFile             string `mapstructure:"ca-file"`                               // PEM bundle trusted in addition to the system CAs
+	CertFile           string `mapstructure:"cert-file" validate:"required_with=KeyFile"` // PEM client certificate for mutual TLS
+	KeyFile            string `mapstructure:"key-file" validate:"required_with=CertFile"`
+	InsecureSkipVerify bool   `mapstructure:"insecure-skip-verify"`
 }
 
 
@@ -120,10 +469,30 @@ func Load(configPath string) (*AppConfig, error) {
 	// Expand environment variables of format ${VAR}
 	expandedContent := os.ExpandEnv(string(rawContent))
 
+	// Decrypt a SOPS-encrypted config, merge the secrets file, decrypt values tagged with !age and resolve
+	// references to secret backends like vault:secret/data/grafana#token, after the expansion so that the
+	// secrets are taken as they are
+	decryptedContent, err := decryptSOPS([]byte(expandedContent))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt config file '%s': %w", configPath, err)
+	}
+	decryptedContent, err = mergeSecretsFile(decryptedContent)
+	if err != nil {
+		return nil, err
+	}
+	decryptedContent, err = decryptAgeValues(decryptedContent)
+	if err != nil {
+		return nil, err
+	}
+	resolvedContent, err := resolveSecretReferences(decryptedContent)
+	if err != nil {
+		return nil, err
+	}
+
 	// Initialize Viper
 	v := viper.New()
 	v.SetConfigType("yaml")
-	if err := v.ReadConfig(bytes.NewBufferString(expandedContent)); err != nil {
+	if err := v.ReadConfig(bytes.NewBuffer(resolvedContent)); err != nil {
 		return nil, fmt.Errorf("failed to parse configuration: %w", err)
 	}
 
@@ -149,6 +518,13 @@ func Load(configPath string) (*AppConfig, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	normalizeNames(&cfg)
+
+	// Credential helpers run before the validation, which requires some of the passwords
+	if err := runCredentialCommands(&cfg); err != nil {
+		return nil, fmt.Errorf("config validation error: %w", err)
+	}
+
 	validate := validator.New()
 
 	validate.RegisterCustomTypeFunc(durationValueRetriever, Duration{})
@@ -157,5 +533,10 @@ func Load(configPath string) (*AppConfig, error) {
 		return nil, fmt.Errorf("config validation error: %w", err)
 	}
 
+	// Instances may each bring their own URL and token
+	if err := resolveCredentials(&cfg.Grafana, cfg.Instances); err != nil {
+		return nil, fmt.Errorf("config validation error: %w", err)
+	}
+
 	return &cfg, nil
 }
\ No newline at end of file
@@ -0,0 +1,17 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadValidatesPostgresConnection(t *testing.T) {
+	for _, dataSourceType := range []string{"postgres", "grafana-postgresql-datasource"} {
+		t.Run(dataSourceType, func(t *testing.T) {
+			_, err := Load(writeTestConfig(t, "  token: token\ndatasources:\n  - name: Postgres\n    type: "+dataSourceType+"\n    url: http://postgres:5432\n"))
+			if err == nil || !strings.Contains(err.Error(), "Host") {
+				t.Errorf("Load() error = %v, want the missing host reported", err)
+			}
+		})
+	}
+}
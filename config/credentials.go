@@ -0,0 +1,155 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// credentialCommandTimeout bounds a credential helper, e.g. one waiting for an interactive login
+const credentialCommandTimeout = 30 * time.Second
+
+// resolveCredentials reads the credentials of the Grafana connection given as files, e.g. mounted secrets, and
+// checks that one kind of credentials is set: a token, a legacy API key, or a username and password.
+// Without credentials only instances bringing their own token are allowed.
+func resolveCredentials(grafana *GrafanaConfig, instances []InstanceConfig) error {
+	files := []struct {
+		key   string
+		path  string
+		value *string
+	}{
+		{"token-file", grafana.TokenFile, &grafana.Token},
+		{"api-key-file", grafana.APIKeyFile, &grafana.APIKey},
+		{"password-file", grafana.PasswordFile, &grafana.Password},
+	}
+	for _, file := range files {
+		if file.path == "" {
+			continue
+		}
+		if *file.value != "" {
+			return fmt.Errorf("grafana.%s can't be used together with grafana.%s", file.key, strings.TrimSuffix(file.key, "-file"))
+		}
+		content, err := os.ReadFile(file.path)
+		if err != nil {
+			return fmt.Errorf("failed to read grafana.%s: %w", file.key, err)
+		}
+		*file.value = strings.TrimSpace(string(content))
+	}
+
+	kinds := 0
+	for _, set := range []bool{grafana.Token != "", grafana.APIKey != "", grafana.Username != "" || grafana.Password != ""} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds > 1 {
+		return fmt.Errorf("grafana.token, grafana.api-key and grafana.username with grafana.password can't be used together")
+	}
+	if (grafana.Username == "") != (grafana.Password == "") {
+		return fmt.Errorf("grafana.username and grafana.password must be set together")
+	}
+
+	if len(instances) == 0 && (grafana.URL == "" || kinds == 0) {
+		return fmt.Errorf("grafana.url and one of grafana.token, grafana.api-key or grafana.username with grafana.password are required")
+	}
+	for _, instance := range instances {
+		if (instance.URL == "" && grafana.URL == "") || (instance.Token == "" && kinds == 0) {
+			return fmt.Errorf("instance '%s' needs a url and a token, or grafana.url and grafana credentials", instance.Name)
+		}
+	}
+	return nil
+}
+
+// runCredentialCommands runs the credential helpers of the Grafana connection, the instances and the data
+// sources, e.g. `token-command: ["/usr/local/bin/get-grafana-token"]`, and sets the credentials to their output.
+// A helper run by several credentials runs once.
+func runCredentialCommands(cfg *AppConfig) error {
+	outputs := map[string]string{}
+	run := func(key string, credential string, command []string, value *string) error {
+		if len(command) == 0 {
+			return nil
+		}
+		if *value != "" {
+			return fmt.Errorf("%s can't be used together with %s", key, credential)
+		}
+		cacheKey := strings.Join(command, "\x00")
+		if _, ran := outputs[cacheKey]; !ran {
+			output, err := runCredentialCommand(command)
+			if err != nil {
+				return fmt.Errorf("failed to run %s: %w", key, err)
+			}
+			outputs[cacheKey] = output
+		}
+		*value = outputs[cacheKey]
+		return nil
+	}
+
+	grafana := &cfg.Grafana
+	for _, helper := range []struct {
+		key     string
+		command []string
+		file    string
+		value   *string
+	}{
+		{"token-command", grafana.TokenCommand, grafana.TokenFile, &grafana.Token},
+		{"api-key-command", grafana.APIKeyCommand, grafana.APIKeyFile, &grafana.APIKey},
+		{"password-command", grafana.PasswordCommand, grafana.PasswordFile, &grafana.Password},
+	} {
+		if len(helper.command) > 0 && helper.file != "" {
+			return fmt.Errorf("grafana.%s can't be used together with grafana.%s-file", helper.key, strings.TrimSuffix(helper.key, "-command"))
+		}
+		if err := run("grafana."+helper.key, "grafana."+strings.TrimSuffix(helper.key, "-command"), helper.command, helper.value); err != nil {
+			return err
+		}
+	}
+
+	dataSources := func(dataSources []DataSource) error {
+		for i := range dataSources {
+			if err := run(fmt.Sprintf("password_command of datasource '%s'", dataSources[i].Name), "its password", dataSources[i].PasswordCommand, &dataSources[i].Password); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := dataSources(cfg.DataSources); err != nil {
+		return err
+	}
+	for i := range cfg.Instances {
+		instance := &cfg.Instances[i]
+		if err := run(fmt.Sprintf("token_command of instance '%s'", instance.Name), "its token", instance.TokenCommand, &instance.Token); err != nil {
+			return err
+		}
+		if err := dataSources(instance.DataSources); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runCredentialCommand runs the helper and returns its output without the surrounding whitespace. Its stderr
+// is part of the error of a failed run.
+func runCredentialCommand(command []string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), credentialCommandTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	helper := exec.CommandContext(ctx, command[0], command[1:]...)
+	helper.Stdout = &stdout
+	helper.Stderr = &stderr
+	if err := helper.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%w: %s", err, message)
+		}
+		return "", err
+	}
+
+	output := strings.TrimSpace(stdout.String())
+	if output == "" {
+		return "", fmt.Errorf("%s printed no credential", command[0])
+	}
+	return output, nil
+}
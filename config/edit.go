@@ -0,0 +1,75 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AppendListEntry adds the entry to the end of a top-level list of the config file, e.g. dashboards, creating
+// the list if it is missing. The file is rewritten with 4-space indentation, comments and tags such as !age are
+// kept.
+func AppendListEntry(configPath string, key string, entry interface{}) error {
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat config file '%s': %w", configPath, err)
+	}
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config file '%s': %w", configPath, err)
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return fmt.Errorf("failed to parse configuration: %w", err)
+	}
+	if len(document.Content) == 0 {
+		document = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("config file '%s' is not a mapping", configPath)
+	}
+
+	var list *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == key {
+			list = root.Content[i+1]
+		}
+	}
+	if list == nil {
+		list = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, list)
+	}
+	// An empty `key:` is null
+	if list.Kind == yaml.ScalarNode && list.Tag == "!!null" {
+		*list = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", HeadComment: list.HeadComment, LineComment: list.LineComment}
+	}
+	if list.Kind != yaml.SequenceNode {
+		return fmt.Errorf("'%s' of config file '%s' is not a list", key, configPath)
+	}
+
+	var entryNode yaml.Node
+	if err := entryNode.Encode(entry); err != nil {
+		return fmt.Errorf("failed to encode the '%s' entry: %w", key, err)
+	}
+	list.Style = 0
+	list.Content = append(list.Content, &entryNode)
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(4)
+	if err := encoder.Encode(&document); err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+
+	if err := os.WriteFile(configPath, out.Bytes(), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write config file '%s': %w", configPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"strings"
+)
+
+// NameConfig defines how the names of dashboards, data sources and folders are normalized when loading
+type NameConfig struct {
+	Case string `mapstructure:"case" validate:"omitempty,oneof=preserve lower upper"` // Defaults to preserve
+}
+
+// NormalizeName trims the name and collapses its whitespace runs into single spaces, then applies the case policy
+func NormalizeName(name string, policy string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	switch policy {
+	case "lower":
+		return strings.ToLower(name)
+	case "upper":
+		return strings.ToUpper(name)
+	}
+	return name
+}
+
+// normalizeNames normalizes the names of the dashboards, data sources and folders and the references to them,
+// so that a reference written differently still finds its resource
+func normalizeNames(cfg *AppConfig) {
+	normalize := func(name *string) {
+		*name = NormalizeName(*name, cfg.Names.Case)
+	}
+	normalizeResources := func(dataSources []DataSource, folders []FolderConfig, dashboards []Dashboard) {
+		for i := range dataSources {
+			normalize(&dataSources[i].Name)
+		}
+		for i := range folders {
+			normalize(&folders[i].Name)
+		}
+		for i := range dashboards {
+			normalize(&dashboards[i].Name)
+			normalize(&dashboards[i].Folder)
+			for j := range dashboards[i].Imports {
+				normalize(&dashboards[i].Imports[j].DataSource)
+			}
+		}
+	}
+
+	normalizeResources(cfg.DataSources, cfg.Folders, cfg.Dashboards)
+	for i := range cfg.Instances {
+		normalizeResources(cfg.Instances[i].DataSources, cfg.Instances[i].Folders, cfg.Instances[i].Dashboards)
+	}
+	for i := range cfg.Orgs {
+		for j := range cfg.Orgs[i].DataSourceAliases {
+			normalize(&cfg.Orgs[i].DataSourceAliases[j].DataSource)
+			normalize(&cfg.Orgs[i].DataSourceAliases[j].Name)
+		}
+	}
+	for i := range cfg.Presets {
+		normalize(&cfg.Presets[i].DataSource)
+		normalize(&cfg.Presets[i].Folder)
+	}
+	for i := range cfg.Annotations {
+		normalize(&cfg.Annotations[i].DataSource)
+		for j := range cfg.Annotations[i].Dashboards {
+			normalize(&cfg.Annotations[i].Dashboards[j])
+		}
+	}
+	for i := range cfg.Alerting.RuleGroups {
+		normalize(&cfg.Alerting.RuleGroups[i].Folder)
+		for j := range cfg.Alerting.RuleGroups[i].Rules {
+			for k := range cfg.Alerting.RuleGroups[i].Rules[j].Queries {
+				normalize(&cfg.Alerting.RuleGroups[i].Rules[j].Queries[k].DataSource)
+			}
+		}
+	}
+	normalize(&cfg.Status.Folder)
+}
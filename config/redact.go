@@ -0,0 +1,111 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the values of secret settings in the output of Redact
+const Redacted = "<redacted>"
+
+// secretKeyPattern matches the setting names holding secrets, e.g. token, password or the key of the SSH tunnel
+var secretKeyPattern = regexp.MustCompile(`(?i)(token|password|passphrase|secret|secure|credential|^key$|api[_-]?key|authorization)`)
+
+// contactPointPublicSettings are the contact point settings shown by Redact, the others are redacted since the
+// URLs and keys of integrations like webhooks, Slack or PagerDuty are credentials whatever their name
+var contactPointPublicSettings = map[string]bool{
+	"addresses": true, "singleEmail": true, "subject": true, "message": true, "title": true, "text": true,
+	"recipient": true, "mentionChannel": true, "severity": true, "class": true, "component": true, "group": true,
+	"httpMethod": true, "maxAlerts": true,
+}
+
+// Redact returns the config as nested maps keyed by the config file keys, with the values of secret settings,
+// e.g. Authorization headers of data sources, and of contact point settings replaced by Redacted, and the
+// user:password@ of URLs stripped. Empty secrets stay empty to show they aren't set.
+func Redact(cfg *AppConfig) map[string]interface{} {
+	redacted, _ := redactValue(reflect.ValueOf(*cfg), false).(map[string]interface{})
+	return redacted
+}
+
+// redactValue converts the value into maps, slices and scalars, redacting it entirely when secret is set
+func redactValue(value reflect.Value, secret bool) interface{} {
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+		return redactValue(value.Elem(), secret)
+	case reflect.Struct:
+		if duration, ok := value.Interface().(Duration); ok {
+			return duration.String()
+		}
+		if header, ok := value.Interface().(DataSourceHeader); ok {
+			return map[string]interface{}{
+				"name":  header.Name,
+				"value": redactValue(reflect.ValueOf(header.Value), secret || secretKeyPattern.MatchString(header.Name)),
+			}
+		}
+		if contactPoint, ok := value.Interface().(ContactPointConfig); ok {
+			settings := map[string]interface{}{}
+			for key, setting := range contactPoint.Settings {
+				settings[key] = redactValue(reflect.ValueOf(&setting).Elem(), secret || !contactPointPublicSettings[key])
+			}
+			return map[string]interface{}{
+				"uid":                     contactPoint.UID,
+				"name":                    contactPoint.Name,
+				"type":                    contactPoint.Type,
+				"settings":                settings,
+				"disable_resolve_message": contactPoint.DisableResolveMessage,
+			}
+		}
+		fields := map[string]interface{}{}
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			key := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+			if !field.IsExported() || key == "" || key == "-" {
+				continue
+			}
+			fields[key] = redactValue(value.Field(i), secret || secretKeyPattern.MatchString(key))
+		}
+		return fields
+	case reflect.Map:
+		entries := map[string]interface{}{}
+		for _, mapKey := range value.MapKeys() {
+			key := mapKey.String()
+			entries[key] = redactValue(value.MapIndex(mapKey), secret || secretKeyPattern.MatchString(key))
+		}
+		return entries
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return nil
+		}
+		items := make([]interface{}, value.Len())
+		for i := range items {
+			items[i] = redactValue(value.Index(i), secret)
+		}
+		return items
+	}
+
+	if secret && !value.IsZero() {
+		return Redacted
+	}
+	if value.Kind() == reflect.String {
+		return redactUserinfo(value.String())
+	}
+	return value.Interface()
+}
+
+// redactUserinfo strips the user:password@ of a URL value, other values are returned unchanged
+func redactUserinfo(value string) string {
+	if !strings.Contains(value, "@") || !strings.Contains(value, "://") {
+		return value
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.User == nil {
+		return value
+	}
+	parsed.User = nil
+	return parsed.String()
+}
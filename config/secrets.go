@@ -0,0 +1,183 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SecretProvider resolves the secret references of a backend, e.g. `vault:secret/data/grafana#token`
+type SecretProvider interface {
+	Resolve(reference string) (string, error) // The reference without the scheme, e.g. secret/data/grafana#token
+}
+
+// secretProviders create the secret backends by the scheme of their references. They are only created for
+// configs referencing them.
+var secretProviders = map[string]func() (SecretProvider, error){
+	"vault": newVaultProvider,
+	"awssm": newAWSProvider(secretsManager),
+	"ssm":   newAWSProvider(parameterStore),
+}
+
+// resolveSecretReferences replaces the string values of the YAML document referencing a secret backend, e.g.
+// `password: vault:secret/data/grafana#db_password`, with the secret. Documents without references are
+// returned unchanged.
+func resolveSecretReferences(content []byte) ([]byte, error) {
+	referenced := false
+	for scheme := range secretProviders {
+		if bytes.Contains(content, []byte(scheme+":")) {
+			referenced = true
+		}
+	}
+	if !referenced {
+		return content, nil
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration: %w", err)
+	}
+
+	providers := map[string]SecretProvider{}
+	found := false
+	var walkErr error
+	walkYAML(&document, func(node *yaml.Node) {
+		if walkErr != nil || node.Kind != yaml.ScalarNode || node.Tag != "!!str" {
+			return
+		}
+		scheme, reference, ok := strings.Cut(node.Value, ":")
+		newProvider, known := secretProviders[scheme]
+		if !ok || !known || reference == "" {
+			return
+		}
+		found = true
+
+		provider, created := providers[scheme]
+		if !created {
+			provider, walkErr = newProvider()
+			if walkErr != nil {
+				return
+			}
+			providers[scheme] = provider
+		}
+
+		secret, err := provider.Resolve(reference)
+		if err != nil {
+			walkErr = fmt.Errorf("failed to resolve secret '%s' at line %d: %w", node.Value, node.Line, err)
+			return
+		}
+		node.Style = yaml.DoubleQuotedStyle
+		node.Value = secret
+	})
+	if walkErr != nil {
+		return nil, walkErr
+	}
+	if !found {
+		return content, nil
+	}
+
+	return yaml.Marshal(&document)
+}
+
+// mergeSecretsFile merges the file of the top-level secrets_file key over the document, e.g. a SOPS-encrypted
+// secrets.enc.yaml next to a plaintext config. Mappings are merged deeply and lists of named items, such as
+// datasources, by name; other values are replaced.
+func mergeSecretsFile(content []byte) ([]byte, error) {
+	if !bytes.Contains(content, []byte("secrets_file")) {
+		return content, nil
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration: %w", err)
+	}
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return content, nil
+	}
+	root := document.Content[0]
+	path := ""
+	if value := mappingValue(root, "secrets_file"); value != nil && value.Kind == yaml.ScalarNode {
+		path = value.Value
+	}
+	if path == "" {
+		return content, nil
+	}
+
+	secrets, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets file '%s': %w", path, err)
+	}
+	secrets, err = decryptSOPS(secrets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secrets file '%s': %w", path, err)
+	}
+	var overlay yaml.Node
+	if err := yaml.Unmarshal(secrets, &overlay); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets file '%s': %w", path, err)
+	}
+	if len(overlay.Content) == 0 {
+		return content, nil
+	}
+	if overlay.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("secrets file '%s' is not a mapping", path)
+	}
+
+	mergeYAML(root, overlay.Content[0])
+	return yaml.Marshal(&document)
+}
+
+// mergeYAML merges the overlay mapping into the base mapping
+func mergeYAML(base *yaml.Node, overlay *yaml.Node) {
+	for i := 0; i+1 < len(overlay.Content); i += 2 {
+		key, value := overlay.Content[i], overlay.Content[i+1]
+		current := mappingValue(base, key.Value)
+		switch {
+		case current == nil:
+			base.Content = append(base.Content, key, value)
+		case current.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			mergeYAML(current, value)
+		case current.Kind == yaml.SequenceNode && value.Kind == yaml.SequenceNode && namedItems(current) && namedItems(value):
+			for _, item := range value.Content {
+				name := mappingValue(item, "name").Value
+				merged := false
+				for _, existing := range current.Content {
+					if mappingValue(existing, "name").Value == name {
+						mergeYAML(existing, item)
+						merged = true
+					}
+				}
+				if !merged {
+					current.Content = append(current.Content, item)
+				}
+			}
+		default:
+			*current = *value
+		}
+	}
+}
+
+// mappingValue returns the value of the key of a mapping node, nil if it's missing
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// namedItems reports whether the items of the sequence node are all mappings with a scalar name
+func namedItems(node *yaml.Node) bool {
+	for _, item := range node.Content {
+		if name := mappingValue(item, "name"); name == nil || name.Kind != yaml.ScalarNode {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/getsops/sops/v3/decrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// decryptSOPS decrypts a file encrypted with `sops --encrypt`, i.e. with a top-level `sops` key, and returns the
+// plaintext document without the `sops` key. The sops library verifies the MAC and looks up the master keys like
+// the sops CLI, e.g. the age identity of SOPS_AGE_KEY or SOPS_AGE_KEY_FILE and the AWS credentials of KMS keys.
+// Other documents are returned unchanged.
+func decryptSOPS(content []byte) ([]byte, error) {
+	if !isSOPSEncrypted(content) {
+		return content, nil
+	}
+
+	plaintext, err := decrypt.Data(content, "yaml")
+	if err != nil {
+		return nil, fmt.Errorf("sops decryption failed: %w", err)
+	}
+	return plaintext, nil
+}
+
+// isSOPSEncrypted reports whether the document has the `sops` metadata of an encrypted file, with its MAC
+func isSOPSEncrypted(content []byte) bool {
+	if !bytes.Contains(content, []byte("sops:")) {
+		return false
+	}
+
+	var document struct {
+		Sops struct {
+			MAC string `yaml:"mac"`
+		} `yaml:"sops"`
+	}
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return false
+	}
+	return document.Sops.MAC != ""
+}
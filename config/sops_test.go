@@ -0,0 +1,65 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The fixtures of testdata/sops were encrypted with `sops encrypt --age` to the key of testdata/sops/age.key
+
+func TestLoadSOPSConfig(t *testing.T) {
+	t.Setenv("SOPS_AGE_KEY_FILE", filepath.Join("testdata", "sops", "age.key"))
+
+	cfg, err := Load(filepath.Join("testdata", "sops", "config.enc.yaml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Grafana.URL != "http://grafana:3000" || cfg.Grafana.Token != "glsa_pa$word" {
+		t.Errorf("grafana = %q, %q, want the decrypted token with its $ kept", cfg.Grafana.URL, cfg.Grafana.Token)
+	}
+	if len(cfg.DataSources) != 1 || cfg.DataSources[0].Password != "db$ecret" || cfg.DataSources[0].Port != 5432 {
+		t.Errorf("datasources = %+v, want the decrypted password and the typed port", cfg.DataSources)
+	}
+}
+
+func TestLoadSOPSConfigTampered(t *testing.T) {
+	t.Setenv("SOPS_AGE_KEY_FILE", filepath.Join("testdata", "sops", "age.key"))
+
+	// The url of the fixture was changed after the encryption, the MAC covers the unencrypted values too
+	_, err := Load(filepath.Join("testdata", "sops", "config.tampered.enc.yaml"))
+	if err == nil || !strings.Contains(err.Error(), "Failed to verify data integrity") {
+		t.Fatalf("Load() error = %v, want a failed integrity check", err)
+	}
+}
+
+func TestLoadSOPSConfigWithoutKey(t *testing.T) {
+	t.Setenv("SOPS_AGE_KEY_FILE", filepath.Join(t.TempDir(), "missing.key"))
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if _, err := Load(filepath.Join("testdata", "sops", "config.enc.yaml")); err == nil {
+		t.Fatal("Load() without the age identity succeeded, want an error")
+	}
+}
+
+func TestLoadSecretsFile(t *testing.T) {
+	t.Setenv("SOPS_AGE_KEY_FILE", filepath.Join("testdata", "sops", "age.key"))
+	secrets, err := filepath.Abs(filepath.Join("testdata", "sops", "secrets.enc.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := writeTestConfig(t, "  token: placeholder\nsecrets_file: "+secrets+"\n"+
+		"datasources:\n  - name: Postgres\n    type: postgres\n    host: postgres\n    port: 5432\n"+
+		"    user: grafana\n    password: placeholder\n    dbname: metrics\n    sslmode: disable\n")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Grafana.Token != "glsa_from_$ecrets" || cfg.Grafana.URL != "http://grafana:3000" {
+		t.Errorf("grafana = %q, %q, want the token of the secrets file and the url of the config", cfg.Grafana.URL, cfg.Grafana.Token)
+	}
+	if len(cfg.DataSources) != 1 || cfg.DataSources[0].Password != "overlay$ecret" || cfg.DataSources[0].Host != "postgres" {
+		t.Errorf("datasources = %+v, want the password merged into the data source of the same name", cfg.DataSources)
+	}
+}
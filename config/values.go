@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadValues reads a per-environment values file substituted into dashboard placeholders.
+// Nested maps are flattened into dotted names, e.g. `slo: {latency_ms: 250}` becomes `slo.latency_ms`.
+func LoadValues(path string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values file '%s': %w", path, err)
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse values file '%s': %w", path, err)
+	}
+
+	values := map[string]interface{}{}
+	flattenValues("", raw, values)
+	return values, nil
+}
+
+// flattenValues copies the nested values into the flat map under dotted names
+func flattenValues(prefix string, nested map[string]interface{}, values map[string]interface{}) {
+	for key, value := range nested {
+		name := key
+		if prefix != "" {
+			name = prefix + "." + key
+		}
+
+		if child, ok := value.(map[string]interface{}); ok {
+			flattenValues(name, child, values)
+			continue
+		}
+		values[name] = value
+	}
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ilya-pishchalnikov/grafana-provisioner/buildinfo"
+)
+
+// Environment variables of the Vault connection, the same the vault CLI uses
+const (
+	VaultAddrEnv      = "VAULT_ADDR"
+	VaultTokenEnv     = "VAULT_TOKEN"     // Defaults to the ~/.vault-token of `vault login`
+	VaultNamespaceEnv = "VAULT_NAMESPACE" // Vault Enterprise namespace
+)
+
+// vaultProvider reads the secrets of `vault:<path>#<key>` references from the KV secrets engine, version 1
+// (`secret/grafana`) or 2 (`secret/data/grafana`)
+type vaultProvider struct {
+	address    string
+	token      string
+	namespace  string
+	httpClient *http.Client
+	secrets    map[string]map[string]interface{} // Read secrets by path, each is read once per load
+}
+
+// newVaultProvider connects to the Vault of VAULT_ADDR with the token of VAULT_TOKEN or ~/.vault-token
+func newVaultProvider() (SecretProvider, error) {
+	address := strings.TrimRight(os.Getenv(VaultAddrEnv), "/")
+	if address == "" {
+		return nil, fmt.Errorf("config contains vault: references but %s is not set", VaultAddrEnv)
+	}
+
+	token := os.Getenv(VaultTokenEnv)
+	if token == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			if content, err := os.ReadFile(filepath.Join(home, ".vault-token")); err == nil {
+				token = strings.TrimSpace(string(content))
+			}
+		}
+	}
+	if token == "" {
+		return nil, fmt.Errorf("config contains vault: references but neither %s nor ~/.vault-token is set", VaultTokenEnv)
+	}
+
+	return &vaultProvider{
+		address:    address,
+		token:      token,
+		namespace:  os.Getenv(VaultNamespaceEnv),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		secrets:    map[string]map[string]interface{}{},
+	}, nil
+}
+
+// Resolve returns the key of the secret at the path of a `<path>#<key>` reference
+func (vault *vaultProvider) Resolve(reference string) (string, error) {
+	path, key, ok := strings.Cut(reference, "#")
+	path = strings.Trim(path, "/")
+	if !ok || path == "" || key == "" {
+		return "", fmt.Errorf("vault references must have the form vault:<path>#<key>")
+	}
+
+	secret, read := vault.secrets[path]
+	if !read {
+		var err error
+		secret, err = vault.read(path)
+		if err != nil {
+			return "", err
+		}
+		vault.secrets[path] = secret
+	}
+
+	value, found := secret[key]
+	if !found {
+		return "", fmt.Errorf("secret '%s' has no key '%s'", path, key)
+	}
+	text, isString := value.(string)
+	if !isString {
+		return "", fmt.Errorf("key '%s' of secret '%s' is not a string", key, path)
+	}
+	return text, nil
+}
+
+// read returns the key-value pairs of the secret at the path
+func (vault *vaultProvider) read(path string) (map[string]interface{}, error) {
+	req, err := http.NewRequest("GET", vault.address+"/v1/"+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", vault.token)
+	req.Header.Set("User-Agent", buildinfo.UserAgent())
+	if vault.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", vault.namespace)
+	}
+
+	resp, err := vault.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret '%s': %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret '%s': %w", path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to read secret '%s': vault returned status %d: %s", path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode secret '%s': %w", path, err)
+	}
+
+	// KV version 2 nests the secret below its metadata
+	if nested, ok := response.Data["data"].(map[string]interface{}); ok {
+		if _, versioned := response.Data["metadata"]; versioned {
+			return nested, nil
+		}
+	}
+	return response.Data, nil
+}
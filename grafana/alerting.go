@@ -0,0 +1,271 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+)
+
+// Defaults applied to rule groups and rules missing the corresponding settings
+const (
+	defaultRuleGroupInterval   = time.Minute
+	defaultAlertQueryTimeRange = 10 * time.Minute
+	expressionDataSourceUID    = "__expr__"
+)
+
+// GetAlertRules fetches all Grafana-managed alert rules from the alerting provisioning API.
+func (client *ApiClient) GetAlertRules() ([]ProvisionedAlertRule, error) {
+	endpoint := client.URL + "/api/v1/provisioning/alert-rules"
+
+	body, err := client.doRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []ProvisionedAlertRule
+	if err := json.Unmarshal(body, &rules); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal alert rules response: %w", err)
+	}
+
+	return rules, nil
+}
+
+// GetAlertRuleGroup fetches a Grafana-managed rule group with its evaluation interval.
+func (client *ApiClient) GetAlertRuleGroup(folderUID string, group string) (*ProvisionedRuleGroup, error) {
+	endpoint := fmt.Sprintf("%s/api/v1/provisioning/folder/%s/rule-groups/%s", client.URL, url.PathEscape(folderUID), url.PathEscape(group))
+
+	body, err := client.doRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var ruleGroup ProvisionedRuleGroup
+	if err := json.Unmarshal(body, &ruleGroup); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal alert rule group response: %w", err)
+	}
+
+	return &ruleGroup, nil
+}
+
+// PutAlertRuleGroup sends a PUT request replacing a Grafana-managed rule group and all of its rules.
+func (client *ApiClient) PutAlertRuleGroup(group *ProvisionedRuleGroup) error {
+	client.Logger.Info("Provisioning alert rule group", "group", group.Title, "folder_uid", group.FolderUID, "rules", len(group.Rules))
+
+	endpoint := fmt.Sprintf("%s/api/v1/provisioning/folder/%s/rule-groups/%s", client.URL, url.PathEscape(group.FolderUID), url.PathEscape(group.Title))
+	data, err := json.Marshal(group)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert rule group: %w", err)
+	}
+
+	if _, err := client.doRequest("PUT", endpoint, data); err != nil {
+		return fmt.Errorf("alert rule group provisioning failed: %w", err)
+	}
+
+	client.Logger.Info("Alert rule group successfully provisioned", "group", group.Title)
+	return nil
+}
+
+// provisionAlertRuleGroups provisions every configured rule group either into Grafana-managed alerting
+// or into the Mimir/Loki ruler selected by the group.
+func provisionAlertRuleGroups(client GrafanaAPI, cfg Config, report *Report, log *slog.Logger) error {
+	if len(cfg.AlertRuleGroups) == 0 {
+		log.Info("No alert rule groups configured for provisioning, skipping alerting.")
+		return nil
+	}
+
+	log.Info("Provisioning alert rule groups")
+
+	var existingRules []ProvisionedAlertRule
+	existingRulesLoaded := false
+	dataSources := map[string]*DataSource{}
+
+	for _, group := range cfg.AlertRuleGroups {
+		if group.Ruler != "" && cfg.DryRun {
+			report.plan(PlanUpdate, KindAlertRuleGroup, group.Name, "push to ruler "+group.Ruler)
+			report.add(ResourceResult{Kind: KindAlertRuleGroup, Name: group.Name, Action: ActionProvisioned})
+			continue
+		}
+		if group.Ruler != "" {
+			if err := provisionRulerRuleGroup(client.Context(), cfg, group, log); err != nil {
+				return report.fail(KindAlertRuleGroup, group.Name, fmt.Errorf("failed to provision rule group '%s' to ruler '%s': %w", group.Name, group.Ruler, err))
+			}
+
+			report.add(ResourceResult{Kind: KindAlertRuleGroup, Name: group.Name, Action: ActionProvisioned})
+			continue
+		}
+
+		// Existing rules are loaded once to keep the UIDs of rules that already exist, new rules get stable UIDs
+		if !existingRulesLoaded {
+			rules, err := client.GetAlertRules()
+			if err != nil {
+				return fmt.Errorf("failed to list existing alert rules: %w", err)
+			}
+			existingRules = rules
+			existingRulesLoaded = true
+		}
+
+		ruleGroup, err := buildProvisionedRuleGroup(client, cfg, group, existingRules, dataSources, log)
+		if err != nil {
+			return report.fail(KindAlertRuleGroup, group.Name, fmt.Errorf("invalid rule group '%s': %w", group.Name, err))
+		}
+
+		if err := client.PutAlertRuleGroup(ruleGroup); err != nil {
+			return report.fail(KindAlertRuleGroup, group.Name, fmt.Errorf("failed to provision rule group '%s': %w", group.Name, err))
+		}
+
+		report.add(ResourceResult{Kind: KindAlertRuleGroup, Name: group.Name, Action: ActionProvisioned})
+		for _, rule := range ruleGroup.Rules {
+			report.add(ResourceResult{Kind: KindAlertRule, Name: group.Name + "/" + rule.Title, Action: ActionProvisioned, UID: rule.UID})
+		}
+	}
+
+	log.Info("All configured alert rule groups provisioned.")
+	return nil
+}
+
+// buildProvisionedRuleGroup converts a configured rule group into the alerting provisioning API model
+func buildProvisionedRuleGroup(client GrafanaAPI, cfg Config, group AlertRuleGroup, existingRules []ProvisionedAlertRule, dataSources map[string]*DataSource, log *slog.Logger) (*ProvisionedRuleGroup, error) {
+	folder, ok := cfg.FoldersMapping[group.Folder]
+	if !ok {
+		return nil, fmt.Errorf("rule group folder '%s' is not defined in the 'folders' configuration list", group.Folder)
+	}
+
+	interval := group.Interval
+	if interval <= 0 {
+		interval = defaultRuleGroupInterval
+	}
+
+	ruleGroup := &ProvisionedRuleGroup{
+		Title:     group.Name,
+		FolderUID: folder.UID,
+		Interval:  int64(interval.Seconds()),
+		Rules:     []ProvisionedAlertRule{},
+	}
+
+	for _, rule := range group.Rules {
+		if rule.Condition == "" || len(rule.Queries) == 0 {
+			return nil, fmt.Errorf("rule '%s' needs a condition and at least one query for Grafana-managed alerting", rule.Title)
+		}
+
+		data := []AlertQueryModel{}
+		for _, query := range rule.Queries {
+			dataSource, err := resolveDataSource(client, query.DataSource, dataSources)
+			if err != nil {
+				return nil, fmt.Errorf("rule '%s' query '%s': %w", rule.Title, query.RefID, err)
+			}
+			data = append(data, buildAlertQueryModel(query, dataSource))
+		}
+		for _, expression := range rule.Expressions {
+			data = append(data, buildAlertExpressionModel(expression))
+		}
+
+		forDuration := rule.For
+		if forDuration == "" {
+			forDuration = "0s"
+		}
+
+		// Rules paused for maintenance stay paused
+		uid, existing := resolveAlertRuleUID(existingRules, folder, group, rule, log)
+		paused := existing != nil && existing.IsPaused
+
+		ruleGroup.Rules = append(ruleGroup.Rules, ProvisionedAlertRule{
+			UID:          uid,
+			Title:        rule.Title,
+			Condition:    rule.Condition,
+			Data:         data,
+			NoDataState:  "NoData",
+			ExecErrState: "Error",
+			For:          forDuration,
+			Labels:       rule.Labels,
+			Annotations:  rule.Annotations,
+			FolderUID:    folder.UID,
+			RuleGroup:    group.Name,
+			IsPaused:     paused,
+		})
+	}
+
+	return ruleGroup, nil
+}
+
+// buildAlertQueryModel renders a data source query of an alert rule
+func buildAlertQueryModel(query AlertQuery, dataSource *DataSource) AlertQueryModel {
+	timeRange := query.RelativeTime
+	if timeRange <= 0 {
+		timeRange = defaultAlertQueryTimeRange
+	}
+
+	model := map[string]interface{}{
+		"refId": query.RefID,
+	}
+
+	// SQL data sources take raw SQL, Prometheus-like data sources an expression
+	if isSQLDataSourceType(dataSource.Type) {
+		model["rawSql"] = query.Expr
+		model["rawQuery"] = true
+		model["format"] = "table"
+		model["editorMode"] = "code"
+	} else {
+		model["expr"] = query.Expr
+	}
+
+	return AlertQueryModel{
+		RefID:             query.RefID,
+		RelativeTimeRange: RelativeTimeRange{From: int64(timeRange.Seconds()), To: 0},
+		DatasourceUID:     dataSource.UID,
+		Model:             model,
+	}
+}
+
+// buildAlertExpressionModel renders a server-side expression of an alert rule
+func buildAlertExpressionModel(expression AlertExpression) AlertQueryModel {
+	model := map[string]interface{}{
+		"refId":      expression.RefID,
+		"type":       expression.Type,
+		"expression": expression.Expression,
+	}
+	if expression.Reducer != "" {
+		model["reducer"] = expression.Reducer
+	}
+
+	return AlertQueryModel{
+		RefID:         expression.RefID,
+		DatasourceUID: expressionDataSourceUID,
+		Model:         model,
+	}
+}
+
+// resolveDataSource looks up a data source by name, caching the results for the run
+func resolveDataSource(client GrafanaAPI, name string, cache map[string]*DataSource) (*DataSource, error) {
+	if dataSource, ok := cache[name]; ok {
+		return dataSource, nil
+	}
+
+	dataSource, err := client.GetDataSource(name)
+	if err != nil {
+		return nil, fmt.Errorf("data source '%s' not found: %w", name, err)
+	}
+
+	cache[name] = dataSource
+	return dataSource, nil
+}
+
+// findExistingRuleUID returns the UID of an existing rule with the same folder, group and title,
+// so updating a group keeps the rule identity instead of recreating it.
+func findExistingRuleUID(existingRules []ProvisionedAlertRule, folderUID string, group string, title string) string {
+	if rule := findExistingRule(existingRules, folderUID, group, title); rule != nil {
+		return rule.UID
+	}
+	return ""
+}
+
+// findExistingRule returns the existing rule with the same folder, group and title, nil if there is none
+func findExistingRule(existingRules []ProvisionedAlertRule, folderUID string, group string, title string) *ProvisionedAlertRule {
+	for i, rule := range existingRules {
+		if rule.FolderUID == folderUID && rule.RuleGroup == group && rule.Title == title {
+			return &existingRules[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package grafana
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// alertingDataSourceTypes are the data source plugins with a backend able to evaluate alert queries
+var alertingDataSourceTypes = map[string]bool{
+	"prometheus":                       true,
+	"loki":                             true,
+	"grafana-postgresql-datasource":    true,
+	"postgres":                         true,
+	"mysql":                            true,
+	"mssql":                            true,
+	"influxdb":                         true,
+	"elasticsearch":                    true,
+	"graphite":                         true,
+	"opentsdb":                         true,
+	"cloudwatch":                       true,
+	"stackdriver":                      true,
+	"grafana-azure-monitor-datasource": true,
+	"grafana-testdata-datasource":      true,
+}
+
+// reservedAlertLabels are set by Grafana on every alert and can't be declared by rules
+var reservedAlertLabels = map[string]bool{
+	"alertname":      true,
+	"grafana_folder": true,
+}
+
+// ruleDurationPattern matches the Prometheus-style durations accepted for `for`, e.g. 5m or 1h30m
+var ruleDurationPattern = regexp.MustCompile(`^([0-9]+(ms|s|m|h|d|w|y))+$`)
+
+// expressionRefPattern matches the `$A` / `${A}` references of math expressions
+var expressionRefPattern = regexp.MustCompile(`\$\{?([A-Za-z0-9_]+)\}?`)
+
+// LintIssue is a problem found in a declared alert rule
+type LintIssue struct {
+	Group   string
+	Rule    string
+	Message string
+}
+
+// String renders the issue with the group and rule it was found in
+func (issue LintIssue) String() string {
+	return fmt.Sprintf("rule group '%s' rule '%s': %s", issue.Group, issue.Rule, issue.Message)
+}
+
+// LintAlertRules validates the declared Grafana-managed alert rules before anything is provisioned:
+// query data sources exist (in the config or in Grafana) and support alerting, refIDs resolve,
+// `for` durations parse and labels don't use names reserved by Grafana.
+func LintAlertRules(client GrafanaAPI, cfg Config) ([]LintIssue, error) {
+	configured := map[string]string{}
+	for _, dataSource := range cfg.DataSources {
+		configured[dataSource.Name] = dataSource.Type
+	}
+	live := map[string]string{}
+
+	// dataSourceType returns the type of the data source, empty if it doesn't exist
+	dataSourceType := func(name string) (string, error) {
+		if dataSourceType, ok := configured[name]; ok {
+			return dataSourceType, nil
+		}
+		if dataSourceType, ok := live[name]; ok {
+			return dataSourceType, nil
+		}
+
+		dataSource, err := client.GetDataSource(name)
+		if errors.Is(err, ErrNotFound) {
+			live[name] = ""
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		live[name] = dataSource.Type
+		return dataSource.Type, nil
+	}
+
+	issues := []LintIssue{}
+	for _, group := range cfg.AlertRuleGroups {
+		for _, rule := range group.Rules {
+			issue := func(format string, args ...interface{}) {
+				issues = append(issues, LintIssue{Group: group.Name, Rule: rule.Title, Message: fmt.Sprintf(format, args...)})
+			}
+
+			if rule.For != "" && !ruleDurationPattern.MatchString(rule.For) {
+				issue("'for' duration '%s' can't be parsed", rule.For)
+			}
+			for label := range rule.Labels {
+				if reservedAlertLabels[label] || strings.HasPrefix(label, "__") {
+					issue("label '%s' is reserved by Grafana", label)
+				}
+			}
+
+			// Ruler targets are evaluated by Mimir/Loki from the rule expr
+			if group.Ruler != "" {
+				continue
+			}
+
+			refIDs := map[string]bool{}
+			for _, query := range rule.Queries {
+				if refIDs[query.RefID] {
+					issue("refID '%s' is used twice", query.RefID)
+				}
+				refIDs[query.RefID] = true
+
+				queryType, err := dataSourceType(query.DataSource)
+				if err != nil {
+					return nil, fmt.Errorf("failed to look up data source '%s': %w", query.DataSource, err)
+				}
+				switch {
+				case queryType == "":
+					issue("query '%s' data source '%s' doesn't exist", query.RefID, query.DataSource)
+				case !alertingDataSourceTypes[queryType]:
+					issue("query '%s' data source '%s' of type '%s' doesn't support alerting", query.RefID, query.DataSource, queryType)
+				}
+			}
+			for _, expression := range rule.Expressions {
+				if refIDs[expression.RefID] {
+					issue("refID '%s' is used twice", expression.RefID)
+				}
+				refIDs[expression.RefID] = true
+			}
+
+			for _, expression := range rule.Expressions {
+				for _, match := range expressionRefPattern.FindAllStringSubmatch(expression.Expression, -1) {
+					if !refIDs[match[1]] {
+						issue("expression '%s' references unknown refID '%s'", expression.RefID, match[1])
+					}
+				}
+				// Reduce and resample expressions name their input directly
+				if expression.Type != "math" && expression.Expression != "" && !strings.Contains(expression.Expression, "$") && !refIDs[expression.Expression] {
+					issue("expression '%s' references unknown refID '%s'", expression.RefID, expression.Expression)
+				}
+			}
+			if rule.Condition != "" && !refIDs[rule.Condition] {
+				issue("condition '%s' is not a query or expression refID", rule.Condition)
+			}
+		}
+	}
+
+	return issues, nil
+}
@@ -0,0 +1,44 @@
+package grafana
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+)
+
+// alertRuleUIDPrefix marks the rule UIDs generated by the provisioner
+const alertRuleUIDPrefix = "gp-"
+
+// StableAlertRuleUID derives the UID of a Grafana-managed rule from its folder name, group and title,
+// so the same rule gets the same UID on every instance it is provisioned to.
+func StableAlertRuleUID(folder string, group string, title string) string {
+	sum := sha256.Sum256([]byte(folder + "\x00" + group + "\x00" + title))
+	// Grafana accepts rule UIDs up to 40 characters
+	return alertRuleUIDPrefix + hex.EncodeToString(sum[:16])
+}
+
+// resolveAlertRuleUID picks the UID of a configured rule and returns the live rule it updates, nil if it is new:
+//  1. A live rule with the configured UID, e.g. from a config exported from another instance
+//  2. A live rule with the same folder, group and title, keeping the identity of rules created before
+//  3. The configured UID, or the stable UID derived from the names
+func resolveAlertRuleUID(existingRules []ProvisionedAlertRule, folder FolderMapping, group AlertRuleGroup, rule AlertRule, log *slog.Logger) (string, *ProvisionedAlertRule) {
+	if rule.UID != "" {
+		for i, existing := range existingRules {
+			if existing.UID == rule.UID {
+				return existing.UID, &existingRules[i]
+			}
+		}
+	}
+
+	if existing := findExistingRule(existingRules, folder.UID, group.Name, rule.Title); existing != nil {
+		if rule.UID != "" && rule.UID != existing.UID {
+			log.Warn("Keeping the UID of the live rule with the same title", "group", group.Name, "rule", rule.Title, "uid", existing.UID, "configured_uid", rule.UID)
+		}
+		return existing.UID, existing
+	}
+
+	if rule.UID != "" {
+		return rule.UID, nil
+	}
+	return StableAlertRuleUID(group.Folder, group.Name, rule.Title), nil
+}
@@ -0,0 +1,121 @@
+package grafana
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+)
+
+// defaultAnnotationIconColor is the icon color Grafana uses for new annotation queries
+const defaultAnnotationIconColor = "rgba(0, 211, 255, 1)"
+
+// resolvedAnnotation is an annotation query with its data source resolved to a live UID.
+type resolvedAnnotation struct {
+	Annotation
+	DataSourceUID  string
+	DataSourceType string
+}
+
+// resolveAnnotations looks up the data sources of all configured annotations.
+func resolveAnnotations(client GrafanaAPI, annotations []Annotation, log *slog.Logger) ([]resolvedAnnotation, error) {
+	resolved := []resolvedAnnotation{}
+
+	for _, annotation := range annotations {
+		dataSource, err := client.GetDataSource(annotation.DataSource)
+		if err != nil {
+			return nil, fmt.Errorf("annotation '%s' data source '%s' not found: %w", annotation.Name, annotation.DataSource, err)
+		}
+
+		resolved = append(resolved, resolvedAnnotation{
+			Annotation:     annotation,
+			DataSourceUID:  dataSource.UID,
+			DataSourceType: dataSource.Type,
+		})
+	}
+
+	log.Debug("Annotation queries resolved", "count", len(resolved))
+	return resolved, nil
+}
+
+// injectAnnotations adds the annotation queries applicable to the dashboard into its annotations.list.
+// An existing entry with the same name is replaced, so repeated runs don't duplicate annotations.
+func injectAnnotations(dashboard DashboardJSON, dashboardName string, annotations []resolvedAnnotation, log *slog.Logger) {
+	for _, annotation := range annotations {
+		if len(annotation.Dashboards) > 0 && !slices.Contains(annotation.Dashboards, dashboardName) {
+			continue
+		}
+
+		annotationsBlock, ok := dashboard["annotations"].(map[string]interface{})
+		if !ok {
+			annotationsBlock = map[string]interface{}{}
+			dashboard["annotations"] = annotationsBlock
+		}
+		list, _ := annotationsBlock["list"].([]interface{})
+
+		entry := buildAnnotationEntry(annotation)
+
+		replaced := false
+		for i, existing := range list {
+			existingMap, ok := existing.(map[string]interface{})
+			if ok && existingMap["name"] == annotation.Name {
+				list[i] = entry
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			list = append(list, entry)
+		}
+
+		annotationsBlock["list"] = list
+		log.Info("Annotation query injected into dashboard", "dashboard", dashboardName, "annotation", annotation.Name)
+	}
+}
+
+// buildAnnotationEntry renders the dashboard JSON model of an annotation query.
+func buildAnnotationEntry(annotation resolvedAnnotation) map[string]interface{} {
+	iconColor := annotation.IconColor
+	if iconColor == "" {
+		iconColor = defaultAnnotationIconColor
+	}
+
+	entry := map[string]interface{}{
+		"name": annotation.Name,
+		"datasource": map[string]interface{}{
+			"type": annotation.DataSourceType,
+			"uid":  annotation.DataSourceUID,
+		},
+		"enable":    true,
+		"hide":      annotation.Hide,
+		"iconColor": iconColor,
+	}
+
+	if isSQLDataSourceType(annotation.DataSourceType) {
+		entry["rawQuery"] = annotation.Query
+		entry["target"] = map[string]interface{}{
+			"rawSql":     annotation.Query,
+			"rawQuery":   true,
+			"format":     "table",
+			"editorMode": "code",
+			"refId":      "Anno",
+		}
+	} else {
+		// Prometheus, Loki and similar data sources take an expression
+		entry["expr"] = annotation.Query
+		entry["target"] = map[string]interface{}{
+			"expr":  annotation.Query,
+			"refId": "Anno",
+		}
+	}
+
+	return entry
+}
+
+// isSQLDataSourceType reports whether the data source plugin is queried with raw SQL
+func isSQLDataSourceType(dataSourceType string) bool {
+	switch dataSourceType {
+	case "grafana-postgresql-datasource", "postgres", "mysql", "mssql":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package grafana
+
+import (
+	"context"
+	"log/slog"
+)
+
+// GrafanaAPI is the Grafana HTTP API surface consumed by the provisioner.
+// ApiClient implements it; unit tests and embedders can inject fakes without HTTP.
+type GrafanaAPI interface {
+	// WithLogger returns a client logging to the given logger, used to scope logs to a resource
+	WithLogger(logger *slog.Logger) GrafanaAPI
+	// WithContext returns a client whose requests and retry delays are canceled with the context
+	WithContext(ctx context.Context) GrafanaAPI
+	// Context returns the context the requests are canceled with
+	Context() context.Context
+
+	// BaseURL returns the Grafana base URL used to build absolute resource URLs
+	BaseURL() string
+	// Deprecations returns the deprecation notices Grafana sent so far, once per notice
+	Deprecations() []Deprecation
+	// CheckHealth makes a single, non-retried health check request
+	CheckHealth() error
+	// ValidateToken checks the credentials and reports the org and role they act in
+	ValidateToken() (*TokenInfo, error)
+	// GetHealth returns the health response carrying the Grafana version
+	GetHealth() (*HealthResponse, error)
+	// GetFrontendSettings returns the build info and feature toggles of the instance
+	GetFrontendSettings() (*FrontendSettings, error)
+	// GetPlugins lists the installed non-core plugins
+	GetPlugins() ([]PluginInfo, error)
+	// QueryDataSources runs panel queries through /api/ds/query
+	QueryDataSources(request *DataSourceQueryRequest) (*DataSourceQueryResponse, error)
+	// EvalAlertRule evaluates the queries and condition of an alert rule through /api/v1/eval
+	EvalAlertRule(request *AlertEvalRequest) (*DataSourceQueryResponse, error)
+
+	GetOrgByName(name string) (*OrgResponse, error)
+	CreateOrg(name string) (int, error)
+	AddOrgUser(orgID int, loginOrEmail string, role string) (bool, error)
+	// UseOrg makes all following requests act in the given organization
+	UseOrg(orgID int)
+
+	FindTeamByName(name string) (*TeamResponse, error)
+	CreateTeam(name string, email string) (int, error)
+	GetTeamGroups(teamID int) ([]string, error)
+	AddTeamGroup(teamID int, groupID string) error
+	RemoveTeamGroup(teamID int, groupID string) error
+	GetTeamMembers(teamID int) ([]OrgUser, error)
+	AddTeamMember(teamID int, userID int) error
+	RemoveTeamMember(teamID int, userID int) error
+	FindOrgUser(loginOrEmail string) (*OrgUser, error)
+
+	FindServiceAccountByName(name string) (*ServiceAccountResponse, error)
+	CreateServiceAccount(name string, role string) (*ServiceAccountResponse, error)
+	GetServiceAccountTokenNames(serviceAccountID int) ([]string, error)
+	CreateServiceAccountToken(serviceAccountID int, name string) (string, error)
+
+	// Lookups by name or UID return an error wrapping ErrNotFound for missing resources
+	GetDataSource(dataSourceName string) (*DataSource, error)
+	GetDataSourceByUID(uid string) (*DataSource, error)
+	GetDataSources() ([]DataSource, error)
+	ListDataSourcesByType(dataSourceType string) ([]DataSource, error)
+	CreateDataSource(ds *DataSourceModel) (*CreateDataSourceResponse, error)
+	UpdateDataSource(uid string, ds *DataSourceModel) (*CreateDataSourceResponse, error)
+	DeleteDataSourceByUID(uid string) error
+
+	GetFolders() ([]FolderResponse, error)
+	SetFolderPermissions(folderUID string, items []PermissionItem) error
+	SetDashboardPermissions(dashboardUID string, items []PermissionItem) error
+	CreateFolderIfNotExists(title string) (*FolderResponse, error)
+	DeleteFolder(uid string) error
+
+	SearchDashboards() ([]DashboardSearchResponse, error)
+	FindFirstDashboardByFolderAndName(name string, folder string) (dashboard DashboardSearchResponse, found bool, err error)
+	FindDashboardsByName(name string) ([]DashboardSearchResponse, error)
+	GetDashboardByUID(uid string) (*DashboardGetResponse, error)
+	ImportDashboard(request *DashboardImportRequest) (*DashboardImportResponse, error)
+	SaveDashboard(request *DashboardSaveRequest) (*DashboardSaveResponse, error)
+	DeleteDashboardByUID(uid string) error
+
+	GetAPIGroup(group string) (*K8sAPIGroup, error)
+	ApplyResource(version string, resource string, namespace string, object *K8sObject) (*K8sObject, error)
+
+	GetAlertRules() ([]ProvisionedAlertRule, error)
+	GetAlertRuleGroup(folderUID string, group string) (*ProvisionedRuleGroup, error)
+	PutAlertRuleGroup(group *ProvisionedRuleGroup) error
+	// GetNotificationPolicyTree and SetNotificationPolicyTree read and replace the notification policy tree
+	GetNotificationPolicyTree() (map[string]interface{}, error)
+	SetNotificationPolicyTree(tree map[string]interface{}) error
+	SetAlertRulePaused(uid string, paused bool) error
+	GetContactPoints() ([]ProvisionedContactPoint, error)
+	CreateContactPoint(contactPoint *ProvisionedContactPoint) (*ProvisionedContactPoint, error)
+	UpdateContactPoint(contactPoint *ProvisionedContactPoint) error
+	GetLegacyNotificationChannels() ([]LegacyNotificationChannel, error)
+	CreateSilence(silence *Silence) (string, error)
+	ExpireSilence(id string) error
+}
+
+// Ensure ApiClient satisfies the GrafanaAPI interface
+var _ GrafanaAPI = (*ApiClient)(nil)
@@ -0,0 +1,339 @@
+package grafana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxBacktestEvaluations bounds the evaluations of a rule, the window or step is too fine beyond it
+const maxBacktestEvaluations = 10000
+
+// AlertEvalRequest is the body of POST /api/v1/eval, evaluating the queries and the condition of a rule as of Now
+type AlertEvalRequest struct {
+	Condition string            `json:"condition"`
+	Data      []AlertQueryModel `json:"data"`
+	Now       time.Time         `json:"now"`
+}
+
+// BacktestOptions are the historical window the rules are evaluated over
+type BacktestOptions struct {
+	From  time.Time
+	To    time.Time
+	Step  time.Duration // Time between the evaluations, 0 uses the interval of the rule group
+	Group string        // Only backtest the rule group with this name, empty for all
+}
+
+// BacktestResult is how a rule would have behaved over the window
+type BacktestResult struct {
+	Group       string
+	Rule        string
+	Evaluations int
+	Firings     int           // Times an alert went from pending or normal to firing
+	Series      int           // Distinct label sets that fired
+	FiringTime  time.Duration // Total time alerts were firing, summed over the series
+	FirstFiring time.Time     // Zero if the rule never fired
+	Error       string        // Why the rule couldn't be backtested
+}
+
+// backtestSeries is the alert state of a label set of a rule
+type backtestSeries struct {
+	activeAt    time.Time
+	firing      bool
+	firingSince time.Time
+}
+
+// backtest replays the evaluations of a rule, an alert fires once its condition held for the `for` duration
+type backtest struct {
+	result      *BacktestResult
+	forDuration time.Duration
+	series      map[string]*backtestSeries
+	fired       map[string]bool
+}
+
+// EvalAlertRule evaluates alert rule queries and their condition through /api/v1/eval
+func (client *ApiClient) EvalAlertRule(request *AlertEvalRequest) (*DataSourceQueryResponse, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal alert rule evaluation: %w", err)
+	}
+
+	body, err := client.doRequest("POST", client.URL+"/api/v1/eval", data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate alert rule: %w", err)
+	}
+
+	var response DataSourceQueryResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal alert rule evaluation response: %w", err)
+	}
+	return &response, nil
+}
+
+// BacktestAlertRules evaluates the configured alert rules at every step of the window and counts how often
+// each would have fired, to tune thresholds and `for` durations before provisioning. Grafana-managed rules are
+// evaluated through /api/v1/eval, rules of Mimir and Loki rulers with range queries of their expression.
+// Recording rules are skipped. Rules that fail to evaluate carry the error, an error is returned only if
+// the options are invalid.
+func BacktestAlertRules(client GrafanaAPI, cfg Config, options BacktestOptions, log *slog.Logger) ([]BacktestResult, error) {
+	// Range queries take whole seconds
+	options.From, options.To = options.From.Truncate(time.Second), options.To.Truncate(time.Second)
+	if !options.From.Before(options.To) {
+		return nil, fmt.Errorf("the backtest window must end after it starts")
+	}
+	log.Info("Backtesting alert rules", "from", options.From.Format(time.RFC3339), "to", options.To.Format(time.RFC3339))
+
+	results := []BacktestResult{}
+	dataSources := map[string]*DataSource{}
+	for _, group := range cfg.AlertRuleGroups {
+		if options.Group != "" && group.Name != options.Group {
+			continue
+		}
+		step := options.Step
+		if step <= 0 {
+			step = group.Interval
+		}
+		if step <= 0 {
+			step = defaultRuleGroupInterval
+		}
+		if evaluations := int(options.To.Sub(options.From)/step) + 1; evaluations > maxBacktestEvaluations {
+			return nil, fmt.Errorf("rule group '%s' would be evaluated %d times, more than %d, use a larger step or a shorter window",
+				group.Name, evaluations, maxBacktestEvaluations)
+		}
+		groupLog := log.With("group", group.Name)
+
+		for _, rule := range group.Rules {
+			if rule.Record != "" {
+				continue
+			}
+			result := BacktestResult{Group: group.Name, Rule: rule.Title}
+			forDuration, err := parseRuleFor(rule.For)
+			if err == nil {
+				run := &backtest{result: &result, forDuration: forDuration, series: map[string]*backtestSeries{}, fired: map[string]bool{}}
+				if group.Ruler != "" {
+					err = backtestRulerRule(client.Context(), cfg, group, rule, options, step, run, groupLog)
+				} else {
+					err = backtestGrafanaRule(client, rule, options, step, dataSources, run)
+				}
+				run.finish(options.To)
+			}
+			if err != nil {
+				result.Error = err.Error()
+				groupLog.Warn("Failed to backtest alert rule", "rule", rule.Title, "error", err)
+			} else {
+				groupLog.Info("Alert rule backtested", "rule", rule.Title, "evaluations", result.Evaluations, "firings", result.Firings)
+			}
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// parseRuleFor parses the `for` duration of a rule, empty for none
+func parseRuleFor(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid for duration '%s': %w", value, err)
+	}
+	return duration, nil
+}
+
+// backtestGrafanaRule evaluates a Grafana-managed rule as of every step of the window
+func backtestGrafanaRule(client GrafanaAPI, rule AlertRule, options BacktestOptions, step time.Duration, dataSources map[string]*DataSource, run *backtest) error {
+	if rule.Condition == "" || len(rule.Queries) == 0 {
+		return fmt.Errorf("rule needs a condition and at least one query for Grafana-managed alerting")
+	}
+	data := []AlertQueryModel{}
+	for _, query := range rule.Queries {
+		dataSource, err := resolveDataSource(client, query.DataSource, dataSources)
+		if err != nil {
+			return fmt.Errorf("query '%s': %w", query.RefID, err)
+		}
+		data = append(data, buildAlertQueryModel(query, dataSource))
+	}
+	for _, expression := range rule.Expressions {
+		data = append(data, buildAlertExpressionModel(expression))
+	}
+
+	for at := options.From; !at.After(options.To); at = at.Add(step) {
+		response, err := client.EvalAlertRule(&AlertEvalRequest{Condition: rule.Condition, Data: data, Now: at})
+		if err != nil {
+			return err
+		}
+		result, ok := response.Results[rule.Condition]
+		if !ok {
+			return fmt.Errorf("evaluation returned no result for the condition '%s'", rule.Condition)
+		}
+		if result.Error != "" {
+			return fmt.Errorf("evaluation at %s failed: %s", at.Format(time.RFC3339), result.Error)
+		}
+
+		active := map[string]bool{}
+		for _, frame := range result.Frames {
+			if key, firing := conditionFrameFiring(frame); firing {
+				active[key] = true
+			}
+		}
+		run.evaluate(at, active)
+	}
+	return nil
+}
+
+// conditionFrameFiring returns the labels of a condition result frame and whether its condition holds, i.e.
+// its last value is neither zero nor missing
+func conditionFrameFiring(frame DataFrame) (string, bool) {
+	for i, field := range frame.Schema.Fields {
+		if field.Type != "number" || i >= len(frame.Data.Values) || len(frame.Data.Values[i]) == 0 {
+			continue
+		}
+		values := frame.Data.Values[i]
+		value, ok := values[len(values)-1].(float64)
+		return labelsKey(field.Labels), ok && value != 0 && !math.IsNaN(value)
+	}
+	return "", false
+}
+
+// backtestRulerRule runs the expression of a ruler rule over the window with a range query. As in Prometheus,
+// every series the expression returns at a step is active.
+func backtestRulerRule(ctx context.Context, cfg Config, group AlertRuleGroup, rule AlertRule, options BacktestOptions, step time.Duration, run *backtest, log *slog.Logger) error {
+	ruler, ok := findRuler(cfg.Rulers, group.Ruler)
+	if !ok {
+		return fmt.Errorf("ruler '%s' is not defined in the 'alerting.rulers' configuration list", group.Ruler)
+	}
+	if rule.Expr == "" {
+		return fmt.Errorf("rule needs an expr to be evaluated by a ruler")
+	}
+
+	samples, err := NewRulerClient(ctx, ruler, cfg.Grafana, log).QueryRange(rule.Expr, options.From, options.To, step)
+	if err != nil {
+		return err
+	}
+	for at := options.From; !at.After(options.To); at = at.Add(step) {
+		run.evaluate(at, samples[at.Unix()])
+	}
+	return nil
+}
+
+// QueryRange runs a range query of the Prometheus or Loki API behind the ruler and returns the label sets of the
+// series with a sample by the Unix time of each step
+func (ruler *RulerClient) QueryRange(query string, start time.Time, end time.Time, step time.Duration) (map[int64]map[string]bool, error) {
+	var endpoint string
+	switch ruler.Type {
+	case "mimir":
+		endpoint = ruler.api.URL + "/prometheus/api/v1/query_range"
+	case "loki":
+		endpoint = ruler.api.URL + "/loki/api/v1/query_range"
+	default:
+		return nil, fmt.Errorf("unsupported ruler type '%s'", ruler.Type)
+	}
+	params := url.Values{
+		"query": {query},
+		"start": {start.UTC().Format(time.RFC3339)},
+		"end":   {end.UTC().Format(time.RFC3339)},
+		"step":  {strconv.FormatFloat(step.Seconds(), 'f', -1, 64)},
+	}
+
+	body, err := ruler.api.doRequest("GET", endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("range query failed: %w", err)
+	}
+	var response struct {
+		Data struct {
+			ResultType string `json:"resultType"`
+			Result     []struct {
+				Metric map[string]string `json:"metric"`
+				Values [][]interface{}   `json:"values"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal range query response: %w", err)
+	}
+	if response.Data.ResultType != "matrix" {
+		return nil, fmt.Errorf("expected a matrix from the range query, got '%s'", response.Data.ResultType)
+	}
+
+	samples := map[int64]map[string]bool{}
+	for _, series := range response.Data.Result {
+		key := labelsKey(series.Metric)
+		for _, sample := range series.Values {
+			timestamp, ok := sample[0].(float64)
+			if !ok {
+				continue
+			}
+			// Align the sample to its step
+			steps := math.Round((timestamp - float64(start.UnixNano())/1e9) / step.Seconds())
+			at := start.Add(time.Duration(steps) * step).Unix()
+			if samples[at] == nil {
+				samples[at] = map[string]bool{}
+			}
+			samples[at][key] = true
+		}
+	}
+	return samples, nil
+}
+
+// evaluate advances the state of the series to the evaluation at the time, with the active label sets
+func (run *backtest) evaluate(at time.Time, active map[string]bool) {
+	run.result.Evaluations++
+	for key, series := range run.series {
+		if active[key] {
+			continue
+		}
+		if series.firing {
+			run.result.FiringTime += at.Sub(series.firingSince)
+		}
+		delete(run.series, key)
+	}
+
+	for key := range active {
+		series, ok := run.series[key]
+		if !ok {
+			series = &backtestSeries{activeAt: at}
+			run.series[key] = series
+		}
+		if !series.firing && at.Sub(series.activeAt) >= run.forDuration {
+			series.firing = true
+			series.firingSince = at
+			run.result.Firings++
+			run.fired[key] = true
+			if run.result.FirstFiring.IsZero() {
+				run.result.FirstFiring = at
+			}
+		}
+	}
+	run.result.Series = len(run.fired)
+}
+
+// finish counts the alerts still firing at the end of the window
+func (run *backtest) finish(end time.Time) {
+	for _, series := range run.series {
+		if series.firing {
+			run.result.FiringTime += end.Sub(series.firingSince)
+		}
+	}
+}
+
+// labelsKey returns a stable key of a label set
+func labelsKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := []string{}
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+labels[key])
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,144 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// Catalog describes the provisioned observability resources, built from the config and the dashboard JSON files
+// without contacting Grafana.
+type Catalog struct {
+	Folders     []CatalogFolder
+	DataSources []DataSource
+	RulerGroups []AlertRuleGroup // Rule groups pushed to Mimir/Loki rulers
+}
+
+// CatalogFolder is a folder with the dashboards and Grafana-managed rule groups it holds
+type CatalogFolder struct {
+	Name       string
+	OwnerTeam  string
+	Dashboards []CatalogDashboard
+	RuleGroups []AlertRuleGroup
+}
+
+// CatalogDashboard is a dashboard with the documentation carried by its JSON
+type CatalogDashboard struct {
+	Name        string
+	Owner       string // Owner team of the folder
+	Source      string // File path or grafana.com dashboard
+	Description string
+	Tags        []string
+	Links       []CatalogLink
+	DataSources []string // Names of the data sources wired in through imports
+}
+
+// CatalogLink is a dashboard link, either to a URL or to the dashboards with the given tags
+type CatalogLink struct {
+	Title string
+	URL   string
+	Tags  []string
+}
+
+// BuildCatalog groups the configured dashboards and rule groups by folder and reads the description, tags and
+// links of every dashboard from its JSON.
+func BuildCatalog(cfg Config, log *slog.Logger) (*Catalog, error) {
+	catalog := &Catalog{DataSources: cfg.DataSources}
+
+	folderIndex := map[string]int{}
+	folder := func(name string) *CatalogFolder {
+		if name == "" {
+			name = "General"
+		}
+		index, ok := folderIndex[name]
+		if !ok {
+			index = len(catalog.Folders)
+			folderIndex[name] = index
+			catalog.Folders = append(catalog.Folders, CatalogFolder{Name: name})
+		}
+		return &catalog.Folders[index]
+	}
+
+	for _, configured := range cfg.Folders {
+		folder(configured.Name).OwnerTeam = configured.OwnerTeam
+	}
+
+	for _, dashboardConfig := range cfg.Dashboards {
+		dashboard, err := catalogDashboard(dashboardConfig, log)
+		if err != nil {
+			return nil, err
+		}
+		target := folder(dashboardConfig.Folder)
+		target.Dashboards = append(target.Dashboards, *dashboard)
+	}
+
+	for _, group := range cfg.AlertRuleGroups {
+		if group.Ruler != "" {
+			catalog.RulerGroups = append(catalog.RulerGroups, group)
+			continue
+		}
+		target := folder(group.Folder)
+		target.RuleGroups = append(target.RuleGroups, group)
+	}
+
+	sort.SliceStable(catalog.Folders, func(i, j int) bool { return catalog.Folders[i].Name < catalog.Folders[j].Name })
+	for _, catalogFolder := range catalog.Folders {
+		for i := range catalogFolder.Dashboards {
+			catalogFolder.Dashboards[i].Owner = catalogFolder.OwnerTeam
+		}
+		sort.SliceStable(catalogFolder.Dashboards, func(i, j int) bool { return catalogFolder.Dashboards[i].Name < catalogFolder.Dashboards[j].Name })
+	}
+	return catalog, nil
+}
+
+// catalogDashboard reads the documentation fields of the dashboard JSON
+func catalogDashboard(cfg Dashboard, log *slog.Logger) (*CatalogDashboard, error) {
+	data, err := loadDashboardJSON(cfg, log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dashboard '%s': %w", cfg.Name, err)
+	}
+
+	var model struct {
+		Description string   `json:"description"`
+		Tags        []string `json:"tags"`
+		Links       []struct {
+			Title string   `json:"title"`
+			URL   string   `json:"url"`
+			Type  string   `json:"type"`
+			Tags  []string `json:"tags"`
+		} `json:"links"`
+	}
+	if err := json.Unmarshal(data, &model); err != nil {
+		return nil, fmt.Errorf("failed to parse dashboard '%s': %w", cfg.Name, err)
+	}
+
+	dashboard := &CatalogDashboard{
+		Name:        cfg.Name,
+		Source:      cfg.File,
+		Description: strings.TrimSpace(model.Description),
+		Tags:        model.Tags,
+	}
+	if cfg.GnetID != 0 {
+		dashboard.Source = fmt.Sprintf("https://grafana.com/grafana/dashboards/%d", cfg.GnetID)
+	}
+	for _, link := range model.Links {
+		catalogLink := CatalogLink{Title: link.Title, URL: link.URL}
+		if link.Type == "dashboards" {
+			catalogLink.URL = ""
+			catalogLink.Tags = link.Tags
+		}
+		dashboard.Links = append(dashboard.Links, catalogLink)
+	}
+
+	seen := map[string]bool{}
+	for _, dashboardImport := range cfg.Imports {
+		if !seen[dashboardImport.DataSource] {
+			seen[dashboardImport.DataSource] = true
+			dashboard.DataSources = append(dashboard.DataSources, dashboardImport.DataSource)
+		}
+	}
+
+	return dashboard, nil
+}
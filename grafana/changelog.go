@@ -0,0 +1,76 @@
+package grafana
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Changes of the changelog between two runs
+const (
+	ChangeAdded   = "added"   // Created, or in the report for the first time
+	ChangeRemoved = "removed" // Deleted, or no longer in the report
+	ChangeChanged = "changed" // Updated, recreated with another UID, or broken or fixed since the previous run
+)
+
+// ChangelogEntry is a resource that changed since the previous run
+type ChangelogEntry struct {
+	Change string // ChangeAdded, ChangeRemoved or ChangeChanged
+	Kind   string
+	Name   string
+	Detail string // e.g. "updated" or "uid abc -> def"
+}
+
+// Changelog compares the resources of the report with the ones of the previous run's report, matched by kind
+// and name. Resources unchanged in both runs aren't listed.
+func Changelog(previous *Report, current *Report) []ChangelogEntry {
+	key := func(resource ResourceResult) string {
+		return resource.Kind + "/" + resource.Name
+	}
+	before := map[string]ResourceResult{}
+	for _, resource := range previous.Resources {
+		if resource.Action != ActionDeleted {
+			before[key(resource)] = resource
+		}
+	}
+
+	entries := []ChangelogEntry{}
+	seen := map[string]bool{}
+	for _, resource := range current.Resources {
+		seen[key(resource)] = true
+		old, existed := before[key(resource)]
+		entry := ChangelogEntry{Kind: resource.Kind, Name: resource.Name}
+
+		switch {
+		case resource.Action == ActionDeleted:
+			entry.Change, entry.Detail = ChangeRemoved, "deleted"
+		case !existed:
+			entry.Change, entry.Detail = ChangeAdded, resource.Action
+		case old.UID != "" && resource.UID != "" && old.UID != resource.UID:
+			entry.Change, entry.Detail = ChangeChanged, fmt.Sprintf("uid %s -> %s", old.UID, resource.UID)
+		case resource.Action == ActionUpdated || resource.Action == ActionCreated:
+			entry.Change, entry.Detail = ChangeChanged, resource.Action
+		case len(resource.Problems) > 0 && len(old.Problems) == 0:
+			entry.Change, entry.Detail = ChangeChanged, "broken: "+strings.Join(resource.Problems, "; ")
+		case len(resource.Problems) == 0 && len(old.Problems) > 0:
+			entry.Change, entry.Detail = ChangeChanged, "no longer broken"
+		default:
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	for name, resource := range before {
+		if !seen[name] {
+			entries = append(entries, ChangelogEntry{Change: ChangeRemoved, Kind: resource.Kind, Name: resource.Name, Detail: "no longer in the report"})
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Kind != entries[j].Kind {
+			return entries[i].Kind < entries[j].Kind
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
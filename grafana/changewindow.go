@@ -0,0 +1,173 @@
+package grafana
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// changeWindowSearchLimit bounds the search for the next opening of a change window
+const changeWindowSearchLimit = 366 * 24 * time.Hour
+
+// cronField is one field of a cron-like schedule with its allowed range and value names
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+// cronFields are the minute, hour, day of month, month and day of week fields in schedule order
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}},
+	{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}},
+}
+
+// ChangeWindow restricts provisioning runs to the minutes matching a cron-like schedule, e.g.
+// `* 9-16 * * mon-thu` for working hours from Monday to Thursday, evaluated in the window's time zone.
+type ChangeWindow struct {
+	Schedule string
+	Location *time.Location
+	Override bool // Run outside the window anyway (--override-window)
+	fields   [5]map[int]bool
+	starDay  [2]bool // Day of month and day of week start with `*`, e.g. `*` or `*/2`
+}
+
+// ParseChangeWindow parses the five-field schedule (minute, hour, day of month, month, day of week)
+// and the IANA time zone, empty for UTC.
+func ParseChangeWindow(schedule string, timezone string) (*ChangeWindow, error) {
+	location := time.UTC
+	if timezone != "" {
+		loaded, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid change window timezone '%s': %w", timezone, err)
+		}
+		location = loaded
+	}
+
+	parts := strings.Fields(schedule)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("invalid change window schedule '%s': expected 5 fields (minute hour day-of-month month day-of-week), got %d", schedule, len(parts))
+	}
+
+	window := &ChangeWindow{Schedule: schedule, Location: location}
+	for i, part := range parts {
+		values, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid change window schedule '%s': %w", schedule, err)
+		}
+		window.fields[i] = values
+	}
+	// Sunday is both 0 and 7
+	if window.fields[4][7] {
+		window.fields[4][0] = true
+	}
+	// Like cron, a day field starting with `*` makes both day fields required, see Contains
+	window.starDay = [2]bool{strings.HasPrefix(parts[2], "*"), strings.HasPrefix(parts[4], "*")}
+
+	return window, nil
+}
+
+// parseCronField expands a comma-separated list of `*`, values and ranges, each with an optional `/step`
+func parseCronField(part string, field cronField) (map[int]bool, error) {
+	values := map[int]bool{}
+
+	for _, item := range strings.Split(part, ",") {
+		rangePart, step := item, 1
+		if index := strings.Index(item, "/"); index >= 0 {
+			parsed, err := strconv.Atoi(item[index+1:])
+			if err != nil || parsed <= 0 {
+				return nil, fmt.Errorf("invalid %s step in '%s'", field.name, item)
+			}
+			rangePart, step = item[:index], parsed
+		}
+
+		low, high := field.min, field.max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if low, err = parseCronValue(bounds[0], field); err != nil {
+				return nil, err
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = parseCronValue(bounds[1], field); err != nil {
+					return nil, err
+				}
+			} else if step > 1 {
+				high = field.max
+			}
+			if high < low {
+				return nil, fmt.Errorf("invalid %s range '%s'", field.name, rangePart)
+			}
+		}
+
+		for value := low; value <= high; value += step {
+			values[value] = true
+		}
+	}
+
+	return values, nil
+}
+
+// parseCronValue parses a number or a month or weekday name within the field range
+func parseCronValue(value string, field cronField) (int, error) {
+	if number, ok := field.names[strings.ToLower(value)]; ok {
+		return number, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < field.min || number > field.max {
+		return 0, fmt.Errorf("invalid %s '%s', expected %d-%d", field.name, value, field.min, field.max)
+	}
+	return number, nil
+}
+
+// Contains reports whether the minute of t, in the window's time zone, matches the schedule.
+// Like cron, a day matches either day field when both are restricted, and both fields when one of them starts
+// with `*` (a bare `*` matches every day).
+func (window *ChangeWindow) Contains(t time.Time) bool {
+	t = t.In(window.Location)
+	if !window.fields[0][t.Minute()] || !window.fields[1][t.Hour()] || !window.fields[3][int(t.Month())] {
+		return false
+	}
+
+	dayOfMonth := window.fields[2][t.Day()]
+	dayOfWeek := window.fields[4][int(t.Weekday())]
+	if window.starDay[0] || window.starDay[1] {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
+
+// NextOpening returns the next minute after t inside the window, false if there is none within a year.
+func (window *ChangeWindow) NextOpening(t time.Time) (time.Time, bool) {
+	next := t.In(window.Location).Truncate(time.Minute).Add(time.Minute)
+	for limit := t.Add(changeWindowSearchLimit); next.Before(limit); next = next.Add(time.Minute) {
+		if window.Contains(next) {
+			return next, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Check refuses runs outside the window unless it is overridden. A nil window allows every run.
+func (window *ChangeWindow) Check(now time.Time) error {
+	if window == nil || window.Override || window.Contains(now) {
+		return nil
+	}
+
+	message := fmt.Sprintf("refusing to run outside the change window '%s' (%s)", window.Schedule, window.Location)
+	if next, ok := window.NextOpening(now); ok {
+		message += fmt.Sprintf(", the next window opens at %s", next.Format(time.RFC3339))
+	}
+	return fmt.Errorf("%s (pass --override-window to run anyway)", message)
+}
@@ -0,0 +1,103 @@
+package grafana
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChangeWindowContains(t *testing.T) {
+	// 2026-03-01 is a Sunday, Europe/Berlin switches to summer time on 2026-03-29 and back on 2026-10-25
+	tests := []struct {
+		name     string
+		schedule string
+		timezone string
+		time     string // UTC
+		want     bool
+	}{
+		{"every minute", "* * * * *", "", "2026-03-02T10:00:00Z", true},
+		{"minute step of a range", "0-30/15 * * * *", "", "2026-03-02T10:15:00Z", true},
+		{"minute outside of a stepped range", "0-30/15 * * * *", "", "2026-03-02T10:45:00Z", false},
+		{"day of month step", "* * */2 * *", "", "2026-03-01T10:00:00Z", true},
+		{"day of month step skips", "* * */2 * *", "", "2026-03-02T10:00:00Z", false},
+		{"day of week step", "* * * * */6", "", "2026-03-07T10:00:00Z", true},
+		{"day of week step skips", "* * * * */6", "", "2026-03-02T10:00:00Z", false},
+		{"both restricted, day of month", "* * 1-7 * mon", "", "2026-03-03T10:00:00Z", true},
+		{"both restricted, day of week", "* * 1-7 * mon", "", "2026-03-09T10:00:00Z", true},
+		{"both restricted, neither", "* * 1-7 * mon", "", "2026-03-10T10:00:00Z", false},
+		{"stepped day of month and day of week", "* * */2 * mon", "", "2026-03-09T10:00:00Z", true},
+		{"stepped day of month only", "* * */2 * mon", "", "2026-03-03T10:00:00Z", false},
+		{"day of week only", "* * */2 * mon", "", "2026-03-02T10:00:00Z", false},
+		{"7 is Sunday", "* * * * 7", "", "2026-03-01T10:00:00Z", true},
+		{"7 is not Monday", "* * * * 7", "", "2026-03-02T10:00:00Z", false},
+		{"range up to 7", "* * * * 5-7", "", "2026-03-01T10:00:00Z", true},
+		{"range up to 7 skips", "* * * * 5-7", "", "2026-03-04T10:00:00Z", false},
+		{"weekday names", "* 9-16 * * mon-thu", "", "2026-03-02T10:00:00Z", true},
+		{"weekday names after hours", "* 9-16 * * mon-thu", "", "2026-03-02T17:00:00Z", false},
+		{"weekday names on friday", "* 9-16 * * Mon-Thu", "", "2026-03-06T10:00:00Z", false},
+		{"month names", "0 9 * jan,mar *", "", "2026-03-02T09:00:00Z", true},
+		{"month names skip", "0 9 * jan,mar *", "", "2026-04-06T09:00:00Z", false},
+		{"time zone", "* 9-16 * * *", "Europe/Berlin", "2026-03-28T08:30:00Z", true},
+		{"time zone before the window", "* 9-16 * * *", "Europe/Berlin", "2026-03-28T07:30:00Z", false},
+		{"summer time", "* 9-16 * * *", "Europe/Berlin", "2026-03-29T07:30:00Z", true},
+		{"repeated hour, first", "30 2 * * *", "Europe/Berlin", "2026-10-25T00:30:00Z", true},
+		{"repeated hour, second", "30 2 * * *", "Europe/Berlin", "2026-10-25T01:30:00Z", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			window, err := ParseChangeWindow(test.schedule, test.timezone)
+			if err != nil {
+				t.Fatalf("ParseChangeWindow() error = %v", err)
+			}
+			if got := window.Contains(mustParseTime(t, test.time)); got != test.want {
+				t.Errorf("Contains(%s) of '%s' = %v, want %v", test.time, test.schedule, got, test.want)
+			}
+		})
+	}
+}
+
+func TestChangeWindowNextOpening(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule string
+		timezone string
+		from     string
+		want     string
+	}{
+		{"next day of the step", "0 9 */2 * *", "", "2026-03-01T10:00:00Z", "2026-03-03T09:00:00Z"},
+		{"next weekend", "0 0 * * */6", "", "2026-03-02T00:00:00Z", "2026-03-07T00:00:00Z"},
+		{"skipped hour of the summer time", "0 2 * * *", "Europe/Berlin", "2026-03-28T03:00:00Z", "2026-03-30T00:00:00Z"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			window, err := ParseChangeWindow(test.schedule, test.timezone)
+			if err != nil {
+				t.Fatalf("ParseChangeWindow() error = %v", err)
+			}
+			next, ok := window.NextOpening(mustParseTime(t, test.from))
+			if !ok || !next.Equal(mustParseTime(t, test.want)) {
+				t.Errorf("NextOpening(%s) = %s, %v, want %s", test.from, next.UTC().Format(time.RFC3339), ok, test.want)
+			}
+		})
+	}
+}
+
+func TestParseChangeWindowErrors(t *testing.T) {
+	for _, schedule := range []string{"* * * *", "60 * * * *", "* * 5-1 * *", "*/0 * * * *", "* * * * fri-mon", "* * * foo *"} {
+		if _, err := ParseChangeWindow(schedule, ""); err == nil {
+			t.Errorf("ParseChangeWindow('%s') succeeded, want an error", schedule)
+		}
+	}
+	if _, err := ParseChangeWindow("* * * * *", "Nowhere/City"); err == nil {
+		t.Error("ParseChangeWindow() with an unknown time zone succeeded, want an error")
+	}
+}
+
+// mustParseTime parses an RFC 3339 time of the test table
+func mustParseTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
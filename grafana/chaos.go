@@ -0,0 +1,58 @@
+package grafana
+
+import (
+	"bytes"
+	"io"
+	"math/rand/v2"
+	"net/http"
+)
+
+// FailureInjection simulates an unreliable Grafana to exercise the retry behavior in integration tests
+type FailureInjection struct {
+	ErrorRate   float64 // Share of requests answered with a simulated 503 Service Unavailable, 0 to 1
+	TimeoutRate float64 // Share of requests failing with a simulated timeout, 0 to 1
+}
+
+// Enabled reports whether any failures are injected
+func (injection FailureInjection) Enabled() bool {
+	return injection.ErrorRate > 0 || injection.TimeoutRate > 0
+}
+
+// injectedTimeoutError is returned for requests failing with a simulated timeout
+type injectedTimeoutError struct{}
+
+func (injectedTimeoutError) Error() string   { return "injected failure: simulated timeout" }
+func (injectedTimeoutError) Timeout() bool   { return true }
+func (injectedTimeoutError) Temporary() bool { return true }
+
+// failureInjectingTransport fails requests at the configured rates before they reach Grafana
+type failureInjectingTransport struct {
+	next      http.RoundTripper
+	injection FailureInjection
+}
+
+// RoundTrip sends the request unless a failure is injected
+func (transport *failureInjectingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	roll := rand.Float64()
+
+	if roll < transport.injection.TimeoutRate {
+		return nil, injectedTimeoutError{}
+	}
+
+	if roll < transport.injection.TimeoutRate+transport.injection.ErrorRate {
+		body := "injected failure: simulated server error"
+		return &http.Response{
+			Status:        "503 Service Unavailable",
+			StatusCode:    http.StatusServiceUnavailable,
+			Proto:         req.Proto,
+			ProtoMajor:    req.ProtoMajor,
+			ProtoMinor:    req.ProtoMinor,
+			Header:        http.Header{"Content-Type": []string{"text/plain"}},
+			Body:          io.NopCloser(bytes.NewBufferString(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	return transport.next.RoundTrip(req)
+}
@@ -2,12 +2,20 @@ package grafana
 
 import (
 	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/buildinfo"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -21,36 +29,212 @@ type ApiClient struct {
 	Retries    int
 	RetryDelay time.Duration
 	Logger     *slog.Logger
+	Monitor    *ServerMonitor  // Attaches the server state to 5xx errors, see StartServerMonitor
+	deprecations *deprecationLog // Deprecation notices of the responses, see Deprecations
+	ctx        context.Context // Cancels the requests and the delays between their retries, see WithContext
 }
 
 // NewClient creates a new Grafana API client
 func NewClient(params ClientParams, logger *slog.Logger) *ApiClient {
-	// Simple defaults
-	if params.Timeout == 0 {
-		params.Timeout = 30 * time.Second
-	}
-	if params.Retries < 0 {
-		params.Retries = 3
+	params = params.withDefaults()
+
+	var transport http.RoundTripper = newTransport(params)
+	if tlsConfig, err := params.TLS.Config(); err != nil {
+		logger.Error("Failed to load the TLS settings of the Grafana client", "error", err)
+		transport = &tlsErrorTransport{err: err}
+	} else if tlsConfig != nil {
+		if params.TLS.InsecureSkipVerify {
+			logger.Warn("Not verifying the TLS certificate of the Grafana server", "url", params.URL)
+		}
+		transport.(*http.Transport).TLSClientConfig = tlsConfig
 	}
-	if params.RetryDelay <= 0 {
-		params.RetryDelay = 5 * time.Second
+	if params.Inject.Enabled() {
+		logger.Warn("Injecting simulated Grafana API failures", "error_rate", params.Inject.ErrorRate, "timeout_rate", params.Inject.TimeoutRate)
+		transport = &failureInjectingTransport{next: transport, injection: params.Inject}
 	}
 
 	client := &ApiClient{
-		URL:   strings.TrimSuffix(params.URL, "/"),
+		URL:   normalizeBaseURL(params.URL),
 		Token: params.Token,
 		HttpClient: &http.Client{
-			Timeout: params.Timeout,
+			Timeout:   params.Timeout,
+			Transport: transport,
 		},
 		Retries:    params.Retries,
 		RetryDelay: params.RetryDelay,
 		Logger:     logger,
+
+		deprecations: &deprecationLog{notices: map[string]*Deprecation{}},
 	}
 
+	if params.APIKey != "" {
+		client.Token = params.APIKey
+	}
 	client.setDefaultHeaders()
+	if params.Username != "" {
+		// Grafana instances without service accounts, or with API keys disabled
+		client.Headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(params.Username+":"+params.Password))
+	}
+	client.Headers["User-Agent"] = params.UserAgent
+	if params.ConsistencyWait > 0 {
+		// Ask caching proxies in front of Grafana to revalidate, so lookups see the results of the run
+		client.Headers["Cache-Control"] = "no-cache"
+	}
 	return client
 }
 
+// normalizeBaseURL trims trailing and duplicate slashes of the Grafana URL, keeping a subpath
+// like https://host/grafana of a Grafana served with serve_from_sub_path.
+func normalizeBaseURL(rawURL string) string {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || parsed.Host == "" {
+		return strings.TrimRight(rawURL, "/")
+	}
+
+	path := parsed.Path
+	for strings.Contains(path, "//") {
+		path = strings.ReplaceAll(path, "//", "/")
+	}
+	parsed.Path = strings.TrimRight(path, "/")
+	parsed.RawPath = ""
+	parsed.RawQuery = ""
+	parsed.Fragment = ""
+	return parsed.String()
+}
+
+// joinResourceURL builds the absolute URL of a resource path returned by Grafana.
+// Behind a subpath Grafana returns paths already carrying it (/grafana/d/uid), which isn't repeated.
+func joinResourceURL(baseURL string, resourcePath string) string {
+	if resourcePath == "" {
+		return baseURL
+	}
+	if parsed, err := url.Parse(resourcePath); err == nil && parsed.IsAbs() {
+		return resourcePath
+	}
+
+	if parsed, err := url.Parse(baseURL); err == nil && parsed.Path != "" {
+		if resourcePath == parsed.Path || strings.HasPrefix(resourcePath, parsed.Path+"/") {
+			resourcePath = strings.TrimPrefix(resourcePath, parsed.Path)
+		}
+	}
+	return baseURL + "/" + strings.TrimLeft(resourcePath, "/")
+}
+
+// newTransport builds the HTTP transport with the connection phase timeouts.
+// The overall timeout is on the http.Client and also covers reading the response body.
+func newTransport(params ClientParams) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   params.DialTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	if params.DialContext != nil {
+		transport.DialContext = params.DialContext
+	}
+	transport.TLSHandshakeTimeout = params.TLSTimeout
+	transport.ResponseHeaderTimeout = params.ResponseHeaderTimeout
+	return transport
+}
+
+// requestIDHeader carries a per-call ID, logged on both sides to correlate requests
+const requestIDHeader = "X-Request-Id"
+
+// newRequestID returns a random ID for the X-Request-Id header
+func newRequestID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(id)
+}
+
+// withDefaults returns a copy of the params with simple defaults applied
+func (params ClientParams) withDefaults() ClientParams {
+	if params.Timeout == 0 {
+		params.Timeout = 30 * time.Second
+	}
+	if params.DialTimeout <= 0 {
+		params.DialTimeout = 10 * time.Second
+	}
+	if params.TLSTimeout <= 0 {
+		params.TLSTimeout = 10 * time.Second
+	}
+	if params.Retries < 0 {
+		params.Retries = 3
+	}
+	if params.RetryDelay <= 0 {
+		params.RetryDelay = 5 * time.Second
+	}
+	if params.UserAgent == "" {
+		// Identifies provisioner traffic in Grafana and reverse-proxy logs
+		params.UserAgent = buildinfo.UserAgent()
+	}
+	return params
+}
+
+// WithLogger returns a copy of the client logging to the given logger, e.g. one scoped to a resource.
+// The copy shares the HTTP client and headers with the original.
+func (client *ApiClient) WithLogger(logger *slog.Logger) GrafanaAPI {
+	scoped := *client
+	scoped.Logger = logger
+	return &scoped
+}
+
+// WithContext returns a copy of the client whose requests and retry delays are canceled with the context,
+// e.g. on SIGINT. The copy shares the HTTP client and headers with the original.
+func (client *ApiClient) WithContext(ctx context.Context) GrafanaAPI {
+	scoped := *client
+	scoped.ctx = ctx
+	return &scoped
+}
+
+// Context returns the context of the requests, context.Background() unless set with WithContext
+func (client *ApiClient) Context() context.Context {
+	if client.ctx == nil {
+		return context.Background()
+	}
+	return client.ctx
+}
+
+// sleep waits for the delay, returning the error of the context early when it is canceled
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// BaseURL returns the Grafana base URL without a trailing slash
+func (client *ApiClient) BaseURL() string {
+	return client.URL
+}
+
+// CheckHealth makes a single request to the /api/health endpoint without retries.
+func (client *ApiClient) CheckHealth() error {
+	req, err := http.NewRequestWithContext(client.Context(), "GET", client.URL+"/api/health", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create health request: %w", err)
+	}
+	req.Header.Set("User-Agent", client.Headers["User-Agent"])
+	req.Header.Set(requestIDHeader, newRequestID())
+
+	resp, err := client.HttpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("health request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health endpoint returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
 // setDefaultHeaders sets default HTTP headers for API requests
 func (apiClient *ApiClient) setDefaultHeaders() {
 	if apiClient.Headers == nil {
@@ -62,16 +246,19 @@ func (apiClient *ApiClient) setDefaultHeaders() {
 }
 
 // GetDataSource fetches a data source by its name.
-// Returns an error if the data source is not found (404) or on other API failures.
+// Returns an error wrapping ErrNotFound if the data source doesn't exist, or on other API failures.
 func (client *ApiClient) GetDataSource(dataSourceName string) (*DataSource, error) {
 	client.Logger.Info("Searching for existing data source by name", "name", dataSourceName)
 
 	// URL-escape the data source name
-	urlPath := fmt.Sprintf("%s/api/datasources/name/%s", client.URL, strings.ReplaceAll(dataSourceName, " ", "%20"))
+	urlPath := fmt.Sprintf("%s/api/datasources/name/%s", client.URL, url.PathEscape(dataSourceName))
 
 	// Execute the GET request
 	resp, err := client.doRequest("GET", urlPath, nil)
 	if err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("data source '%s': %w", dataSourceName, ErrNotFound)
+		}
 		return nil, fmt.Errorf("failed to make get data source request: %w", err)
 	}
 
@@ -86,8 +273,8 @@ func (client *ApiClient) GetDataSource(dataSourceName string) (*DataSource, erro
 	return dataSource, nil
 }
 
-
-func (client *ApiClient) GetDataSources(log *slog.Logger) ([]DataSource, error) {
+// GetDataSources lists all data sources of the organization.
+func (client *ApiClient) GetDataSources() ([]DataSource, error) {
 	// Construct the full API URL
 	endpoint := fmt.Sprintf("%s/api/datasources", client.URL)
 
@@ -106,7 +293,9 @@ func (client *ApiClient) GetDataSources(log *slog.Logger) ([]DataSource, error)
 		URL       string                 `json:"url"`
 		IsDefault bool                   `json:"isDefault"`
 		Datebase  string                 `json:"database"`
+		User      string                 `json:"user"`
 		JSONData  map[string]interface{} `json:"jsonData"`
+		ReadOnly  bool                   `json:"readOnly"`
 	}
 	
 	if err := json.Unmarshal(body, &rawDataSources); err != nil {
@@ -124,37 +313,39 @@ func (client *ApiClient) GetDataSources(log *slog.Logger) ([]DataSource, error)
 			URL:       rawSource.URL,
 			IsDefault: rawSource.IsDefault,
 			Database:  rawSource.Datebase,
+			User:      rawSource.User,
+			JSONData:  rawSource.JSONData,
+			ReadOnly:  rawSource.ReadOnly,
 		}
 	}
 
-	log.Info("grafana datasources request successfully parsed")
+	client.Logger.Info("grafana datasources request successfully parsed")
 
 	// Return the struct
 	return dataSources, nil
 }
 
+// ListDataSourcesByType lists the data sources of the given plugin type, e.g. "prometheus".
+func (client *ApiClient) ListDataSourcesByType(dataSourceType string) ([]DataSource, error) {
+	dataSources, err := client.GetDataSources()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list data sources: %w", err)
+	}
+
+	matching := []DataSource{}
+	for _, dataSource := range dataSources {
+		if dataSource.Type == dataSourceType {
+			matching = append(matching, dataSource)
+		}
+	}
+	return matching, nil
+}
+
 // CreateDataSource sends a POST request to create a new data source.
-func (client *ApiClient) CreateDataSource(ds *PostgreSQLDataSourceModel) (*CreateDataSourceResponse, error) {
+func (client *ApiClient) CreateDataSource(ds *DataSourceModel) (*CreateDataSourceResponse, error) {
 	client.Logger.Info("Creating new data source", "name", ds.Name)
 
-	// Создаем правильную структуру для Grafana API
-	requestData := map[string]interface{}{
-		"name":      ds.Name,
-		"type":      ds.Type,
-		"access":    ds.Access,
-		"url":       ds.URL,
-		"database":  ds.Database,
-		"user":      ds.User,
-		"isDefault": ds.IsDefault,
-		"jsonData": map[string]interface{}{
-			"sslmode":         ds.SSLMode,
-			"postgresVersion": 1300, // Укажите версию PostgreSQL
-			"timescaledb":     false,
-		},
-		"secureJsonData": map[string]string{
-			"password": ds.Password,
-		},
-	}
+	requestData := dataSourceRequestData(ds)
 
 	url := client.URL + "/api/datasources"
 	data, err := json.Marshal(requestData)
@@ -162,7 +353,7 @@ func (client *ApiClient) CreateDataSource(ds *PostgreSQLDataSourceModel) (*Creat
 		return nil, fmt.Errorf("failed to marshal data source model: %w", err)
 	}
 
-	respBody, err := client.doRequest("POST", url, bytes.NewBuffer(data))
+	respBody, err := client.doRequest("POST", url, data)
 	if err != nil {
 		return nil, fmt.Errorf("data source creation failed: %w", err)
 	}
@@ -176,42 +367,303 @@ func (client *ApiClient) CreateDataSource(ds *PostgreSQLDataSourceModel) (*Creat
 	return &response, nil
 }
 
+// dataSourceRequestData builds the Grafana API request body for creating or updating a data source
+func dataSourceRequestData(ds *DataSourceModel) map[string]interface{} {
+	jsonData := map[string]interface{}{
+		"sslmode":         ds.SSLMode,
+		"postgresVersion": 1300, // Укажите версию PostgreSQL
+		"timescaledb":     false,
+	}
+	secureJsonData := map[string]string{
+		"password": ds.Password,
+	}
+	basicAuth := false
+	if ds.Type != DataSourceTypePostgres {
+		// The other types only get the settings of the config
+		jsonData, secureJsonData = prometheusJSONData(ds.Prometheus), map[string]string{}
+		switch {
+		case ds.BasicAuth || isHTTPDataSourceType(ds.Type):
+			if ds.User != "" {
+				basicAuth = true
+				secureJsonData["basicAuthPassword"] = ds.Password
+			}
+		case ds.Password != "":
+			// SQL and other plugins take the user and password of their own connection
+			secureJsonData["password"] = ds.Password
+		}
+	}
+
+	// Explicit settings win over the same keys in the raw jsonData
+	for key, value := range ds.JSONData {
+		jsonData[key] = value
+	}
+	for key, value := range ds.SecureJSONData {
+		secureJsonData[key] = value
+	}
+
+	// Custom headers are numbered from 1, the names in jsonData and the values in secureJsonData
+	for i, header := range ds.Headers {
+		jsonData[fmt.Sprintf("httpHeaderName%d", i+1)] = header.Name
+		secureJsonData[fmt.Sprintf("httpHeaderValue%d", i+1)] = header.Value
+	}
+	if ds.ForwardOAuthIdentity {
+		jsonData["oauthPassThru"] = true
+	}
+	if len(ds.KeepCookies) > 0 {
+		jsonData["keepCookies"] = ds.KeepCookies
+	}
+	for key, value := range ds.KeepJSONData {
+		if _, set := jsonData[key]; !set {
+			jsonData[key] = value
+		}
+	}
+
+	// Создаем правильную структуру для Grafana API
+	request := map[string]interface{}{
+		"name":           ds.Name,
+		"type":           ds.Type,
+		"access":         ds.Access,
+		"url":            ds.URL,
+		"database":       ds.Database,
+		"user":           ds.User,
+		"isDefault":      ds.IsDefault,
+		"uid":            ds.UID,
+		"jsonData":       jsonData,
+		"secureJsonData": secureJsonData,
+	}
+	if basicAuth {
+		// HTTP-based data sources take the credentials as basic auth
+		delete(request, "user")
+		request["basicAuth"] = true
+		request["basicAuthUser"] = ds.User
+	}
+	return request
+}
+
+// prometheusJSONData renders the Prometheus settings into jsonData
+func prometheusJSONData(settings *PrometheusSettings) map[string]interface{} {
+	jsonData := map[string]interface{}{}
+	if settings == nil {
+		return jsonData
+	}
+
+	if settings.ScrapeInterval != "" {
+		jsonData["timeInterval"] = settings.ScrapeInterval
+	}
+	if settings.QueryTimeout != "" {
+		jsonData["queryTimeout"] = settings.QueryTimeout
+	}
+	if settings.HTTPMethod != "" {
+		jsonData["httpMethod"] = settings.HTTPMethod
+	}
+	if len(settings.Exemplars) > 0 {
+		destinations := []interface{}{}
+		for _, exemplar := range settings.Exemplars {
+			destination := map[string]interface{}{"name": exemplar.Name}
+			if exemplar.DataSourceUID != "" {
+				destination["datasourceUid"] = exemplar.DataSourceUID
+			}
+			if exemplar.URL != "" {
+				destination["url"] = exemplar.URL
+			}
+			destinations = append(destinations, destination)
+		}
+		jsonData["exemplarTraceIdDestinations"] = destinations
+	}
+	return jsonData
+}
+
+// GetDataSourceByUID fetches a data source by its UID.
+// Returns an error wrapping ErrNotFound if the data source doesn't exist.
+func (client *ApiClient) GetDataSourceByUID(uid string) (*DataSource, error) {
+	endpoint := fmt.Sprintf("%s/api/datasources/uid/%s", client.URL, url.PathEscape(uid))
+
+	resp, err := client.doRequest("GET", endpoint, nil)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("data source with uid '%s': %w", uid, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to make get data source request: %w", err)
+	}
+
+	dataSource := &DataSource{}
+	if err := json.Unmarshal(resp, dataSource); err != nil {
+		return nil, fmt.Errorf("failed to decode get data source response for uid '%s': %w", uid, err)
+	}
+
+	return dataSource, nil
+}
+
+// UpdateDataSource sends a PUT request to replace the data source with the given UID.
+func (client *ApiClient) UpdateDataSource(uid string, ds *DataSourceModel) (*CreateDataSourceResponse, error) {
+	client.Logger.Info("Updating data source", "name", ds.Name, "uid", uid)
+
+	requestData := dataSourceRequestData(ds)
+	requestData["uid"] = uid
+
+	endpoint := fmt.Sprintf("%s/api/datasources/uid/%s", client.URL, url.PathEscape(uid))
+	data, err := json.Marshal(requestData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal data source model: %w", err)
+	}
+
+	respBody, err := client.doRequest("PUT", endpoint, data)
+	if err != nil {
+		return nil, fmt.Errorf("data source update failed: %w", err)
+	}
+
+	var response CreateDataSourceResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal data source update response: %w", err)
+	}
+
+	client.Logger.Info("Data source successfully updated", "name", response.Datasource.Name, "uid", uid)
+	return &response, nil
+}
+
+// GetDashboardByUID fetches the full dashboard model and its metadata by UID.
+// Returns an error wrapping ErrNotFound if the dashboard doesn't exist.
+func (client *ApiClient) GetDashboardByUID(uid string) (*DashboardGetResponse, error) {
+	endpoint := fmt.Sprintf("%s/api/dashboards/uid/%s", client.URL, url.PathEscape(uid))
+
+	resp, err := client.doRequest("GET", endpoint, nil)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("dashboard with uid '%s': %w", uid, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to make get dashboard request: %w", err)
+	}
+
+	var response DashboardGetResponse
+	if err := json.Unmarshal(resp, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode get dashboard response for uid '%s': %w", uid, err)
+	}
+
+	return &response, nil
+}
+
+// SaveDashboard sends a POST request to create or update a dashboard from its full JSON model.
+func (client *ApiClient) SaveDashboard(request *DashboardSaveRequest) (*DashboardSaveResponse, error) {
+	client.Logger.Info("Saving dashboard", "overwrite", request.Overwrite)
+
+	url := client.URL + "/api/dashboards/db"
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal dashboard save request: %w", err)
+	}
+
+	respBody, err := client.doRequest("POST", url, data)
+	if err != nil {
+		return nil, fmt.Errorf("dashboard save failed: %w", err)
+	}
+
+	var response DashboardSaveResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal dashboard save response: %w", err)
+	}
+
+	client.Logger.Info("Dashboard successfully saved", "uid", response.UID, "version", response.Version)
+	return &response, nil
+}
+
 // ImportDashboard sends a POST request to import a dashboard.
-func (client *ApiClient) ImportDashboard(request *DashboardImportRequest) error {
+func (client *ApiClient) ImportDashboard(request *DashboardImportRequest) (*DashboardImportResponse, error) {
 	client.Logger.Info("Importing dashboard", "overwrite", request.Overwrite)
 
 	url := client.URL + "/api/dashboards/import"
 	data, err := json.Marshal(request)
 	if err != nil {
-		return fmt.Errorf("failed to marshal dashboard import request: %w", err)
+		return nil, fmt.Errorf("failed to marshal dashboard import request: %w", err)
 	}
 
-	_, err = client.doRequest("POST", url, bytes.NewBuffer(data))
+	respBody, err := client.doRequest("POST", url, data)
 	if err != nil {
-		return fmt.Errorf("dashboard import failed: %w", err)
+		return nil, fmt.Errorf("dashboard import failed: %w", err)
 	}
 
-	client.Logger.Info("Dashboard successfully imported")
-	return nil
+	var response DashboardImportResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal dashboard import response: %w", err)
+	}
+
+	client.Logger.Info("Dashboard successfully imported", "uid", response.UID, "url", response.ImportedURL)
+	return &response, nil
+}
+
+// APIError is an error response returned by the Grafana API
+type APIError struct {
+	StatusCode    int
+	Attempt       int
+	Body          string
+	ServerContext string // Server state observed around the call by the ServerMonitor, if enabled
+}
+
+// Error renders the API error, the format is matched by callers checking for "Status 409"
+func (apiErr *APIError) Error() string {
+	message := fmt.Sprintf("Grafana API error (Status %d) on attempt %d: %s", apiErr.StatusCode, apiErr.Attempt, apiErr.Body)
+	if apiErr.ServerContext != "" {
+		message += " [server: " + apiErr.ServerContext + "]"
+	}
+	return message
+}
+
+// IsAuthError reports whether the credentials were rejected (401) or lack permissions (403)
+func (apiErr *APIError) IsAuthError() bool {
+	return apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden
+}
+
+// ErrNotFound is wrapped by lookups by name or UID when the resource doesn't exist
+var ErrNotFound = errors.New("not found")
+
+// isNotFound reports whether the Grafana API answered 404 Not Found
+func isNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
 }
 
 // doRequest handles the actual HTTP request with retries
-func (client *ApiClient) doRequest(method, url string, body io.Reader) ([]byte, error) {
+func (client *ApiClient) doRequest(method, url string, body []byte) ([]byte, error) {
+	return client.doRequestWithHeaders(method, url, body, nil)
+}
+
+// doRequestWithHeaders is doRequest with extra headers overriding the client defaults for this request
+func (client *ApiClient) doRequestWithHeaders(method, url string, body []byte, headers map[string]string) ([]byte, error) {
+	// One request ID per API call, shared by its retries
+	requestID := newRequestID()
+	client.Logger.Debug("Grafana API request", "method", method, "url", url, "request_id", requestID)
+	started := time.Now()
+
+	ctx := client.Context()
 	var lastErr error
 	for i := 0; i < client.Retries; i++ {
-		req, err := http.NewRequest(method, url, body)
+		// Every attempt sends the complete payload
+		var payload io.Reader
+		if body != nil {
+			payload = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, url, payload)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create request: %w", err)
 		}
 		for key, value := range client.Headers {
 			req.Header.Set(key, value)
 		}
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		req.Header.Set(requestIDHeader, requestID)
 
 		resp, err := client.HttpClient.Do(req)
 		if err != nil {
+			// A canceled run doesn't retry
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("request canceled on attempt %d: %w", i+1, ctx.Err())
+			}
 			lastErr = fmt.Errorf("http request failed on attempt %d: %w", i+1, err)
-			client.Logger.Warn("Grafana API request failed, retrying...", "error", lastErr.Error(), "attempt", i+1)
-			time.Sleep(client.RetryDelay)
+			client.Logger.Warn("Grafana API request failed, retrying...", "error", lastErr.Error(), "attempt", i+1, "request_id", requestID)
+			if err := sleep(ctx, client.RetryDelay); err != nil {
+				return nil, fmt.Errorf("request canceled while retrying: %w", err)
+			}
 			continue
 		}
 		defer resp.Body.Close()
@@ -220,6 +672,7 @@ func (client *ApiClient) doRequest(method, url string, body io.Reader) ([]byte,
 		if err != nil {
 			return nil, fmt.Errorf("failed to read response body: %w", err)
 		}
+		client.deprecations.record(method, url, resp.Header, respBody)
 
 		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			// Success
@@ -227,22 +680,45 @@ func (client *ApiClient) doRequest(method, url string, body io.Reader) ([]byte,
 		}
 
 		// Handle error response from API
-		errorMsg := fmt.Sprintf("Grafana API error (Status %d) on attempt %d: %s", resp.StatusCode, i+1, string(respBody))
-		lastErr = errors.New(errorMsg)
-		client.Logger.Warn("Grafana API returned error, retrying...", "error", errorMsg, "attempt", i+1)
+		apiErr := &APIError{StatusCode: resp.StatusCode, Attempt: i + 1, Body: string(respBody)}
+		client.annotateServerError(apiErr, started)
+		lastErr = apiErr
+
+		// Grafana never lets the API change resources of its file provisioning, e.g. 403 on read-only data sources
+		if apiErr.IsFileProvisioned() {
+			client.Logger.Error("Grafana API refused to change a file-provisioned resource, not retrying", "error", apiErr.Error(), "url", url, "request_id", requestID)
+			return nil, fmt.Errorf("%w: %w", ErrFileProvisioned, apiErr)
+		}
 
-		// Rewind body if it's a seekable buffer (for retry)
-		// if body, ok := body.(*bytes.Buffer); ok {
-		// 	body = bytes.NewBuffer(body.Bytes())
-		// }
-		time.Sleep(client.RetryDelay)
+		// Authentication and authorization failures won't succeed on retry
+		if apiErr.IsAuthError() {
+			client.Logger.Error("Grafana API rejected the credentials, not retrying", "error", apiErr.Error(), "url", url, "request_id", requestID)
+			return nil, fmt.Errorf("request rejected: %w", apiErr)
+		}
+
+		// A missing resource won't appear on retry either
+		if apiErr.StatusCode == http.StatusNotFound {
+			client.Logger.Debug("Grafana API resource not found", "url", url, "request_id", requestID)
+			return nil, fmt.Errorf("resource not found: %w", apiErr)
+		}
+
+		// A conflict, e.g. a resource created by a concurrent run, won't go away on retry either
+		if apiErr.StatusCode == http.StatusConflict {
+			client.Logger.Debug("Grafana API reported a conflict", "url", url, "request_id", requestID)
+			return nil, fmt.Errorf("request conflicts with the current state: %w", apiErr)
+		}
+
+		client.Logger.Warn("Grafana API returned error, retrying...", "error", apiErr.Error(), "attempt", i+1, "request_id", requestID)
+		if err := sleep(ctx, client.RetryDelay); err != nil {
+			return nil, fmt.Errorf("request canceled while retrying: %w", err)
+		}
 	}
 
 	return nil, fmt.Errorf("failed to execute request after %d attempts: %w", client.Retries, lastErr)
 }
 
 // GetFolders fetches the list of all existing dashboard folders
-func (client *ApiClient) GetFolders(log *slog.Logger) ([]FolderResponse, error) {
+func (client *ApiClient) GetFolders() ([]FolderResponse, error) {
 	// Construct the full API URL for folders
 	endpoint := fmt.Sprintf("%s/api/folders", client.URL)
 
@@ -259,14 +735,14 @@ func (client *ApiClient) GetFolders(log *slog.Logger) ([]FolderResponse, error)
 		return nil, fmt.Errorf("failed to unmarshal folders response: %w", err)
 	}
 
-	log.Info("grafana folders request successfully parsed")
+	client.Logger.Info("grafana folders request successfully parsed")
 
 	// Return the struct
 	return folders, nil
 }
 
 // CreateFolder sends a POST request to create a new folder.
-func (client *ApiClient) CreateFolder(title string, log *slog.Logger) (*FolderResponse, error) {
+func (client *ApiClient) CreateFolder(title string) (*FolderResponse, error) {
 	client.Logger.Info("Creating new folder", "title", title)
 
 	requestData := CreateFolderRequest{
@@ -279,7 +755,7 @@ func (client *ApiClient) CreateFolder(title string, log *slog.Logger) (*FolderRe
 		return nil, fmt.Errorf("failed to marshal folder model: %w", err)
 	}
 
-	respBody, err := client.doRequest("POST", url, bytes.NewBuffer(data))
+	respBody, err := client.doRequest("POST", url, data)
 	if err != nil {
 		// Grafana API returns 409 if folder with the same name already exists.
 		if strings.Contains(err.Error(), "Status 409") {
@@ -300,11 +776,12 @@ func (client *ApiClient) CreateFolder(title string, log *slog.Logger) (*FolderRe
 }
 
 // CreateFolderIfNotExists creates a folder if it doesn't exist.
-// Returns the folder response whether it was created or already existed.
-func (client *ApiClient) CreateFolderIfNotExists(title string, log *slog.Logger) (*FolderResponse, error) {
+// Returns the folder response whether it was created or already existed, also when a concurrent run
+// created it between the lookup and the creation.
+func (client *ApiClient) CreateFolderIfNotExists(title string) (*FolderResponse, error) {
 	// The API for folder creation returns a conflict error (409) if the folder already exists.
 	// We handle this by attempting creation and then searching if a conflict occurs.
-	
+
 	createRequest := CreateFolderRequest{
 		Title: title,
 	}
@@ -314,21 +791,31 @@ func (client *ApiClient) CreateFolderIfNotExists(title string, log *slog.Logger)
 		return nil, fmt.Errorf("failed to marshal create folder request: %w", err)
 	}
 
-	foldersResponse, err := client.GetFolders(log)
+	existing, err := client.findFolderByTitle(title)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch folders list: %w", err)
+		return nil, err
 	}
-
-	for _, folder := range foldersResponse {
-		if folder.Title == title {
-			return &folder, nil
-		}
+	if existing != nil {
+		return existing, nil
 	}
 
 	folderResponse := &FolderResponse{}
 	
 	// Execute the request to create a folder
-	resp, err := client.doRequest("POST", client.URL + "/api/folders", bytes.NewReader(body))
+	resp, err := client.doRequest("POST", client.URL + "/api/folders", body)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+		// Another run or goroutine created the folder since the lookup, use its folder
+		client.Logger.Info("Folder created concurrently (409 Conflict), fetching it", "title", title)
+		existing, err := client.findFolderByTitle(title)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			return nil, fmt.Errorf("folder '%s' reported as existing (409 Conflict) but not found: %w", title, apiErr)
+		}
+		return existing, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to make create folder request: %w", err)
 	}
@@ -341,26 +828,36 @@ func (client *ApiClient) CreateFolderIfNotExists(title string, log *slog.Logger)
 	return folderResponse, nil
 }
 
-// GetFolderByTitle searches for a folder by its title.
-func (client *ApiClient) GetFolderByTitle(title string) (*FolderResponse, error) {
-	// URL escape the title for API call
-	urlPath := fmt.Sprintf("%s/api/folders/%s", client.URL, strings.ReplaceAll(title, " ", "%20"))
-	
-	resp, err := client.doRequest("GET", urlPath, nil)
+// findFolderByTitle returns the folder with the title, nil if there is none
+func (client *ApiClient) findFolderByTitle(title string) (*FolderResponse, error) {
+	folders, err := client.GetFolders()
 	if err != nil {
-		return nil, fmt.Errorf("failed to make get folder request: %w", err)
+		return nil, fmt.Errorf("failed to fetch folders list: %w", err)
 	}
-	
-	folderResponse := &FolderResponse{}
-	if err := json.NewDecoder(bytes.NewReader(resp)).Decode(folderResponse); err != nil {
-		return nil, fmt.Errorf("failed to decode get folder response: %w", err)
+
+	for _, folder := range folders {
+		if folder.Title == title {
+			return &folder, nil
+		}
 	}
+	return nil, nil
+}
 
-	return folderResponse, nil
+// GetFolderByTitle searches the folder list for a folder by its title. Returns an error wrapping ErrNotFound
+// if there is none.
+func (client *ApiClient) GetFolderByTitle(title string) (*FolderResponse, error) {
+	folder, err := client.findFolderByTitle(title)
+	if err != nil {
+		return nil, err
+	}
+	if folder == nil {
+		return nil, fmt.Errorf("folder '%s': %w", title, ErrNotFound)
+	}
+	return folder, nil
 }
 
 // SearchDashboards fetches a list of all existing dashboards and folders from the /api/search endpoint.
-func (client *ApiClient) SearchDashboards(log *slog.Logger) ([]DashboardSearchResponse, error) {
+func (client *ApiClient) SearchDashboards() ([]DashboardSearchResponse, error) {
 	// Конструируем полный URL API для поиска дашбордов.
 	endpoint := fmt.Sprintf("%s/api/search", client.URL)
 
@@ -377,18 +874,19 @@ func (client *ApiClient) SearchDashboards(log *slog.Logger) ([]DashboardSearchRe
 		return nil, fmt.Errorf("failed to unmarshal dashboard search response: %w", err)
 	}
 
-	log.Info("grafana dashboard search request successfully parsed")
+	client.Logger.Info("grafana dashboard search request successfully parsed")
 
 	return searchResults, nil
 }
 
 // FindFirstDashboardByFolderAndName searches for a dashboard by its title and the title of its containing folder.
-func (client *ApiClient) FindFirstDashboardByFolderAndName(name string, folder string, log *slog.Logger) (DashboardSearchResponse, error) {
-	log.Info("Searching for dashboard", "name", name, "folder", folder)
+// found is false if no such dashboard exists.
+func (client *ApiClient) FindFirstDashboardByFolderAndName(name string, folder string) (dashboard DashboardSearchResponse, found bool, err error) {
+	client.Logger.Info("Searching for dashboard", "name", name, "folder", folder)
 	
-	searchResults, err := client.SearchDashboards(log)
+	searchResults, err := client.SearchDashboards()
 	if err != nil {
-		return DashboardSearchResponse{}, fmt.Errorf("failed to search dashboards: %w", err)
+		return DashboardSearchResponse{}, false, fmt.Errorf("failed to search dashboards: %w", err)
 	}
 
 	// Итерируемся по результатам, чтобы найти дашборд, который соответствует обоим критериям
@@ -406,11 +904,205 @@ func (client *ApiClient) FindFirstDashboardByFolderAndName(name string, folder s
 			isSpecificFolder := result.FolderTitle == folder
 
 			if isSpecificFolder || isGeneralFolder {
-				log.Info("Dashboard found", "name", name, "folder", result.FolderTitle)
-				return result, nil
+				client.Logger.Info("Dashboard found", "name", name, "folder", result.FolderTitle)
+				return result, true, nil
+			}
+		}
+	}
+
+	return DashboardSearchResponse{}, false, nil
+}
+
+// FindDashboardsByName returns all dashboards with the given title regardless of their folder.
+func (client *ApiClient) FindDashboardsByName(name string) ([]DashboardSearchResponse, error) {
+	searchResults, err := client.SearchDashboards()
+	if err != nil {
+		return nil, fmt.Errorf("failed to search dashboards: %w", err)
+	}
+
+	dashboards := []DashboardSearchResponse{}
+	for _, result := range searchResults {
+		if result.Type == "dash-db" && result.Title == name {
+			dashboards = append(dashboards, result)
+		}
+	}
+
+	return dashboards, nil
+}
+
+// DeleteDashboardByUID sends a DELETE request to remove a dashboard.
+func (client *ApiClient) DeleteDashboardByUID(uid string) error {
+	client.Logger.Info("Deleting dashboard", "uid", uid)
+
+	endpoint := fmt.Sprintf("%s/api/dashboards/uid/%s", client.URL, url.PathEscape(uid))
+	if _, err := client.doRequest("DELETE", endpoint, nil); err != nil {
+		return fmt.Errorf("dashboard deletion failed: %w", err)
+	}
+
+	client.Logger.Info("Dashboard successfully deleted", "uid", uid)
+	return nil
+}
+
+// DeleteDataSourceByUID sends a DELETE request to remove a data source.
+func (client *ApiClient) DeleteDataSourceByUID(uid string) error {
+	client.Logger.Info("Deleting data source", "uid", uid)
+
+	endpoint := fmt.Sprintf("%s/api/datasources/uid/%s", client.URL, url.PathEscape(uid))
+	if _, err := client.doRequest("DELETE", endpoint, nil); err != nil {
+		return fmt.Errorf("data source deletion failed: %w", err)
+	}
+
+	client.Logger.Info("Data source successfully deleted", "uid", uid)
+	return nil
+}
+
+// GetFolderByUID fetches a folder by its UID.
+// Returns an error wrapping ErrNotFound if the folder doesn't exist.
+func (client *ApiClient) GetFolderByUID(uid string) (*FolderResponse, error) {
+	endpoint := fmt.Sprintf("%s/api/folders/%s", client.URL, url.PathEscape(uid))
+
+	resp, err := client.doRequest("GET", endpoint, nil)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("folder with uid '%s': %w", uid, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to make get folder request: %w", err)
+	}
+
+	folderResponse := &FolderResponse{}
+	if err := json.Unmarshal(resp, folderResponse); err != nil {
+		return nil, fmt.Errorf("failed to decode get folder response for uid '%s': %w", uid, err)
+	}
+
+	return folderResponse, nil
+}
+
+// UpdateFolder sends a PUT request to rename the folder with the given UID.
+func (client *ApiClient) UpdateFolder(uid string, title string) (*FolderResponse, error) {
+	client.Logger.Info("Updating folder", "uid", uid, "title", title)
+
+	requestData := UpdateFolderRequest{
+		Title:     title,
+		Overwrite: true,
+	}
+
+	endpoint := fmt.Sprintf("%s/api/folders/%s", client.URL, url.PathEscape(uid))
+	data, err := json.Marshal(requestData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal folder model: %w", err)
+	}
+
+	respBody, err := client.doRequest("PUT", endpoint, data)
+	if err != nil {
+		return nil, fmt.Errorf("folder update failed: %w", err)
+	}
+
+	var response FolderResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal folder update response: %w", err)
+	}
+
+	client.Logger.Info("Folder successfully updated", "title", response.Title, "uid", response.UID)
+	return &response, nil
+}
+
+// DeleteFolder sends a DELETE request to remove a folder together with its dashboards.
+func (client *ApiClient) DeleteFolder(uid string) error {
+	client.Logger.Info("Deleting folder", "uid", uid)
+
+	endpoint := fmt.Sprintf("%s/api/folders/%s", client.URL, url.PathEscape(uid))
+	if _, err := client.doRequest("DELETE", endpoint, nil); err != nil {
+		return fmt.Errorf("folder deletion failed: %w", err)
+	}
+
+	client.Logger.Info("Folder successfully deleted", "uid", uid)
+	return nil
+}
+
+// ValidateToken checks that the configured token is accepted and reports the org and role it acts in.
+// Only the org lookup is required; the user and role lookups are not available for every token type.
+func (client *ApiClient) ValidateToken() (*TokenInfo, error) {
+	resp, err := client.doRequest("GET", client.URL+"/api/org", nil)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+			return nil, fmt.Errorf("grafana rejected the token (401 Unauthorized), check the grafana credentials: %w", err)
+		}
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden {
+			return nil, fmt.Errorf("token is not allowed to read the current organization (403 Forbidden): %w", err)
+		}
+		return nil, fmt.Errorf("failed to validate token: %w", err)
+	}
+
+	var org struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(resp, &org); err != nil {
+		return nil, fmt.Errorf("failed to decode current organization response: %w", err)
+	}
+
+	info := &TokenInfo{OrgID: org.ID, OrgName: org.Name, Role: "unknown"}
+
+	// Login of the user or service account behind the token
+	if resp, err := client.doRequestOnce("GET", client.URL+"/api/user"); err == nil {
+		var user struct {
+			Login          string `json:"login"`
+			IsGrafanaAdmin bool   `json:"isGrafanaAdmin"`
+		}
+		if json.Unmarshal(resp, &user) == nil {
+			info.Login = user.Login
+			info.IsGrafanaAdmin = user.IsGrafanaAdmin
+		}
+	}
+
+	// Role in the current org
+	if resp, err := client.doRequestOnce("GET", client.URL+"/api/user/orgs"); err == nil {
+		var orgs []struct {
+			OrgID int    `json:"orgId"`
+			Role  string `json:"role"`
+		}
+		if json.Unmarshal(resp, &orgs) == nil {
+			for _, userOrg := range orgs {
+				if userOrg.OrgID == org.ID {
+					info.Role = userOrg.Role
+				}
 			}
 		}
 	}
 
-	return DashboardSearchResponse{}, nil
-}
\ No newline at end of file
+	client.Logger.Info("Token validated", "org_id", info.OrgID, "org", info.OrgName, "login", info.Login, "role", info.Role)
+	return info, nil
+}
+
+// doRequestOnce makes a single GET-style request without retries, for optional lookups
+func (client *ApiClient) doRequestOnce(method, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(client.Context(), method, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, value := range client.Headers {
+		req.Header.Set(key, value)
+	}
+	requestID := newRequestID()
+	req.Header.Set(requestIDHeader, requestID)
+	client.Logger.Debug("Grafana API request", "method", method, "url", url, "request_id", requestID)
+
+	resp, err := client.HttpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("http request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	client.deprecations.record(method, url, resp.Header, respBody)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &APIError{StatusCode: resp.StatusCode, Attempt: 1, Body: string(respBody)}
+	}
+
+	return respBody, nil
+}
@@ -0,0 +1,230 @@
+package grafana
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client of the test server URL, retrying once without delay
+func newTestClient(t *testing.T, url string) *ApiClient {
+	t.Helper()
+	return NewClient(ClientParams{URL: url, Token: "token", Retries: 2, RetryDelay: time.Millisecond}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// subpathServer serves Grafana under /grafana/ and records the paths of the requests
+func subpathServer(t *testing.T, paths *[]string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/grafana/api/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"database":"ok","version":"11.0.0"}`))
+	})
+	mux.HandleFunc("/grafana/api/folders", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"uid":"ops","title":"Ops","url":"/grafana/dashboards/f/ops/ops"}]`))
+	})
+	mux.HandleFunc("/grafana/api/datasources/uid/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"uid":"a/b","name":"Postgres"}`))
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*paths = append(*paths, r.URL.EscapedPath())
+		if !strings.HasPrefix(r.URL.Path, "/grafana/") || strings.Contains(r.URL.Path, "//") {
+			http.NotFound(w, r)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSubpathBaseURL(t *testing.T) {
+	for _, base := range []string{"/grafana", "/grafana/", "//grafana//"} {
+		t.Run(base, func(t *testing.T) {
+			paths := []string{}
+			server := subpathServer(t, &paths)
+			client := newTestClient(t, server.URL+base)
+
+			if client.BaseURL() != server.URL+"/grafana" {
+				t.Errorf("BaseURL() = %q, want %q", client.BaseURL(), server.URL+"/grafana")
+			}
+			if err := client.CheckHealth(); err != nil {
+				t.Errorf("CheckHealth() error = %v", err)
+			}
+			health, err := client.GetHealth()
+			if err != nil || health.Version != "11.0.0" {
+				t.Errorf("GetHealth() = %+v, %v", health, err)
+			}
+			folders, err := client.GetFolders()
+			if err != nil || len(folders) != 1 {
+				t.Fatalf("GetFolders() = %+v, %v", folders, err)
+			}
+			if _, err := client.GetDataSourceByUID("a/b"); err != nil {
+				t.Errorf("GetDataSourceByUID() error = %v", err)
+			}
+
+			want := []string{"/grafana/api/health", "/grafana/api/health", "/grafana/api/folders", "/grafana/api/datasources/uid/a%2Fb"}
+			if strings.Join(paths, " ") != strings.Join(want, " ") {
+				t.Errorf("requested paths = %v, want %v", paths, want)
+			}
+			if url := joinResourceURL(client.BaseURL(), folders[0].URL); url != server.URL+"/grafana/dashboards/f/ops/ops" {
+				t.Errorf("folder URL = %q, the subpath is repeated or missing", url)
+			}
+		})
+	}
+}
+
+func TestSubpathHealthFailure(t *testing.T) {
+	paths := []string{}
+	server := subpathServer(t, &paths)
+
+	// Without the subpath the health check hits the reverse proxy, not Grafana
+	if err := newTestClient(t, server.URL).CheckHealth(); err == nil {
+		t.Error("CheckHealth() without the subpath succeeded, want an error")
+	}
+	if len(paths) != 1 || paths[0] != "/api/health" {
+		t.Errorf("requested paths = %v, want [/api/health]", paths)
+	}
+}
+
+func TestJoinResourceURL(t *testing.T) {
+	tests := []struct {
+		base, path, want string
+	}{
+		{"https://host", "/d/uid/name", "https://host/d/uid/name"},
+		{"https://host/grafana", "/grafana/d/uid/name", "https://host/grafana/d/uid/name"},
+		{"https://host/grafana", "/d/uid/name", "https://host/grafana/d/uid/name"},
+		{"https://host/grafana", "/grafanax/d/uid", "https://host/grafana/grafanax/d/uid"},
+		{"https://host/grafana", "", "https://host/grafana"},
+		{"https://host/grafana", "https://other/d/uid", "https://other/d/uid"},
+	}
+	for _, test := range tests {
+		if got := joinResourceURL(test.base, test.path); got != test.want {
+			t.Errorf("joinResourceURL(%q, %q) = %q, want %q", test.base, test.path, got, test.want)
+		}
+	}
+}
+
+func TestCreateFolderIfNotExistsConflict(t *testing.T) {
+	created := false
+	posts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/folders":
+			if !created {
+				w.Write([]byte(`[]`))
+				return
+			}
+			w.Write([]byte(`[{"id":7,"uid":"ops","title":"Ops"}]`))
+		case r.Method == "POST" && r.URL.Path == "/api/folders":
+			// A concurrent run created the folder between the lookup and the creation
+			posts++
+			created = true
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"message":"a folder with the same name already exists"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	folder, err := newTestClient(t, server.URL).CreateFolderIfNotExists("Ops")
+	if err != nil {
+		t.Fatalf("CreateFolderIfNotExists() error = %v", err)
+	}
+	if folder.UID != "ops" || folder.ID != 7 {
+		t.Errorf("CreateFolderIfNotExists() = %+v, want the existing folder 'ops'", folder)
+	}
+	if posts != 1 {
+		t.Errorf("folder creation sent %d times, a conflict must not be retried", posts)
+	}
+}
+
+func TestRetrySendsFullBody(t *testing.T) {
+	const payload = `{"dashboard":{"title":"Ops"},"overwrite":true}`
+	bodies := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	for _, method := range []string{"POST", "PUT"} {
+		bodies = bodies[:0]
+		if _, err := newTestClient(t, server.URL).doRequest(method, server.URL+"/api/dashboards/db", []byte(payload)); err != nil {
+			t.Fatalf("%s error = %v", method, err)
+		}
+		if len(bodies) != 2 {
+			t.Fatalf("%s sent %d attempts, want 2", method, len(bodies))
+		}
+		for i, body := range bodies {
+			if body != payload {
+				t.Errorf("%s attempt %d sent body %q, want %q", method, i+1, body, payload)
+			}
+		}
+	}
+}
+
+func TestDataSourceRequestDataCredentials(t *testing.T) {
+	tests := []struct {
+		name       string
+		model      DataSourceModel
+		wantAuth   bool
+		wantSecret string
+	}{
+		{"postgres", DataSourceModel{Type: DataSourceTypePostgres, User: "grafana", Password: "secret"}, false, "password"},
+		{"mysql", DataSourceModel{Type: "mysql", User: "grafana", Password: "secret"}, false, "password"},
+		{"clickhouse", DataSourceModel{Type: "grafana-clickhouse-datasource", User: "grafana", Password: "secret"}, false, "password"},
+		{"loki", DataSourceModel{Type: "loki", User: "grafana", Password: "secret"}, true, "basicAuthPassword"},
+		{"explicit basic auth", DataSourceModel{Type: "marcusolsson-json-datasource", User: "grafana", Password: "secret", BasicAuth: true}, true, "basicAuthPassword"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := dataSourceRequestData(&test.model)
+			basicAuth, _ := request["basicAuth"].(bool)
+			if basicAuth != test.wantAuth {
+				t.Errorf("basicAuth = %v, want %v", basicAuth, test.wantAuth)
+			}
+			user := request["user"]
+			if test.wantAuth {
+				user = request["basicAuthUser"]
+			}
+			if user != "grafana" {
+				t.Errorf("user = %v, want grafana", user)
+			}
+			secrets := request["secureJsonData"].(map[string]string)
+			if len(secrets) != 1 || secrets[test.wantSecret] != "secret" {
+				t.Errorf("secureJsonData = %v, want only %s", secrets, test.wantSecret)
+			}
+		})
+	}
+}
+
+func TestGetFolderByTitle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/folders" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`[{"uid":"ops","title":"Ops Team"}]`))
+	}))
+	defer server.Close()
+	client := newTestClient(t, server.URL)
+
+	folder, err := client.GetFolderByTitle("Ops Team")
+	if err != nil || folder.UID != "ops" {
+		t.Errorf("GetFolderByTitle() = %+v, %v, want the folder 'ops'", folder, err)
+	}
+	if _, err := client.GetFolderByTitle("Missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetFolderByTitle() of a missing folder error = %v, want ErrNotFound", err)
+	}
+}
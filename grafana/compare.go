@@ -0,0 +1,240 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+)
+
+// Snapshot is the comparable state of the resources a config manages on a target: a Grafana instance or the
+// config itself
+type Snapshot struct {
+	Target    string
+	Resources map[string]ResourceSnapshot // By kind and name
+	Partial   bool                        // Fields missing from the snapshot aren't compared, e.g. the ones of the config
+}
+
+// ResourceSnapshot holds the compared fields of a resource, each rendered as canonical JSON
+type ResourceSnapshot struct {
+	Kind   string
+	Name   string
+	Fields map[string]string
+}
+
+// Divergence is a difference of a resource between two snapshots. A or B is empty for a resource missing from it.
+type Divergence struct {
+	Kind  string
+	Name  string
+	Field string // Empty when the resource is missing from a snapshot
+	A     string
+	B     string
+}
+
+// LiveSnapshot reads the configured folders, data sources and dashboards from the instance. Data sources are
+// found like provisioning does, their jsonData is compared without Grafana's defaults being known. Dashboards are
+// compared by their panels, variables and tags, data source UIDs being instance-specific.
+func LiveSnapshot(client GrafanaAPI, cfg Config, target string, log *slog.Logger) (*Snapshot, error) {
+	snapshot := &Snapshot{Target: target, Resources: map[string]ResourceSnapshot{}}
+	if cfg.Grafana.Org != "" {
+		orgID, err := resolveOrgID(client, cfg.Grafana.Org, nil)
+		if err != nil {
+			return nil, err
+		}
+		client.UseOrg(orgID)
+	}
+
+	liveFolders, err := client.GetFolders()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+	for _, folder := range cfg.Folders {
+		for _, liveFolder := range liveFolders {
+			if liveFolder.Title == folder.Name {
+				snapshot.add(KindFolder, folder.Name, map[string]interface{}{})
+				break
+			}
+		}
+	}
+
+	liveSources, err := client.GetDataSources()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list data sources: %w", err)
+	}
+	for _, dataSource := range cfg.DataSources {
+		for _, source := range liveSources {
+			if dataSourceMatches(source, dataSource) {
+				fields := map[string]interface{}{"type": source.Type, "url": source.URL, "database": source.Database, "user": source.User}
+				for key, value := range source.JSONData {
+					if key != pruneMarkerKey {
+						fields["jsonData."+key] = value
+					}
+				}
+				snapshot.add(KindDataSource, dataSource.Name, fields)
+				break
+			}
+		}
+	}
+
+	for _, dashboard := range cfg.Dashboards {
+		live, found, err := client.FindFirstDashboardByFolderAndName(dashboard.Name, dashboard.Folder)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search dashboard '%s': %w", dashboard.Name, err)
+		}
+		if !found {
+			continue
+		}
+		model, err := client.GetDashboardByUID(live.UID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get dashboard '%s': %w", dashboard.Name, err)
+		}
+		fields := dashboardSnapshotFields(model.Dashboard)
+		fields["tags"] = withoutTag(model.Dashboard["tags"], cfg.pruneTag())
+		snapshot.add(KindDashboard, dashboard.Name, fields)
+	}
+
+	log.Info("Snapshot taken", "target", target, "resources", len(snapshot.Resources))
+	return snapshot, nil
+}
+
+// ConfigSnapshot returns the state the config describes, without contacting Grafana: the rendered settings of
+// the data sources and the panels and variables of the dashboard files. grafana.com dashboards are downloaded.
+func ConfigSnapshot(cfg Config, log *slog.Logger) (*Snapshot, error) {
+	snapshot := &Snapshot{Target: "config", Resources: map[string]ResourceSnapshot{}, Partial: true}
+
+	for _, folder := range cfg.Folders {
+		snapshot.add(KindFolder, folder.Name, map[string]interface{}{})
+	}
+
+	for _, dataSource := range cfg.DataSources {
+		request := dataSourceRequestData(newDataSourceModel(dataSource))
+		fields := map[string]interface{}{"type": request["type"], "url": request["url"], "database": request["database"]}
+		if user, ok := request["user"]; ok {
+			fields["user"] = user
+		}
+		jsonData, _ := request["jsonData"].(map[string]interface{})
+		for key, value := range jsonData {
+			fields["jsonData."+key] = value
+		}
+		snapshot.add(KindDataSource, dataSource.Name, fields)
+	}
+
+	quiet := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, dashboard := range cfg.Dashboards {
+		data, err := loadDashboardJSON(dashboard, quiet)
+		if err != nil {
+			return nil, err
+		}
+		var model DashboardJSON
+		if err := json.Unmarshal(data, &model); err != nil {
+			return nil, fmt.Errorf("failed to parse dashboard file %s: %w", dashboard.File, err)
+		}
+		if dashboard.MigrateGraphPanels {
+			migrateGraphPanels(model, quiet)
+		}
+		snapshot.add(KindDashboard, dashboard.Name, dashboardSnapshotFields(model))
+	}
+
+	log.Info("Snapshot of the config taken", "resources", len(snapshot.Resources))
+	return snapshot, nil
+}
+
+// CompareSnapshots lists the resources missing from one of the snapshots and the fields differing between them,
+// ordered by kind, name and field
+func CompareSnapshots(a *Snapshot, b *Snapshot) []Divergence {
+	keys := map[string]bool{}
+	for key := range a.Resources {
+		keys[key] = true
+	}
+	for key := range b.Resources {
+		keys[key] = true
+	}
+
+	divergences := []Divergence{}
+	for key := range keys {
+		resourceA, inA := a.Resources[key]
+		resourceB, inB := b.Resources[key]
+		switch {
+		case !inA:
+			divergences = append(divergences, Divergence{Kind: resourceB.Kind, Name: resourceB.Name, B: "present"})
+			continue
+		case !inB:
+			divergences = append(divergences, Divergence{Kind: resourceA.Kind, Name: resourceA.Name, A: "present"})
+			continue
+		}
+
+		fields := map[string]bool{}
+		for field := range resourceA.Fields {
+			fields[field] = true
+		}
+		for field := range resourceB.Fields {
+			fields[field] = true
+		}
+		for field := range fields {
+			valueA, setA := resourceA.Fields[field]
+			valueB, setB := resourceB.Fields[field]
+			if (!setA && a.Partial) || (!setB && b.Partial) || valueA == valueB {
+				continue
+			}
+			divergences = append(divergences, Divergence{Kind: resourceA.Kind, Name: resourceA.Name, Field: field, A: valueA, B: valueB})
+		}
+	}
+
+	sort.Slice(divergences, func(i, j int) bool {
+		if divergences[i].Kind != divergences[j].Kind {
+			return divergences[i].Kind < divergences[j].Kind
+		}
+		if divergences[i].Name != divergences[j].Name {
+			return divergences[i].Name < divergences[j].Name
+		}
+		return divergences[i].Field < divergences[j].Field
+	})
+	return divergences
+}
+
+// add records the resource with its fields rendered as canonical JSON
+func (snapshot *Snapshot) add(kind string, name string, fields map[string]interface{}) {
+	rendered := map[string]string{}
+	for field, value := range fields {
+		data, err := json.Marshal(normalizeJSON(value))
+		if err != nil {
+			data = []byte(fmt.Sprint(value))
+		}
+		rendered[field] = string(data)
+	}
+	snapshot.Resources[kind+"/"+name] = ResourceSnapshot{Kind: kind, Name: name, Fields: rendered}
+}
+
+// dashboardSnapshotFields returns the compared fields of a dashboard model: the type and title of every panel,
+// nested ones included, the names of the variables and the refresh interval
+func dashboardSnapshotFields(dashboard DashboardJSON) map[string]interface{} {
+	panels := []string{}
+	walkDashboardPanels(dashboard, func(panel map[string]interface{}) {
+		panels = append(panels, fmt.Sprintf("%v: %v", panel["type"], panel["title"]))
+	})
+
+	variables := []string{}
+	templating, _ := dashboard["templating"].(map[string]interface{})
+	list, _ := templating["list"].([]interface{})
+	for _, item := range list {
+		if variable, ok := item.(map[string]interface{}); ok {
+			variables = append(variables, fmt.Sprint(variable["name"]))
+		}
+	}
+
+	return map[string]interface{}{"panels": panels, "variables": variables, "refresh": dashboard["refresh"]}
+}
+
+// withoutTag returns the tags without the one, e.g. the prune tag of the run
+func withoutTag(tags interface{}, tag string) []string {
+	list, _ := tags.([]interface{})
+	kept := []string{}
+	for _, item := range list {
+		if value := fmt.Sprint(item); value != tag {
+			kept = append(kept, value)
+		}
+	}
+	sort.Strings(kept)
+	return kept
+}
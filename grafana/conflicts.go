@@ -0,0 +1,92 @@
+package grafana
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+// Policies for dashboards saved with overwrite disabled whose live version has changed
+const (
+	ConflictFail   = "fail"   // Stop the run
+	ConflictMerge  = "merge"  // Apply the configured content over the live dashboard
+	ConflictPrompt = "prompt" // Ask whether to overwrite the live changes
+)
+
+// isVersionConflict reports whether Grafana refused the save because the dashboard changed (412 Precondition Failed)
+func isVersionConflict(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusPreconditionFailed
+}
+
+// resolveDashboardConflict handles a version conflict of a dashboard saved with overwrite disabled.
+// Returns nil response if the dashboard was skipped.
+func resolveDashboardConflict(client GrafanaAPI, cfg Config, prepared *preparedDashboard, conflictErr error, log *slog.Logger) (*DashboardImportResponse, error) {
+	if prepared.Existing.UID == "" {
+		return nil, conflictErr
+	}
+
+	live, err := client.GetDashboardByUID(prepared.Existing.UID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the live version after a conflict: %w", err)
+	}
+	liveVersion := live.Meta.Version
+	log.Warn("Dashboard was changed in Grafana since it was last provisioned", "uid", prepared.Existing.UID, "live_version", liveVersion, "updated", live.Meta.Updated, "policy", prepared.Config.OnConflict)
+
+	desired := renderDashboardInputs(prepared.Request.Dashboard, prepared.InputValues)
+	delete(desired, "__inputs")
+	delete(desired, "__requires")
+
+	switch prepared.Config.OnConflict {
+	case ConflictMerge:
+		desired = mergeDashboard(live.Dashboard, desired)
+	case ConflictPrompt:
+		if cfg.ConfirmConflict == nil || !cfg.ConfirmConflict(prepared.Config.Name, liveVersion) {
+			log.Warn("Keeping the live dashboard, configured changes are not applied")
+			return nil, nil
+		}
+	default:
+		return nil, fmt.Errorf("dashboard was changed in Grafana (live version %d), set 'on_conflict: merge' or 'overwrite: true' to apply the config: %w", liveVersion, conflictErr)
+	}
+
+	// Save against the version just fetched, anything newer still conflicts
+	desired["id"] = live.Dashboard["id"]
+	desired["uid"] = prepared.Existing.UID
+	desired["version"] = liveVersion
+
+	saveResponse, err := client.SaveDashboard(&DashboardSaveRequest{
+		Dashboard: desired,
+		FolderUID: prepared.Request.FolderUID,
+		Overwrite: false,
+		Message:   prepared.Request.Message,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save dashboard over live version %d: %w", liveVersion, err)
+	}
+
+	return &DashboardImportResponse{
+		UID:         saveResponse.UID,
+		Title:       prepared.Config.Name,
+		Imported:    true,
+		ImportedURL: saveResponse.URL,
+		Slug:        saveResponse.Slug,
+		DashboardID: saveResponse.ID,
+		FolderUID:   prepared.Request.FolderUID,
+	}, nil
+}
+
+// mergeDashboard takes the provisioned content (panels, variables, annotations, links, tags, time settings)
+// from the desired dashboard and keeps every other setting changed in the live dashboard.
+func mergeDashboard(live DashboardJSON, desired DashboardJSON) DashboardJSON {
+	merged := renderDashboardInputs(live, nil)
+	for _, key := range dashboardContentKeys {
+		if value, ok := desired[key]; ok {
+			merged[key] = value
+		} else {
+			delete(merged, key)
+		}
+	}
+	merged["title"] = desired["title"]
+	return merged
+}
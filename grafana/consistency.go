@@ -0,0 +1,69 @@
+package grafana
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// consistencyPollInterval is the delay between the lookups of a resource just written
+const consistencyPollInterval = time.Second
+
+// waitUntilVisible polls the lookup until it finds the resource just written or the timeout passes. Behind a
+// caching proxy, search results can lag right after a mutation, so later lookups by name would fail spuriously.
+func waitUntilVisible(ctx context.Context, kind string, name string, timeout time.Duration, lookup func() (bool, error), log *slog.Logger) error {
+	if timeout <= 0 {
+		return nil
+	}
+
+	deadline := time.Now().Add(timeout)
+	for attempt := 1; ; attempt++ {
+		visible, err := lookup()
+		if err != nil {
+			return fmt.Errorf("failed to look up %s '%s' after writing it: %w", kind, name, err)
+		}
+		if visible {
+			if attempt > 1 {
+				log.Info("Written resource became visible", "kind", kind, "name", name, "attempts", attempt)
+			}
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s '%s' is not visible %s after writing it, a cache in front of Grafana may serve stale results", kind, name, timeout)
+		}
+
+		log.Debug("Written resource not visible yet, polling", "kind", kind, "name", name, "attempt", attempt)
+		if err := sleep(ctx, consistencyPollInterval); err != nil {
+			return fmt.Errorf("canceled while waiting for %s '%s' to become visible: %w", kind, name, err)
+		}
+	}
+}
+
+// waitForDashboard waits until the search finds the dashboard with the UID in its folder
+func waitForDashboard(client GrafanaAPI, dashboard Dashboard, uid string, timeout time.Duration, log *slog.Logger) error {
+	return waitUntilVisible(client.Context(), KindDashboard, dashboard.Name, timeout, func() (bool, error) {
+		candidates, err := client.FindDashboardsByName(dashboard.Name)
+		if err != nil {
+			return false, err
+		}
+		for _, candidate := range candidates {
+			if (uid == "" || candidate.UID == uid) && isSameFolder(dashboard.Folder, candidate.FolderTitle) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}, log)
+}
+
+// waitForDataSource waits until the data source can be looked up by name
+func waitForDataSource(client GrafanaAPI, name string, timeout time.Duration, log *slog.Logger) error {
+	return waitUntilVisible(client.Context(), KindDataSource, name, timeout, func() (bool, error) {
+		_, err := client.GetDataSource(name)
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return err == nil, err
+	}, log)
+}
@@ -0,0 +1,167 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"reflect"
+)
+
+// KindContactPoint is the report kind of contact points
+const KindContactPoint = "contact-point"
+
+// redactedSetting is the value the provisioning API returns for the secure settings of a contact point
+const redactedSetting = "[REDACTED]"
+
+// ContactPoint defines a unified alerting contact point, a single integration with its type-specific settings
+type ContactPoint struct {
+	UID                   string // Optional, the live contact point with the same name is updated otherwise
+	Name                  string
+	Type                  string                 // Integration type, e.g. email, slack, pagerduty or webhook
+	Settings              map[string]interface{} // Integration settings, secure ones included
+	DisableResolveMessage bool
+}
+
+// ProvisionedContactPoint is the structure of a contact point in the alerting provisioning API
+type ProvisionedContactPoint struct {
+	UID                   string                 `json:"uid,omitempty"`
+	Name                  string                 `json:"name"`
+	Type                  string                 `json:"type"`
+	Settings              map[string]interface{} `json:"settings"`
+	DisableResolveMessage bool                   `json:"disableResolveMessage"`
+}
+
+// GetContactPoints returns the contact points of the current organization, secure settings redacted
+func (client *ApiClient) GetContactPoints() ([]ProvisionedContactPoint, error) {
+	body, err := client.doRequest("GET", client.URL+"/api/v1/provisioning/contact-points", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contact points: %w", err)
+	}
+
+	var contactPoints []ProvisionedContactPoint
+	if err := json.Unmarshal(body, &contactPoints); err != nil {
+		return nil, fmt.Errorf("failed to decode contact points: %w", err)
+	}
+	return contactPoints, nil
+}
+
+// CreateContactPoint creates the contact point, it stays editable in the UI
+func (client *ApiClient) CreateContactPoint(contactPoint *ProvisionedContactPoint) (*ProvisionedContactPoint, error) {
+	data, err := json.Marshal(contactPoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal contact point: %w", err)
+	}
+
+	headers := map[string]string{"X-Disable-Provenance": "true"}
+	body, err := client.doRequestWithHeaders("POST", client.URL+"/api/v1/provisioning/contact-points", data, headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create contact point '%s': %w", contactPoint.Name, err)
+	}
+
+	var created ProvisionedContactPoint
+	if err := json.Unmarshal(body, &created); err != nil {
+		return nil, fmt.Errorf("failed to decode created contact point: %w", err)
+	}
+
+	client.Logger.Info("Contact point successfully created", "name", created.Name, "uid", created.UID)
+	return &created, nil
+}
+
+// UpdateContactPoint replaces the contact point with the UID
+func (client *ApiClient) UpdateContactPoint(contactPoint *ProvisionedContactPoint) error {
+	data, err := json.Marshal(contactPoint)
+	if err != nil {
+		return fmt.Errorf("failed to marshal contact point: %w", err)
+	}
+
+	headers := map[string]string{"X-Disable-Provenance": "true"}
+	endpoint := client.URL + "/api/v1/provisioning/contact-points/" + url.PathEscape(contactPoint.UID)
+	if _, err := client.doRequestWithHeaders("PUT", endpoint, data, headers); err != nil {
+		return fmt.Errorf("failed to update contact point '%s': %w", contactPoint.Name, err)
+	}
+
+	client.Logger.Info("Contact point successfully updated", "name", contactPoint.Name, "uid", contactPoint.UID)
+	return nil
+}
+
+// provisionContactPoints creates the configured contact points and updates the live ones that differ, matched
+// by UID or by name. The secure settings are redacted by Grafana and can't be compared, changing only those
+// doesn't update the contact point.
+func provisionContactPoints(client GrafanaAPI, cfg Config, report *Report, log *slog.Logger) error {
+	if len(cfg.ContactPoints) == 0 {
+		return nil
+	}
+
+	live, err := client.GetContactPoints()
+	if err != nil {
+		return err
+	}
+
+	log.Info("Provisioning contact points")
+	for _, contactPoint := range cfg.ContactPoints {
+		desired := &ProvisionedContactPoint{
+			UID:                   contactPoint.UID,
+			Name:                  contactPoint.Name,
+			Type:                  contactPoint.Type,
+			Settings:              contactPoint.Settings,
+			DisableResolveMessage: contactPoint.DisableResolveMessage,
+		}
+		if desired.Settings == nil {
+			desired.Settings = map[string]interface{}{}
+		}
+
+		var existing *ProvisionedContactPoint
+		for i, candidate := range live {
+			if (desired.UID != "" && candidate.UID == desired.UID) || (desired.UID == "" && candidate.Name == desired.Name) {
+				existing = &live[i]
+				break
+			}
+		}
+
+		if existing == nil {
+			created, err := client.CreateContactPoint(desired)
+			if err != nil {
+				return report.fail(KindContactPoint, contactPoint.Name, err)
+			}
+			report.add(ResourceResult{Kind: KindContactPoint, Name: contactPoint.Name, Action: ActionCreated, UID: created.UID})
+			continue
+		}
+
+		desired.UID = existing.UID
+		if contactPointMatches(*existing, *desired) {
+			log.Debug("Contact point unchanged", "name", contactPoint.Name, "uid", existing.UID)
+			report.add(ResourceResult{Kind: KindContactPoint, Name: contactPoint.Name, Action: ActionUnchanged, UID: existing.UID})
+			continue
+		}
+		if err := client.UpdateContactPoint(desired); err != nil {
+			return report.fail(KindContactPoint, contactPoint.Name, err)
+		}
+		report.add(ResourceResult{Kind: KindContactPoint, Name: contactPoint.Name, Action: ActionUpdated, UID: existing.UID})
+	}
+
+	log.Info("All configured contact points provisioned.")
+	return nil
+}
+
+// contactPointMatches reports whether the live contact point has the desired name, type and settings,
+// the redacted secure settings of the live one excepted
+func contactPointMatches(live ProvisionedContactPoint, desired ProvisionedContactPoint) bool {
+	if live.Name != desired.Name || live.Type != desired.Type || live.DisableResolveMessage != desired.DisableResolveMessage {
+		return false
+	}
+
+	liveSettings := map[string]interface{}{}
+	desiredSettings := map[string]interface{}{}
+	for key, value := range live.Settings {
+		if value != redactedSetting {
+			liveSettings[key] = value
+		}
+	}
+	for key, value := range desired.Settings {
+		if live.Settings[key] != redactedSetting {
+			desiredSettings[key] = value
+		}
+	}
+	return reflect.DeepEqual(normalizeJSON(liveSettings), normalizeJSON(desiredSettings))
+}
@@ -0,0 +1,119 @@
+package grafana
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// dataSourceUpdatedMessage marks the responses of updated data sources for the report
+const dataSourceUpdatedMessage = "Updated"
+
+// dataSourceFields are the top-level settings of a data source compared with the config
+var dataSourceFields = []string{"name", "type", "access", "url", "database", "user", "basicAuth", "basicAuthUser"}
+
+// updateDataSource updates the matched live data source when it differs from the config. Its other jsonData
+// keys and whether it is the default data source are kept, it is renamed only when the configured name is
+// free. Secrets can't be read back: a changed one is only updated along with another change, or with
+// Config.UpdateSecrets, missing ones are always set.
+func updateDataSource(client GrafanaAPI, cfg Config, matched DataSource, model *DataSourceModel, existingSources []DataSource, log *slog.Logger) (*CreateDataSourceResponse, error) {
+	unchanged := &CreateDataSourceResponse{
+		Datasource: CreateDataSourceResponseDatasource{ID: matched.ID, UID: matched.UID, Name: matched.Name, Message: "Already exists"},
+	}
+
+	live, err := client.GetDataSourceByUID(matched.UID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data source '%s': %w", matched.Name, err)
+	}
+
+	model.UID = live.UID
+	model.IsDefault = live.IsDefault
+	model.KeepJSONData = live.JSONData
+	for _, source := range existingSources {
+		if model.Name != live.Name && source.Name == model.Name && source.UID != live.UID {
+			log.Warn("Configured name belongs to another data source, keeping the live name", "name", model.Name, "live_name", live.Name, "uid", live.UID)
+			model.Name = live.Name
+		}
+	}
+
+	changes := dataSourceChanges(live, dataSourceRequestData(model), cfg.UpdateSecrets)
+	if len(changes) == 0 {
+		log.Info("Data source matches the config", "uid", live.UID)
+		return unchanged, nil
+	}
+	if live.ReadOnly {
+		err := fmt.Errorf("data source '%s' (uid %s) differs in %s: %w", live.Name, live.UID, strings.Join(changes, ", "), ErrFileProvisioned)
+		if !skipFileProvisioned(cfg, err) {
+			return nil, err
+		}
+		log.Warn("Data source is file-provisioned, not updating it", "uid", live.UID, "changes", strings.Join(changes, ", "))
+		return unchanged, nil
+	}
+
+	log.Info("Data source differs from the config, updating it", "uid", live.UID, "changes", strings.Join(changes, ", "))
+	response, err := client.UpdateDataSource(live.UID, model)
+	if err != nil {
+		return nil, err
+	}
+	response.Datasource.ID = live.ID
+	response.Datasource.UID = live.UID
+	response.Datasource.Name = model.Name
+	response.Datasource.Message = dataSourceUpdatedMessage
+	return response, nil
+}
+
+// dataSourceChanges lists the settings of the request that differ from the live data source, e.g. `url` or
+// `jsonData.sslmode`. Secrets count as changed when they aren't set or, with updateSecrets, always.
+func dataSourceChanges(live *DataSource, request map[string]interface{}, updateSecrets bool) []string {
+	current := map[string]interface{}{
+		"name":          live.Name,
+		"type":          live.Type,
+		"access":        live.Access,
+		"url":           live.URL,
+		"database":      live.Database,
+		"user":          live.User,
+		"basicAuth":     live.BasicAuth,
+		"basicAuthUser": live.BasicAuthUser,
+	}
+
+	changes := []string{}
+	for _, field := range dataSourceFields {
+		desired, set := request[field]
+		if !set {
+			continue
+		}
+		if field == "type" && isDataSourceType(live.Type, fmt.Sprint(desired)) {
+			continue
+		}
+		if !reflect.DeepEqual(normalizeJSON(desired), normalizeJSON(current[field])) {
+			changes = append(changes, field)
+		}
+	}
+
+	jsonData, _ := request["jsonData"].(map[string]interface{})
+	keys := []string{}
+	for key := range jsonData {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !reflect.DeepEqual(normalizeJSON(jsonData[key]), normalizeJSON(live.JSONData[key])) {
+			changes = append(changes, "jsonData."+key)
+		}
+	}
+
+	secureJSONData, _ := request["secureJsonData"].(map[string]string)
+	keys = []string{}
+	for key, value := range secureJSONData {
+		if value != "" && (updateSecrets || !live.SecureJSONFields[key]) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		changes = append(changes, "secureJsonData."+key)
+	}
+	return changes
+}
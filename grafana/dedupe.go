@@ -0,0 +1,171 @@
+package grafana
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// DuplicateCopy is a single live copy of a duplicated resource.
+type DuplicateCopy struct {
+	UID      string
+	Name     string
+	Folder   string // Folder title, dashboards only
+	Managed  bool   // The copy matches the config and is kept
+	Obsolete bool   // The copy is safe to delete
+}
+
+// DuplicateGroup groups live resources of one kind sharing the same logical name.
+type DuplicateGroup struct {
+	Kind   string
+	Name   string
+	Copies []DuplicateCopy
+}
+
+// suffixedNamePattern matches data source names with the counter suffix added on name conflicts
+var suffixedNamePattern = regexp.MustCompile(`^(.+)_(\d+)$`)
+
+// FindDuplicates finds dashboards sharing the same title across folders and
+// data sources created with a `_N` suffix by earlier runs. Dashboard copies outside the configured folder
+// and suffixed data sources are only obsolete if they carry the prune tag, unless includeUntagged is set.
+func FindDuplicates(client GrafanaAPI, cfg Config, includeUntagged bool, log *slog.Logger) ([]DuplicateGroup, error) {
+	dashboardGroups, err := findDuplicateDashboards(client, cfg, includeUntagged, log)
+	if err != nil {
+		return nil, err
+	}
+
+	dataSourceGroups, err := findDuplicateDataSources(client, cfg, includeUntagged, log)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(dashboardGroups, dataSourceGroups...), nil
+}
+
+// DeleteDuplicate removes a single obsolete copy from Grafana.
+func DeleteDuplicate(client GrafanaAPI, kind string, duplicate DuplicateCopy) error {
+	if !duplicate.Obsolete {
+		return fmt.Errorf("%s '%s' (uid %s) is not obsolete, refusing to delete it", kind, duplicate.Name, duplicate.UID)
+	}
+
+	switch kind {
+	case KindDashboard:
+		return client.DeleteDashboardByUID(duplicate.UID)
+	case KindDataSource:
+		return client.DeleteDataSourceByUID(duplicate.UID)
+	}
+
+	return fmt.Errorf("unknown duplicate kind '%s'", kind)
+}
+
+// findDuplicateDashboards groups dashboards by title. For titles declared in config the copy in the
+// configured folder is managed and the other copies carrying the prune tag are obsolete. Untagged copies
+// may be unrelated dashboards created by hand and are kept unless includeUntagged is set.
+func findDuplicateDashboards(client GrafanaAPI, cfg Config, includeUntagged bool, log *slog.Logger) ([]DuplicateGroup, error) {
+	searchResults, err := client.SearchDashboards()
+	if err != nil {
+		return nil, fmt.Errorf("failed to search dashboards: %w", err)
+	}
+
+	byTitle := map[string][]DashboardSearchResponse{}
+	titles := []string{}
+	for _, result := range searchResults {
+		if result.Type != "dash-db" {
+			continue
+		}
+		if _, ok := byTitle[result.Title]; !ok {
+			titles = append(titles, result.Title)
+		}
+		byTitle[result.Title] = append(byTitle[result.Title], result)
+	}
+
+	groups := []DuplicateGroup{}
+	for _, title := range titles {
+		results := byTitle[title]
+		if len(results) < 2 {
+			continue
+		}
+
+		configured, isConfigured := findDashboardConfig(cfg, title)
+		tag := cfg.pruneTag()
+
+		group := DuplicateGroup{Kind: KindDashboard, Name: title}
+		for _, result := range results {
+			managed := isConfigured && isSameFolder(configured.Folder, result.FolderTitle)
+			group.Copies = append(group.Copies, DuplicateCopy{
+				UID:      result.UID,
+				Name:     result.Title,
+				Folder:   result.FolderTitle,
+				Managed:  managed,
+				Obsolete: isConfigured && !managed && (includeUntagged || hasTag(result.Tags, tag)),
+			})
+		}
+
+		groups = append(groups, group)
+	}
+
+	log.Info("Duplicate dashboards search completed", "groups", len(groups))
+	return groups, nil
+}
+
+// findDuplicateDataSources groups configured data sources with their `_N`-suffixed copies.
+// The copy matching the configured type, URL and database is managed, other suffixed copies marked with
+// the prune tag are obsolete. Unmarked ones may be unrelated data sources and are kept unless includeUntagged is set.
+func findDuplicateDataSources(client GrafanaAPI, cfg Config, includeUntagged bool, log *slog.Logger) ([]DuplicateGroup, error) {
+	existingSources, err := client.GetDataSources()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list existing data sources: %w", err)
+	}
+
+	tag := cfg.pruneTag()
+	groups := []DuplicateGroup{}
+	for _, dataSource := range cfg.DataSources {
+		group := DuplicateGroup{Kind: KindDataSource, Name: dataSource.Name}
+
+		for _, source := range existingSources {
+			suffixed := false
+			if match := suffixedNamePattern.FindStringSubmatch(source.Name); match != nil && match[1] == dataSource.Name {
+				suffixed = true
+			}
+			if source.Name != dataSource.Name && !suffixed {
+				continue
+			}
+
+			managed := dataSourceMatches(source, dataSource)
+			owned := includeUntagged || source.JSONData[pruneMarkerKey] == tag
+			group.Copies = append(group.Copies, DuplicateCopy{
+				UID:      source.UID,
+				Name:     source.Name,
+				Managed:  managed,
+				Obsolete: suffixed && !managed && owned && !source.ReadOnly, // Grafana refuses to delete file-provisioned ones
+			})
+		}
+
+		if len(group.Copies) > 1 {
+			groups = append(groups, group)
+		}
+	}
+
+	log.Info("Duplicate data sources search completed", "groups", len(groups))
+	return groups, nil
+}
+
+// findDashboardConfig returns the dashboard config with the given name
+func findDashboardConfig(cfg Config, name string) (Dashboard, bool) {
+	for _, dashboard := range cfg.Dashboards {
+		if dashboard.Name == name {
+			return dashboard, true
+		}
+	}
+	return Dashboard{}, false
+}
+
+// isSameFolder compares a configured folder name with a folder title returned by the search API.
+// Grafana returns an empty FolderTitle for dashboards in the 'General' folder.
+func isSameFolder(configured string, live string) bool {
+	if strings.EqualFold(configured, "General") {
+		return live == "" || strings.EqualFold(live, "General")
+	}
+	return configured == live
+}
@@ -0,0 +1,45 @@
+package grafana
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+)
+
+// dataSourcesAPI lists the live data sources, the other calls of GrafanaAPI aren't implemented
+type dataSourcesAPI struct {
+	GrafanaAPI
+	dataSources []DataSource
+}
+
+func (api dataSourcesAPI) GetDataSources() ([]DataSource, error) {
+	return api.dataSources, nil
+}
+
+func TestFindDuplicateDataSourcesOwnership(t *testing.T) {
+	marked := map[string]interface{}{pruneMarkerKey: defaultPruneTag}
+	live := []DataSource{
+		{UID: "managed", Name: "Metrics", Type: "prometheus", URL: "http://prometheus:9090"},
+		{UID: "marked", Name: "Metrics_1", Type: "prometheus", URL: "http://old:9090", JSONData: marked},
+		{UID: "manual", Name: "Metrics_2", Type: "prometheus", URL: "http://team:9090"},
+		{UID: "provisioned", Name: "Metrics_3", Type: "prometheus", URL: "http://file:9090", JSONData: marked, ReadOnly: true},
+	}
+	cfg := Config{DataSources: []DataSource{{Name: "Metrics", Type: "prometheus", URL: "http://prometheus:9090"}}}
+
+	for _, includeUntagged := range []bool{false, true} {
+		groups, err := findDuplicateDataSources(dataSourcesAPI{dataSources: live}, cfg, includeUntagged, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		if err != nil {
+			t.Fatalf("findDuplicateDataSources() error = %v", err)
+		}
+		if len(groups) != 1 {
+			t.Fatalf("findDuplicateDataSources() = %d groups, want 1", len(groups))
+		}
+
+		want := map[string]bool{"managed": false, "marked": true, "manual": includeUntagged, "provisioned": false}
+		for _, duplicate := range groups[0].Copies {
+			if duplicate.Obsolete != want[duplicate.UID] {
+				t.Errorf("includeUntagged %v: %s obsolete = %v, want %v", includeUntagged, duplicate.UID, duplicate.Obsolete, want[duplicate.UID])
+			}
+		}
+	}
+}
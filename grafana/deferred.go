@@ -0,0 +1,54 @@
+package grafana
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// ErrMissingDataSource marks dashboards whose imports reference a data source that can't be found (yet)
+var ErrMissingDataSource = errors.New("data source of the dashboard import not found")
+
+// deferredDashboard is a dashboard whose preparation failed on a missing data source
+type deferredDashboard struct {
+	Config    Dashboard
+	FolderUID string
+	Err       error
+}
+
+// retryDeferredDashboards prepares the dashboards that failed on a missing data source again, after the other
+// dashboards, up to grafana.retries times with grafana.retry-delay in between. A data source created earlier in
+// the run may not be visible yet, e.g. behind a cache or on another replica, so this isn't failing the run.
+// Dry runs don't retry, the planned data sources are visible right away.
+func retryDeferredDashboards(client GrafanaAPI, cfg Config, deferred []deferredDashboard, annotations []resolvedAnnotation, report *Report, log *slog.Logger) ([]*preparedDashboard, error) {
+	preparedDashboards := []*preparedDashboard{}
+	for attempt := 1; len(deferred) > 0; attempt++ {
+		if cfg.DryRun || attempt > cfg.Grafana.Retries {
+			last := deferred[0]
+			return nil, report.fail(KindDashboard, last.Config.Name, fmt.Errorf("dashboard provisioning failed for dashboard '%s': %w", last.Config.Name, last.Err))
+		}
+
+		log.Warn("Data sources of dashboards are not visible yet, retrying", "dashboards", len(deferred), "attempt", attempt, "delay", cfg.Grafana.RetryDelay)
+		if err := sleep(client.Context(), cfg.Grafana.RetryDelay); err != nil {
+			return nil, fmt.Errorf("canceled while waiting for the data sources of dashboards: %w", err)
+		}
+
+		remaining := []deferredDashboard{}
+		for _, dashboard := range deferred {
+			dashboardLog := log.With("dashboard", dashboard.Config.Name)
+			prepared, err := prepareDashboard(client.WithLogger(dashboardLog), dashboard.Config, dashboard.FolderUID, cfg.dashboardOwner(dashboard.Config), annotations, cfg.Values, cfg.ValueSources, cfg.Git, dashboardLog)
+			if errors.Is(err, ErrMissingDataSource) {
+				dashboard.Err = err
+				remaining = append(remaining, dashboard)
+				continue
+			}
+			if err != nil {
+				return nil, report.fail(KindDashboard, dashboard.Config.Name, fmt.Errorf("dashboard provisioning failed for dashboard '%s': %w", dashboard.Config.Name, err))
+			}
+			dashboardLog.Info("Data sources of the dashboard became visible", "attempt", attempt)
+			preparedDashboards = append(preparedDashboards, prepared)
+		}
+		deferred = remaining
+	}
+	return preparedDashboards, nil
+}
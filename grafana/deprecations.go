@@ -0,0 +1,115 @@
+package grafana
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Deprecation is a deprecation notice Grafana sent for the API calls of a run
+type Deprecation struct {
+	Notice    string   // e.g. the Warning header or the deprecation message of the body
+	Sunset    string   // Removal date of the Sunset header, if any
+	Endpoints []string // Method and path of the calls, e.g. "GET /api/search"
+	Count     int      // Number of responses with the notice
+}
+
+// warningAgent matches the code and agent of a `Warning: 299 - "text"` header, e.g. of the k8s-style APIs
+var warningAgent = regexp.MustCompile(`^\s*299\s+\S+\s+`)
+
+// deprecationLog collects the deprecation notices of the responses, shared by the copies of a client
+type deprecationLog struct {
+	mu      sync.Mutex
+	notices map[string]*Deprecation
+}
+
+// record adds the deprecation notices of the response to the call to the log, nil logs nothing
+func (deprecations *deprecationLog) record(method string, endpoint string, header http.Header, body []byte) {
+	if deprecations == nil {
+		return
+	}
+	notices := deprecationNotices(header, body)
+	if len(notices) == 0 {
+		return
+	}
+
+	path := endpoint
+	if parsed, err := url.Parse(endpoint); err == nil {
+		path = parsed.Path
+	}
+	call := method + " " + path
+
+	deprecations.mu.Lock()
+	defer deprecations.mu.Unlock()
+	for _, notice := range notices {
+		deprecation, ok := deprecations.notices[notice]
+		if !ok {
+			deprecation = &Deprecation{Notice: notice, Sunset: header.Get("Sunset")}
+			deprecations.notices[notice] = deprecation
+		}
+		deprecation.Count++
+		if !slices.Contains(deprecation.Endpoints, call) {
+			deprecation.Endpoints = append(deprecation.Endpoints, call)
+		}
+	}
+}
+
+// list returns the collected notices in order
+func (deprecations *deprecationLog) list() []Deprecation {
+	deprecations.mu.Lock()
+	defer deprecations.mu.Unlock()
+
+	list := []Deprecation{}
+	for _, deprecation := range deprecations.notices {
+		list = append(list, *deprecation)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Notice < list[j].Notice })
+	return list
+}
+
+// deprecationNotices returns the notices of the Deprecation (RFC 9745) and Warning 299 headers and of the
+// `message` of JSON bodies mentioning a deprecation, e.g. of the folderId parameters replaced by folderUid
+func deprecationNotices(header http.Header, body []byte) []string {
+	notices := []string{}
+	for _, warning := range header.Values("Warning") {
+		if warningAgent.MatchString(warning) {
+			notices = append(notices, strings.Trim(warningAgent.ReplaceAllString(warning, ""), `"`))
+		}
+	}
+	if deprecated := header.Get("Deprecation"); deprecated != "" && len(notices) == 0 {
+		notices = append(notices, "endpoint is deprecated (Deprecation: "+deprecated+")")
+	}
+
+	var response struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &response) == nil && strings.Contains(strings.ToLower(response.Message), "deprecat") {
+		notices = append(notices, response.Message)
+	}
+	return notices
+}
+
+// Deprecations returns the deprecation notices Grafana sent for the calls of the client and its copies
+func (client *ApiClient) Deprecations() []Deprecation {
+	if client.deprecations == nil {
+		return []Deprecation{}
+	}
+	return client.deprecations.list()
+}
+
+// logDeprecations warns once about every deprecation notice of the run
+func logDeprecations(deprecations []Deprecation, log *slog.Logger) {
+	for _, deprecation := range deprecations {
+		attrs := []interface{}{"notice", deprecation.Notice, "endpoints", strings.Join(deprecation.Endpoints, ", "), "responses", deprecation.Count}
+		if deprecation.Sunset != "" {
+			attrs = append(attrs, "sunset", deprecation.Sunset)
+		}
+		log.Warn("Grafana reported a deprecated API usage, update the provisioner before it is removed", attrs...)
+	}
+}
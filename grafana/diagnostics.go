@@ -0,0 +1,106 @@
+package grafana
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// unsafeFileNameChars matches characters not used in dump file names
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// diagnoseImportFailure makes a rejected dashboard import actionable: it adds Grafana's error message
+// and the expected vs provided __inputs to the error, and dumps the rendered import payload if dumpDir is set.
+func diagnoseImportFailure(importErr error, prepared *preparedDashboard, dumpDir string, log *slog.Logger) error {
+	var apiErr *APIError
+	if errors.Is(importErr, ErrFileProvisioned) {
+		return importErr
+	}
+	if !errors.As(importErr, &apiErr) || (apiErr.StatusCode != http.StatusBadRequest && apiErr.StatusCode != http.StatusUnprocessableEntity) {
+		return importErr
+	}
+
+	// Grafana error bodies look like {"message": "...", "status": "..."}
+	message := strings.TrimSpace(apiErr.Body)
+	var body struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal([]byte(apiErr.Body), &body) == nil && body.Message != "" {
+		message = body.Message
+	}
+
+	expected := expectedInputNames(prepared.Request.Dashboard)
+	provided := []string{}
+	for name := range prepared.InputValues {
+		provided = append(provided, name)
+	}
+	sort.Strings(provided)
+
+	missing := []string{}
+	for _, name := range expected {
+		if !slices.Contains(provided, name) {
+			missing = append(missing, name)
+		}
+	}
+
+	log.Error("Grafana rejected the dashboard import",
+		"status", apiErr.StatusCode, "message", message,
+		"expected_inputs", expected, "provided_inputs", provided, "missing_inputs", missing)
+
+	if dumpDir != "" {
+		if path, err := dumpImportRequest(dumpDir, prepared); err != nil {
+			log.Warn("Failed to dump the dashboard import payload", "error", err)
+		} else {
+			log.Info("Dashboard import payload dumped", "file", path)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("dashboard import rejected: %s; inputs %v are expected by the dashboard but not mapped in 'imports' (provided %v): %w", message, missing, provided, importErr)
+	}
+	return fmt.Errorf("dashboard import rejected: %s; expected inputs %v, provided %v: %w", message, expected, provided, importErr)
+}
+
+// expectedInputNames returns the names of the __inputs declared by the exported dashboard
+func expectedInputNames(dashboard DashboardJSON) []string {
+	names := []string{}
+	inputs, _ := dashboard["__inputs"].([]interface{})
+	for _, input := range inputs {
+		inputMap, ok := input.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, ok := inputMap["name"].(string); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// dumpImportRequest writes the import request as sent to Grafana into the dump directory
+func dumpImportRequest(dumpDir string, prepared *preparedDashboard) (string, error) {
+	if err := os.MkdirAll(dumpDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create dump directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(prepared.Request, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal dashboard import request: %w", err)
+	}
+
+	fileName := unsafeFileNameChars.ReplaceAllString(prepared.Config.Name, "_") + ".import.json"
+	path := filepath.Join(dumpDir, fileName)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write dump file: %w", err)
+	}
+	return path, nil
+}
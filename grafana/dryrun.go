@@ -0,0 +1,405 @@
+package grafana
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Planned change operations of a dry run
+const (
+	PlanCreate = "create"
+	PlanUpdate = "update"
+	PlanDelete = "delete"
+)
+
+// PlannedChange is a change a dry run would have made to Grafana
+type PlannedChange struct {
+	Operation string // PlanCreate, PlanUpdate or PlanDelete
+	Kind      string
+	Name      string
+	Detail    string // e.g. the folder of a dashboard
+}
+
+// plan records a change a dry run would have made
+func (report *Report) plan(operation string, kind string, name string, detail string) {
+	report.Plan = append(report.Plan, PlannedChange{Operation: operation, Kind: kind, Name: name, Detail: detail})
+}
+
+// dryRunState is shared by a dry-run client and the clients derived from it with WithLogger
+type dryRunState struct {
+	report      *Report
+	nextID      int
+	dataSources []DataSource
+	folders     []FolderResponse
+	teams       []TeamResponse
+	dashboards  map[string]*DashboardGetResponse
+}
+
+// dryRunClient reads from Grafana but records every change into the plan of the report instead of making it.
+// The resources it would have created are served back by the reads, so the later steps of the run can look them
+// up like after a real run.
+type dryRunClient struct {
+	GrafanaAPI
+	state *dryRunState
+	log   *slog.Logger
+}
+
+// newDryRunClient wraps the client so the run only plans its changes into the report
+func newDryRunClient(client GrafanaAPI, report *Report, log *slog.Logger) *dryRunClient {
+	return &dryRunClient{
+		GrafanaAPI: client,
+		state:      &dryRunState{report: report, dashboards: map[string]*DashboardGetResponse{}},
+		log:        log,
+	}
+}
+
+// record logs and plans a change
+func (client *dryRunClient) record(operation string, kind string, name string, detail string) {
+	client.log.Info("Dry run: change planned", "operation", operation, "kind", kind, "name", name, "detail", detail)
+	client.state.report.plan(operation, kind, name, detail)
+}
+
+// plannedID returns an ID for a resource that doesn't exist yet. Planned IDs are negative so they never match
+// a live resource.
+func (client *dryRunClient) plannedID() int {
+	client.state.nextID--
+	return client.state.nextID
+}
+
+// plannedUID returns a UID for a resource that doesn't exist yet
+func (client *dryRunClient) plannedUID() string {
+	return fmt.Sprintf("dry-run%d", client.plannedID())
+}
+
+func (client *dryRunClient) WithLogger(logger *slog.Logger) GrafanaAPI {
+	return &dryRunClient{GrafanaAPI: client.GrafanaAPI.WithLogger(logger), state: client.state, log: logger}
+}
+
+func (client *dryRunClient) WithContext(ctx context.Context) GrafanaAPI {
+	return &dryRunClient{GrafanaAPI: client.GrafanaAPI.WithContext(ctx), state: client.state, log: client.log}
+}
+
+func (client *dryRunClient) CreateOrg(name string) (int, error) {
+	client.record(PlanCreate, KindOrg, name, "")
+	return client.plannedID(), nil
+}
+
+func (client *dryRunClient) AddOrgUser(orgID int, loginOrEmail string, role string) (bool, error) {
+	client.record(PlanUpdate, KindOrg, fmt.Sprint(orgID), fmt.Sprintf("add user '%s' as %s if missing", loginOrEmail, role))
+	return true, nil
+}
+
+func (client *dryRunClient) FindTeamByName(name string) (*TeamResponse, error) {
+	for _, team := range client.state.teams {
+		if team.Name == name {
+			return &team, nil
+		}
+	}
+	return client.GrafanaAPI.FindTeamByName(name)
+}
+
+func (client *dryRunClient) CreateTeam(name string, email string) (int, error) {
+	client.record(PlanCreate, KindTeam, name, "")
+	team := TeamResponse{ID: client.plannedID(), Name: name, Email: email}
+	client.state.teams = append(client.state.teams, team)
+	return team.ID, nil
+}
+
+func (client *dryRunClient) GetTeamGroups(teamID int) ([]string, error) {
+	if teamID < 0 {
+		return nil, nil
+	}
+	return client.GrafanaAPI.GetTeamGroups(teamID)
+}
+
+func (client *dryRunClient) AddTeamGroup(teamID int, groupID string) error {
+	client.record(PlanUpdate, KindTeam, fmt.Sprint(teamID), fmt.Sprintf("add group '%s'", groupID))
+	return nil
+}
+
+func (client *dryRunClient) RemoveTeamGroup(teamID int, groupID string) error {
+	client.record(PlanUpdate, KindTeam, fmt.Sprint(teamID), fmt.Sprintf("remove group '%s'", groupID))
+	return nil
+}
+
+func (client *dryRunClient) GetTeamMembers(teamID int) ([]OrgUser, error) {
+	if teamID < 0 {
+		return nil, nil
+	}
+	return client.GrafanaAPI.GetTeamMembers(teamID)
+}
+
+func (client *dryRunClient) AddTeamMember(teamID int, userID int) error {
+	client.record(PlanUpdate, KindTeam, fmt.Sprint(teamID), fmt.Sprintf("add user %d", userID))
+	return nil
+}
+
+func (client *dryRunClient) RemoveTeamMember(teamID int, userID int) error {
+	client.record(PlanUpdate, KindTeam, fmt.Sprint(teamID), fmt.Sprintf("remove user %d", userID))
+	return nil
+}
+
+func (client *dryRunClient) CreateServiceAccount(name string, role string) (*ServiceAccountResponse, error) {
+	client.record(PlanCreate, KindServiceAccount, name, "role "+role)
+	return &ServiceAccountResponse{ID: client.plannedID(), Name: name, Role: role}, nil
+}
+
+func (client *dryRunClient) GetServiceAccountTokenNames(serviceAccountID int) ([]string, error) {
+	if serviceAccountID < 0 {
+		return nil, nil
+	}
+	return client.GrafanaAPI.GetServiceAccountTokenNames(serviceAccountID)
+}
+
+func (client *dryRunClient) CreateServiceAccountToken(serviceAccountID int, name string) (string, error) {
+	client.record(PlanCreate, KindServiceAccount, fmt.Sprint(serviceAccountID), fmt.Sprintf("token '%s'", name))
+	return "", nil
+}
+
+func (client *dryRunClient) GetDataSource(dataSourceName string) (*DataSource, error) {
+	for _, dataSource := range client.state.dataSources {
+		if dataSource.Name == dataSourceName {
+			return &dataSource, nil
+		}
+	}
+	return client.GrafanaAPI.GetDataSource(dataSourceName)
+}
+
+func (client *dryRunClient) GetDataSourceByUID(uid string) (*DataSource, error) {
+	for _, dataSource := range client.state.dataSources {
+		if dataSource.UID == uid {
+			return &dataSource, nil
+		}
+	}
+	return client.GrafanaAPI.GetDataSourceByUID(uid)
+}
+
+func (client *dryRunClient) GetDataSources() ([]DataSource, error) {
+	dataSources, err := client.GrafanaAPI.GetDataSources()
+	if err != nil {
+		return nil, err
+	}
+	return append(dataSources, client.state.dataSources...), nil
+}
+
+func (client *dryRunClient) ListDataSourcesByType(dataSourceType string) ([]DataSource, error) {
+	dataSources, err := client.GrafanaAPI.ListDataSourcesByType(dataSourceType)
+	if err != nil {
+		return nil, err
+	}
+	for _, dataSource := range client.state.dataSources {
+		if dataSource.Type == dataSourceType {
+			dataSources = append(dataSources, dataSource)
+		}
+	}
+	return dataSources, nil
+}
+
+func (client *dryRunClient) CreateDataSource(ds *DataSourceModel) (*CreateDataSourceResponse, error) {
+	uid := ds.UID
+	if uid == "" {
+		uid = client.plannedUID()
+	}
+	client.record(PlanCreate, KindDataSource, ds.Name, ds.Type)
+
+	client.state.dataSources = append(client.state.dataSources, DataSource{
+		ID:        client.plannedID(),
+		UID:       uid,
+		Name:      ds.Name,
+		Type:      ds.Type,
+		URL:       ds.URL,
+		Database:  ds.Database,
+		User:      ds.User,
+		IsDefault: ds.IsDefault,
+	})
+	return &CreateDataSourceResponse{Datasource: CreateDataSourceResponseDatasource{UID: uid, Name: ds.Name}}, nil
+}
+
+func (client *dryRunClient) UpdateDataSource(uid string, ds *DataSourceModel) (*CreateDataSourceResponse, error) {
+	client.record(PlanUpdate, KindDataSource, ds.Name, ds.Type)
+	return &CreateDataSourceResponse{Datasource: CreateDataSourceResponseDatasource{UID: uid, Name: ds.Name}}, nil
+}
+
+func (client *dryRunClient) DeleteDataSourceByUID(uid string) error {
+	client.record(PlanDelete, KindDataSource, uid, "")
+	return nil
+}
+
+func (client *dryRunClient) GetFolders() ([]FolderResponse, error) {
+	folders, err := client.GrafanaAPI.GetFolders()
+	if err != nil {
+		return nil, err
+	}
+	return append(folders, client.state.folders...), nil
+}
+
+func (client *dryRunClient) SetFolderPermissions(folderUID string, items []PermissionItem) error {
+	client.record(PlanUpdate, KindFolder, client.folderTitle(folderUID), fmt.Sprintf("replace permissions with %d items", len(items)))
+	return nil
+}
+
+func (client *dryRunClient) SetDashboardPermissions(dashboardUID string, items []PermissionItem) error {
+	client.record(PlanUpdate, KindDashboard, dashboardUID, fmt.Sprintf("replace permissions with %d items", len(items)))
+	return nil
+}
+
+func (client *dryRunClient) CreateContactPoint(contactPoint *ProvisionedContactPoint) (*ProvisionedContactPoint, error) {
+	client.record(PlanCreate, KindContactPoint, contactPoint.Name, "type "+contactPoint.Type)
+	created := *contactPoint
+	if created.UID == "" {
+		created.UID = client.plannedUID()
+	}
+	return &created, nil
+}
+
+func (client *dryRunClient) UpdateContactPoint(contactPoint *ProvisionedContactPoint) error {
+	client.record(PlanUpdate, KindContactPoint, contactPoint.Name, "type "+contactPoint.Type)
+	return nil
+}
+
+func (client *dryRunClient) CreateFolderIfNotExists(title string) (*FolderResponse, error) {
+	folders, err := client.GetFolders()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch folders list: %w", err)
+	}
+	for _, folder := range folders {
+		if folder.Title == title {
+			return &folder, nil
+		}
+	}
+
+	client.record(PlanCreate, KindFolder, title, "")
+	folder := FolderResponse{ID: client.plannedID(), UID: client.plannedUID(), Title: title}
+	client.state.folders = append(client.state.folders, folder)
+	return &folder, nil
+}
+
+func (client *dryRunClient) DeleteFolder(uid string) error {
+	client.record(PlanDelete, KindFolder, client.folderTitle(uid), "uid "+uid)
+	return nil
+}
+
+// folderTitle returns the title of the folder for the plan, the UID if it isn't known
+func (client *dryRunClient) folderTitle(folderUID string) string {
+	if folderUID == "" {
+		return "General"
+	}
+	folders, err := client.GetFolders()
+	if err == nil {
+		for _, folder := range folders {
+			if folder.UID == folderUID {
+				return folder.Title
+			}
+		}
+	}
+	return folderUID
+}
+
+func (client *dryRunClient) GetDashboardByUID(uid string) (*DashboardGetResponse, error) {
+	if dashboard, ok := client.state.dashboards[uid]; ok {
+		return dashboard, nil
+	}
+	return client.GrafanaAPI.GetDashboardByUID(uid)
+}
+
+// planDashboard plans saving the dashboard and serves it back to the health checks
+func (client *dryRunClient) planDashboard(dashboard DashboardJSON, uid string, folderUID string) (string, error) {
+	title, _ := dashboard["title"].(string)
+
+	operation := PlanCreate
+	if uid == "" {
+		uid = client.plannedUID()
+	} else if _, err := client.GrafanaAPI.GetDashboardByUID(uid); err == nil {
+		operation = PlanUpdate
+	} else if !errors.Is(err, ErrNotFound) {
+		return "", err
+	}
+	client.record(operation, KindDashboard, title, "in folder "+client.folderTitle(folderUID))
+
+	planned := DashboardJSON{}
+	for key, value := range dashboard {
+		planned[key] = value
+	}
+	planned["uid"] = uid
+	client.state.dashboards[uid] = &DashboardGetResponse{Dashboard: planned}
+	return uid, nil
+}
+
+func (client *dryRunClient) ImportDashboard(request *DashboardImportRequest) (*DashboardImportResponse, error) {
+	// The inputs are rendered the way the import API would, so the health check sees the data source UIDs
+	inputValues := map[string]string{}
+	for _, item := range request.Inputs {
+		input, _ := item.(map[string]interface{})
+		name, _ := input["name"].(string)
+		value, _ := input["value"].(string)
+		inputValues[name] = value
+	}
+	dashboard := renderDashboardInputs(request.Dashboard, inputValues)
+
+	uid, _ := request.Dashboard["uid"].(string)
+	uid, err := client.planDashboard(dashboard, uid, request.FolderUID)
+	if err != nil {
+		return nil, err
+	}
+	title, _ := request.Dashboard["title"].(string)
+	return &DashboardImportResponse{UID: uid, Title: title, Imported: true, ImportedURL: "/d/" + uid, FolderUID: request.FolderUID}, nil
+}
+
+func (client *dryRunClient) SaveDashboard(request *DashboardSaveRequest) (*DashboardSaveResponse, error) {
+	uid, _ := request.Dashboard["uid"].(string)
+	uid, err := client.planDashboard(request.Dashboard, uid, request.FolderUID)
+	if err != nil {
+		return nil, err
+	}
+	return &DashboardSaveResponse{UID: uid, URL: "/d/" + uid, Status: "success"}, nil
+}
+
+func (client *dryRunClient) DeleteDashboardByUID(uid string) error {
+	name := uid
+	if live, err := client.GrafanaAPI.GetDashboardByUID(uid); err == nil {
+		name, _ = live.Dashboard["title"].(string)
+	}
+	client.record(PlanDelete, KindDashboard, name, "uid "+uid)
+	return nil
+}
+
+func (client *dryRunClient) ApplyResource(version string, resource string, namespace string, object *K8sObject) (*K8sObject, error) {
+	title, _ := object.Spec["title"].(string)
+	switch resource {
+	case "folders":
+		client.record(PlanCreate, KindFolder, title, "")
+		client.state.folders = append(client.state.folders, FolderResponse{UID: object.Metadata.Name, Title: title})
+	case "dashboards":
+		if _, err := client.planDashboard(object.Spec, object.Metadata.Name, object.Metadata.Annotations[k8sFolderAnnotation]); err != nil {
+			return nil, err
+		}
+	default:
+		client.record(PlanUpdate, strings.TrimSuffix(resource, "s"), object.Metadata.Name, "")
+	}
+	return object, nil
+}
+
+func (client *dryRunClient) PutAlertRuleGroup(group *ProvisionedRuleGroup) error {
+	client.record(PlanUpdate, KindAlertRuleGroup, group.Title, fmt.Sprintf("%d rules in folder %s", len(group.Rules), client.folderTitle(group.FolderUID)))
+	return nil
+}
+
+func (client *dryRunClient) SetNotificationPolicyTree(tree map[string]interface{}) error {
+	client.record(PlanUpdate, KindNotificationPolicies, "root", "")
+	return nil
+}
+
+func (client *dryRunClient) SetAlertRulePaused(uid string, paused bool) error {
+	client.record(PlanUpdate, KindAlertRule, uid, fmt.Sprintf("paused %t", paused))
+	return nil
+}
+
+// discardSecretSink drops the secrets of a dry run, no token is created
+type discardSecretSink struct{}
+
+func (discardSecretSink) Put(name string, value string) error {
+	return nil
+}
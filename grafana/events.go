@@ -0,0 +1,77 @@
+package grafana
+
+import (
+	"time"
+)
+
+// Provisioning phases reported by PhaseStarted events, in run order
+const (
+	PhaseConnect     = "connect" // Waiting for the API, validating the token, orgs
+	PhaseDataSources = "datasources"
+	PhaseTeams       = "teams"
+	PhaseFolders     = "folders"
+	PhaseDashboards  = "dashboards"
+	PhaseAlerting    = "alerting"
+	PhaseOrgs        = "orgs" // Dashboards imported into further organizations
+)
+
+// Event is a progress event of a provisioning run: PhaseStarted, ResourceApplied, ResourceFailed or DashboardBroken.
+// Embedders receive them through Config.OnEvent to render their own progress.
+type Event interface {
+	isEvent()
+}
+
+// PhaseStarted is emitted when the run moves on to the next phase
+type PhaseStarted struct {
+	Phase string
+}
+
+// ResourceApplied is emitted for every resource result added to the report
+type ResourceApplied struct {
+	Result ResourceResult
+}
+
+// ResourceFailed is emitted when provisioning a resource fails, the run stops after it
+type ResourceFailed struct {
+	Kind string
+	Name string
+	Err  error
+}
+
+// DashboardBroken is emitted when an imported dashboard references data sources that don't exist
+type DashboardBroken struct {
+	Name     string
+	UID      string
+	Problems []string
+}
+
+func (PhaseStarted) isEvent()    {}
+func (ResourceApplied) isEvent() {}
+func (ResourceFailed) isEvent()  {}
+func (DashboardBroken) isEvent() {}
+
+// EventChannel returns an OnEvent callback together with the channel it sends the events to.
+// The callback blocks when the buffer is full, so the channel must be drained while the run is in progress.
+func EventChannel(buffer int) (func(Event), <-chan Event) {
+	events := make(chan Event, buffer)
+	return func(event Event) { events <- event }, events
+}
+
+// emit passes the event to the embedder callback, if any
+func (report *Report) emit(event Event) {
+	if report.onEvent != nil {
+		report.onEvent(event)
+	}
+}
+
+// phase announces the start of a provisioning phase
+func (report *Report) phase(phase string) {
+	report.since = time.Now()
+	report.emit(PhaseStarted{Phase: phase})
+}
+
+// fail announces the failure of a resource and returns the error
+func (report *Report) fail(kind string, name string, err error) error {
+	report.emit(ResourceFailed{Kind: kind, Name: name, Err: err})
+	return err
+}
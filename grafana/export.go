@@ -0,0 +1,292 @@
+package grafana
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// nonInputNameChars matches characters replaced when deriving __inputs names from data source names
+var nonInputNameChars = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// builtinDataSourceUIDs are data source references that exist on every instance
+var builtinDataSourceUIDs = map[string]bool{
+	"grafana":         true,
+	"-- Grafana --":   true,
+	"-- Mixed --":     true,
+	"-- Dashboard --": true,
+}
+
+// ExportedDashboard is a live dashboard prepared for writing to a file
+type ExportedDashboard struct {
+	Dashboard   DashboardJSON
+	FolderTitle string
+	Imports     []DashboardImport // Input to data source name mappings to put in the config, share-externally format only
+}
+
+// ExportDashboard fetches a dashboard by UID. With external set, the data source references are
+// converted to __inputs (Grafana's "Export for sharing externally" format), so the JSON can be
+// imported into other instances and provisioned again with `imports`.
+func ExportDashboard(client GrafanaAPI, uid string, external bool, dataSources []DataSource) (*ExportedDashboard, error) {
+	live, err := client.GetDashboardByUID(uid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch dashboard '%s': %w", uid, err)
+	}
+
+	exported := &ExportedDashboard{
+		Dashboard:   live.Dashboard,
+		FolderTitle: live.Meta.FolderTitle,
+	}
+	if !external {
+		return exported, nil
+	}
+
+	exported.Dashboard, exported.Imports = externalizeDashboard(live.Dashboard, dataSources)
+	return exported, nil
+}
+
+// externalizeDashboard returns a copy of the dashboard with data source references replaced by
+// `${DS_NAME}` inputs and the matching __inputs and __requires entries.
+func externalizeDashboard(dashboard DashboardJSON, dataSources []DataSource) (DashboardJSON, []DashboardImport) {
+	byUID := map[string]DataSource{}
+	byName := map[string]DataSource{}
+	for _, dataSource := range dataSources {
+		byUID[dataSource.UID] = dataSource
+		byName[dataSource.Name] = dataSource
+	}
+
+	used := map[string]DataSource{}
+	exported := renderDashboardInputs(dashboard, nil)
+	exported = replaceDataSourceRefs(map[string]interface{}(exported), byUID, byName, used).(map[string]interface{})
+
+	names := []string{}
+	for name := range used {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	inputs := []interface{}{}
+	requires := []interface{}{}
+	imports := []DashboardImport{}
+	for _, name := range names {
+		dataSource := used[name]
+		inputs = append(inputs, map[string]interface{}{
+			"name":        name,
+			"label":       dataSource.Name,
+			"description": "",
+			"type":        "datasource",
+			"pluginId":    dataSource.Type,
+			"pluginName":  dataSource.Type,
+		})
+		requires = append(requires, map[string]interface{}{
+			"type": "datasource",
+			"id":   dataSource.Type,
+			"name": dataSource.Type,
+		})
+		imports = append(imports, DashboardImport{Name: name, DataSource: dataSource.Name})
+	}
+
+	exported["__inputs"] = inputs
+	exported["__requires"] = requires
+	// The target instance assigns its own ID
+	exported["id"] = nil
+
+	return exported, imports
+}
+
+// replaceDataSourceRefs walks the dashboard model replacing references to known data sources,
+// both object references ({"type", "uid"}) and legacy ones by name, with input references
+func replaceDataSourceRefs(value interface{}, byUID map[string]DataSource, byName map[string]DataSource, used map[string]DataSource) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, item := range typed {
+			if key == "datasource" {
+				typed[key] = replaceDataSourceRef(item, byUID, byName, used)
+				continue
+			}
+			typed[key] = replaceDataSourceRefs(item, byUID, byName, used)
+		}
+		// Data source template variables keep their current selection in current.value
+		if typed["type"] == "datasource" {
+			if current, ok := typed["current"].(map[string]interface{}); ok {
+				if name, ok := current["text"].(string); ok {
+					if dataSource, ok := byName[name]; ok {
+						input := inputName(dataSource)
+						used[input] = dataSource
+						current["value"] = "${" + input + "}"
+					}
+				}
+			}
+		}
+		return typed
+	case []interface{}:
+		for i, item := range typed {
+			typed[i] = replaceDataSourceRefs(item, byUID, byName, used)
+		}
+		return typed
+	}
+	return value
+}
+
+// replaceDataSourceRef replaces a single "datasource" value
+func replaceDataSourceRef(ref interface{}, byUID map[string]DataSource, byName map[string]DataSource, used map[string]DataSource) interface{} {
+	switch typed := ref.(type) {
+	case map[string]interface{}:
+		uid, _ := typed["uid"].(string)
+		if dataSource, ok := byUID[uid]; ok && !builtinDataSourceUIDs[uid] {
+			input := inputName(dataSource)
+			used[input] = dataSource
+			typed["uid"] = "${" + input + "}"
+		}
+		return typed
+	case string:
+		if dataSource, ok := byName[typed]; ok && !builtinDataSourceUIDs[typed] {
+			input := inputName(dataSource)
+			used[input] = dataSource
+			return "${" + input + "}"
+		}
+	}
+	return ref
+}
+
+// inputName derives the __inputs name of a data source, e.g. "metrics pg" becomes DS_METRICS_PG
+func inputName(dataSource DataSource) string {
+	return "DS_" + strings.Trim(nonInputNameChars.ReplaceAllString(strings.ToUpper(dataSource.Name), "_"), "_")
+}
+
+// MarshalDashboard encodes the dashboard as canonical JSON for files kept in git: keys sorted at every level,
+// two-space indentation or none when minified, <, > and & left unescaped like Grafana's own exports, and a final
+// newline. Exports of an unchanged dashboard are byte-identical, so diffs only show real changes.
+func MarshalDashboard(dashboard DashboardJSON, minify bool) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if !minify {
+		encoder.SetIndent("", "  ")
+	}
+
+	// Dashboard models are decoded into maps, which encoding/json writes with sorted keys
+	if err := encoder.Encode(map[string]interface{}(dashboard)); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// ExportAlertRuleGroups converts the live Grafana-managed rule groups back into configured rule groups keeping the
+// rule UIDs, so the rules exported from one instance update the same rules when applied to another.
+// Rules using expressions the config can't declare are returned as issues instead.
+func ExportAlertRuleGroups(client GrafanaAPI, dataSources []DataSource) ([]AlertRuleGroup, []LintIssue, error) {
+	rules, err := client.GetAlertRules()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list alert rules: %w", err)
+	}
+
+	folders, err := client.GetFolders()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+	folderTitles := map[string]string{}
+	for _, folder := range folders {
+		folderTitles[folder.UID] = folder.Title
+	}
+
+	dataSourceNames := map[string]string{}
+	for _, dataSource := range dataSources {
+		dataSourceNames[dataSource.UID] = dataSource.Name
+	}
+
+	groups := []AlertRuleGroup{}
+	groupIndex := map[string]int{}
+	issues := []LintIssue{}
+
+	for _, rule := range rules {
+		key := rule.FolderUID + "/" + rule.RuleGroup
+		index, ok := groupIndex[key]
+		if !ok {
+			liveGroup, err := client.GetAlertRuleGroup(rule.FolderUID, rule.RuleGroup)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to fetch rule group '%s': %w", rule.RuleGroup, err)
+			}
+
+			index = len(groups)
+			groupIndex[key] = index
+			groups = append(groups, AlertRuleGroup{
+				Name:     rule.RuleGroup,
+				Folder:   folderTitles[rule.FolderUID],
+				Interval: time.Duration(liveGroup.Interval) * time.Second,
+			})
+		}
+
+		exported, err := exportAlertRule(rule, dataSourceNames)
+		if err != nil {
+			issues = append(issues, LintIssue{Group: rule.RuleGroup, Rule: rule.Title, Message: err.Error()})
+			continue
+		}
+		groups[index].Rules = append(groups[index].Rules, exported)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Folder != groups[j].Folder {
+			return groups[i].Folder < groups[j].Folder
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups, issues, nil
+}
+
+// exportAlertRule converts a live rule with its queries and math or reduce expressions
+func exportAlertRule(rule ProvisionedAlertRule, dataSourceNames map[string]string) (AlertRule, error) {
+	exported := AlertRule{
+		UID:         rule.UID,
+		Title:       rule.Title,
+		Condition:   rule.Condition,
+		For:         rule.For,
+		Labels:      rule.Labels,
+		Annotations: rule.Annotations,
+	}
+	if exported.For == "0s" {
+		exported.For = ""
+	}
+
+	for _, data := range rule.Data {
+		modelString := func(key string) string {
+			value, _ := data.Model[key].(string)
+			return value
+		}
+
+		if data.DatasourceUID == expressionDataSourceUID {
+			expressionType := modelString("type")
+			if expressionType != "math" && expressionType != "reduce" {
+				return AlertRule{}, fmt.Errorf("expression '%s' of type '%s' can't be exported, only math and reduce are supported", data.RefID, expressionType)
+			}
+			exported.Expressions = append(exported.Expressions, AlertExpression{
+				RefID:      data.RefID,
+				Type:       expressionType,
+				Expression: modelString("expression"),
+				Reducer:    modelString("reducer"),
+			})
+			continue
+		}
+
+		dataSource, ok := dataSourceNames[data.DatasourceUID]
+		if !ok {
+			return AlertRule{}, fmt.Errorf("query '%s' uses unknown data source UID '%s'", data.RefID, data.DatasourceUID)
+		}
+		expr := modelString("expr")
+		if expr == "" {
+			expr = modelString("rawSql")
+		}
+		exported.Queries = append(exported.Queries, AlertQuery{
+			RefID:        data.RefID,
+			DataSource:   dataSource,
+			Expr:         expr,
+			RelativeTime: time.Duration(data.RelativeTimeRange.From) * time.Second,
+		})
+	}
+
+	return exported, nil
+}
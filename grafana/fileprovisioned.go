@@ -0,0 +1,40 @@
+package grafana
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Policies for data sources and dashboards managed by the file provisioning of Grafana
+const (
+	FileProvisionedFail = "fail" // Stop the run
+	FileProvisionedSkip = "skip" // Leave the resource as is and continue
+)
+
+// ErrFileProvisioned is wrapped when Grafana refuses to change a resource of its own file provisioning
+var ErrFileProvisioned = errors.New("resource is file-provisioned, cannot manage via API")
+
+// fileProvisionedMessages are the messages of Grafana refusing changes to file-provisioned resources,
+// e.g. "Cannot save provisioned dashboard" or "Cannot delete read-only data source"
+var fileProvisionedMessages = []string{"provisioned dashboard", "read-only data source"}
+
+// IsFileProvisioned reports whether Grafana refused the change because the resource is file-provisioned
+func (apiErr *APIError) IsFileProvisioned() bool {
+	if apiErr.StatusCode != http.StatusBadRequest && apiErr.StatusCode != http.StatusForbidden {
+		return false
+	}
+	body := strings.ToLower(apiErr.Body)
+	for _, message := range fileProvisionedMessages {
+		if strings.Contains(body, message) {
+			return true
+		}
+	}
+	return false
+}
+
+// skipFileProvisioned reports whether the error is a refused change of a file-provisioned resource that the
+// policy of the config skips
+func skipFileProvisioned(cfg Config, err error) bool {
+	return cfg.FileProvisioned == FileProvisionedSkip && errors.Is(err, ErrFileProvisioned)
+}
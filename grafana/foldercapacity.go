@@ -0,0 +1,105 @@
+package grafana
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// FolderCapacity is the usability guideline on the number of dashboards in a folder. A zero MaxDashboards
+// disables the warnings.
+type FolderCapacity struct {
+	MaxDashboards int  // Dashboards a folder should hold at most
+	Enforce       bool // Fail instead of warning when a folder holds more
+}
+
+// FolderStat is the number of dashboards a folder holds after the run
+type FolderStat struct {
+	Folder     string
+	Dashboards int  // Live dashboards and the configured ones still to create
+	Configured int  // Dashboards of the folder in the config
+	OverLimit  bool // Exceeds FolderCapacity.MaxDashboards
+}
+
+// FolderDashboardCounts counts the dashboards per folder after the run: the live ones and the configured ones not
+// live yet. Sorted by the count, the fullest folder first.
+func FolderDashboardCounts(live []DashboardSearchResponse, dashboards []Dashboard, capacity FolderCapacity) []FolderStat {
+	stats := map[string]*FolderStat{}
+	stat := func(folder string) *FolderStat {
+		if folder == "" || strings.EqualFold(folder, "General") {
+			folder = "General"
+		}
+		if stats[folder] == nil {
+			stats[folder] = &FolderStat{Folder: folder}
+		}
+		return stats[folder]
+	}
+
+	for _, result := range live {
+		if result.Type == "dash-db" {
+			stat(result.FolderTitle).Dashboards++
+		}
+	}
+	for _, dashboard := range dashboards {
+		folder := stat(dashboard.Folder)
+		folder.Configured++
+		found := false
+		for _, result := range live {
+			if result.Type == "dash-db" && result.Title == dashboard.Name && isSameFolder(folder.Folder, result.FolderTitle) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			folder.Dashboards++
+		}
+	}
+
+	counts := []FolderStat{}
+	for _, folder := range stats {
+		folder.OverLimit = capacity.MaxDashboards > 0 && folder.Dashboards > capacity.MaxDashboards
+		counts = append(counts, *folder)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Dashboards != counts[j].Dashboards {
+			return counts[i].Dashboards > counts[j].Dashboards
+		}
+		return counts[i].Folder < counts[j].Folder
+	})
+	return counts
+}
+
+// checkFolderCapacity warns, or fails when enforced, about the folders that would hold more dashboards than the
+// capacity after the run. Dry runs also put the counts into Report.FolderStats.
+func checkFolderCapacity(client GrafanaAPI, cfg Config, report *Report, log *slog.Logger) error {
+	if !cfg.DryRun && cfg.FolderCapacity.MaxDashboards <= 0 {
+		return nil
+	}
+
+	live, err := client.SearchDashboards()
+	if err != nil {
+		return fmt.Errorf("failed to count the dashboards of the folders: %w", err)
+	}
+	stats := FolderDashboardCounts(live, cfg.Dashboards, cfg.FolderCapacity)
+	if cfg.DryRun {
+		report.FolderStats = stats
+	}
+
+	over := []string{}
+	for _, stat := range stats {
+		if !stat.OverLimit {
+			continue
+		}
+		over = append(over, fmt.Sprintf("'%s' (%d)", stat.Folder, stat.Dashboards))
+		if !cfg.FolderCapacity.Enforce {
+			log.Warn("Folder holds more dashboards than the guideline, consider splitting it into subfolders by team or service",
+				"folder", stat.Folder, "dashboards", stat.Dashboards, "max", cfg.FolderCapacity.MaxDashboards)
+		}
+	}
+	if len(over) > 0 && cfg.FolderCapacity.Enforce {
+		return fmt.Errorf("folders would hold more than %d dashboards: %s, split them into subfolders",
+			cfg.FolderCapacity.MaxDashboards, strings.Join(over, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package grafana
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// gitTagPrefix starts the dashboard tags carrying git metadata, replaced on every import
+const gitTagPrefix = "git-"
+
+// GitMetadata is the provenance of the provisioned dashboards in the repository they are kept in
+type GitMetadata struct {
+	Commit string
+	Branch string // Empty on a detached HEAD
+	Repo   string // Repository path of the origin remote, e.g. org/dashboards
+}
+
+// DetectGitMetadata reads the commit, branch and origin repository of the git work tree at dir.
+// Returns nil if dir isn't inside a git work tree or git isn't installed.
+func DetectGitMetadata(dir string) *GitMetadata {
+	commit, err := gitOutput(dir, "rev-parse", "HEAD")
+	if err != nil {
+		return nil
+	}
+
+	metadata := &GitMetadata{Commit: commit}
+	if branch, err := gitOutput(dir, "rev-parse", "--abbrev-ref", "HEAD"); err == nil && branch != "HEAD" {
+		metadata.Branch = branch
+	}
+	if remote, err := gitOutput(dir, "config", "--get", "remote.origin.url"); err == nil {
+		metadata.Repo = repoPath(remote)
+	}
+	return metadata
+}
+
+// ShortCommit returns the abbreviated commit SHA
+func (metadata *GitMetadata) ShortCommit() string {
+	if len(metadata.Commit) > 12 {
+		return metadata.Commit[:12]
+	}
+	return metadata.Commit
+}
+
+// String renders the metadata for dashboard version messages, e.g. "org/dashboards@main (3f2a9c1d0b7e)"
+func (metadata *GitMetadata) String() string {
+	location := strings.Trim(metadata.Repo+"@"+metadata.Branch, "@")
+	if location == "" {
+		return metadata.ShortCommit()
+	}
+	return fmt.Sprintf("%s (%s)", location, metadata.ShortCommit())
+}
+
+// Tags returns the dashboard tags carrying the metadata
+func (metadata *GitMetadata) Tags() []string {
+	tags := []string{gitTagPrefix + "commit:" + metadata.ShortCommit()}
+	if metadata.Branch != "" {
+		tags = append(tags, gitTagPrefix+"branch:"+metadata.Branch)
+	}
+	if metadata.Repo != "" {
+		tags = append(tags, gitTagPrefix+"repo:"+metadata.Repo)
+	}
+	return tags
+}
+
+// injectGitTags replaces the git metadata tags of the dashboard with the current ones
+func injectGitTags(dashboard DashboardJSON, metadata *GitMetadata) {
+	tags := []interface{}{}
+	if existing, ok := dashboard["tags"].([]interface{}); ok {
+		for _, tag := range existing {
+			if name, ok := tag.(string); ok && strings.HasPrefix(name, gitTagPrefix) {
+				continue
+			}
+			tags = append(tags, tag)
+		}
+	}
+	for _, tag := range metadata.Tags() {
+		tags = append(tags, tag)
+	}
+	dashboard["tags"] = tags
+}
+
+// gitOutput runs a git command in dir and returns its trimmed output
+func gitOutput(dir string, args ...string) (string, error) {
+	command := exec.Command("git", args...)
+	command.Dir = dir
+	output, err := command.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// repoPath extracts org/name from an ssh or https remote URL
+func repoPath(remote string) string {
+	path := strings.TrimSuffix(remote, ".git")
+	if index := strings.Index(path, "://"); index >= 0 {
+		path = path[index+3:]
+		if slash := strings.Index(path, "/"); slash >= 0 {
+			path = path[slash+1:]
+		}
+	} else if colon := strings.Index(path, ":"); colon >= 0 {
+		path = path[colon+1:]
+	}
+	return path
+}
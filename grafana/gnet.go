@@ -0,0 +1,44 @@
+package grafana
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// gnetBaseURL is the grafana.com API serving the public dashboards catalog
+const gnetBaseURL = "https://grafana.com/api/dashboards"
+
+// gnetHTTPClient is used for grafana.com downloads, which are independent of the target Grafana instance
+var gnetHTTPClient = &http.Client{Timeout: 60 * time.Second}
+
+// downloadGnetDashboard downloads the JSON of a grafana.com dashboard revision (0 means the latest one)
+func downloadGnetDashboard(gnetID int, revision int, log *slog.Logger) ([]byte, error) {
+	revisionPath := "latest"
+	if revision > 0 {
+		revisionPath = strconv.Itoa(revision)
+	}
+
+	url := fmt.Sprintf("%s/%d/revisions/%s/download", gnetBaseURL, gnetID, revisionPath)
+	log.Info("Downloading dashboard from grafana.com", "gnet_id", gnetID, "revision", revisionPath)
+
+	resp, err := gnetHTTPClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download grafana.com dashboard %d: %w", gnetID, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read grafana.com dashboard %d: %w", gnetID, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("grafana.com returned status %d for dashboard %d revision %s", resp.StatusCode, gnetID, revisionPath)
+	}
+
+	return data, nil
+}
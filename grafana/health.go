@@ -0,0 +1,165 @@
+package grafana
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// checkDashboardHealth fetches every dashboard imported by the run back from Grafana and records the panels
+// referencing data sources that don't exist. Such dashboards are imported but broken: the run doesn't fail,
+// the problems are added to their report results.
+func checkDashboardHealth(client GrafanaAPI, report *Report, log *slog.Logger) error {
+	dataSources, err := client.GetDataSources()
+	if err != nil {
+		return fmt.Errorf("failed to list data sources for the dashboard health check: %w", err)
+	}
+	known := map[string]bool{}
+	for _, dataSource := range dataSources {
+		known[dataSource.UID] = true
+		known[dataSource.Name] = true
+	}
+
+	broken := 0
+	for i, resource := range report.Resources {
+		if resource.Kind != KindDashboard || resource.UID == "" || resource.Action == ActionSkipped {
+			continue
+		}
+
+		live, err := client.GetDashboardByUID(resource.UID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch dashboard '%s' for the health check: %w", resource.Name, err)
+		}
+
+		problems := missingPanelDataSources(live.Dashboard, known)
+		if len(problems) == 0 {
+			continue
+		}
+
+		broken++
+		report.Resources[i].Problems = problems
+		report.emit(DashboardBroken{Name: resource.Name, UID: resource.UID, Problems: problems})
+		log.Warn("Dashboard imported but broken", "dashboard", resource.Name, "uid", resource.UID, "problems", strings.Join(problems, "; "))
+	}
+
+	log.Info("Dashboard health checked", "broken", broken)
+	return nil
+}
+
+// missingPanelDataSources lists the panels and panel queries, rows included, referencing unknown data sources
+func missingPanelDataSources(dashboard DashboardJSON, known map[string]bool) []string {
+	problems := []string{}
+
+	var walk func(panels []interface{})
+	walk = func(panels []interface{}) {
+		for _, item := range panels {
+			panel, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			title, _ := panel["title"].(string)
+
+			if ref, ok := missingDataSourceRef(panel["datasource"], known); ok {
+				problems = append(problems, fmt.Sprintf("panel '%s' references missing data source '%s'", title, ref))
+			}
+			targets, _ := panel["targets"].([]interface{})
+			for _, target := range targets {
+				query, ok := target.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if ref, ok := missingDataSourceRef(query["datasource"], known); ok {
+					refID, _ := query["refId"].(string)
+					problems = append(problems, fmt.Sprintf("panel '%s' query '%s' references missing data source '%s'", title, refID, ref))
+				}
+			}
+
+			// Collapsed rows keep their panels nested
+			nested, _ := panel["panels"].([]interface{})
+			walk(nested)
+		}
+	}
+	panels, _ := dashboard["panels"].([]interface{})
+	walk(panels)
+
+	sort.Strings(problems)
+	return problems
+}
+
+// missingDataSourceRef returns the referenced data source when it doesn't exist. Empty references use the
+// default data source, template variables and built-in data sources are resolved by Grafana.
+func missingDataSourceRef(ref interface{}, known map[string]bool) (string, bool) {
+	var value string
+	switch typed := ref.(type) {
+	case map[string]interface{}:
+		value, _ = typed["uid"].(string)
+	case string:
+		value = typed
+	}
+
+	if value == "" || strings.HasPrefix(value, "$") || builtinDataSourceUIDs[value] || value == expressionDataSourceUID || known[value] {
+		return "", false
+	}
+	return value, true
+}
+
+// checkDataSourceQueries runs the test query of every data source that has one through Grafana's query API, so a
+// data source Grafana accepts but that can't reach its backend or returns nothing is caught. Like broken
+// dashboards, failing data sources don't fail the run: the problem is added to their report results.
+func checkDataSourceQueries(client GrafanaAPI, cfg Config, report *Report, log *slog.Logger) {
+	for _, dataSource := range cfg.DataSources {
+		if dataSource.TestQuery == "" {
+			continue
+		}
+		for i, resource := range report.Resources {
+			if resource.Kind != KindDataSource || resource.Name != dataSource.Name || resource.UID == "" {
+				continue
+			}
+
+			problem := testDataSourceQuery(client, dataSource, resource.UID)
+			if problem == "" {
+				log.Info("Data source test query passed", "datasource", dataSource.Name, "query", dataSource.TestQuery)
+				continue
+			}
+			report.Resources[i].Problems = append(report.Resources[i].Problems, problem)
+			log.Warn("Data source test query failed", "datasource", dataSource.Name, "query", dataSource.TestQuery, "problem", problem)
+		}
+	}
+}
+
+// testDataSourceQuery runs the test query of the data source and returns the problem found, empty when it
+// returned data
+func testDataSourceQuery(client GrafanaAPI, dataSource DataSource, uid string) string {
+	query := map[string]interface{}{
+		"refId":      "A",
+		"datasource": map[string]interface{}{"uid": uid},
+	}
+	if !isHTTPDataSourceType(dataSource.Type) {
+		// PostgreSQL and the other SQL plugins
+		query["rawSql"] = dataSource.TestQuery
+		query["format"] = "table"
+	} else {
+		// PromQL for Prometheus, LogQL for Loki
+		query["expr"] = dataSource.TestQuery
+		query["instant"] = true
+	}
+
+	response, err := client.QueryDataSources(&DataSourceQueryRequest{Queries: []map[string]interface{}{query}, From: "now-5m", To: "now"})
+	if err != nil {
+		return fmt.Sprintf("test query '%s' failed: %v", dataSource.TestQuery, err)
+	}
+
+	result := response.Results["A"]
+	if result.Error != "" {
+		return fmt.Sprintf("test query '%s' failed: %s", dataSource.TestQuery, result.Error)
+	}
+	rows := 0
+	for _, frame := range result.Frames {
+		rows += frameRows(frame)
+	}
+	if rows == 0 {
+		return fmt.Sprintf("test query '%s' returned no data", dataSource.TestQuery)
+	}
+	return ""
+}
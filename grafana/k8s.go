@@ -0,0 +1,250 @@
+package grafana
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// API backends for dashboards and folders
+const (
+	APIModeAuto   = "auto"   // Use the k8s-style APIs when the Grafana version supports them
+	APIModeLegacy = "legacy" // Always use /api/dashboards/import and /api/folders
+	APIModeK8s    = "k8s"    // Always use the k8s-style apis/dashboard.grafana.app and apis/folder.grafana.app
+)
+
+const (
+	dashboardAPIGroup = "dashboard.grafana.app"
+	folderAPIGroup    = "folder.grafana.app"
+
+	// k8sFieldManager owns the fields applied with server-side apply
+	k8sFieldManager = "grafana-provisioner"
+	// k8sFolderAnnotation places a dashboard or folder into its parent folder
+	k8sFolderAnnotation = "grafana.app/folder"
+	// k8sMinMajorVersion is the first Grafana release serving the k8s-style APIs
+	k8sMinMajorVersion = 11
+)
+
+// k8sBackend holds the resolved k8s-style API versions and the namespace of the current org
+type k8sBackend struct {
+	Namespace        string
+	DashboardVersion string
+	FolderVersion    string
+}
+
+// GetHealth returns the /api/health response, including the Grafana version.
+func (client *ApiClient) GetHealth() (*HealthResponse, error) {
+	resp, err := client.doRequest("GET", client.URL+"/api/health", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get health: %w", err)
+	}
+
+	var health HealthResponse
+	if err := json.Unmarshal(resp, &health); err != nil {
+		return nil, fmt.Errorf("failed to decode health response: %w", err)
+	}
+	return &health, nil
+}
+
+// GetAPIGroup returns the discovery document of a k8s-style API group
+func (client *ApiClient) GetAPIGroup(group string) (*K8sAPIGroup, error) {
+	resp, err := client.doRequestOnce("GET", fmt.Sprintf("%s/apis/%s", client.URL, group))
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover API group '%s': %w", group, err)
+	}
+
+	var apiGroup K8sAPIGroup
+	if err := json.Unmarshal(resp, &apiGroup); err != nil {
+		return nil, fmt.Errorf("failed to decode API group '%s': %w", group, err)
+	}
+	return &apiGroup, nil
+}
+
+// ApplyResource creates or updates a k8s-style resource with server-side apply.
+// Fields set by other managers (e.g. edits in the UI) are taken over, as the import API overwrites them too.
+func (client *ApiClient) ApplyResource(version string, resource string, namespace string, object *K8sObject) (*K8sObject, error) {
+	client.Logger.Info("Applying resource", "kind", object.Kind, "name", object.Metadata.Name, "namespace", namespace)
+
+	endpoint := fmt.Sprintf("%s/apis/%s/namespaces/%s/%s/%s?fieldManager=%s&force=true",
+		client.URL, version, url.PathEscape(namespace), resource, url.PathEscape(object.Metadata.Name), k8sFieldManager)
+
+	// JSON is valid YAML, so the apply patch can be sent as-is
+	data, err := json.Marshal(object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s '%s': %w", object.Kind, object.Metadata.Name, err)
+	}
+
+	headers := map[string]string{"Content-Type": "application/apply-patch+yaml"}
+	resp, err := client.doRequestWithHeaders("PATCH", endpoint, data, headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply %s '%s': %w", object.Kind, object.Metadata.Name, err)
+	}
+
+	var applied K8sObject
+	if err := json.Unmarshal(resp, &applied); err != nil {
+		return nil, fmt.Errorf("failed to decode applied %s '%s': %w", object.Kind, object.Metadata.Name, err)
+	}
+
+	client.Logger.Info("Resource successfully applied", "kind", applied.Kind, "name", applied.Metadata.Name, "generation", applied.Metadata.Generation)
+	return &applied, nil
+}
+
+// resolveAPIBackend decides between the legacy and the k8s-style APIs.
+// Returns nil when the legacy APIs are used.
+func resolveAPIBackend(client GrafanaAPI, mode string, token *TokenInfo, log *slog.Logger) (*k8sBackend, error) {
+	if mode == "" {
+		mode = APIModeAuto
+	}
+	if mode == APIModeLegacy {
+		log.Info("Using legacy dashboard and folder APIs")
+		return nil, nil
+	}
+
+	if mode == APIModeAuto {
+		health, err := client.GetHealth()
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect Grafana version: %w", err)
+		}
+		if parseMajorVersion(health.Version) < k8sMinMajorVersion {
+			log.Info("Grafana version has no k8s-style APIs, using legacy dashboard and folder APIs", "version", health.Version)
+			return nil, nil
+		}
+	}
+
+	dashboardVersion, err := preferredAPIVersion(client, dashboardAPIGroup)
+	if err == nil {
+		var folderVersion string
+		folderVersion, err = preferredAPIVersion(client, folderAPIGroup)
+		if err == nil {
+			backend := &k8sBackend{
+				Namespace:        orgNamespace(token.OrgID),
+				DashboardVersion: dashboardVersion,
+				FolderVersion:    folderVersion,
+			}
+			log.Info("Using k8s-style dashboard and folder APIs", "dashboards", dashboardVersion, "folders", folderVersion, "namespace", backend.Namespace)
+			return backend, nil
+		}
+	}
+
+	// The APIs may be disabled by feature toggles even on a recent version
+	if mode == APIModeAuto {
+		log.Warn("k8s-style APIs are not available, using legacy dashboard and folder APIs", "error", err)
+		return nil, nil
+	}
+	return nil, err
+}
+
+// preferredAPIVersion returns the "group/version" preferred by the server for the API group
+func preferredAPIVersion(client GrafanaAPI, group string) (string, error) {
+	apiGroup, err := client.GetAPIGroup(group)
+	if err != nil {
+		return "", err
+	}
+	if apiGroup.PreferredVersion.GroupVersion == "" {
+		return "", fmt.Errorf("API group '%s' has no preferred version", group)
+	}
+	return apiGroup.PreferredVersion.GroupVersion, nil
+}
+
+// parseMajorVersion returns the major part of a Grafana version such as "11.3.0" or "12.0.0-pre", 0 if unknown
+func parseMajorVersion(version string) int {
+	major, _, _ := strings.Cut(version, ".")
+	value, err := strconv.Atoi(major)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// orgNamespace returns the k8s namespace Grafana serves an org's resources in
+func orgNamespace(orgID int) string {
+	if orgID <= 1 {
+		return "default"
+	}
+	return fmt.Sprintf("org-%d", orgID)
+}
+
+// generateUID derives a stable resource name, so repeated runs apply to the same object
+func generateUID(parts ...string) string {
+	sum := sha1.Sum([]byte(strings.Join(parts, "/")))
+	return "gp" + hex.EncodeToString(sum[:])[:12]
+}
+
+// applyFolder creates the folder with the k8s-style API unless a folder with the title already exists
+func applyFolder(client GrafanaAPI, backend *k8sBackend, title string) (*FolderResponse, error) {
+	folders, err := client.GetFolders()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch folders list: %w", err)
+	}
+	for _, folder := range folders {
+		if folder.Title == title {
+			return &folder, nil
+		}
+	}
+
+	folder := &K8sObject{
+		APIVersion: backend.FolderVersion,
+		Kind:       "Folder",
+		Metadata:   K8sObjectMeta{Name: generateUID("folder", title)},
+		Spec:       map[string]interface{}{"title": title},
+	}
+
+	applied, err := client.ApplyResource(backend.FolderVersion, "folders", backend.Namespace, folder)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FolderResponse{
+		UID:   applied.Metadata.Name,
+		Title: title,
+		URL:   "/dashboards/f/" + applied.Metadata.Name + "/",
+	}, nil
+}
+
+// applyDashboard saves the prepared dashboard with the k8s-style API.
+// The inputs are rendered on the client side since the apply API doesn't process __inputs.
+func applyDashboard(client GrafanaAPI, backend *k8sBackend, prepared *preparedDashboard) (*DashboardImportResponse, error) {
+	name := prepared.Existing.UID
+	if name == "" {
+		name, _ = prepared.Request.Dashboard["uid"].(string)
+	}
+	if name == "" {
+		name = generateUID("dashboard", prepared.Config.Folder, prepared.Config.Name)
+	}
+
+	spec := renderDashboardInputs(prepared.Request.Dashboard, prepared.InputValues)
+	delete(spec, "__inputs")
+	delete(spec, "__requires")
+	delete(spec, "id")
+	spec["uid"] = name
+
+	metadata := K8sObjectMeta{Name: name}
+	if prepared.Request.FolderUID != "" {
+		metadata.Annotations = map[string]string{k8sFolderAnnotation: prepared.Request.FolderUID}
+	}
+
+	dashboard := &K8sObject{
+		APIVersion: backend.DashboardVersion,
+		Kind:       "Dashboard",
+		Metadata:   metadata,
+		Spec:       spec,
+	}
+
+	applied, err := client.ApplyResource(backend.DashboardVersion, "dashboards", backend.Namespace, dashboard)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DashboardImportResponse{
+		UID:         applied.Metadata.Name,
+		Title:       prepared.Config.Name,
+		Imported:    true,
+		ImportedURL: "/d/" + applied.Metadata.Name,
+		FolderUID:   prepared.Request.FolderUID,
+	}, nil
+}
@@ -0,0 +1,195 @@
+package grafana
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Label selector operators
+const (
+	selectorEquals    = "="
+	selectorNotEquals = "!="
+	selectorExists    = "exists"
+	selectorNotExists = "!exists"
+)
+
+// Selector is a comma-separated list of label requirements that must all hold, e.g. team=payments,tier!=dev.
+// A bare key requires the label to be set, !key requires it to be unset.
+type Selector []labelRequirement
+
+// labelRequirement is a single requirement of a selector
+type labelRequirement struct {
+	Key      string
+	Operator string
+	Value    string
+}
+
+// ParseSelector parses a label selector, an empty one matches everything
+func ParseSelector(selector string) (Selector, error) {
+	requirements := Selector{}
+	for _, part := range strings.Split(selector, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		requirement := labelRequirement{}
+		switch {
+		case strings.Contains(part, "!="):
+			key, value, _ := strings.Cut(part, "!=")
+			requirement = labelRequirement{Key: key, Operator: selectorNotEquals, Value: value}
+		case strings.Contains(part, "="):
+			key, value, _ := strings.Cut(part, "=")
+			requirement = labelRequirement{Key: key, Operator: selectorEquals, Value: value}
+		case strings.HasPrefix(part, "!"):
+			requirement = labelRequirement{Key: part[1:], Operator: selectorNotExists}
+		default:
+			requirement = labelRequirement{Key: part, Operator: selectorExists}
+		}
+
+		// Label keys are lowercased by the config loader
+		requirement.Key = strings.ToLower(strings.TrimSpace(requirement.Key))
+		requirement.Value = strings.TrimSpace(requirement.Value)
+		if requirement.Key == "" {
+			return nil, fmt.Errorf("invalid label selector '%s': requirement '%s' has no key", selector, part)
+		}
+		requirements = append(requirements, requirement)
+	}
+	return requirements, nil
+}
+
+// Matches reports whether the labels satisfy every requirement of the selector
+func (selector Selector) Matches(labels map[string]string) bool {
+	for _, requirement := range selector {
+		value, ok := labels[requirement.Key]
+		switch requirement.Operator {
+		case selectorEquals:
+			if !ok || value != requirement.Value {
+				return false
+			}
+		case selectorNotEquals:
+			if ok && value == requirement.Value {
+				return false
+			}
+		case selectorExists:
+			if !ok {
+				return false
+			}
+		case selectorNotExists:
+			if ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// String formats the selector the way it is parsed
+func (selector Selector) String() string {
+	parts := []string{}
+	for _, requirement := range selector {
+		switch requirement.Operator {
+		case selectorExists:
+			parts = append(parts, requirement.Key)
+		case selectorNotExists:
+			parts = append(parts, "!"+requirement.Key)
+		default:
+			parts = append(parts, requirement.Key+requirement.Operator+requirement.Value)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// DashboardLabels returns the labels of the dashboard: the labels of its folder overridden by its own
+func (cfg Config) DashboardLabels(dashboard Dashboard) map[string]string {
+	labels := map[string]string{}
+	for _, folder := range cfg.Folders {
+		if isSameFolder(dashboard.Folder, folder.Name) {
+			for key, value := range folder.Labels {
+				labels[key] = value
+			}
+		}
+	}
+	for key, value := range dashboard.Labels {
+		labels[key] = value
+	}
+	return labels
+}
+
+// SelectResources narrows the config to the data sources, folders and dashboards matching the selector, so a run,
+// a verify or a dedupe only touches them. The folders holding selected dashboards are kept, and so are the
+// Grafana-managed rule groups of the kept folders. Resources without labels, e.g. teams, orgs, ruler rule groups
+// and notification policies, are left out of a selected run.
+func SelectResources(cfg Config, selector Selector, log *slog.Logger) Config {
+	if len(selector) == 0 {
+		return cfg
+	}
+
+	dashboards := []Dashboard{}
+	for _, dashboard := range cfg.Dashboards {
+		if selector.Matches(cfg.DashboardLabels(dashboard)) {
+			dashboards = append(dashboards, dashboard)
+		}
+	}
+
+	folders := []Folder{}
+	for _, folder := range cfg.Folders {
+		keep := selector.Matches(folder.Labels)
+		for _, dashboard := range dashboards {
+			keep = keep || isSameFolder(dashboard.Folder, folder.Name)
+		}
+		if keep {
+			folders = append(folders, folder)
+		}
+	}
+
+	dataSources := []DataSource{}
+	for _, dataSource := range cfg.DataSources {
+		if selector.Matches(dataSource.Labels) {
+			dataSources = append(dataSources, dataSource)
+		}
+	}
+
+	ruleGroups := []AlertRuleGroup{}
+	for _, group := range cfg.AlertRuleGroups {
+		for _, folder := range folders {
+			if group.Ruler == "" && isSameFolder(group.Folder, folder.Name) {
+				ruleGroups = append(ruleGroups, group)
+				break
+			}
+		}
+	}
+
+	log.Info("Resources selected by labels", "selector", selector.String(),
+		"datasources", len(dataSources), "folders", len(folders), "dashboards", len(dashboards), "rule_groups", len(ruleGroups))
+
+	cfg.Dashboards = dashboards
+	cfg.Folders = folders
+	cfg.DataSources = dataSources
+	cfg.AlertRuleGroups = ruleGroups
+	cfg.Orgs = nil
+	cfg.Teams = nil
+	cfg.ContactPoints = nil
+	cfg.NotificationPolicies = nil
+	// Everything left out would be pruned
+	if cfg.Prune {
+		log.Warn("Pruning is disabled for runs with a label selector")
+		cfg.Prune = false
+	}
+	return cfg
+}
+
+// CountsByLabel counts the resources of the report by the value of the label and by action. Resources without
+// the label are counted under the empty value.
+func (report *Report) CountsByLabel(key string) map[string]map[string]int {
+	counts := map[string]map[string]int{}
+	for _, resource := range report.Resources {
+		value := resource.Labels[key]
+		if counts[value] == nil {
+			counts[value] = map[string]int{}
+		}
+		counts[value][resource.Action]++
+	}
+	return counts
+}
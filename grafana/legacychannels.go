@@ -0,0 +1,101 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// LegacyNotificationChannel is a notification channel of the legacy alerting, removed in Grafana 11
+type LegacyNotificationChannel struct {
+	ID                    int                    `json:"id"`
+	UID                   string                 `json:"uid"`
+	Name                  string                 `json:"name"`
+	Type                  string                 `json:"type"`
+	IsDefault             bool                   `json:"isDefault"`
+	SendReminder          bool                   `json:"sendReminder"`
+	Frequency             string                 `json:"frequency"`
+	DisableResolveMessage bool                   `json:"disableResolveMessage"`
+	Settings              map[string]interface{} `json:"settings"`
+	SecureFields          map[string]bool        `json:"secureFields"` // Secure settings that are set, their values are never returned
+}
+
+// unsupportedChannelTypes are the legacy channel types without a unified alerting integration
+var unsupportedChannelTypes = map[string]bool{
+	"hipchat": true,
+	"sensu":   true,
+}
+
+// envNameChars matches the characters replaced in the environment placeholders of secure settings
+var envNameChars = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// GetLegacyNotificationChannels returns the notification channels of a Grafana with legacy alerting
+func (client *ApiClient) GetLegacyNotificationChannels() ([]LegacyNotificationChannel, error) {
+	body, err := client.doRequest("GET", client.URL+"/api/alert-notifications", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list legacy notification channels: %w", err)
+	}
+
+	var channels []LegacyNotificationChannel
+	if err := json.Unmarshal(body, &channels); err != nil {
+		return nil, fmt.Errorf("failed to decode legacy notification channels: %w", err)
+	}
+	return channels, nil
+}
+
+// MigrateNotificationChannels converts legacy notification channels into contact points with the same name,
+// UID, type and settings. Secure settings can't be read back, they become `${CONTACT_<NAME>_<SETTING>}`
+// environment placeholders to set before provisioning. Returns the notes on what couldn't be carried over.
+func MigrateNotificationChannels(channels []LegacyNotificationChannel) ([]ContactPoint, []string) {
+	contactPoints := []ContactPoint{}
+	notes := []string{}
+	for _, channel := range channels {
+		if unsupportedChannelTypes[channel.Type] {
+			notes = append(notes, fmt.Sprintf("channel '%s': type '%s' has no contact point integration, skipped", channel.Name, channel.Type))
+			continue
+		}
+
+		settings := map[string]interface{}{}
+		for key, value := range channel.Settings {
+			settings[key] = value
+		}
+		secure := []string{}
+		for key, set := range channel.SecureFields {
+			if set {
+				secure = append(secure, key)
+			}
+		}
+		sort.Strings(secure)
+		for _, key := range secure {
+			placeholder := secretPlaceholder(channel.Name, key)
+			settings[key] = "${" + placeholder + "}"
+			notes = append(notes, fmt.Sprintf("channel '%s': secure setting '%s' can't be exported, set %s", channel.Name, key, placeholder))
+		}
+
+		if channel.IsDefault {
+			notes = append(notes, fmt.Sprintf("channel '%s' was the default channel, make it the root receiver of alerting.policies", channel.Name))
+		}
+		if channel.SendReminder {
+			notes = append(notes, fmt.Sprintf("channel '%s' sent reminders every %s, use the repeat_interval of its notification policy", channel.Name, channel.Frequency))
+		}
+
+		contactPoints = append(contactPoints, ContactPoint{
+			UID:                   channel.UID,
+			Name:                  channel.Name,
+			Type:                  channel.Type,
+			Settings:              settings,
+			DisableResolveMessage: channel.DisableResolveMessage,
+		})
+	}
+	return contactPoints, notes
+}
+
+// secretPlaceholder returns the environment variable name of a secure contact point setting
+func secretPlaceholder(name string, key string) string {
+	sanitize := func(value string) string {
+		return strings.Trim(envNameChars.ReplaceAllString(strings.ToUpper(value), "_"), "_")
+	}
+	return "CONTACT_" + sanitize(name) + "_" + sanitize(key)
+}
@@ -0,0 +1,129 @@
+package grafana
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// dashboardLinkPattern matches `${dashboard:NAME}` placeholders, replaced with the URL of the configured dashboard
+// of that name, e.g. in dashboard links and panel data links used for drilldowns
+var dashboardLinkPattern = regexp.MustCompile(`\$\{dashboard:([^}]+)\}`)
+
+// dashboardLinkTargets returns the names of the dashboards linked to by placeholders, each once
+func dashboardLinkTargets(dashboard DashboardJSON) []string {
+	targets := []string{}
+	seen := map[string]bool{}
+	var walk func(value interface{})
+	walk = func(value interface{}) {
+		switch typed := value.(type) {
+		case map[string]interface{}:
+			for _, item := range typed {
+				walk(item)
+			}
+		case []interface{}:
+			for _, item := range typed {
+				walk(item)
+			}
+		case string:
+			for _, match := range dashboardLinkPattern.FindAllStringSubmatch(typed, -1) {
+				if !seen[match[1]] {
+					seen[match[1]] = true
+					targets = append(targets, match[1])
+				}
+			}
+		}
+	}
+	walk(map[string]interface{}(dashboard))
+	return targets
+}
+
+// orderDashboardsByLinks orders the prepared dashboards so new dashboards, whose UID Grafana assigns, are applied
+// before the dashboards linking to them, in the config order otherwise. New dashboards linking to each other in a
+// cycle, or to themselves, get a stable generated UID up front instead.
+func orderDashboardsByLinks(prepared []*preparedDashboard, log *slog.Logger) ([]*preparedDashboard, error) {
+	byName := map[string]*preparedDashboard{}
+	for _, dashboard := range prepared {
+		byName[dashboard.Config.Name] = dashboard
+	}
+
+	targets := map[*preparedDashboard][]*preparedDashboard{}
+	for _, dashboard := range prepared {
+		for _, name := range dashboardLinkTargets(dashboard.Request.Dashboard) {
+			target, ok := byName[name]
+			if !ok {
+				return nil, fmt.Errorf("dashboard '%s' links to dashboard '%s', which is not configured", dashboard.Config.Name, name)
+			}
+			targets[dashboard] = append(targets[dashboard], target)
+		}
+	}
+
+	ordered := []*preparedDashboard{}
+	done := map[*preparedDashboard]bool{}
+	for len(ordered) < len(prepared) {
+		progressed := false
+		for _, dashboard := range prepared {
+			if done[dashboard] {
+				continue
+			}
+			ready := true
+			for _, target := range targets[dashboard] {
+				ready = ready && (done[target] || dashboardUID(target) != "")
+			}
+			if ready {
+				ordered = append(ordered, dashboard)
+				done[dashboard] = true
+				progressed = true
+			}
+		}
+		if progressed {
+			continue
+		}
+
+		// A cycle: the first remaining dashboard goes next with the UIDs of its targets assigned
+		for _, dashboard := range prepared {
+			if done[dashboard] {
+				continue
+			}
+			for _, target := range targets[dashboard] {
+				if !done[target] && dashboardUID(target) == "" {
+					uid := generateUID("dashboard", target.Config.Folder, target.Config.Name)
+					target.Request.Dashboard["uid"] = uid
+					log.Info("Dashboards link to each other, assigning the UID of the link target up front",
+						"dashboard", dashboard.Config.Name, "target", target.Config.Name, "uid", uid)
+				}
+			}
+			ordered = append(ordered, dashboard)
+			done[dashboard] = true
+			break
+		}
+	}
+	return ordered, nil
+}
+
+// dashboardUID returns the UID the prepared dashboard will be applied with, empty if Grafana assigns it
+func dashboardUID(prepared *preparedDashboard) string {
+	uid, _ := prepared.Request.Dashboard["uid"].(string)
+	return uid
+}
+
+// renderDashboardLinks replaces the `${dashboard:NAME}` placeholders of the dashboard with the URLs of the
+// dashboards, by their names in the config
+func renderDashboardLinks(dashboard DashboardJSON, uids map[string]string) (DashboardJSON, error) {
+	targets := dashboardLinkTargets(dashboard)
+	if len(targets) == 0 {
+		return dashboard, nil
+	}
+
+	replacements := []string{}
+	for _, name := range targets {
+		uid := uids[name]
+		if uid == "" {
+			return nil, fmt.Errorf("the UID of the linked dashboard '%s' is not known yet", name)
+		}
+		replacements = append(replacements, "${dashboard:"+name+"}", "/d/"+uid)
+	}
+	rendered, _ := renderValue(map[string]interface{}(dashboard), strings.NewReplacer(replacements...)).(map[string]interface{})
+	return rendered, nil
+}
@@ -0,0 +1,203 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Bounds of the server snapshots kept by the monitor
+const (
+	serverSnapshotLimit  = 30
+	serverContextHorizon = time.Minute // Snapshots older than this before a failed call are not attached
+)
+
+// serverStatsKeys are the /api/admin/stats counters attached to failed calls when they changed
+var serverStatsKeys = []string{"dashboards", "datasources", "alerts", "activeSessions", "activeUsers"}
+
+// ServerSnapshot is the server state observed by one poll of the monitor
+type ServerSnapshot struct {
+	At       time.Time
+	Latency  time.Duration
+	Database string         // Health of the Grafana database, "ok" when healthy
+	Err      string         // Set when /api/health couldn't be reached
+	Stats    map[string]int // Selected admin stats, nil without server admin permissions
+}
+
+// ServerMonitor polls /api/health and /api/admin/stats while a run is in progress, so failed API calls,
+// e.g. opaque 500s during imports, carry what the server looked like around the failure.
+type ServerMonitor struct {
+	client    *ApiClient
+	interval  time.Duration
+	mu        sync.Mutex
+	snapshots []ServerSnapshot
+	noStats   bool // The admin stats were refused, they are not polled again
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// StartServerMonitor starts polling the server every interval and attaches the observations to the 5xx errors
+// returned by the client. The returned function stops the polling.
+func (client *ApiClient) StartServerMonitor(interval time.Duration) func() {
+	// The monitor polls through its own copy of the client, so its failures are not annotated themselves
+	pollingClient := *client
+	pollingClient.Monitor = nil
+	pollingClient.Logger = client.Logger.With("component", "server-monitor")
+
+	monitor := &ServerMonitor{
+		client:   &pollingClient,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	client.Monitor = monitor
+	client.Logger.Info("Live server monitoring enabled", "interval", interval)
+
+	go monitor.run()
+	return func() {
+		close(monitor.stop)
+		<-monitor.done
+	}
+}
+
+// run polls until stopped
+func (monitor *ServerMonitor) run() {
+	defer close(monitor.done)
+
+	ticker := time.NewTicker(monitor.interval)
+	defer ticker.Stop()
+	for {
+		monitor.poll()
+		select {
+		case <-monitor.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll records one snapshot of the server health and admin stats
+func (monitor *ServerMonitor) poll() {
+	snapshot := ServerSnapshot{At: time.Now()}
+
+	body, err := monitor.client.doRequestOnce("GET", monitor.client.URL+"/api/health")
+	snapshot.Latency = time.Since(snapshot.At)
+	if err != nil {
+		snapshot.Err = err.Error()
+	} else {
+		var health HealthResponse
+		if err := json.Unmarshal(body, &health); err == nil {
+			snapshot.Database = health.Database
+		}
+	}
+
+	if !monitor.noStats && snapshot.Err == "" {
+		snapshot.Stats, err = monitor.adminStats()
+		if err != nil {
+			monitor.client.Logger.Debug("Admin stats not available, polling the health only", "error", err)
+			monitor.noStats = true
+		}
+	}
+
+	monitor.mu.Lock()
+	defer monitor.mu.Unlock()
+	monitor.snapshots = append(monitor.snapshots, snapshot)
+	if len(monitor.snapshots) > serverSnapshotLimit {
+		monitor.snapshots = monitor.snapshots[len(monitor.snapshots)-serverSnapshotLimit:]
+	}
+}
+
+// adminStats fetches the selected server counters, needs server admin permissions
+func (monitor *ServerMonitor) adminStats() (map[string]int, error) {
+	body, err := monitor.client.doRequestOnce("GET", monitor.client.URL+"/api/admin/stats")
+	if err != nil {
+		return nil, err
+	}
+
+	var stats map[string]interface{}
+	if err := json.Unmarshal(body, &stats); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal admin stats: %w", err)
+	}
+
+	selected := map[string]int{}
+	for _, key := range serverStatsKeys {
+		if value, ok := stats[key].(float64); ok {
+			selected[key] = int(value)
+		}
+	}
+	return selected, nil
+}
+
+// Context describes the server state from shortly before the call started until now: failed or unhealthy polls,
+// the slowest health check and the admin stats that changed. Empty when nothing was observed.
+func (monitor *ServerMonitor) Context(callStarted time.Time) string {
+	monitor.mu.Lock()
+	defer monitor.mu.Unlock()
+
+	var observed []ServerSnapshot
+	for _, snapshot := range monitor.snapshots {
+		if snapshot.At.After(callStarted.Add(-serverContextHorizon)) {
+			observed = append(observed, snapshot)
+		}
+	}
+	if len(observed) == 0 {
+		return ""
+	}
+
+	// Unhealthy polls are grouped by what was observed, keeping the first occurrence
+	facts := []string{}
+	counts := map[string]int{}
+	firstSeen := map[string]time.Time{}
+	order := []string{}
+	slowest := observed[0]
+	for _, snapshot := range observed {
+		observation := ""
+		switch {
+		case snapshot.Err != "":
+			observation = "health unreachable (" + snapshot.Err + ")"
+		case snapshot.Database != "" && snapshot.Database != "ok":
+			observation = fmt.Sprintf("database '%s'", snapshot.Database)
+		}
+		if observation != "" {
+			if counts[observation] == 0 {
+				order = append(order, observation)
+				firstSeen[observation] = snapshot.At
+			}
+			counts[observation]++
+		}
+		if snapshot.Latency > slowest.Latency {
+			slowest = snapshot
+		}
+	}
+	for _, observation := range order {
+		facts = append(facts, fmt.Sprintf("%s in %d of %d polls since %s", observation, counts[observation], len(observed), firstSeen[observation].Format(time.TimeOnly)))
+	}
+	if len(facts) == 0 {
+		facts = append(facts, fmt.Sprintf("health ok in %d polls", len(observed)))
+	}
+	facts = append(facts, fmt.Sprintf("slowest health check %s at %s", slowest.Latency.Round(time.Millisecond), slowest.At.Format(time.TimeOnly)))
+
+	first, last := observed[0].Stats, observed[len(observed)-1].Stats
+	if first != nil && last != nil {
+		for _, key := range serverStatsKeys {
+			if first[key] != last[key] {
+				facts = append(facts, fmt.Sprintf("%s %d→%d", key, first[key], last[key]))
+			}
+		}
+	}
+
+	return strings.Join(facts, ", ")
+}
+
+// annotateServerError attaches the monitor context to server-side (5xx) API errors
+func (client *ApiClient) annotateServerError(apiErr *APIError, callStarted time.Time) {
+	if client.Monitor == nil || apiErr.StatusCode < 500 {
+		return
+	}
+	apiErr.ServerContext = client.Monitor.Context(callStarted)
+	if apiErr.ServerContext != "" {
+		client.Logger.Debug("Server context attached to the API error", "status", apiErr.StatusCode, "server", apiErr.ServerContext)
+	}
+}
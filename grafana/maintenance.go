@@ -0,0 +1,126 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+)
+
+// SetAlertRulePaused pauses or resumes the evaluation of a single Grafana-managed alert rule.
+// The rule is read and written back as raw JSON so fields unknown to the provisioner are kept.
+func (client *ApiClient) SetAlertRulePaused(uid string, paused bool) error {
+	endpoint := fmt.Sprintf("%s/api/v1/provisioning/alert-rules/%s", client.URL, url.PathEscape(uid))
+
+	body, err := client.doRequest("GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get alert rule '%s': %w", uid, err)
+	}
+
+	var rule map[string]interface{}
+	if err := json.Unmarshal(body, &rule); err != nil {
+		return fmt.Errorf("failed to decode alert rule '%s': %w", uid, err)
+	}
+	rule["isPaused"] = paused
+
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert rule: %w", err)
+	}
+
+	if _, err := client.doRequest("PUT", endpoint, data); err != nil {
+		return fmt.Errorf("failed to update alert rule '%s': %w", uid, err)
+	}
+
+	client.Logger.Info("Alert rule pause state updated", "uid", uid, "paused", paused)
+	return nil
+}
+
+// PauseAlerts pauses or resumes all rules of the configured Grafana-managed rule groups
+// and returns the UIDs of the rules whose state was changed.
+func PauseAlerts(client GrafanaAPI, cfg Config, paused bool, log *slog.Logger) ([]string, error) {
+	params := cfg.Grafana.withDefaults()
+	if params.Org != "" {
+		orgID, err := resolveOrgID(client, params.Org, nil)
+		if err != nil {
+			return nil, err
+		}
+		client.UseOrg(orgID)
+	}
+
+	return setManagedAlertRulesPaused(client, cfg, paused, log)
+}
+
+// setManagedAlertRulesPaused changes the pause state of the managed rules not already in that state
+func setManagedAlertRulesPaused(client GrafanaAPI, cfg Config, paused bool, log *slog.Logger) ([]string, error) {
+	rules, err := managedAlertRules(client, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := []string{}
+	for _, rule := range rules {
+		if rule.IsPaused == paused {
+			continue
+		}
+		if err := client.SetAlertRulePaused(rule.UID, paused); err != nil {
+			return changed, err
+		}
+		changed = append(changed, rule.UID)
+	}
+
+	log.Info("Managed alert rules updated", "paused", paused, "changed", len(changed), "managed", len(rules))
+	return changed, nil
+}
+
+// resumeAlertRules resumes the rules paused for the duration of a provisioning run
+func resumeAlertRules(client GrafanaAPI, uids []string, log *slog.Logger) error {
+	for _, uid := range uids {
+		if err := client.SetAlertRulePaused(uid, false); err != nil {
+			return err
+		}
+	}
+
+	log.Info("Alert rules paused for provisioning resumed", "count", len(uids))
+	return nil
+}
+
+// managedAlertRules returns the live rules belonging to the configured Grafana-managed rule groups.
+// Rule groups pushed to Mimir/Loki rulers can't be paused and are left out.
+func managedAlertRules(client GrafanaAPI, cfg Config) ([]ProvisionedAlertRule, error) {
+	folders, err := client.GetFolders()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+	folderTitles := map[string]string{}
+	for _, folder := range folders {
+		folderTitles[folder.UID] = folder.Title
+	}
+
+	groups := map[string]bool{}
+	for _, group := range cfg.AlertRuleGroups {
+		if group.Ruler == "" {
+			groups[group.Folder+"/"+group.Name] = true
+		}
+	}
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	rules, err := client.GetAlertRules()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list existing alert rules: %w", err)
+	}
+
+	managed := []ProvisionedAlertRule{}
+	for _, rule := range rules {
+		folder, ok := folderTitles[rule.FolderUID]
+		if !ok {
+			continue
+		}
+		if groups[folder+"/"+rule.RuleGroup] {
+			managed = append(managed, rule)
+		}
+	}
+	return managed, nil
+}
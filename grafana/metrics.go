@@ -0,0 +1,256 @@
+package grafana
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultMetricsJob is the job label of the pushed metrics when none is configured
+const defaultMetricsJob = "grafana-provisioner"
+
+// MetricsPush configures where the metrics of a run are pushed, so one-shot CI runs are observable fleet-wide
+type MetricsPush struct {
+	Pushgateway string            // Prometheus Pushgateway base URL, the run replaces the metrics of its group
+	RemoteWrite string            // Prometheus remote-write endpoint, e.g. Grafana Cloud's /api/prom/push
+	Job         string            // Job label, defaults to grafana-provisioner
+	Username    string            // Basic auth, e.g. the Grafana Cloud instance ID
+	Password    string            // Basic auth password or API token
+	Labels      map[string]string // Added to every series and to the Pushgateway grouping key
+}
+
+// enabled reports whether any push target is configured
+func (push MetricsPush) enabled() bool {
+	return push.Pushgateway != "" || push.RemoteWrite != ""
+}
+
+// runMetric is a single sample describing the run
+type runMetric struct {
+	Name   string
+	Help   string
+	Labels map[string]string
+	Value  float64
+}
+
+// pushRunMetrics pushes the metrics of the run to the configured targets, failed runs included
+func pushRunMetrics(cfg Config, report *Report, runErr error, log *slog.Logger) error {
+	push := cfg.Metrics
+	if push.Job == "" {
+		push.Job = defaultMetricsJob
+	}
+
+	// The Grafana instance tells the runs of a batch apart
+	labels := map[string]string{}
+	if grafanaURL, err := url.Parse(cfg.Grafana.URL); err == nil && grafanaURL.Host != "" {
+		labels["instance"] = grafanaURL.Host
+	}
+	// The shards of a config push their own group
+	if cfg.Shard != nil {
+		labels["shard"] = strconv.Itoa(cfg.Shard.Index)
+	}
+	for key, value := range push.Labels {
+		labels[key] = value
+	}
+
+	metrics := buildRunMetrics(report, runErr)
+	httpClient := &http.Client{Timeout: cfg.Grafana.withDefaults().Timeout}
+
+	if push.Pushgateway != "" {
+		if err := pushToGateway(httpClient, push, labels, metrics); err != nil {
+			return fmt.Errorf("failed to push metrics to the Pushgateway: %w", err)
+		}
+		log.Info("Run metrics pushed to the Pushgateway", "url", push.Pushgateway, "job", push.Job)
+	}
+
+	if push.RemoteWrite != "" {
+		if err := pushRemoteWrite(httpClient, push, labels, metrics, report.FinishedAt); err != nil {
+			return fmt.Errorf("failed to remote-write metrics: %w", err)
+		}
+		log.Info("Run metrics remote-written", "url", push.RemoteWrite, "job", push.Job)
+	}
+	return nil
+}
+
+// buildRunMetrics describes the outcome, duration and resource counts of the run
+func buildRunMetrics(report *Report, runErr error) []runMetric {
+	success := 1.0
+	if runErr != nil {
+		success = 0
+	}
+
+	metrics := []runMetric{
+		{Name: "grafana_provisioner_run_success", Help: "Whether the last provisioning run succeeded.", Value: success},
+		{Name: "grafana_provisioner_run_duration_seconds", Help: "Duration of the last provisioning run.", Value: report.FinishedAt.Sub(report.StartedAt).Seconds()},
+		{Name: "grafana_provisioner_run_timestamp_seconds", Help: "Time the last provisioning run finished.", Value: float64(report.FinishedAt.UnixMilli()) / 1000},
+		{Name: "grafana_provisioner_broken_resources", Help: "Resources provisioned with problems by the last run.", Value: float64(len(report.Broken()))},
+		{Name: "grafana_provisioner_build_info", Help: "Version of the provisioner of the last run.", Labels: map[string]string{"version": report.ToolVersion}, Value: 1},
+	}
+
+	counts := map[[2]string]int{}
+	for _, resource := range report.Resources {
+		counts[[2]string{resource.Kind, resource.Action}]++
+	}
+	keys := [][2]string{}
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	for _, key := range keys {
+		metrics = append(metrics, runMetric{
+			Name:   "grafana_provisioner_resources",
+			Help:   "Resources handled by the last run by kind and action.",
+			Labels: map[string]string{"kind": key[0], "action": key[1]},
+			Value:  float64(counts[key]),
+		})
+	}
+	return metrics
+}
+
+// pushToGateway replaces the metrics of the job and labels group on the Pushgateway with the text format
+func pushToGateway(httpClient *http.Client, push MetricsPush, labels map[string]string, metrics []runMetric) error {
+	path := "/metrics/job/" + url.PathEscape(push.Job)
+	for _, key := range sortedKeys(labels) {
+		path += "/" + url.PathEscape(key) + "/" + url.PathEscape(labels[key])
+	}
+
+	var body bytes.Buffer
+	written := map[string]bool{}
+	for _, metric := range metrics {
+		if !written[metric.Name] {
+			written[metric.Name] = true
+			fmt.Fprintf(&body, "# HELP %s %s\n# TYPE %s gauge\n", metric.Name, metric.Help, metric.Name)
+		}
+		body.WriteString(metric.Name)
+		if len(metric.Labels) > 0 {
+			pairs := []string{}
+			for _, key := range sortedKeys(metric.Labels) {
+				pairs = append(pairs, fmt.Sprintf("%s=%q", key, metric.Labels[key]))
+			}
+			body.WriteString("{" + strings.Join(pairs, ",") + "}")
+		}
+		fmt.Fprintf(&body, " %g\n", metric.Value)
+	}
+
+	return sendMetrics(httpClient, "PUT", strings.TrimRight(push.Pushgateway, "/")+path, push, &body, map[string]string{
+		"Content-Type": "text/plain; version=0.0.4",
+	})
+}
+
+// pushRemoteWrite sends the metrics as one sample per series with the remote-write protocol
+func pushRemoteWrite(httpClient *http.Client, push MetricsPush, labels map[string]string, metrics []runMetric, at time.Time) error {
+	var request []byte
+	for _, metric := range metrics {
+		series := map[string]string{"__name__": metric.Name, "job": push.Job}
+		for key, value := range labels {
+			series[key] = value
+		}
+		for key, value := range metric.Labels {
+			series[key] = value
+		}
+
+		// Labels must be sorted by name
+		var timeSeries []byte
+		for _, key := range sortedKeys(series) {
+			var label []byte
+			label = appendProtoBytes(label, 1, []byte(key))
+			label = appendProtoBytes(label, 2, []byte(series[key]))
+			timeSeries = appendProtoBytes(timeSeries, 1, label)
+		}
+
+		var sample []byte
+		sample = binary.AppendUvarint(sample, 1<<3|1) // value, fixed64
+		sample = binary.LittleEndian.AppendUint64(sample, math.Float64bits(metric.Value))
+		sample = binary.AppendUvarint(sample, 2<<3) // timestamp, varint
+		sample = binary.AppendUvarint(sample, uint64(at.UnixMilli()))
+		timeSeries = appendProtoBytes(timeSeries, 2, sample)
+
+		request = appendProtoBytes(request, 1, timeSeries)
+	}
+
+	return sendMetrics(httpClient, "POST", push.RemoteWrite, push, bytes.NewReader(snappyEncode(request)), map[string]string{
+		"Content-Type":                      "application/x-protobuf",
+		"Content-Encoding":                  "snappy",
+		"X-Prometheus-Remote-Write-Version": "0.1.0",
+	})
+}
+
+// sendMetrics sends the metrics request with the basic auth of the push config
+func sendMetrics(httpClient *http.Client, method string, target string, push MetricsPush, body io.Reader, headers map[string]string) error {
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if push.Username != "" || push.Password != "" {
+		req.SetBasicAuth(push.Username, push.Password)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s returned %d: %s", target, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// appendProtoBytes appends a length-delimited protobuf field
+func appendProtoBytes(data []byte, field int, value []byte) []byte {
+	data = binary.AppendUvarint(data, uint64(field)<<3|2)
+	data = binary.AppendUvarint(data, uint64(len(value)))
+	return append(data, value...)
+}
+
+// snappyEncode frames the data as a snappy block of literals. Nothing is compressed, but every remote-write
+// receiver can decode it and the requests of a run are small.
+func snappyEncode(data []byte) []byte {
+	encoded := binary.AppendUvarint(nil, uint64(len(data)))
+	for len(data) > 0 {
+		chunk := data
+		if len(chunk) > 65536 {
+			chunk = chunk[:65536]
+		}
+		length := len(chunk) - 1
+		switch {
+		case length < 60:
+			encoded = append(encoded, byte(length)<<2)
+		case length < 1<<8:
+			encoded = append(encoded, 60<<2, byte(length))
+		default:
+			encoded = append(encoded, 61<<2, byte(length), byte(length>>8))
+		}
+		encoded = append(encoded, chunk...)
+		data = data[len(chunk):]
+	}
+	return encoded
+}
+
+// sortedKeys returns the keys of the map in order
+func sortedKeys(values map[string]string) []string {
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,99 @@
+package grafana
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// provisionOrgDashboards imports the configured dashboards into every further organization marked with
+// Dashboards. Data source names of the imports and annotations go through the org's aliases, so each import
+// pass resolves the org-local data source UIDs. Folders are created in the org without their access settings,
+// teams and service accounts belong to the main org. The results are added to the report prefixed with the org.
+func provisionOrgDashboards(client GrafanaAPI, cfg Config, orgIDs map[string]int, mainOrgID int, report *Report, log *slog.Logger) error {
+	for _, org := range cfg.Orgs {
+		if !org.Dashboards {
+			continue
+		}
+		orgID, err := resolveOrgID(client, org.Name, orgIDs)
+		if err != nil {
+			return err
+		}
+		// The main org already has them
+		if orgID == mainOrgID {
+			continue
+		}
+
+		orgLog := log.With("org", org.Name)
+		// Organizations planned by a dry run don't exist to read their folders and dashboards from
+		if orgID < 0 {
+			orgLog.Info("Dry run: dashboards of the planned organization are not planned")
+			continue
+		}
+		orgLog.Info("Importing dashboards into organization")
+		client.UseOrg(orgID)
+
+		orgCfg := orgDashboardsConfig(cfg, org, orgID)
+		orgReport := &Report{ToolVersion: report.ToolVersion, StartedAt: report.StartedAt, onEvent: report.onEvent, since: report.since}
+		err = provisionFolders(client, &orgCfg, orgReport, orgLog)
+		if err == nil {
+			err = provisionDashboards(client, orgCfg, orgReport, orgLog)
+		}
+		if err == nil {
+			err = checkDashboardHealth(client, orgReport, orgLog)
+		}
+
+		for _, resource := range orgReport.Resources {
+			resource.Name = org.Name + "/" + resource.Name
+			report.Resources = append(report.Resources, resource)
+		}
+		report.since = orgReport.since
+		if err != nil {
+			client.UseOrg(mainOrgID)
+			return fmt.Errorf("dashboard import into organization '%s' failed: %w", org.Name, err)
+		}
+	}
+
+	client.UseOrg(mainOrgID)
+	return nil
+}
+
+// orgDashboardsConfig returns the config of the dashboard import pass of the organization
+func orgDashboardsConfig(cfg Config, org Org, orgID int) Config {
+	alias := func(name string) string {
+		if orgName, ok := org.DataSourceAliases[name]; ok {
+			return orgName
+		}
+		return name
+	}
+
+	orgCfg := cfg
+	orgCfg.FoldersMapping = nil
+	if cfg.k8s != nil {
+		backend := *cfg.k8s
+		backend.Namespace = orgNamespace(orgID)
+		orgCfg.k8s = &backend
+	}
+
+	orgCfg.Folders = []Folder{}
+	for _, folder := range cfg.Folders {
+		orgCfg.Folders = append(orgCfg.Folders, Folder{Name: folder.Name, Labels: folder.Labels})
+	}
+
+	orgCfg.Dashboards = []Dashboard{}
+	for _, dashboard := range cfg.Dashboards {
+		imports := []DashboardImport{}
+		for _, dashboardImport := range dashboard.Imports {
+			imports = append(imports, DashboardImport{Name: dashboardImport.Name, DataSource: alias(dashboardImport.DataSource)})
+		}
+		dashboard.Imports = imports
+		orgCfg.Dashboards = append(orgCfg.Dashboards, dashboard)
+	}
+
+	orgCfg.Annotations = []Annotation{}
+	for _, annotation := range cfg.Annotations {
+		annotation.DataSource = alias(annotation.DataSource)
+		orgCfg.Annotations = append(orgCfg.Annotations, annotation)
+	}
+
+	return orgCfg
+}
@@ -0,0 +1,156 @@
+package grafana
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// defaultOrgRole is the role the provisioning user gets in the organizations it creates
+const defaultOrgRole = "Admin"
+
+// GetOrgByName looks up an organization by name. Returns nil if it doesn't exist.
+func (client *ApiClient) GetOrgByName(name string) (*OrgResponse, error) {
+	resp, err := client.doRequestOnce("GET", fmt.Sprintf("%s/api/orgs/name/%s", client.URL, url.PathEscape(name)))
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get organization '%s': %w", name, err)
+	}
+
+	var org OrgResponse
+	if err := json.Unmarshal(resp, &org); err != nil {
+		return nil, fmt.Errorf("failed to decode organization '%s': %w", name, err)
+	}
+	return &org, nil
+}
+
+// CreateOrg creates a new organization and returns its ID
+func (client *ApiClient) CreateOrg(name string) (int, error) {
+	client.Logger.Info("Creating new organization", "name", name)
+
+	data, err := json.Marshal(map[string]string{"name": name})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal organization model: %w", err)
+	}
+
+	resp, err := client.doRequest("POST", client.URL+"/api/orgs", data)
+	if err != nil {
+		return 0, fmt.Errorf("organization creation failed: %w", err)
+	}
+
+	var response struct {
+		OrgID   int    `json:"orgId"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(resp, &response); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal organization creation response: %w", err)
+	}
+
+	client.Logger.Info("Organization successfully created", "name", name, "id", response.OrgID)
+	return response.OrgID, nil
+}
+
+// AddOrgUser adds an existing user (or the user behind a token) to the organization.
+// Returns false if the user is already a member.
+func (client *ApiClient) AddOrgUser(orgID int, loginOrEmail string, role string) (bool, error) {
+	data, err := json.Marshal(map[string]string{"loginOrEmail": loginOrEmail, "role": role})
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal organization user model: %w", err)
+	}
+
+	_, err = client.doRequest("POST", fmt.Sprintf("%s/api/orgs/%d/users", client.URL, orgID), data)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to add user '%s' to organization %d: %w", loginOrEmail, orgID, err)
+	}
+
+	client.Logger.Info("User added to organization", "login", loginOrEmail, "org_id", orgID, "role", role)
+	return true, nil
+}
+
+// UseOrg makes all following requests act in the given organization (X-Grafana-Org-Id)
+func (client *ApiClient) UseOrg(orgID int) {
+	client.Headers["X-Grafana-Org-Id"] = strconv.Itoa(orgID)
+	client.Logger.Info("Switched to organization", "org_id", orgID)
+}
+
+// provisionOrgs creates the configured organizations and adds the provisioning user to each of them.
+// Returns the organization IDs by name.
+func provisionOrgs(client GrafanaAPI, orgs []Org, token *TokenInfo, report *Report, log *slog.Logger) (map[string]int, error) {
+	orgIDs := map[string]int{}
+	if len(orgs) == 0 {
+		return orgIDs, nil
+	}
+
+	if !token.IsGrafanaAdmin {
+		return nil, fmt.Errorf("creating organizations requires Grafana server admin credentials, '%s' is not a server admin", token.Login)
+	}
+
+	log.Info("Provisioning Grafana organizations")
+	for _, org := range orgs {
+		action := ActionUnchanged
+		existing, err := client.GetOrgByName(org.Name)
+		if err != nil {
+			return nil, report.fail(KindOrg, org.Name, err)
+		}
+
+		var orgID int
+		if existing != nil {
+			orgID = existing.ID
+		} else {
+			orgID, err = client.CreateOrg(org.Name)
+			if err != nil {
+				return nil, report.fail(KindOrg, org.Name, fmt.Errorf("failed to provision organization '%s': %w", org.Name, err))
+			}
+			action = ActionCreated
+		}
+		orgIDs[org.Name] = orgID
+
+		// So the same credentials can provision resources into the org
+		if token.Login != "" {
+			role := org.Role
+			if role == "" {
+				role = defaultOrgRole
+			}
+			if _, err := client.AddOrgUser(orgID, token.Login, role); err != nil {
+				return nil, report.fail(KindOrg, org.Name, err)
+			}
+		}
+
+		report.add(ResourceResult{
+			Kind:   KindOrg,
+			Name:   org.Name,
+			Action: action,
+			UID:    strconv.Itoa(orgID),
+		})
+	}
+
+	log.Info("All configured organizations provisioned.")
+	return orgIDs, nil
+}
+
+// resolveOrgID returns the ID of the organization to provision into
+func resolveOrgID(client GrafanaAPI, name string, orgIDs map[string]int) (int, error) {
+	if orgID, ok := orgIDs[name]; ok {
+		return orgID, nil
+	}
+
+	org, err := client.GetOrgByName(name)
+	if err != nil {
+		return 0, err
+	}
+	if org == nil {
+		return 0, fmt.Errorf("organization '%s' does not exist, add it to 'orgs' to create it", name)
+	}
+	return org.ID, nil
+}
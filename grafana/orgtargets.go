@@ -0,0 +1,128 @@
+package grafana
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+)
+
+// splitOrgTargets moves the data sources and dashboards whose org isn't the one of the run out of the config.
+// Returns their org configs by org name, each with the folders of its dashboards.
+func splitOrgTargets(cfg *Config, mainOrg string) map[string]Config {
+	targets := map[string]Config{}
+	target := func(org string) Config {
+		orgCfg, ok := targets[org]
+		if !ok {
+			orgCfg = *cfg
+			orgCfg.FoldersMapping = nil
+			orgCfg.DataSources = []DataSource{}
+			orgCfg.Folders = []Folder{}
+			orgCfg.Dashboards = []Dashboard{}
+			// Annotations and alerting reference the data sources and folders of the run
+			orgCfg.Annotations = []Annotation{}
+		}
+		return orgCfg
+	}
+	targeted := func(org string) bool {
+		return org != "" && org != mainOrg
+	}
+
+	dataSources := []DataSource{}
+	for _, dataSource := range cfg.DataSources {
+		if !targeted(dataSource.Org) {
+			dataSources = append(dataSources, dataSource)
+			continue
+		}
+		orgCfg := target(dataSource.Org)
+		orgCfg.DataSources = append(orgCfg.DataSources, dataSource)
+		targets[dataSource.Org] = orgCfg
+	}
+	cfg.DataSources = dataSources
+
+	dashboards := []Dashboard{}
+	for _, dashboard := range cfg.Dashboards {
+		if !targeted(dashboard.Org) {
+			dashboards = append(dashboards, dashboard)
+			continue
+		}
+		orgCfg := target(dashboard.Org)
+		orgCfg.Dashboards = append(orgCfg.Dashboards, dashboard)
+		for _, folder := range cfg.Folders {
+			if folder.Name == dashboard.Folder && !hasFolder(orgCfg.Folders, folder.Name) {
+				// Access settings belong to the teams and service accounts of the main org
+				orgCfg.Folders = append(orgCfg.Folders, Folder{Name: folder.Name, Labels: folder.Labels})
+			}
+		}
+		targets[dashboard.Org] = orgCfg
+	}
+	cfg.Dashboards = dashboards
+	return targets
+}
+
+// hasFolder reports whether the folder with the name is in the list
+func hasFolder(folders []Folder, name string) bool {
+	for _, folder := range folders {
+		if folder.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// provisionOrgTargets provisions the data sources, folders and dashboards configured with another org than the
+// one of the run into their organizations, switching with X-Grafana-Org-Id. The results are added to the report
+// prefixed with the org.
+func provisionOrgTargets(client GrafanaAPI, targets map[string]Config, orgIDs map[string]int, mainOrgID int, report *Report, log *slog.Logger) error {
+	orgs := []string{}
+	for org := range targets {
+		orgs = append(orgs, org)
+	}
+	sort.Strings(orgs)
+
+	for _, org := range orgs {
+		orgID, err := resolveOrgID(client, org, orgIDs)
+		if err != nil {
+			return err
+		}
+
+		orgLog := log.With("org", org)
+		// Organizations planned by a dry run don't exist to read their resources from
+		if orgID < 0 {
+			orgLog.Info("Dry run: resources of the planned organization are not planned")
+			continue
+		}
+		orgLog.Info("Provisioning resources into organization")
+		client.UseOrg(orgID)
+
+		orgCfg := targets[org]
+		if orgCfg.k8s != nil {
+			backend := *orgCfg.k8s
+			backend.Namespace = orgNamespace(orgID)
+			orgCfg.k8s = &backend
+		}
+		orgReport := &Report{ToolVersion: report.ToolVersion, StartedAt: report.StartedAt, onEvent: report.onEvent, since: report.since}
+		_, err = provisionDataSources(client, orgCfg, orgReport, orgLog)
+		if err == nil {
+			err = provisionFolders(client, &orgCfg, orgReport, orgLog)
+		}
+		if err == nil {
+			err = provisionDashboards(client, orgCfg, orgReport, orgLog)
+		}
+		if err == nil {
+			err = checkDashboardHealth(client, orgReport, orgLog)
+		}
+
+		for _, resource := range orgReport.Resources {
+			resource.Name = org + "/" + resource.Name
+			report.Resources = append(report.Resources, resource)
+		}
+		report.since = orgReport.since
+		if err != nil {
+			client.UseOrg(mainOrgID)
+			return fmt.Errorf("provisioning into organization '%s' failed: %w", org, err)
+		}
+	}
+
+	client.UseOrg(mainOrgID)
+	return nil
+}
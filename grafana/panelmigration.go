@@ -0,0 +1,307 @@
+package grafana
+
+import (
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// graphOnlyKeys are the options of the graph panel without a meaning for the timeseries panel
+var graphOnlyKeys = []string{
+	"aliasColors", "bars", "dashLength", "dashes", "fill", "fillGradient", "grid", "hiddenSeries", "legend",
+	"lines", "linewidth", "nullPointMode", "percentage", "pointradius", "points", "renderer", "seriesOverrides",
+	"spaceLength", "stack", "steppedLine", "thresholds", "timeRegions", "tooltip", "xaxis", "yaxes", "yaxis",
+}
+
+// graphLegendCalcs maps the legend values of the graph panel to the reducers of the timeseries legend
+var graphLegendCalcs = []struct {
+	option  string
+	reducer string
+}{
+	{"min", "min"}, {"max", "max"}, {"avg", "mean"}, {"current", "lastNotNull"}, {"total", "sum"},
+}
+
+// migrateGraphPanels converts the deprecated graph panels of the dashboard to timeseries panels, mapping the
+// draw style, axes, legend, tooltip, thresholds, series overrides and alias colors. Graph panels with a legacy
+// alert or a series or histogram x-axis have no timeseries equivalent and are kept. Returns the migrated count.
+func migrateGraphPanels(dashboard DashboardJSON, log *slog.Logger) int {
+	migrated := 0
+	walkDashboardPanels(dashboard, func(panel map[string]interface{}) {
+		if panelType, _ := panel["type"].(string); panelType != "graph" {
+			return
+		}
+		title, _ := panel["title"].(string)
+		if _, legacy := panel["alert"]; legacy {
+			log.Warn("Graph panel has a legacy alert, not migrating it", "panel", title)
+			return
+		}
+		xaxis, _ := panel["xaxis"].(map[string]interface{})
+		if mode, _ := xaxis["mode"].(string); mode != "" && mode != "time" {
+			log.Warn("Graph panel has no time x-axis, not migrating it", "panel", title, "mode", mode)
+			return
+		}
+		migrateGraphPanel(panel)
+		migrated++
+	})
+	return migrated
+}
+
+// migrateGraphPanel rewrites the graph panel in place as a timeseries panel
+func migrateGraphPanel(panel map[string]interface{}) {
+	custom := map[string]interface{}{
+		"drawStyle":         "line",
+		"lineInterpolation": "linear",
+		"lineWidth":         numberOption(panel["linewidth"], 1),
+		"fillOpacity":       numberOption(panel["fill"], 0) * 10,
+		"pointSize":         numberOption(panel["pointradius"], 2) * 2,
+		"showPoints":        "never",
+		"spanNulls":         panel["nullPointMode"] == "connected",
+		"axisPlacement":     "auto",
+	}
+	if panel["bars"] == true {
+		custom["drawStyle"] = "bars"
+	} else if panel["lines"] == false && panel["points"] == true {
+		custom["drawStyle"] = "points"
+	}
+	if panel["points"] == true {
+		custom["showPoints"] = "always"
+	}
+	if panel["lines"] == false && panel["bars"] != true {
+		custom["lineWidth"] = 0
+	}
+	if panel["steppedLine"] == true {
+		custom["lineInterpolation"] = "stepAfter"
+	}
+	if numberOption(panel["fillGradient"], 0) > 0 {
+		custom["gradientMode"] = "opacity"
+	}
+	if panel["stack"] == true {
+		mode := "normal"
+		if panel["percentage"] == true {
+			mode = "percent"
+		}
+		custom["stacking"] = map[string]interface{}{"mode": mode, "group": "A"}
+	}
+
+	defaults := map[string]interface{}{}
+	if fieldConfig, ok := panel["fieldConfig"].(map[string]interface{}); ok {
+		if existing, ok := fieldConfig["defaults"].(map[string]interface{}); ok {
+			defaults = existing
+		}
+	}
+	if yaxes, _ := panel["yaxes"].([]interface{}); len(yaxes) > 0 {
+		left, _ := yaxes[0].(map[string]interface{})
+		if format, _ := left["format"].(string); format != "" {
+			defaults["unit"] = format
+		}
+		if label, _ := left["label"].(string); label != "" {
+			custom["axisLabel"] = label
+		}
+		if left["show"] == false {
+			custom["axisPlacement"] = "hidden"
+		}
+		if logBase := numberOption(left["logBase"], 1); logBase > 1 {
+			custom["scaleDistribution"] = map[string]interface{}{"type": "log", "log": logBase}
+		}
+		for _, bound := range []string{"min", "max"} {
+			if value, ok := parseNumberOption(left[bound]); ok {
+				defaults[bound] = value
+			}
+		}
+		if decimals, ok := parseNumberOption(left["decimals"]); ok {
+			defaults["decimals"] = decimals
+		}
+	}
+	if decimals, ok := parseNumberOption(panel["decimals"]); ok {
+		defaults["decimals"] = decimals
+	}
+	if steps, style := graphThresholds(panel["thresholds"]); steps != nil {
+		defaults["thresholds"] = map[string]interface{}{"mode": "absolute", "steps": steps}
+		custom["thresholdsStyle"] = map[string]interface{}{"mode": style}
+	}
+	defaults["custom"] = custom
+
+	overrides := graphSeriesOverrides(panel["seriesOverrides"])
+	overrides = append(overrides, graphAliasColors(panel["aliasColors"])...)
+	panel["fieldConfig"] = map[string]interface{}{"defaults": defaults, "overrides": overrides}
+	panel["options"] = map[string]interface{}{
+		"legend":  graphLegend(panel["legend"]),
+		"tooltip": graphTooltip(panel["tooltip"]),
+	}
+
+	for _, key := range graphOnlyKeys {
+		delete(panel, key)
+	}
+	panel["type"] = "timeseries"
+}
+
+// graphLegend maps the legend of the graph panel to the legend options of the timeseries panel
+func graphLegend(option interface{}) map[string]interface{} {
+	legend, _ := option.(map[string]interface{})
+	options := map[string]interface{}{
+		"showLegend":  legend["show"] != false,
+		"displayMode": "list",
+		"placement":   "bottom",
+		"calcs":       []interface{}{},
+	}
+	if legend["alignAsTable"] == true {
+		options["displayMode"] = "table"
+	}
+	if legend["rightSide"] == true {
+		options["placement"] = "right"
+	}
+	calcs := []interface{}{}
+	for _, calc := range graphLegendCalcs {
+		if legend[calc.option] == true {
+			calcs = append(calcs, calc.reducer)
+		}
+	}
+	options["calcs"] = calcs
+	return options
+}
+
+// graphTooltip maps the tooltip of the graph panel to the tooltip options of the timeseries panel
+func graphTooltip(option interface{}) map[string]interface{} {
+	tooltip, _ := option.(map[string]interface{})
+	options := map[string]interface{}{"mode": "single", "sort": "none"}
+	if tooltip["shared"] == true {
+		options["mode"] = "multi"
+	}
+	switch numberOption(tooltip["sort"], 0) {
+	case 1:
+		options["sort"] = "asc"
+	case 2:
+		options["sort"] = "desc"
+	}
+	return options
+}
+
+// graphThresholds maps the thresholds of the graph panel to threshold steps above a green base and the
+// thresholds style, nil if the panel has none
+func graphThresholds(option interface{}) ([]interface{}, string) {
+	thresholds, _ := option.([]interface{})
+	if len(thresholds) == 0 {
+		return nil, ""
+	}
+	style := "line"
+	values := []float64{}
+	colors := map[float64]string{}
+	for _, item := range thresholds {
+		threshold, _ := item.(map[string]interface{})
+		value, ok := parseNumberOption(threshold["value"])
+		if !ok {
+			continue
+		}
+		values = append(values, value)
+		colors[value] = "red"
+		if colorMode, _ := threshold["colorMode"].(string); colorMode == "warning" {
+			colors[value] = "orange"
+		} else if color, _ := threshold["lineColor"].(string); colorMode == "custom" && color != "" {
+			colors[value] = color
+		}
+		if threshold["fill"] == true {
+			style = "line+area"
+		}
+	}
+	sort.Float64s(values)
+	steps := []interface{}{map[string]interface{}{"color": "green", "value": nil}}
+	for _, value := range values {
+		steps = append(steps, map[string]interface{}{"color": colors[value], "value": value})
+	}
+	return steps, style
+}
+
+// graphSeriesOverrides maps the series overrides of the graph panel to field overrides of the series names
+// or regular expressions they match
+func graphSeriesOverrides(option interface{}) []interface{} {
+	overrides := []interface{}{}
+	seriesOverrides, _ := option.([]interface{})
+	for _, item := range seriesOverrides {
+		seriesOverride, _ := item.(map[string]interface{})
+		alias, _ := seriesOverride["alias"].(string)
+		if alias == "" {
+			continue
+		}
+		properties := []interface{}{}
+		property := func(id string, value interface{}) {
+			properties = append(properties, map[string]interface{}{"id": id, "value": value})
+		}
+		if numberOption(seriesOverride["yaxis"], 1) == 2 {
+			property("custom.axisPlacement", "right")
+		}
+		if color, _ := seriesOverride["color"].(string); color != "" {
+			property("color", map[string]interface{}{"mode": "fixed", "fixedColor": color})
+		}
+		if fill, ok := parseNumberOption(seriesOverride["fill"]); ok {
+			property("custom.fillOpacity", fill*10)
+		}
+		if lineWidth, ok := parseNumberOption(seriesOverride["linewidth"]); ok {
+			property("custom.lineWidth", lineWidth)
+		}
+		if seriesOverride["bars"] == true {
+			property("custom.drawStyle", "bars")
+		}
+		if transform, _ := seriesOverride["transform"].(string); transform == "negative-Y" {
+			property("custom.transform", "negative-Y")
+		}
+		if seriesOverride["dashes"] == true {
+			property("custom.lineStyle", map[string]interface{}{"fill": "dash", "dash": []interface{}{10, 10}})
+		}
+		if len(properties) == 0 {
+			continue
+		}
+		overrides = append(overrides, map[string]interface{}{"matcher": seriesMatcher(alias), "properties": properties})
+	}
+	return overrides
+}
+
+// graphAliasColors maps the alias colors of the graph panel to fixed color overrides of the series
+func graphAliasColors(option interface{}) []interface{} {
+	overrides := []interface{}{}
+	aliasColors, _ := option.(map[string]interface{})
+	names := []string{}
+	for name := range aliasColors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		color, _ := aliasColors[name].(string)
+		if color == "" {
+			continue
+		}
+		overrides = append(overrides, map[string]interface{}{
+			"matcher":    map[string]interface{}{"id": "byName", "options": name},
+			"properties": []interface{}{map[string]interface{}{"id": "color", "value": map[string]interface{}{"mode": "fixed", "fixedColor": color}}},
+		})
+	}
+	return overrides
+}
+
+// seriesMatcher matches the series by name, or by the regular expression of a `/.../` alias
+func seriesMatcher(alias string) map[string]interface{} {
+	if len(alias) > 1 && strings.HasPrefix(alias, "/") && strings.HasSuffix(alias, "/") {
+		return map[string]interface{}{"id": "byRegexp", "options": alias[1 : len(alias)-1]}
+	}
+	return map[string]interface{}{"id": "byName", "options": alias}
+}
+
+// numberOption returns the numeric panel option, or the fallback when it isn't set
+func numberOption(option interface{}, fallback float64) float64 {
+	if value, ok := parseNumberOption(option); ok {
+		return value
+	}
+	return fallback
+}
+
+// parseNumberOption parses the panel option, a JSON number or a numeric string like the axis bounds
+func parseNumberOption(option interface{}) (float64, bool) {
+	switch value := option.(type) {
+	case float64:
+		return value, true
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		return number, err == nil
+	}
+	return 0, false
+}
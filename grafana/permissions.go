@@ -0,0 +1,159 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+)
+
+// Folder and dashboard permission levels
+const (
+	PermissionView  = 1
+	PermissionEdit  = 2
+	PermissionAdmin = 4
+)
+
+// Permission levels of the config
+const (
+	PermissionLevelView  = "view"
+	PermissionLevelEdit  = "edit"
+	PermissionLevelAdmin = "admin"
+)
+
+// permissionLevels maps the config permission levels to the API ones
+var permissionLevels = map[string]int{
+	PermissionLevelView:  PermissionView,
+	PermissionLevelEdit:  PermissionEdit,
+	PermissionLevelAdmin: PermissionAdmin,
+}
+
+// SetFolderPermissions replaces all permissions of the folder with the given items.
+// Permissions not listed are removed, the Admin role and server admins keep full access.
+func (client *ApiClient) SetFolderPermissions(folderUID string, items []PermissionItem) error {
+	client.Logger.Info("Setting folder permissions", "uid", folderUID, "items", len(items))
+
+	data, err := json.Marshal(map[string][]PermissionItem{"items": items})
+	if err != nil {
+		return fmt.Errorf("failed to marshal folder permissions: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/api/folders/%s/permissions", client.URL, url.PathEscape(folderUID))
+	if _, err := client.doRequest("POST", endpoint, data); err != nil {
+		return fmt.Errorf("failed to set folder permissions: %w", err)
+	}
+
+	client.Logger.Info("Folder permissions successfully set", "uid", folderUID)
+	return nil
+}
+
+// SetDashboardPermissions replaces all permissions of the dashboard with the given items, which then no longer
+// inherits the permissions of its folder. The Admin role and server admins keep full access.
+func (client *ApiClient) SetDashboardPermissions(dashboardUID string, items []PermissionItem) error {
+	client.Logger.Info("Setting dashboard permissions", "uid", dashboardUID, "items", len(items))
+
+	data, err := json.Marshal(map[string][]PermissionItem{"items": items})
+	if err != nil {
+		return fmt.Errorf("failed to marshal dashboard permissions: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/api/dashboards/uid/%s/permissions", client.URL, url.PathEscape(dashboardUID))
+	if _, err := client.doRequest("POST", endpoint, data); err != nil {
+		return fmt.Errorf("failed to set dashboard permissions: %w", err)
+	}
+
+	client.Logger.Info("Dashboard permissions successfully set", "uid", dashboardUID)
+	return nil
+}
+
+// resolvePermissions converts the configured permissions to API items, looking up the teams and users.
+// Teams are not created, they must exist or be in the teams of the config.
+func resolvePermissions(client GrafanaAPI, permissions []Permission) ([]PermissionItem, error) {
+	items := []PermissionItem{}
+	for _, permission := range permissions {
+		level, ok := permissionLevels[permission.Level]
+		if !ok {
+			return nil, fmt.Errorf("unknown permission level '%s', use view, edit or admin", permission.Level)
+		}
+		item := PermissionItem{Role: permission.Role, Permission: level}
+
+		switch {
+		case permission.Team != "":
+			team, err := client.FindTeamByName(permission.Team)
+			if err != nil {
+				return nil, err
+			}
+			if team == nil {
+				return nil, fmt.Errorf("team '%s' of the permissions doesn't exist, add it to teams", permission.Team)
+			}
+			item.TeamID = team.ID
+		case permission.User != "":
+			user, err := client.FindOrgUser(permission.User)
+			if err != nil {
+				return nil, err
+			}
+			if user == nil {
+				return nil, fmt.Errorf("user '%s' of the permissions is not a member of the organization", permission.User)
+			}
+			item.UserID = user.UserID
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// applyDashboardPermissions replaces the permissions of the dashboard with the configured ones
+func applyDashboardPermissions(client GrafanaAPI, dashboardUID string, permissions []Permission, log *slog.Logger) error {
+	items, err := resolvePermissions(client, permissions)
+	if err != nil {
+		return err
+	}
+	if err := client.SetDashboardPermissions(dashboardUID, items); err != nil {
+		return err
+	}
+
+	log.Info("Dashboard permissions applied", "uid", dashboardUID, "items", len(items))
+	return nil
+}
+
+// applyFolderAccess sets the configured folder permissions and those of the owner team and the folder service
+// account. The owner team gets Edit and everyone else View only; without an owner team or permissions the
+// Grafana defaults (Viewer View, Editor Edit) are kept. The team and the service account are created if needed.
+func applyFolderAccess(client GrafanaAPI, cfg *Config, folder FolderResponse, folderConfig Folder, report *Report, log *slog.Logger) error {
+	items := []PermissionItem{
+		{Role: "Viewer", Permission: PermissionView},
+		{Role: "Editor", Permission: PermissionEdit},
+	}
+	if folderConfig.OwnerTeam != "" {
+		items[1].Permission = PermissionView
+	}
+	if folderConfig.Permissions != nil {
+		var err error
+		if items, err = resolvePermissions(client, folderConfig.Permissions); err != nil {
+			return err
+		}
+	}
+
+	if folderConfig.OwnerTeam != "" {
+		teamID, _, err := ensureTeam(client, Team{Name: folderConfig.OwnerTeam})
+		if err != nil {
+			return fmt.Errorf("failed to provision owner team '%s': %w", folderConfig.OwnerTeam, err)
+		}
+		items = append(items, PermissionItem{TeamID: teamID, Permission: PermissionEdit})
+	}
+
+	if folderConfig.ServiceAccount {
+		serviceAccountID, err := ensureFolderServiceAccount(client, cfg, folderConfig.Name, report, log)
+		if err != nil {
+			return fmt.Errorf("failed to provision folder service account: %w", err)
+		}
+		items = append(items, PermissionItem{UserID: serviceAccountID, Permission: PermissionEdit})
+	}
+
+	if err := client.SetFolderPermissions(folder.UID, items); err != nil {
+		return err
+	}
+
+	log.Info("Folder access applied", "folder", folder.Title, "owner_team", folderConfig.OwnerTeam, "service_account", folderConfig.ServiceAccount, "items", len(items))
+	return nil
+}
@@ -0,0 +1,238 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+)
+
+// Strategies for provisioning the notification policy tree
+const (
+	PolicyStrategyMerge   = "merge"   // Keep the manually managed routes, replace the managed sub-route only
+	PolicyStrategyReplace = "replace" // Replace the whole tree
+)
+
+// KindNotificationPolicies is the report kind of the notification policy tree
+const KindNotificationPolicies = "notification-policies"
+
+// managedRouteMarker is the matcher identifying the sub-route holding the provisioner routes. It matches
+// every alert, so the sub-route is transparent: added last, it receives the alerts no manual route took,
+// and the ones none of its routes take either go to the root receiver as before.
+var managedRouteMarker = []interface{}{"grafana_provisioner", "=~", ".*"}
+
+// routeMatcherOperators are the operators of route matchers, longest first so "!=" isn't read as "="
+var routeMatcherOperators = []string{"=~", "!~", "!=", "="}
+
+// NotificationPolicies defines the provisioner-managed routes of the notification policy tree
+type NotificationPolicies struct {
+	Strategy string // PolicyStrategyMerge or PolicyStrategyReplace, empty means merge
+	Receiver string // Root receiver set with the replace strategy, empty keeps the live one
+	Routes   []PolicyRoute
+}
+
+// PolicyRoute is a notification policy routing the matching alerts to a contact point
+type PolicyRoute struct {
+	Receiver          string     // Contact point, empty inherits the parent's
+	Matchers          [][]string // Label, operator and value triples
+	GroupBy           []string
+	Continue          bool // Keep matching the following sibling routes
+	GroupWait         string
+	GroupInterval     string
+	RepeatInterval    string
+	MuteTimeIntervals []string
+	Routes            []PolicyRoute // Nested policies
+}
+
+// ParseRouteMatcher parses a route matcher like team=payments or severity=~"critical|warning"
+func ParseRouteMatcher(matcher string) ([]string, error) {
+	for i := range matcher {
+		for _, operator := range routeMatcherOperators {
+			if strings.HasPrefix(matcher[i:], operator) {
+				label := strings.TrimSpace(matcher[:i])
+				value := strings.Trim(strings.TrimSpace(matcher[i+len(operator):]), `"`)
+				if label == "" {
+					return nil, fmt.Errorf("route matcher '%s' has no label", matcher)
+				}
+				return []string{label, operator, value}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("route matcher '%s' has no operator, use =, !=, =~ or !~", matcher)
+}
+
+// GetNotificationPolicyTree fetches the notification policy tree, keeping every field of the live routes.
+func (client *ApiClient) GetNotificationPolicyTree() (map[string]interface{}, error) {
+	body, err := client.doRequest("GET", client.URL+"/api/v1/provisioning/policies", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification policies: %w", err)
+	}
+
+	var tree map[string]interface{}
+	if err := json.Unmarshal(body, &tree); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal notification policies: %w", err)
+	}
+	return tree, nil
+}
+
+// SetNotificationPolicyTree replaces the notification policy tree. The tree stays editable in the UI,
+// so the manually managed routes can still be changed there.
+func (client *ApiClient) SetNotificationPolicyTree(tree map[string]interface{}) error {
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification policies: %w", err)
+	}
+
+	headers := map[string]string{"X-Disable-Provenance": "true"}
+	if _, err := client.doRequestWithHeaders("PUT", client.URL+"/api/v1/provisioning/policies", data, headers); err != nil {
+		return fmt.Errorf("failed to set notification policies: %w", err)
+	}
+
+	client.Logger.Info("Notification policies successfully provisioned")
+	return nil
+}
+
+// provisionNotificationPolicies puts the configured routes into the notification policy tree. With the merge
+// strategy they replace the routes of the managed sub-route only, the other routes are left as they are.
+func provisionNotificationPolicies(client GrafanaAPI, cfg Config, report *Report, log *slog.Logger) error {
+	policies := cfg.NotificationPolicies
+	if policies == nil {
+		return nil
+	}
+
+	live, err := client.GetNotificationPolicyTree()
+	if err != nil {
+		return report.fail(KindNotificationPolicies, "root", err)
+	}
+	delete(live, "provenance")
+
+	tree := mergePolicyTree(live, *policies)
+	if reflect.DeepEqual(normalizeJSON(live), normalizeJSON(tree)) {
+		log.Info("Notification policies unchanged", "strategy", policyStrategy(*policies), "routes", len(policies.Routes))
+		report.add(ResourceResult{Kind: KindNotificationPolicies, Name: "root", Action: ActionUnchanged})
+		return nil
+	}
+
+	log.Info("Provisioning notification policies", "strategy", policyStrategy(*policies), "routes", len(policies.Routes))
+	if err := client.SetNotificationPolicyTree(tree); err != nil {
+		return report.fail(KindNotificationPolicies, "root", err)
+	}
+	report.add(ResourceResult{Kind: KindNotificationPolicies, Name: "root", Action: ActionUpdated})
+	return nil
+}
+
+// mergePolicyTree returns the live tree with the configured routes applied by the strategy
+func mergePolicyTree(live map[string]interface{}, policies NotificationPolicies) map[string]interface{} {
+	tree := map[string]interface{}{}
+	for key, value := range live {
+		tree[key] = value
+	}
+
+	managed := []interface{}{}
+	for _, route := range policies.Routes {
+		managed = append(managed, policyRouteJSON(route))
+	}
+
+	if policyStrategy(policies) == PolicyStrategyReplace {
+		if policies.Receiver != "" {
+			tree["receiver"] = policies.Receiver
+		}
+		tree["routes"] = managed
+		return tree
+	}
+
+	// The routes of the managed sub-route are replaced, the manual ones keep their order before it
+	routes := []interface{}{}
+	liveRoutes, _ := live["routes"].([]interface{})
+	for _, route := range liveRoutes {
+		if !isManagedRoute(route) {
+			routes = append(routes, route)
+		}
+	}
+	if len(managed) > 0 {
+		routes = append(routes, map[string]interface{}{
+			"object_matchers": []interface{}{managedRouteMarker},
+			"routes":          managed,
+		})
+	}
+	tree["routes"] = routes
+	return tree
+}
+
+// isManagedRoute reports whether the route is the managed sub-route
+func isManagedRoute(route interface{}) bool {
+	fields, ok := route.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	matchers, _ := fields["object_matchers"].([]interface{})
+	for _, matcher := range matchers {
+		if reflect.DeepEqual(normalizeJSON(matcher), normalizeJSON(managedRouteMarker)) {
+			return true
+		}
+	}
+	return false
+}
+
+// policyRouteJSON converts the route into the provisioning API model
+func policyRouteJSON(route PolicyRoute) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if route.Receiver != "" {
+		fields["receiver"] = route.Receiver
+	}
+	if len(route.Matchers) > 0 {
+		matchers := []interface{}{}
+		for _, matcher := range route.Matchers {
+			matchers = append(matchers, []interface{}{matcher[0], matcher[1], matcher[2]})
+		}
+		fields["object_matchers"] = matchers
+	}
+	if len(route.GroupBy) > 0 {
+		fields["group_by"] = route.GroupBy
+	}
+	if route.Continue {
+		fields["continue"] = true
+	}
+	if route.GroupWait != "" {
+		fields["group_wait"] = route.GroupWait
+	}
+	if route.GroupInterval != "" {
+		fields["group_interval"] = route.GroupInterval
+	}
+	if route.RepeatInterval != "" {
+		fields["repeat_interval"] = route.RepeatInterval
+	}
+	if len(route.MuteTimeIntervals) > 0 {
+		fields["mute_time_intervals"] = route.MuteTimeIntervals
+	}
+	if len(route.Routes) > 0 {
+		nested := []interface{}{}
+		for _, child := range route.Routes {
+			nested = append(nested, policyRouteJSON(child))
+		}
+		fields["routes"] = nested
+	}
+	return fields
+}
+
+// policyStrategy returns the strategy, merge by default
+func policyStrategy(policies NotificationPolicies) string {
+	if policies.Strategy == "" {
+		return PolicyStrategyMerge
+	}
+	return policies.Strategy
+}
+
+// normalizeJSON round-trips the value through JSON so typed and decoded values compare equal
+func normalizeJSON(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return value
+	}
+	return normalized
+}
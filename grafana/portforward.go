@@ -0,0 +1,189 @@
+package grafana
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// portForwardReadyTimeout bounds waiting for the port-forward to start listening
+const portForwardReadyTimeout = 30 * time.Second
+
+// OpenKubePortForward port-forwards a local port to a ready pod behind the Grafana service and returns a dialer
+// sending the connections to the Grafana URL through it. Other addresses, e.g. rulers, are dialed directly.
+func OpenKubePortForward(target KubeTarget, grafanaURL string, log *slog.Logger) (DialFunc, func() error, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if target.Kubeconfig != "" {
+		loadingRules.ExplicitPath = target.Kubeconfig
+	}
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{CurrentContext: target.Context})
+
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	namespace := target.Namespace
+	if namespace == "" {
+		namespace, _, err = clientConfig.Namespace()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve the kubeconfig namespace: %w", err)
+		}
+	}
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create the Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), portForwardReadyTimeout)
+	defer cancel()
+
+	pod, podPort, err := resolveServicePod(ctx, clientset, namespace, target.Service, target.Port)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	roundTripper, upgrader, err := spdy.RoundTripperFor(restConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create the port-forward transport: %w", err)
+	}
+	requestURL := clientset.CoreV1().RESTClient().Post().Resource("pods").Namespace(namespace).Name(pod).SubResource("portforward").URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: roundTripper}, "POST", requestURL)
+
+	stop := make(chan struct{})
+	ready := make(chan struct{})
+	errOut := &bytes.Buffer{}
+	forwarder, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, []string{fmt.Sprintf("0:%d", podPort)}, stop, ready, io.Discard, errOut)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create the port-forward: %w", err)
+	}
+
+	failed := make(chan error, 1)
+	go func() {
+		failed <- forwarder.ForwardPorts()
+	}()
+
+	select {
+	case <-ready:
+	case err := <-failed:
+		return nil, nil, fmt.Errorf("port-forward to pod '%s' failed: %w %s", pod, err, errOut.String())
+	case <-ctx.Done():
+		close(stop)
+		return nil, nil, fmt.Errorf("port-forward to pod '%s' did not become ready: %w", pod, ctx.Err())
+	}
+
+	ports, err := forwarder.GetPorts()
+	if err != nil || len(ports) == 0 {
+		close(stop)
+		return nil, nil, fmt.Errorf("failed to get the forwarded port: %w", err)
+	}
+	localAddr := net.JoinHostPort("127.0.0.1", fmt.Sprint(ports[0].Local))
+	log.Info("Kubernetes port-forward established", "namespace", namespace, "service", target.Service, "pod", pod, "pod_port", podPort, "local", localAddr)
+
+	grafanaAddr := ""
+	if parsed, err := url.Parse(grafanaURL); err == nil {
+		grafanaAddr = hostWithPort(parsed)
+	}
+
+	directDialer := &net.Dialer{Timeout: 10 * time.Second}
+	dial := func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		if addr == grafanaAddr {
+			addr = localAddr
+		}
+		return directDialer.DialContext(ctx, network, addr)
+	}
+
+	closeForward := func() error {
+		close(stop)
+		return nil
+	}
+	return dial, closeForward, nil
+}
+
+// resolveServicePod picks a ready pod behind the service and the container port the service port targets.
+// Without a port the first port of the service is used.
+func resolveServicePod(ctx context.Context, clientset kubernetes.Interface, namespace string, serviceName string, port int) (string, int, error) {
+	service, err := clientset.CoreV1().Services(namespace).Get(ctx, serviceName, metav1.GetOptions{})
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to get service '%s/%s': %w", namespace, serviceName, err)
+	}
+	if len(service.Spec.Selector) == 0 {
+		return "", 0, fmt.Errorf("service '%s/%s' has no pod selector", namespace, serviceName)
+	}
+
+	var servicePort *corev1.ServicePort
+	for i, candidate := range service.Spec.Ports {
+		if port == 0 || int(candidate.Port) == port {
+			servicePort = &service.Spec.Ports[i]
+			break
+		}
+	}
+	if servicePort == nil {
+		return "", 0, fmt.Errorf("service '%s/%s' has no port %d", namespace, serviceName, port)
+	}
+
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(service.Spec.Selector).String(),
+	})
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to list the pods of service '%s/%s': %w", namespace, serviceName, err)
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || !isPodReady(pod) {
+			continue
+		}
+		podPort, err := resolveTargetPort(pod, *servicePort)
+		if err != nil {
+			return "", 0, err
+		}
+		return pod.Name, podPort, nil
+	}
+
+	return "", 0, fmt.Errorf("service '%s/%s' has no ready pods", namespace, serviceName)
+}
+
+// isPodReady reports whether the pod has the Ready condition
+func isPodReady(pod corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// resolveTargetPort returns the container port of the pod a service port targets, resolving named ports
+func resolveTargetPort(pod corev1.Pod, servicePort corev1.ServicePort) (int, error) {
+	targetPort := servicePort.TargetPort
+	if targetPort.StrVal == "" {
+		if targetPort.IntVal == 0 {
+			return int(servicePort.Port), nil
+		}
+		return int(targetPort.IntVal), nil
+	}
+
+	for _, container := range pod.Spec.Containers {
+		for _, containerPort := range container.Ports {
+			if containerPort.Name == targetPort.StrVal {
+				return int(containerPort.ContainerPort), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("pod '%s' has no container port named '%s'", pod.Name, targetPort.StrVal)
+}
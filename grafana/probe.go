@@ -0,0 +1,175 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Grafana editions reported by the probe
+const (
+	EditionOSS        = "OSS"
+	EditionEnterprise = "Enterprise"
+	EditionCloud      = "Cloud"
+)
+
+// Probed features
+const (
+	FeatureNestedFolders    = "nested-folders"
+	FeatureUnifiedAlerting  = "unified-alerting"
+	FeaturePublicDashboards = "public-dashboards"
+	FeatureK8sAPIs          = "k8s-apis"
+)
+
+// FrontendSettings is the subset of /api/frontend/settings describing the instance capabilities
+type FrontendSettings struct {
+	BuildInfo struct {
+		Version string `json:"version"`
+		Edition string `json:"edition"`
+	} `json:"buildInfo"`
+	FeatureToggles          map[string]bool `json:"featureToggles"`
+	UnifiedAlertingEnabled  bool            `json:"unifiedAlertingEnabled"`
+	PublicDashboardsEnabled *bool           `json:"publicDashboardsEnabled"` // Missing before Grafana 10.2
+}
+
+// PluginInfo is an installed plugin returned by /api/plugins
+type PluginInfo struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Info struct {
+		Version string `json:"version"`
+	} `json:"info"`
+
+	AngularDetected bool `json:"angularDetected"` // Grafana 10
+	Angular         struct {
+		Detected bool `json:"detected"`
+	} `json:"angular"` // Grafana 11 and later
+}
+
+// IsAngular reports whether Grafana detected the deprecated AngularJS framework in the plugin
+func (plugin PluginInfo) IsAngular() bool {
+	return plugin.AngularDetected || plugin.Angular.Detected
+}
+
+// ProbeReport describes the capabilities of the live instance and the config parts it can't provision
+type ProbeReport struct {
+	Version     string
+	Edition     string
+	Features    map[string]bool
+	Plugins     []PluginInfo
+	Token       *TokenInfo
+	Unsupported []string
+}
+
+// GetFrontendSettings fetches the instance settings the Grafana frontend boots with.
+func (client *ApiClient) GetFrontendSettings() (*FrontendSettings, error) {
+	body, err := client.doRequest("GET", client.URL+"/api/frontend/settings", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get frontend settings: %w", err)
+	}
+
+	var settings FrontendSettings
+	if err := json.Unmarshal(body, &settings); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal frontend settings: %w", err)
+	}
+	return &settings, nil
+}
+
+// GetPlugins lists the installed plugins, core plugins excluded.
+func (client *ApiClient) GetPlugins() ([]PluginInfo, error) {
+	body, err := client.doRequest("GET", client.URL+"/api/plugins?core=0", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plugins: %w", err)
+	}
+
+	var plugins []PluginInfo
+	if err := json.Unmarshal(body, &plugins); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal plugins response: %w", err)
+	}
+	return plugins, nil
+}
+
+// Probe reports the version, edition, features and plugins of the instance and which parts of the config
+// it doesn't support.
+func Probe(client GrafanaAPI, cfg Config, log *slog.Logger) (*ProbeReport, error) {
+	token, err := client.ValidateToken()
+	if err != nil {
+		return nil, fmt.Errorf("token validation failed: %w", err)
+	}
+
+	settings, err := client.GetFrontendSettings()
+	if err != nil {
+		return nil, err
+	}
+	plugins, err := client.GetPlugins()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].ID < plugins[j].ID })
+
+	report := &ProbeReport{
+		Version:  settings.BuildInfo.Version,
+		Edition:  probeEdition(client.BaseURL(), settings.BuildInfo.Edition),
+		Features: map[string]bool{},
+		Plugins:  plugins,
+		Token:    token,
+	}
+
+	report.Features[FeatureNestedFolders] = settings.FeatureToggles["nestedFolders"] || parseMajorVersion(report.Version) >= 11
+	report.Features[FeatureUnifiedAlerting] = settings.UnifiedAlertingEnabled
+	if settings.PublicDashboardsEnabled != nil {
+		report.Features[FeaturePublicDashboards] = *settings.PublicDashboardsEnabled
+	} else {
+		report.Features[FeaturePublicDashboards] = settings.FeatureToggles["publicDashboards"]
+	}
+	_, k8sErr := preferredAPIVersion(client, dashboardAPIGroup)
+	report.Features[FeatureK8sAPIs] = k8sErr == nil
+
+	report.Unsupported = unsupportedConfig(cfg, report)
+	log.Info("Grafana instance probed", "version", report.Version, "edition", report.Edition, "plugins", len(plugins), "unsupported", len(report.Unsupported))
+	return report, nil
+}
+
+// probeEdition tells OSS, Enterprise and Grafana Cloud apart, Cloud stacks report the Enterprise edition
+func probeEdition(baseURL string, edition string) string {
+	if parsed, err := url.Parse(baseURL); err == nil && strings.HasSuffix(parsed.Hostname(), ".grafana.net") {
+		return EditionCloud
+	}
+	if strings.EqualFold(edition, "Enterprise") {
+		return EditionEnterprise
+	}
+	return EditionOSS
+}
+
+// unsupportedConfig lists the configured resources the probed instance can't provision
+func unsupportedConfig(cfg Config, report *ProbeReport) []string {
+	unsupported := []string{}
+
+	for _, team := range cfg.Teams {
+		if team.Groups != nil && report.Edition == EditionOSS {
+			unsupported = append(unsupported, fmt.Sprintf("team '%s': team sync groups need Grafana Enterprise or Cloud", team.Name))
+		}
+	}
+
+	if cfg.Grafana.API == APIModeK8s && !report.Features[FeatureK8sAPIs] {
+		unsupported = append(unsupported, "grafana.api 'k8s': the k8s-style dashboard and folder APIs are not available")
+	}
+
+	if !report.Features[FeatureUnifiedAlerting] {
+		for _, group := range cfg.AlertRuleGroups {
+			if group.Ruler == "" {
+				unsupported = append(unsupported, fmt.Sprintf("rule group '%s': Grafana-managed alert rules need unified alerting", group.Name))
+			}
+		}
+	}
+
+	if len(cfg.Orgs) > 0 && report.Token != nil && !report.Token.IsGrafanaAdmin {
+		unsupported = append(unsupported, fmt.Sprintf("orgs: creating organizations needs Grafana server admin credentials, '%s' is not a server admin", report.Token.Login))
+	}
+
+	return unsupported
+}
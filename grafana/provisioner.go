@@ -1,63 +1,332 @@
 package grafana
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/buildinfo"
 	"log/slog"
-	"net/http"
 	"os"
 	"strings"
 	"time"
 )
 
-// RunProvisioning executes the full provisioning workflow
-func RunProvisioning(cfg Config, log *slog.Logger) error {
-	log.Info("Starting Grafana provisioning process")
+// RunProvisioning executes the full provisioning workflow and returns the report of provisioned resources.
+// Canceling the context, e.g. on SIGINT, aborts the requests in flight and their retries.
+func RunProvisioning(ctx context.Context, cfg Config, log *slog.Logger) (*Report, error) {
 	client := NewClient(cfg.Grafana, log)
+	if cfg.Grafana.LiveTail > 0 {
+		stopMonitor := client.StartServerMonitor(cfg.Grafana.LiveTail)
+		defer stopMonitor()
+	}
+	return RunProvisioningWithClient(ctx, client, cfg, log)
+}
+
+// RunProvisioningWithClient executes the full provisioning workflow against the given Grafana API implementation
+func RunProvisioningWithClient(ctx context.Context, client GrafanaAPI, cfg Config, log *slog.Logger) (*Report, error) {
+	log.Info("Starting Grafana provisioning process", "version", buildinfo.Version, "commit", buildinfo.Commit)
+	report := &Report{ToolVersion: buildinfo.Version, StartedAt: time.Now(), onEvent: cfg.OnEvent}
+
+	// Nothing is changed outside the change window, the status dashboard included
+	if err := cfg.ChangeWindow.Check(report.StartedAt); err != nil {
+		return report, err
+	}
+	if cfg.ChangeWindow != nil && !cfg.ChangeWindow.Contains(report.StartedAt) {
+		log.Warn("Running outside the change window", "schedule", cfg.ChangeWindow.Schedule, "timezone", cfg.ChangeWindow.Location.String())
+	}
+
+	client = client.WithContext(ctx)
+
+	// Parallel jobs provision the other shards of the config
+	if cfg.Shard != nil {
+		cfg = cfg.Shard.apply(cfg)
+		report.Shard = cfg.Shard.String()
+		log.Info("Provisioning a shard of the config", "shard", report.Shard, "folders", len(cfg.Folders), "dashboards", len(cfg.Dashboards), "alert_rule_groups", len(cfg.AlertRuleGroups))
+	}
+
+	// A dry run reads through to Grafana but plans every change instead of making it
+	if cfg.DryRun {
+		log.Info("Dry run: changes are planned, Grafana is not changed")
+		client = newDryRunClient(client, report, log)
+		cfg.Grafana.ConsistencyWait = 0
+		if cfg.SecretSink != nil {
+			cfg.SecretSink = discardSecretSink{}
+		}
+	}
+
+	err := runProvisioningSteps(client, &cfg, report, log)
+	report.FinishedAt = time.Now()
+	report.Deprecations = client.Deprecations()
+	logDeprecations(report.Deprecations, log)
+	if cfg.DryRun {
+		log.Info("Dry run completed", "planned_changes", len(report.Plan))
+		return report, err
+	}
+
+	// Publish the outcome, failed runs included, on the status dashboard. Canceled runs too, within the request timeout.
+	if cfg.StatusDashboard.Enabled {
+		if statusErr := publishStatusDashboard(client.WithContext(context.WithoutCancel(ctx)), cfg, report, err, log); statusErr != nil {
+			log.Warn("Failed to update the provisioning status dashboard", "error", statusErr)
+		}
+	}
+	if cfg.Metrics.enabled() {
+		if pushErr := pushRunMetrics(cfg, report, err, log); pushErr != nil {
+			log.Warn("Failed to push the run metrics", "error", pushErr)
+		}
+	}
+
+	if err != nil {
+		return report, err
+	}
+	log.Info("Grafana provisioning completed successfully")
+	return report, nil
+}
+
+// runProvisioningSteps provisions all configured resources in order, stopping at the first failure
+func runProvisioningSteps(client GrafanaAPI, cfg *Config, report *Report, log *slog.Logger) error {
+	params := cfg.Grafana.withDefaults()
+	report.phase(PhaseConnect)
 
 	// 1. Wait for Grafana API availability
-	if err := waitForGrafanaAPI(client); err != nil {
+	if err := waitForGrafanaAPI(client, params.Retries, params.RetryDelay, log); err != nil {
 		return fmt.Errorf("grafana API did not become available: %w", err)
 	}
 
+	// Fail fast on a rejected token instead of retrying every subsequent call
+	token, err := client.ValidateToken()
+	if err != nil {
+		return fmt.Errorf("token validation failed: %w", err)
+	}
+	if err := checkTokenScope(token, *cfg, log); err != nil {
+		return err
+	}
+
+	// Create missing organizations and switch to the one to provision into. The other shards look them up.
+	orgs := cfg.Orgs
+	if !cfg.Shard.shared() {
+		orgs = nil
+	}
+	orgIDs, err := provisionOrgs(client, orgs, token, report, log)
+	if err != nil {
+		return fmt.Errorf("organization provisioning failed: %w", err)
+	}
+	if params.Org != "" {
+		orgID, err := resolveOrgID(client, params.Org, orgIDs)
+		if err != nil {
+			return err
+		}
+		client.UseOrg(orgID)
+		token.OrgID = orgID
+	}
+
+	// Pick the dashboard and folder API backend by the Grafana version
+	cfg.k8s, err = resolveAPIBackend(client, params.API, token, log)
+	if err != nil {
+		return fmt.Errorf("failed to select API backend: %w", err)
+	}
+
+	// Resources with an org of their own are provisioned after the ones of the run
+	mainOrg := params.Org
+	if mainOrg == "" {
+		mainOrg = token.OrgName
+	}
+	orgTargets := splitOrgTargets(cfg, mainOrg)
+
+	// Catch broken alert rules before anything is changed
+	issues, err := LintAlertRules(client, *cfg)
+	if err != nil {
+		return fmt.Errorf("failed to lint alert rules: %w", err)
+	}
+	if len(issues) > 0 {
+		messages := []string{}
+		for _, issue := range issues {
+			log.Error("Invalid alert rule", "group", issue.Group, "rule", issue.Rule, "problem", issue.Message)
+			messages = append(messages, issue.String())
+		}
+		return fmt.Errorf("%d problems found in the alert rules: %s", len(issues), strings.Join(messages, "; "))
+	}
+	if err := checkFolderCapacity(client, *cfg, report, log); err != nil {
+		return err
+	}
+
+	// Undoing the pause and the silence isn't canceled with the run
+	cleanup := client.WithContext(context.WithoutCancel(client.Context()))
+
+	// Avoid alert storms while data sources and dashboards change
+	if cfg.PauseAlerts && !cfg.DryRun {
+		paused, err := setManagedAlertRulesPaused(client, *cfg, true, log)
+		defer func() {
+			if resumeErr := resumeAlertRules(cleanup, paused, log); resumeErr != nil {
+				log.Error("Failed to resume paused alert rules, run 'maintenance resume'", "error", resumeErr)
+			}
+		}()
+		if err != nil {
+			return fmt.Errorf("failed to pause alert rules: %w", err)
+		}
+	}
+
+	// Keep the churn of the run from paging the on-call, alerts still evaluate
+	if cfg.SilenceAlerts && !cfg.DryRun {
+		silence, err := silenceManagedAlerts(client, *cfg, log)
+		if err != nil {
+			return fmt.Errorf("failed to silence alerts: %w", err)
+		}
+		if silence != nil {
+			defer func() {
+				if endErr := endSilence(cleanup, silence, cfg.SilenceGrace, log); endErr != nil {
+					log.Error("Failed to end the silence of the run, expire it in Grafana", "silence", silence.ID, "error", endErr)
+				}
+			}()
+		}
+	}
+
 	// 2. Provision Data Source
-	_, err := provisionDataSources(client, cfg, log)
+	report.phase(PhaseDataSources)
+	_, err = provisionDataSources(client, *cfg, report, log)
 	if err != nil {
 		return fmt.Errorf("data source provisioning failed: %w", err)
 	}
+	// Planned data sources can't be queried
+	if !cfg.DryRun {
+		checkDataSourceQueries(client, *cfg, report, log)
+	}
 
-	// 3. Provision Folders from config and create mapping
-	if err := provisionFolders(client, &cfg, log); err != nil {
+	// 3. Provision teams and their team sync mappings
+	report.phase(PhaseTeams)
+	if err := provisionTeams(client, cfg.Teams, report, log); err != nil {
+		return fmt.Errorf("team provisioning failed: %w", err)
+	}
+
+	// 4. Provision Folders from config and create mapping
+	report.phase(PhaseFolders)
+	if err := provisionFolders(client, cfg, report, log); err != nil {
 		return fmt.Errorf("folder provisioning failed: %w", err)
 	}
 
-	// 4. Provision Dashboards (handle multiple dashboards from config)
-	if err := provisionDashboards(client, cfg, log); err != nil {
+	// 5. Provision Dashboards (handle multiple dashboards from config)
+	report.phase(PhaseDashboards)
+	if err := provisionDashboards(client, *cfg, report, log); err != nil {
 		return fmt.Errorf("dashboard provisioning failed: %w", err)
 	}
+	if err := checkDashboardHealth(client, report, log); err != nil {
+		return err
+	}
+
+	// 6. Provision alert rule groups (Grafana-managed or pushed to Mimir/Loki rulers)
+	report.phase(PhaseAlerting)
+	if err := provisionAlertRuleGroups(client, *cfg, report, log); err != nil {
+		return fmt.Errorf("alert rule provisioning failed: %w", err)
+	}
+	if err := provisionContactPoints(client, *cfg, report, log); err != nil {
+		return fmt.Errorf("contact point provisioning failed: %w", err)
+	}
+	if err := provisionNotificationPolicies(client, *cfg, report, log); err != nil {
+		return fmt.Errorf("notification policy provisioning failed: %w", err)
+	}
+
+	// 7. Import the dashboards into the further organizations with their own data sources
+	report.phase(PhaseOrgs)
+	if err := provisionOrgDashboards(client, *cfg, orgIDs, token.OrgID, report, log); err != nil {
+		return err
+	}
+	if err := provisionOrgTargets(client, orgTargets, orgIDs, token.OrgID, report, log); err != nil {
+		return err
+	}
+
+	// 8. Delete the owned resources removed from the config
+	if cfg.Prune {
+		if err := pruneResources(client, *cfg, report, log); err != nil {
+			return fmt.Errorf("pruning failed: %w", err)
+		}
+	}
 
-	log.Info("Grafana provisioning completed successfully")
 	return nil
 }
 
 // provisionDashboards iterates over the configured dashboards and provisions each one.
-func provisionDashboards(client *ApiClient, cfg Config, log *slog.Logger) error {
+func provisionDashboards(client GrafanaAPI, cfg Config, report *Report, log *slog.Logger) error {
 	if len(cfg.Dashboards) == 0 {
 		log.Info("No dashboards configured for provisioning, skipping dashboard creation.")
 		return nil
 	}
 
+	// Resolve annotation query data sources once for all dashboards
+	annotations, err := resolveAnnotations(client, cfg.Annotations, log)
+	if err != nil {
+		return fmt.Errorf("annotation provisioning failed: %w", err)
+	}
+
 	log.Info("Provisioning Grafana dashboards")
+	preparedDashboards := []*preparedDashboard{}
+	deferred := []deferredDashboard{}
 	for _, dashboardConfig := range cfg.Dashboards {
+		// Scope the logs of everything done for this dashboard
+		dashboardLog := log.With("dashboard", dashboardConfig.Name)
+		dashboardClient := client.WithLogger(dashboardLog)
+
 		// 1. Validate and get folder UID for the dashboard
-		dashboardFolderUID, err := getDashboardFolderUID(cfg, dashboardConfig, log)
+		dashboardFolderUID, err := getDashboardFolderUID(cfg, dashboardConfig, dashboardLog)
 		if err != nil {
-			return fmt.Errorf("dashboard folder validation failed for dashboard '%s': %w", dashboardConfig.Name, err)
+			return report.fail(KindDashboard, dashboardConfig.Name, fmt.Errorf("dashboard folder validation failed for dashboard '%s': %w", dashboardConfig.Name, err))
 		}
 
-		// 2. Provision the specific dashboard
-		if err := provisionDashboard(client, dashboardConfig, dashboardFolderUID, log); err != nil {
-			return fmt.Errorf("dashboard provisioning failed for dashboard '%s': %w", dashboardConfig.Name, err)
+		// 2. Prepare the import request of the specific dashboard
+		prepared, err := prepareDashboard(dashboardClient, dashboardConfig, dashboardFolderUID, cfg.dashboardOwner(dashboardConfig), annotations, cfg.Values, cfg.ValueSources, cfg.Git, dashboardLog)
+		if errors.Is(err, ErrMissingDataSource) {
+			// Retried once the other dashboards are prepared
+			dashboardLog.Warn("Data source of the dashboard not found, deferring it", "error", err)
+			deferred = append(deferred, deferredDashboard{Config: dashboardConfig, FolderUID: dashboardFolderUID, Err: err})
+			continue
+		}
+		if err != nil {
+			return report.fail(KindDashboard, dashboardConfig.Name, fmt.Errorf("dashboard provisioning failed for dashboard '%s': %w", dashboardConfig.Name, err))
+		}
+		preparedDashboards = append(preparedDashboards, prepared)
+	}
+	retried, err := retryDeferredDashboards(client, cfg, deferred, annotations, report, log)
+	if err != nil {
+		return err
+	}
+	preparedDashboards = append(preparedDashboards, retried...)
+	// Pruning runs only delete the dashboards they tagged
+	if cfg.Prune {
+		for _, prepared := range preparedDashboards {
+			injectPruneTag(prepared.Request.Dashboard, cfg.pruneTag())
+		}
+	}
+
+	// 3. Apply the dashboards linked to by `${dashboard:NAME}` placeholders first
+	preparedDashboards, err = orderDashboardsByLinks(preparedDashboards, log)
+	if err != nil {
+		return fmt.Errorf("dashboard link resolution failed: %w", err)
+	}
+
+	// 4. Refuse to overwrite too many existing dashboards at once
+	if err := checkDashboardOverwrites(client, cfg.Safety, preparedDashboards, log); err != nil {
+		return err
+	}
+
+	// 5. Import the dashboards, rendering the links with the UIDs known so far
+	uids := map[string]string{}
+	for _, prepared := range preparedDashboards {
+		uids[prepared.Config.Name] = dashboardUID(prepared)
+	}
+	for _, prepared := range preparedDashboards {
+		dashboardClient := client.WithLogger(log.With("dashboard", prepared.Config.Name))
+		prepared.Request.Dashboard, err = renderDashboardLinks(prepared.Request.Dashboard, uids)
+		if err == nil {
+			err = importDashboard(dashboardClient, cfg, prepared, report, log)
+		}
+		if err != nil {
+			return report.fail(KindDashboard, prepared.Config.Name, fmt.Errorf("dashboard provisioning failed for dashboard '%s': %w", prepared.Config.Name, err))
+		}
+		uids[prepared.Config.Name] = report.Resources[len(report.Resources)-1].UID
+
+		if prepared.Config.Permissions != nil && uids[prepared.Config.Name] != "" {
+			if err := applyDashboardPermissions(dashboardClient, uids[prepared.Config.Name], prepared.Config.Permissions, log); err != nil {
+				return report.fail(KindDashboard, prepared.Config.Name, fmt.Errorf("failed to apply permissions of dashboard '%s': %w", prepared.Config.Name, err))
+			}
 		}
 	}
 	log.Info("All configured dashboards provisioned.")
@@ -66,7 +335,7 @@ func provisionDashboards(client *ApiClient, cfg Config, log *slog.Logger) error
 
 
 // provisionFolders creates all folders defined in the config and stores their IDs/UIDs in Config.FoldersMapping.
-func provisionFolders(client *ApiClient, cfg *Config, log *slog.Logger) error {
+func provisionFolders(client GrafanaAPI, cfg *Config, report *Report, log *slog.Logger) error {
 	cfg.FoldersMapping = make(map[string]FolderMapping)
 	
 	// Create a map of folders from the main config (which contains the names)
@@ -80,17 +349,40 @@ func provisionFolders(client *ApiClient, cfg *Config, log *slog.Logger) error {
 
 	log.Info("Provisioning Grafana folders")
 	for _, folderConfig := range folderConfigs {
-		resp, err := client.CreateFolderIfNotExists(folderConfig.Name, log)
+		folderClient := client.WithLogger(log.With("folder", folderConfig.Name))
+
+		var resp *FolderResponse
+		var err error
+		if cfg.k8s != nil {
+			resp, err = applyFolder(folderClient, cfg.k8s, folderConfig.Name)
+		} else {
+			resp, err = folderClient.CreateFolderIfNotExists(folderConfig.Name)
+		}
 		if err != nil {
-			return fmt.Errorf("failed to provision folder '%s': %w", folderConfig.Name, err)
+			return report.fail(KindFolder, folderConfig.Name, fmt.Errorf("failed to provision folder '%s': %w", folderConfig.Name, err))
 		}
 		
+		if folderConfig.OwnerTeam != "" || folderConfig.ServiceAccount || folderConfig.Permissions != nil {
+			if err := applyFolderAccess(folderClient, cfg, *resp, folderConfig, report, log); err != nil {
+				return report.fail(KindFolder, folderConfig.Name, fmt.Errorf("failed to apply access of folder '%s': %w", folderConfig.Name, err))
+			}
+		}
+
 		// Store the mapping for later use (e.g., dashboard creation)
 		cfg.FoldersMapping[resp.Title] = FolderMapping{
 			ID:    resp.ID,
 			UID:   resp.UID,
 			Title: resp.Title,
 		}
+
+		report.add(ResourceResult{
+			Kind:   KindFolder,
+			Name:   folderConfig.Name,
+			Action: ActionProvisioned,
+			UID:    resp.UID,
+			URL:    joinResourceURL(client.BaseURL(), resp.URL),
+			Labels: folderConfig.Labels,
+		})
 	}
 
 	log.Info("All configured folders provisioned and mapped.")
@@ -126,40 +418,27 @@ func getDashboardFolderUID(cfg Config, dashboardConfig Dashboard, log *slog.Logg
 }
 
 // Helper to wait for Grafana API to be ready
-func waitForGrafanaAPI(client *ApiClient) error {
-	client.Logger.Info("Waiting for Grafana API to become ready...")
+func waitForGrafanaAPI(client GrafanaAPI, retries int, retryDelay time.Duration, log *slog.Logger) error {
+	log.Info("Waiting for Grafana API to become ready...")
 
-	url := client.URL + "/api/health"
-	for i := 0; i < client.Retries; i++ {
-		req, _ := http.NewRequest("GET", url, nil)
-		resp, err := client.HttpClient.Do(req)
-
-		if err == nil && resp.StatusCode == 200 {
-			client.Logger.Info("Grafana API is ready")
-			resp.Body.Close()
+	for i := 0; i < retries; i++ {
+		err := client.CheckHealth()
+		if err == nil {
+			log.Info("Grafana API is ready")
 			return nil
 		}
 
-		if resp==nil {			
-			client.Logger.Warn("Grafana API not ready, retrying...", "error", err, "attempt", i+1)
-		} else {			
-			client.Logger.Warn("Grafana API not ready, retrying...", "error", err, "status", resp.StatusCode, "attempt", i+1)
-		}
-		
-		if resp == nil {
-			client.Logger.Warn("Grafana API not ready, retrying...", "error", err, "attempt", i+1)
-		} else {
-			client.Logger.Warn("Grafana API not ready, retrying...", "error", err, "status", resp.StatusCode, "attempt", i+1)
+		log.Warn("Grafana API not ready, retrying...", "error", err, "attempt", i+1)
+		if err := sleep(client.Context(), retryDelay); err != nil {
+			return fmt.Errorf("canceled while waiting for the Grafana API: %w", err)
 		}
-		
-		time.Sleep(client.RetryDelay)
 	}
 
-	return fmt.Errorf("failed to reach Grafana API after %d attempts", client.Retries)
+	return fmt.Errorf("failed to reach Grafana API after %d attempts", retries)
 }
 
-func provisionDataSources(client *ApiClient, cfg Config, log *slog.Logger) (*[]CreateDataSourceResponse, error) {
-	existingSources, err := client.GetDataSources(log)
+func provisionDataSources(client GrafanaAPI, cfg Config, report *Report, log *slog.Logger) (*[]CreateDataSourceResponse, error) {
+	existingSources, err := client.GetDataSources()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list existing data sources: %w", err)
 	}
@@ -167,33 +446,73 @@ func provisionDataSources(client *ApiClient, cfg Config, log *slog.Logger) (*[]C
 	sourceResponses := []CreateDataSourceResponse{}
 
 	for _, dataSource := range cfg.DataSources {
-		sourceResponce, err := provisionDataSource(client, dataSource, existingSources, log)
+		dataSourceLog := log.With("datasource", dataSource.Name)
+		// Pruning runs only delete the data sources they marked
+		if cfg.Prune {
+			dataSource.JSONData = withPruneMarker(dataSource.JSONData, cfg.pruneTag())
+		}
+		sourceResponce, err := provisionDataSource(client.WithLogger(dataSourceLog), cfg, dataSource, existingSources, dataSourceLog)
 		if err != nil {
-			return nil, fmt.Errorf("failed to provision datasource '%s': %w", dataSource.Name, err)
+			return nil, report.fail(KindDataSource, dataSource.Name, fmt.Errorf("failed to provision datasource '%s': %w", dataSource.Name, err))
 		}
 
 		sourceResponses = append(sourceResponses, *sourceResponce);
+
+		if err := reportDataSource(client, dataSource, sourceResponce, report); err != nil {
+			return nil, report.fail(KindDataSource, dataSource.Name, err)
+		}
+
+		// Dashboard imports look the data source up by name
+		if report.Resources[len(report.Resources)-1].Action == ActionCreated {
+			if err := waitForDataSource(client, sourceResponce.Datasource.Name, cfg.Grafana.ConsistencyWait, dataSourceLog); err != nil {
+				return nil, report.fail(KindDataSource, dataSource.Name, err)
+			}
+		}
 	}
 
 	return &sourceResponses, nil
 }
 
-// Helper to create the data source
-func provisionDataSource(client *ApiClient, dataSource DataSource, existingSources []DataSource, log *slog.Logger) (*CreateDataSourceResponse, error) {
-    // Check if a data source with the same type, URL and database already exists
+// reportDataSource adds the data source provisioning result to the report.
+// The UID is looked up by name when the API response doesn't carry it (409 Conflict).
+func reportDataSource(client GrafanaAPI, dataSourceConfig DataSource, response *CreateDataSourceResponse, report *Report) error {
+	action := ActionCreated
+	switch response.Datasource.Message {
+	case "Already exists":
+		action = ActionUnchanged
+	case dataSourceUpdatedMessage:
+		action = ActionUpdated
+	}
+
+	uid := response.Datasource.UID
+	if uid == "" {
+		dataSource, err := client.GetDataSource(response.Datasource.Name)
+		if err != nil {
+			return fmt.Errorf("failed to resolve UID of data source '%s': %w", dataSourceConfig.Name, err)
+		}
+		uid = dataSource.UID
+		action = ActionUnchanged
+	}
+
+	report.add(ResourceResult{
+		Kind:   KindDataSource,
+		Name:   dataSourceConfig.Name,
+		Action: action,
+		UID:    uid,
+		Labels: dataSourceConfig.Labels,
+	})
+	return nil
+}
+
+// Helper to create the data source, or update the existing one when it differs from the config
+func provisionDataSource(client GrafanaAPI, cfg Config, dataSource DataSource, existingSources []DataSource, log *slog.Logger) (*CreateDataSourceResponse, error) {
+    // Check if the data source already exists, by default with the same type, URL and database
     for _, source := range existingSources {
-        if source.Type == dataSource.Type && source.URL == dataSource.URL && source.Database == dataSource.Database {
-            log.Info(fmt.Sprintf("data source of type '%s' with URL '%s' and database '%s' already exists (ID: %d). Skipping creation.", 
-                source.Type, source.URL, source.Database, source.ID))
-
-			return &CreateDataSourceResponse{
-				Datasource: CreateDataSourceResponseDatasource {
-					ID: source.ID,
-					UID: source.UID,
-					Name: source.Name,
-					Message: "Already exists",
-				},
-			}, nil
+        if dataSourceMatches(source, dataSource) {
+            log.Info(fmt.Sprintf("data source of type '%s' with URL '%s' and database '%s' already exists (ID: %d). Checking for changes.", 
+                source.Type, source.URL, source.Database, source.ID), "match", dataSource.Match)
+
+			return updateDataSource(client, cfg, source, newDataSourceModel(dataSource), existingSources, log)
         }
     }
 	
@@ -221,18 +540,8 @@ func provisionDataSource(client *ApiClient, dataSource DataSource, existingSourc
             break
         }
     }
-	
-	dsModel := &PostgreSQLDataSourceModel{
-		Name:      sourceToCreate.Name,
-		Type:      "grafana-postgresql-datasource",
-		Access:    "direct",
-		URL:       sourceToCreate.URL,
-		Database:  sourceToCreate.Database,
-		User:      sourceToCreate.User,
-		Password:  sourceToCreate.Password,
-		SSLMode:   sourceToCreate.SSLMode,
-		IsDefault: false,
-	}
+
+	dsModel := newDataSourceModel(sourceToCreate)
 
 	// Attempt to create the data source
 	resp, err := client.CreateDataSource(dsModel)
@@ -253,41 +562,205 @@ func provisionDataSource(client *ApiClient, dataSource DataSource, existingSourc
 	return resp, err
 }
 
-// Helper to import the dashboard
-func provisionDashboard(client *ApiClient, cfg Dashboard, folderUID string, log *slog.Logger) error {
-	log.Info("Reading dashboard file", "file", cfg.File)
-	data, err := os.ReadFile(cfg.File)
+// newDataSourceModel returns the API model of the configured data source
+func newDataSourceModel(dataSource DataSource) *DataSourceModel {
+	access := "direct"
+	if dataSource.Type != DataSourceTypePostgres {
+		// Queries go through the Grafana backend
+		access = "proxy"
+	}
+
+	return &DataSourceModel{
+		Name:                 dataSource.Name,
+		UID:                  dataSource.UID,
+		Type:                 dataSource.Type,
+		Access:               access,
+		URL:                  dataSource.URL,
+		Database:             dataSource.Database,
+		User:                 dataSource.User,
+		Password:             dataSource.Password,
+		SSLMode:              dataSource.SSLMode,
+		IsDefault:            false,
+		Headers:              dataSource.Headers,
+		ForwardOAuthIdentity: dataSource.ForwardOAuthIdentity,
+		KeepCookies:          dataSource.KeepCookies,
+		JSONData:             dataSource.JSONData,
+		SecureJSONData:       dataSource.SecureJSONData,
+		Prometheus:           dataSource.Prometheus,
+		BasicAuth:            dataSource.BasicAuth,
+	}
+}
+
+// describeDataSourceIdentity renders the identity key of the desired data source for messages
+func describeDataSourceIdentity(desired DataSource) string {
+	switch desired.Match {
+	case MatchByName:
+		return fmt.Sprintf("name '%s'", desired.Name)
+	case MatchByUID:
+		return fmt.Sprintf("uid '%s'", desired.UID)
+	case MatchByTypeURLDatabaseUser:
+		return fmt.Sprintf("type '%s' with URL '%s', database '%s' and user '%s'", desired.Type, desired.URL, desired.Database, desired.User)
+	}
+	return fmt.Sprintf("type '%s' with URL '%s' and database '%s'", desired.Type, desired.URL, desired.Database)
+}
+
+// dataSourceMatches reports whether an existing data source is the desired one by its identity key
+func dataSourceMatches(existing DataSource, desired DataSource) bool {
+	switch desired.Match {
+	case MatchByName:
+		return existing.Name == desired.Name
+	case MatchByUID:
+		return existing.UID == desired.UID
+	case MatchByTypeURLDatabaseUser:
+		return existing.Type == desired.Type && existing.URL == desired.URL && existing.Database == desired.Database && existing.User == desired.User
+	}
+	return existing.Type == desired.Type && existing.URL == desired.URL && existing.Database == desired.Database
+}
+
+// httpDataSourceTypes are the plugins querying an HTTP API, the credentials of the config are their basic auth
+var httpDataSourceTypes = map[string]bool{
+	DataSourceTypePrometheus:        true,
+	"loki":                          true,
+	"tempo":                         true,
+	"jaeger":                        true,
+	"zipkin":                        true,
+	"elasticsearch":                 true,
+	"grafana-opensearch-datasource": true,
+	"graphite":                      true,
+	"influxdb":                      true,
+	"alertmanager":                  true,
+	"grafana-pyroscope-datasource":  true,
+}
+
+// isHTTPDataSourceType reports whether the plugin type queries an HTTP API with basic auth, unlike SQL plugins
+// such as mysql, mssql or grafana-clickhouse-datasource which take a database user and password
+func isHTTPDataSourceType(pluginType string) bool {
+	return httpDataSourceTypes[strings.ToLower(pluginType)]
+}
+
+// isDataSourceType reports whether the plugin type of a data source is the required one. The PostgreSQL
+// plugin answers to both its current and its legacy ID.
+func isDataSourceType(actual string, required string) bool {
+	normalize := func(pluginType string) string {
+		if pluginType == "postgres" {
+			return DataSourceTypePostgres
+		}
+		return pluginType
+	}
+	return strings.EqualFold(normalize(actual), normalize(required))
+}
+
+// preparedDashboard is a dashboard import request together with the state it was prepared from
+type preparedDashboard struct {
+	Config      Dashboard
+	Existing    DashboardSearchResponse // Empty if the dashboard doesn't exist yet
+	InputValues map[string]string       // Dashboard input names mapped to data source UIDs
+	Request     *DashboardImportRequest
+}
+
+// Helper to prepare the dashboard import
+func prepareDashboard(client GrafanaAPI, cfg Dashboard, folderUID string, owner dashboardOwner, annotations []resolvedAnnotation, values map[string]interface{}, sources []ValueSource, git *GitMetadata, log *slog.Logger) (*preparedDashboard, error) {
+	data, err := loadDashboardJSON(cfg, log)
 	if err != nil {
-		return fmt.Errorf("failed to read dashboard file %s: %w", cfg.File, err)
+		return nil, err
 	}
 
 	var rawDashboard DashboardJSON
 	if err := json.Unmarshal(data, &rawDashboard); err != nil {
-		return fmt.Errorf("failed to parse dashboard JSON: %w", err)
+		return nil, fmt.Errorf("failed to parse dashboard JSON: %w", err)
+	}
+
+	// Substitute the per-environment thresholds and limits
+	rawDashboard, err = renderDashboardSourcedValues(rawDashboard, values, sources, log)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MigrateGraphPanels {
+		if migrated := migrateGraphPanels(rawDashboard, log.With("dashboard", cfg.Name)); migrated > 0 {
+			log.Info("Graph panels migrated to timeseries", "dashboard", cfg.Name, "panels", migrated)
+		}
 	}
 
-	// 1. Prepare input values map by resolving all data source UIDs
+	// 1. Prepare input values map by resolving all data source UIDs, each input may use another data source
 	inputValues := make(map[string]string)
+	expectedInputs := dataSourceInputs(rawDashboard)
+	resolved := map[string]*DataSource{}
 	for _, importCfg := range cfg.Imports {
 		// Get data source by name
-		dashboardDataSource, err := client.GetDataSource(importCfg.DataSource)
-		if err != nil {
-			return fmt.Errorf("dashboard dataSource '%s' not found for dashboard '%s' (variable '%s'): %w", importCfg.DataSource, cfg.Name, importCfg.Name, err)
+		dashboardDataSource, ok := resolved[importCfg.DataSource]
+		if !ok {
+			dashboardDataSource, err = client.GetDataSource(importCfg.DataSource)
+			if errors.Is(err, ErrNotFound) {
+				err = fmt.Errorf("%w: %w", ErrMissingDataSource, err)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("dashboard dataSource '%s' not found for dashboard '%s' (variable '%s'): %w", importCfg.DataSource, cfg.Name, importCfg.Name, err)
+			}
+			resolved[importCfg.DataSource] = dashboardDataSource
 		}
-		
+		// A dashboard written for another data source type imports fine but shows no data
+		if cfg.RequiresDataSourceType != "" && !isDataSourceType(dashboardDataSource.Type, cfg.RequiresDataSourceType) {
+			return nil, fmt.Errorf("dashboard '%s' requires %s data sources, but '%s' (variable '%s') is of type '%s'", cfg.Name, cfg.RequiresDataSourceType, importCfg.DataSource, importCfg.Name, dashboardDataSource.Type)
+		}
+		// Files without __inputs reference the variables as ${NAME}, e.g. in db mode
+		pluginID, expected := expectedInputs[importCfg.Name]
+		if !expected && len(expectedInputs) > 0 {
+			log.Warn("Import is not an input of the dashboard file", "dashboard", cfg.Name, "variable", importCfg.Name)
+		}
+		if pluginID != "" && !isDataSourceType(dashboardDataSource.Type, pluginID) {
+			return nil, fmt.Errorf("input '%s' of dashboard '%s' expects a %s data source, but '%s' is of type '%s'", importCfg.Name, cfg.Name, pluginID, importCfg.DataSource, dashboardDataSource.Type)
+		}
+
 		// Map variable name to data source UID
 		inputValues[importCfg.Name] = dashboardDataSource.UID
 	}
+	for name := range expectedInputs {
+		if _, mapped := inputValues[name]; !mapped {
+			return nil, fmt.Errorf("input '%s' of dashboard '%s' is not mapped to a data source in imports", name, cfg.Name)
+		}
+	}
 
-	existingDashboard, err := client.FindFirstDashboardByFolderAndName(cfg.Name, cfg.Folder, log)
+	existingDashboard, found, err := client.FindFirstDashboardByFolderAndName(cfg.Name, cfg.Folder)
 	if err != nil {
-		return fmt.Errorf("failed to find existing dashboard: %w", err)
+		return nil, fmt.Errorf("failed to find existing dashboard: %w", err)
+	}
+
+	// The dashboard may already exist in another folder (e.g. the folder was changed in config).
+	// Reusing its UID makes the import move it instead of creating a duplicate.
+	if !found {
+		movedDashboard, err := findDashboardInOtherFolder(client, cfg, owner, log)
+		if err != nil {
+			return nil, err
+		}
+		if movedDashboard != nil {
+			existingDashboard = *movedDashboard
+		}
+	}
+
+	// New dashboards keep the UID of the JSON unless it belongs to another dashboard
+	uid := existingDashboard.UID
+	if uid == "" {
+		localUID, adopted, err := resolveLocalDashboardUID(client, cfg, rawDashboard, log)
+		if err != nil {
+			return nil, err
+		}
+		if adopted != nil {
+			existingDashboard = *adopted
+		}
+		uid = localUID
 	}
 
 	rawDashboard["title"] = cfg.Name
 	rawDashboard["id"] = existingDashboard.ID
-	rawDashboard["uid"] = existingDashboard.UID
+	rawDashboard["uid"] = uid
+
+	injectAnnotations(rawDashboard, cfg.Name, annotations, log)
 
+	message := "Automated provisioning by " + buildinfo.UserAgent()
+	if git != nil {
+		injectGitTags(rawDashboard, git)
+		message += " from " + git.String()
+	}
 
 	// Get the target folder UID. If 'folderUID' is empty (for 'General' folder), the API handles it.
 	// If the dashboard folder is 'General', we pass an empty folderUID to the import API call.
@@ -308,11 +781,202 @@ func provisionDashboard(client *ApiClient, cfg Dashboard, folderUID string, log
 		Dashboard: rawDashboard,
 		Inputs: inputs,
 		FolderUID: folderUID,
-		Overwrite: true, // Always overwrite to apply latest changes
-		Message:   "Automated provisioning by grafana-provisioner",
+		Overwrite: cfg.Overwrite, // Overwrite by default to apply latest changes
+		Message:   message,
 	}
 
-	return client.ImportDashboard(importRequest)
+	return &preparedDashboard{
+		Config:      cfg,
+		Existing:    existingDashboard,
+		InputValues: inputValues,
+		Request:     importRequest,
+	}, nil
+}
+
+// Helper to import the prepared dashboard, with the k8s-style API when the backend is set
+func importDashboard(client GrafanaAPI, cfg Config, prepared *preparedDashboard, report *Report, log *slog.Logger) error {
+	var importResponse *DashboardImportResponse
+	var err error
+	if cfg.k8s != nil {
+		importResponse, err = applyDashboard(client, cfg.k8s, prepared)
+	} else if prepared.Config.Mode == DashboardModeDB {
+		importResponse, err = saveDashboard(client, prepared)
+	} else {
+		importResponse, err = client.ImportDashboard(prepared.Request)
+		if err != nil {
+			err = diagnoseImportFailure(err, prepared, cfg.DumpDir, log.With("dashboard", prepared.Config.Name))
+		}
+	}
+
+	// With overwrite disabled, a live change since the last run is a 412 conflict
+	if err != nil && !prepared.Request.Overwrite && isVersionConflict(err) {
+		importResponse, err = resolveDashboardConflict(client, cfg, prepared, err, log.With("dashboard", prepared.Config.Name))
+		if err == nil && importResponse == nil {
+			report.add(ResourceResult{
+				Kind:   KindDashboard,
+				Name:   prepared.Config.Name,
+				Action: ActionSkipped,
+				UID:    prepared.Existing.UID,
+				URL:    joinResourceURL(client.BaseURL(), prepared.Existing.URL),
+				Labels: cfg.DashboardLabels(prepared.Config),
+			})
+			return nil
+		}
+	}
+	if skipFileProvisioned(cfg, err) {
+		log.Warn("Dashboard is file-provisioned, skipping it", "dashboard", prepared.Config.Name, "uid", prepared.Existing.UID)
+		report.add(ResourceResult{
+			Kind:   KindDashboard,
+			Name:   prepared.Config.Name,
+			Action: ActionSkipped,
+			UID:    prepared.Existing.UID,
+			URL:    joinResourceURL(client.BaseURL(), prepared.Existing.URL),
+			Labels: cfg.DashboardLabels(prepared.Config),
+		})
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	action := ActionCreated
+	if prepared.Existing.UID != "" {
+		action = ActionUpdated
+	} else if err := waitForDashboard(client, prepared.Config, importResponse.UID, cfg.Grafana.ConsistencyWait, log.With("dashboard", prepared.Config.Name)); err != nil {
+		return err
+	}
+
+	report.add(ResourceResult{
+		Kind:   KindDashboard,
+		Name:   prepared.Config.Name,
+		Action: action,
+		UID:    importResponse.UID,
+		URL:    joinResourceURL(client.BaseURL(), importResponse.ImportedURL),
+		Labels: cfg.DashboardLabels(prepared.Config),
+	})
+	return nil
+}
+
+// saveDashboard saves the prepared dashboard with /api/dashboards/db instead of the import API.
+// The data source references are rewritten here, so dashboards without __inputs can be mapped too.
+func saveDashboard(client GrafanaAPI, prepared *preparedDashboard) (*DashboardImportResponse, error) {
+	dashboard := renderDashboardInputs(prepared.Request.Dashboard, prepared.InputValues)
+	delete(dashboard, "__inputs")
+	delete(dashboard, "__requires")
+
+	saveResponse, err := client.SaveDashboard(&DashboardSaveRequest{
+		Dashboard: dashboard,
+		FolderUID: prepared.Request.FolderUID,
+		Overwrite: prepared.Request.Overwrite,
+		Message:   prepared.Request.Message,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &DashboardImportResponse{
+		UID:         saveResponse.UID,
+		Title:       prepared.Config.Name,
+		Imported:    true,
+		ImportedURL: saveResponse.URL,
+		Slug:        saveResponse.Slug,
+		DashboardID: saveResponse.ID,
+		FolderUID:   prepared.Request.FolderUID,
+	}, nil
+}
+
+// loadDashboardJSON reads the dashboard JSON from its file or downloads it from grafana.com
+func loadDashboardJSON(cfg Dashboard, log *slog.Logger) ([]byte, error) {
+	if cfg.GnetID != 0 {
+		return downloadGnetDashboard(cfg.GnetID, cfg.Revision, log)
+	}
+
+	log.Info("Reading dashboard file", "file", cfg.File)
+	data, err := os.ReadFile(cfg.File)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dashboard file %s: %w", cfg.File, err)
+	}
+	return data, nil
+}
+
+// dashboardOwner is what a run may adopt as a dashboard moved out of another folder: copies with the prune tag,
+// outside of the folders of other dashboards configured with the same name
+type dashboardOwner struct {
+	Tag            string
+	ClaimedFolders []string
+}
+
+// dashboardOwner returns the ownership of the dashboard among the configured dashboards
+func (cfg Config) dashboardOwner(dashboard Dashboard) dashboardOwner {
+	owner := dashboardOwner{Tag: cfg.pruneTag()}
+	for _, other := range cfg.Dashboards {
+		if other.Name == dashboard.Name && other.Folder != dashboard.Folder {
+			owner.ClaimedFolders = append(owner.ClaimedFolders, other.Folder)
+		}
+	}
+	return owner
+}
+
+// findDashboardInOtherFolder looks up a dashboard with the configured name outside of the configured folder.
+// Only a copy with the prune tag that no other configured dashboard claims is moved, others are left alone.
+// Returns nil if no such dashboard exists, and an error if more than one does.
+func findDashboardInOtherFolder(client GrafanaAPI, cfg Dashboard, owner dashboardOwner, log *slog.Logger) (*DashboardSearchResponse, error) {
+	candidates, err := client.FindDashboardsByName(cfg.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search dashboard '%s' in other folders: %w", cfg.Name, err)
+	}
+
+	owned := []DashboardSearchResponse{}
+	for _, candidate := range candidates {
+		if isClaimedFolder(owner.ClaimedFolders, candidate.FolderTitle) {
+			continue
+		}
+		if !hasTag(candidate.Tags, owner.Tag) {
+			log.Warn("Dashboard with the same name in another folder isn't tagged by this tool, not moving it", "name", cfg.Name, "uid", candidate.UID, "folder", candidate.FolderTitle, "tag", owner.Tag)
+			continue
+		}
+		owned = append(owned, candidate)
+	}
+
+	if len(owned) == 0 {
+		return nil, nil
+	}
+	if len(owned) > 1 {
+		folders := make([]string, 0, len(owned))
+		for _, candidate := range owned {
+			folders = append(folders, fmt.Sprintf("'%s'", candidate.FolderTitle))
+		}
+		return nil, fmt.Errorf("dashboard '%s' exists in several other folders (%s), delete the copies that shouldn't be moved to '%s'", cfg.Name, strings.Join(folders, ", "), cfg.Folder)
+	}
+
+	moved := owned[0]
+	log.Info("Dashboard exists in a different folder, moving it", "name", cfg.Name, "uid", moved.UID, "from", moved.FolderTitle, "to", cfg.Folder)
+	return &moved, nil
+}
+
+// isClaimedFolder reports whether the live folder is one of the configured folders
+func isClaimedFolder(claimed []string, live string) bool {
+	for _, folder := range claimed {
+		if isSameFolder(folder, live) {
+			return true
+		}
+	}
+	return false
+}
+
+// dataSourceInputs returns the plugin IDs of the data source `__inputs` of the exported dashboard by input name,
+// empty for inputs without a plugin ID
+func dataSourceInputs(dashboard DashboardJSON) map[string]string {
+	pluginIDs := map[string]string{}
+	inputs, _ := dashboard["__inputs"].([]interface{})
+	for _, input := range inputs {
+		fields, _ := input.(map[string]interface{})
+		name, _ := fields["name"].(string)
+		if inputType, _ := fields["type"].(string); inputType == "datasource" && name != "" {
+			pluginIDs[name], _ = fields["pluginId"].(string)
+		}
+	}
+	return pluginIDs
 }
 
 // processInputs processes input variables and sets their values
@@ -0,0 +1,87 @@
+package grafana
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// searchAPI answers the dashboard searches by name, the other calls of GrafanaAPI aren't implemented
+type searchAPI struct {
+	GrafanaAPI
+	dashboards []DashboardSearchResponse
+}
+
+func (api searchAPI) FindDashboardsByName(name string) ([]DashboardSearchResponse, error) {
+	found := []DashboardSearchResponse{}
+	for _, dashboard := range api.dashboards {
+		if dashboard.Title == name {
+			found = append(found, dashboard)
+		}
+	}
+	return found, nil
+}
+
+func TestFindDashboardInOtherFolder(t *testing.T) {
+	tagged := func(uid, folder string) DashboardSearchResponse {
+		return DashboardSearchResponse{UID: uid, Title: "Overview", FolderTitle: folder, Tags: []string{defaultPruneTag}}
+	}
+	tests := []struct {
+		name       string
+		dashboards []DashboardSearchResponse
+		configured []Dashboard
+		wantUID    string
+		wantErr    string
+	}{
+		{
+			name:       "moves the tagged copy",
+			dashboards: []DashboardSearchResponse{tagged("old", "A")},
+			wantUID:    "old",
+		},
+		{
+			name:       "leaves untagged copies alone",
+			dashboards: []DashboardSearchResponse{{UID: "manual", Title: "Overview", FolderTitle: "A"}},
+		},
+		{
+			name:       "leaves copies claimed by another entry alone",
+			dashboards: []DashboardSearchResponse{tagged("a", "A")},
+			configured: []Dashboard{{Name: "Overview", Folder: "A"}},
+		},
+		{
+			name:       "moves the unclaimed copy only",
+			dashboards: []DashboardSearchResponse{tagged("a", "A"), tagged("old", "C")},
+			configured: []Dashboard{{Name: "Overview", Folder: "A"}},
+			wantUID:    "old",
+		},
+		{
+			name:       "fails with several tagged copies",
+			dashboards: []DashboardSearchResponse{tagged("a", "A"), tagged("c", "C")},
+			wantErr:    "several other folders ('A', 'C')",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dashboard := Dashboard{Name: "Overview", Folder: "B"}
+			cfg := Config{Dashboards: append([]Dashboard{dashboard}, test.configured...)}
+
+			moved, err := findDashboardInOtherFolder(searchAPI{dashboards: test.dashboards}, dashboard, cfg.dashboardOwner(dashboard), slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("findDashboardInOtherFolder() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("findDashboardInOtherFolder() error = %v", err)
+			}
+			uid := ""
+			if moved != nil {
+				uid = moved.UID
+			}
+			if uid != test.wantUID {
+				t.Errorf("findDashboardInOtherFolder() moved %q, want %q", uid, test.wantUID)
+			}
+		})
+	}
+}
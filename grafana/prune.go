@@ -0,0 +1,176 @@
+package grafana
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// defaultPruneTag marks the dashboards and data sources a pruning run owns when no tag is configured
+const defaultPruneTag = "grafana-provisioner"
+
+// pruneMarkerKey is the jsonData key marking the data sources a pruning run owns, data sources have no tags
+const pruneMarkerKey = "provisionedBy"
+
+// pruneTag returns the tag marking the resources owned by pruning runs
+func (cfg Config) pruneTag() string {
+	if cfg.PruneTag == "" {
+		return defaultPruneTag
+	}
+	return cfg.PruneTag
+}
+
+// injectPruneTag adds the prune tag to the tags of the dashboard, once
+func injectPruneTag(dashboard DashboardJSON, tag string) {
+	tags, _ := dashboard["tags"].([]interface{})
+	for _, existing := range tags {
+		if existing == tag {
+			return
+		}
+	}
+	dashboard["tags"] = append(tags, tag)
+}
+
+// hasTag reports whether the tags of a dashboard include the tag
+func hasTag(tags []string, tag string) bool {
+	for _, existing := range tags {
+		if existing == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// withPruneMarker returns a copy of the jsonData of the data source with the prune marker set
+func withPruneMarker(jsonData map[string]interface{}, tag string) map[string]interface{} {
+	marked := map[string]interface{}{pruneMarkerKey: tag}
+	for key, value := range jsonData {
+		marked[key] = value
+	}
+	return marked
+}
+
+// pruneResources deletes the dashboards and data sources carrying the prune tag that the run didn't provision,
+// e.g. after they were removed from the config, and the unconfigured folders left empty by that. Resources
+// created by hand or before pruning was enabled don't carry the tag and are never deleted. File-provisioned ones
+// are skipped or fail the run, see Config.FileProvisioned.
+func pruneResources(client GrafanaAPI, cfg Config, report *Report, log *slog.Logger) error {
+	tag := cfg.pruneTag()
+	log.Info("Pruning resources removed from the config", "tag", tag)
+
+	provisioned := map[string]bool{}
+	for _, resource := range report.Resources {
+		if resource.UID != "" {
+			provisioned[resource.Kind+"/"+resource.UID] = true
+		}
+	}
+
+	dashboards, err := client.SearchDashboards()
+	if err != nil {
+		return fmt.Errorf("failed to list dashboards: %w", err)
+	}
+	prunedDashboards := []DashboardSearchResponse{}
+	remaining := map[string]int{} // Dashboards, subfolders and alert rules left per folder UID
+	for _, dashboard := range dashboards {
+		if dashboard.Type != "dash-db" {
+			remaining[dashboard.FolderUID]++
+			continue
+		}
+		owned := hasTag(dashboard.Tags, tag)
+		// The dashboards of other shards are pruned by their own runs
+		if owned && !provisioned[KindDashboard+"/"+dashboard.UID] && cfg.Shard.owns(dashboard.FolderTitle) {
+			prunedDashboards = append(prunedDashboards, dashboard)
+		} else {
+			remaining[dashboard.FolderUID]++
+		}
+	}
+
+	dataSources, err := client.GetDataSources()
+	if err != nil {
+		return fmt.Errorf("failed to list data sources: %w", err)
+	}
+	prunedDataSources := []DataSource{}
+	for _, dataSource := range dataSources {
+		if dataSource.JSONData[pruneMarkerKey] != tag || provisioned[KindDataSource+"/"+dataSource.UID] || !cfg.Shard.shared() {
+			continue
+		}
+		// Grafana lists the data sources of its file provisioning as read-only, deleting them is refused
+		if dataSource.ReadOnly {
+			err := fmt.Errorf("data source '%s' (uid %s): %w", dataSource.Name, dataSource.UID, ErrFileProvisioned)
+			if !skipFileProvisioned(cfg, err) {
+				return report.fail(KindDataSource, dataSource.Name, err)
+			}
+			log.Warn("Data source is file-provisioned, not pruning it", "datasource", dataSource.Name, "uid", dataSource.UID)
+			report.add(ResourceResult{Kind: KindDataSource, Name: dataSource.Name, Action: ActionSkipped, UID: dataSource.UID})
+			continue
+		}
+		prunedDataSources = append(prunedDataSources, dataSource)
+	}
+
+	rules, err := client.GetAlertRules()
+	if err != nil {
+		return fmt.Errorf("failed to list alert rules: %w", err)
+	}
+	for _, rule := range rules {
+		remaining[rule.FolderUID]++
+	}
+
+	// Only folders emptied by the prune go, folders that were empty before may be in use otherwise
+	emptied := map[string]bool{}
+	for _, dashboard := range prunedDashboards {
+		if dashboard.FolderUID != "" && remaining[dashboard.FolderUID] == 0 {
+			emptied[dashboard.FolderUID] = true
+		}
+	}
+	for _, folder := range cfg.FoldersMapping {
+		delete(emptied, folder.UID)
+	}
+	folders, err := client.GetFolders()
+	if err != nil {
+		return fmt.Errorf("failed to list folders: %w", err)
+	}
+	prunedFolders := []FolderResponse{}
+	for _, folder := range folders {
+		if emptied[folder.UID] {
+			prunedFolders = append(prunedFolders, folder)
+		}
+	}
+
+	if err := cfg.Safety.CheckDeletes(len(prunedDashboards) + len(prunedDataSources) + len(prunedFolders)); err != nil {
+		return err
+	}
+
+	kept := map[string]bool{} // Folders of file-provisioned dashboards left in place
+	for _, dashboard := range prunedDashboards {
+		log.Info("Pruning dashboard", "dashboard", dashboard.Title, "folder", dashboard.FolderTitle, "uid", dashboard.UID)
+		if err := client.DeleteDashboardByUID(dashboard.UID); err != nil {
+			if skipFileProvisioned(cfg, err) {
+				log.Warn("Dashboard is file-provisioned, not pruning it", "dashboard", dashboard.Title, "uid", dashboard.UID)
+				report.add(ResourceResult{Kind: KindDashboard, Name: dashboard.Title, Action: ActionSkipped, UID: dashboard.UID})
+				kept[dashboard.FolderUID] = true
+				continue
+			}
+			return report.fail(KindDashboard, dashboard.Title, err)
+		}
+		report.add(ResourceResult{Kind: KindDashboard, Name: dashboard.Title, Action: ActionDeleted, UID: dashboard.UID})
+	}
+	for _, dataSource := range prunedDataSources {
+		log.Info("Pruning data source", "datasource", dataSource.Name, "uid", dataSource.UID)
+		if err := client.DeleteDataSourceByUID(dataSource.UID); err != nil {
+			return report.fail(KindDataSource, dataSource.Name, err)
+		}
+		report.add(ResourceResult{Kind: KindDataSource, Name: dataSource.Name, Action: ActionDeleted, UID: dataSource.UID})
+	}
+	for _, folder := range prunedFolders {
+		if kept[folder.UID] {
+			continue
+		}
+		log.Info("Pruning folder", "folder", folder.Title, "uid", folder.UID)
+		if err := client.DeleteFolder(folder.UID); err != nil {
+			return report.fail(KindFolder, folder.Title, err)
+		}
+		report.add(ResourceResult{Kind: KindFolder, Name: folder.Title, Action: ActionDeleted, UID: folder.UID})
+	}
+
+	log.Info("Resources pruned", "dashboards", len(prunedDashboards), "datasources", len(prunedDataSources), "folders", len(prunedFolders))
+	return nil
+}
@@ -0,0 +1,115 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// renderDashboardInputs returns a deep copy of the dashboard with all `${INPUT}` references replaced
+// by their values, the same way the import API substitutes __inputs on the server side.
+func renderDashboardInputs(dashboard DashboardJSON, inputValues map[string]string) DashboardJSON {
+	replacements := []string{}
+	for name, value := range inputValues {
+		replacements = append(replacements, "${"+name+"}", value)
+	}
+	replacer := strings.NewReplacer(replacements...)
+
+	rendered, _ := renderValue(map[string]interface{}(dashboard), replacer).(map[string]interface{})
+	return rendered
+}
+
+// renderValue walks a decoded JSON value replacing input references in all strings
+func renderValue(value interface{}, replacer *strings.Replacer) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			copied[key] = renderValue(item, replacer)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(typed))
+		for i, item := range typed {
+			copied[i] = renderValue(item, replacer)
+		}
+		return copied
+	case string:
+		return replacer.Replace(typed)
+	}
+	return value
+}
+
+// dashboardContentKeys are the parts of a dashboard model compared to detect content changes.
+// Identity and bookkeeping fields (id, uid, version, __inputs) are left out on purpose.
+var dashboardContentKeys = []string{"panels", "templating", "annotations", "links", "tags", "time", "refresh"}
+
+// dashboardContentEqual reports whether two dashboard models have the same content
+func dashboardContentEqual(a DashboardJSON, b DashboardJSON) bool {
+	for _, key := range dashboardContentKeys {
+		left, _ := json.Marshal(a[key])
+		right, _ := json.Marshal(b[key])
+		if string(left) != string(right) {
+			return false
+		}
+	}
+	return true
+}
+
+// valuePlaceholderPattern matches `${values.NAME}` placeholders of the environment values file
+var valuePlaceholderPattern = regexp.MustCompile(`\$\{values\.([A-Za-z0-9_.-]+)\}`)
+
+// renderDashboardValues returns a deep copy of the dashboard with all `${values.NAME}` placeholders replaced.
+// A string consisting of a single placeholder takes the value with its type, so thresholds stay numbers.
+func renderDashboardValues(dashboard DashboardJSON, values map[string]interface{}) (DashboardJSON, error) {
+	missing := map[string]bool{}
+	rendered, _ := renderValuePlaceholders(map[string]interface{}(dashboard), values, missing).(map[string]interface{})
+
+	if len(missing) > 0 {
+		names := []string{}
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("dashboard placeholders without a value in the values file or the value sources: %s", strings.Join(names, ", "))
+	}
+	return rendered, nil
+}
+
+// renderValuePlaceholders walks a decoded JSON value replacing placeholders, collecting the unknown names
+func renderValuePlaceholders(value interface{}, values map[string]interface{}, missing map[string]bool) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			copied[key] = renderValuePlaceholders(item, values, missing)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(typed))
+		for i, item := range typed {
+			copied[i] = renderValuePlaceholders(item, values, missing)
+		}
+		return copied
+	case string:
+		if match := valuePlaceholderPattern.FindStringSubmatch(typed); match != nil && match[0] == typed {
+			if replacement, ok := values[match[1]]; ok {
+				return replacement
+			}
+			missing[match[1]] = true
+			return typed
+		}
+		return valuePlaceholderPattern.ReplaceAllStringFunc(typed, func(placeholder string) string {
+			name := valuePlaceholderPattern.FindStringSubmatch(placeholder)[1]
+			replacement, ok := values[name]
+			if !ok {
+				missing[name] = true
+				return placeholder
+			}
+			return fmt.Sprint(replacement)
+		})
+	}
+	return value
+}
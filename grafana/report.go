@@ -0,0 +1,110 @@
+package grafana
+
+import (
+	"github.com/ilya-pishchalnikov/grafana-provisioner/buildinfo"
+	"time"
+)
+
+// Resource kinds used in run reports
+const (
+	KindOrg            = "org"
+	KindTeam           = "team"
+	KindServiceAccount = "service-account"
+	KindFolder         = "folder"
+	KindDataSource     = "datasource"
+	KindDashboard      = "dashboard"
+	KindAlertRuleGroup = "alert-rule-group"
+	KindAlertRule      = "alert-rule"
+)
+
+// Resource actions used in run reports
+const (
+	ActionCreated     = "created"
+	ActionUpdated     = "updated"
+	ActionUnchanged   = "unchanged"
+	ActionProvisioned = "provisioned" // Created or already existing, the API does not tell
+	ActionSkipped     = "skipped"     // Left as is, e.g. a conflicting dashboard kept on prompt
+	ActionDeleted     = "deleted"     // Removed from the config and pruned
+)
+
+// ResourceResult is the outcome of provisioning a single resource.
+type ResourceResult struct {
+	Kind     string
+	Name     string // Logical name from the config
+	Action   string
+	UID      string
+	URL      string            // Absolute URL of the resource in Grafana, if it has one
+	Problems []string          // Found after provisioning, e.g. panels referencing missing data sources
+	Labels   map[string]string // Labels of the configured resource, dashboards include their folder's
+	Duration time.Duration     // Time since the previous result or the start of its phase
+}
+
+// Report collects the results of a provisioning run.
+type Report struct {
+	ToolVersion  string // Version of the provisioner that made the changes
+	StartedAt    time.Time
+	FinishedAt   time.Time
+	Resources    []ResourceResult
+	Plan         []PlannedChange // Changes a dry run would have made, see Config.DryRun
+	FolderStats  []FolderStat    // Dashboards per folder after the planned changes, dry runs only
+	Deprecations []Deprecation   // Deprecation notices Grafana sent for the API calls of the run
+	Shard        string          // Shard of the config the run provisioned, e.g. 2/5, empty for all
+	onEvent      func(Event)
+	since        time.Time // End of the previous result or start of the phase, see add
+}
+
+// ReferenceMap maps logical resource names from the config to their live identifiers.
+type ReferenceMap struct {
+	Generator   string            `json:"generator" yaml:"generator"`     // Tool and version that wrote the map
+	DataSources map[string]string `json:"datasources" yaml:"datasources"` // Data source name to UID
+	Dashboards  map[string]string `json:"dashboards" yaml:"dashboards"`   // Dashboard name to URL
+	AlertRules  map[string]string `json:"alert_rules" yaml:"alert_rules"` // Rule group and title to UID
+}
+
+// add appends a resource result to the report
+func (report *Report) add(result ResourceResult) {
+	now := time.Now()
+	if !report.since.IsZero() {
+		result.Duration = now.Sub(report.since)
+	}
+	report.since = now
+	report.Resources = append(report.Resources, result)
+	report.emit(ResourceApplied{Result: result})
+}
+
+// Broken returns the resources provisioned with problems, e.g. dashboards imported but referencing missing data sources.
+func (report *Report) Broken() []ResourceResult {
+	broken := []ResourceResult{}
+	for _, resource := range report.Resources {
+		if len(resource.Problems) > 0 {
+			broken = append(broken, resource)
+		}
+	}
+	return broken
+}
+
+// References builds the reference map of the provisioned data sources, dashboards and alert rules.
+func (report *Report) References() ReferenceMap {
+	references := ReferenceMap{
+		Generator:   buildinfo.Name + "/" + report.ToolVersion,
+		DataSources: map[string]string{},
+		Dashboards:  map[string]string{},
+		AlertRules:  map[string]string{},
+	}
+
+	for _, resource := range report.Resources {
+		if resource.Action == ActionDeleted {
+			continue
+		}
+		switch resource.Kind {
+		case KindDataSource:
+			references.DataSources[resource.Name] = resource.UID
+		case KindDashboard:
+			references.Dashboards[resource.Name] = resource.URL
+		case KindAlertRule:
+			references.AlertRules[resource.Name] = resource.UID
+		}
+	}
+
+	return references
+}
@@ -0,0 +1,118 @@
+package grafana
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RulerClient pushes rule groups to a Cortex-compatible ruler API (Mimir or Loki).
+type RulerClient struct {
+	api  *ApiClient
+	Type string
+}
+
+// NewRulerClient creates a ruler client reusing the Grafana client timeouts and retries, its requests are
+// canceled with the context like those of the Grafana client
+func NewRulerClient(ctx context.Context, ruler Ruler, params ClientParams, logger *slog.Logger) *RulerClient {
+	params.URL = ruler.URL
+	params.Token = ruler.Token
+	params.APIKey, params.Username, params.Password = "", "", ""
+	// The client certificate authenticates against Grafana, the CA bundle of the internal PKI is kept
+	params.TLS.CertFile, params.TLS.KeyFile = "", ""
+
+	api := NewClient(params, logger.With("ruler", ruler.Name))
+	api.ctx = ctx
+	api.Headers["Content-Type"] = "application/yaml"
+	if ruler.Token == "" {
+		delete(api.Headers, "Authorization")
+	}
+	if ruler.Tenant != "" {
+		api.Headers["X-Scope-OrgID"] = ruler.Tenant
+	}
+
+	return &RulerClient{api: api, Type: ruler.Type}
+}
+
+// SetRuleGroup creates or replaces a rule group in the given namespace.
+func (ruler *RulerClient) SetRuleGroup(namespace string, group RulerRuleGroup) error {
+	ruler.api.Logger.Info("Pushing rule group to ruler", "namespace", namespace, "group", group.Name, "rules", len(group.Rules))
+
+	var endpoint string
+	switch ruler.Type {
+	case "mimir":
+		endpoint = fmt.Sprintf("%s/prometheus/config/v1/rules/%s", ruler.api.URL, url.PathEscape(namespace))
+	case "loki":
+		endpoint = fmt.Sprintf("%s/loki/api/v1/rules/%s", ruler.api.URL, url.PathEscape(namespace))
+	default:
+		return fmt.Errorf("unsupported ruler type '%s'", ruler.Type)
+	}
+
+	data, err := yaml.Marshal(group)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ruler rule group: %w", err)
+	}
+
+	if _, err := ruler.api.doRequest("POST", endpoint, data); err != nil {
+		return fmt.Errorf("ruler rule group push failed: %w", err)
+	}
+
+	ruler.api.Logger.Info("Rule group successfully pushed to ruler", "namespace", namespace, "group", group.Name)
+	return nil
+}
+
+// provisionRulerRuleGroup converts a configured rule group to the Prometheus rule format and pushes it to its ruler
+func provisionRulerRuleGroup(ctx context.Context, cfg Config, group AlertRuleGroup, log *slog.Logger) error {
+	ruler, ok := findRuler(cfg.Rulers, group.Ruler)
+	if !ok {
+		return fmt.Errorf("ruler '%s' is not defined in the 'alerting.rulers' configuration list", group.Ruler)
+	}
+
+	namespace := group.Namespace
+	if namespace == "" {
+		namespace = group.Folder
+	}
+	if namespace == "" {
+		return fmt.Errorf("rule group needs a namespace or a folder to be pushed to a ruler")
+	}
+
+	rulerGroup := RulerRuleGroup{Name: group.Name}
+	if group.Interval > 0 {
+		rulerGroup.Interval = group.Interval.String()
+	}
+
+	for _, rule := range group.Rules {
+		if rule.Expr == "" {
+			return fmt.Errorf("rule '%s' needs an expr to be pushed to a ruler", rule.Title)
+		}
+
+		rulerRule := RulerRule{
+			Expr:        rule.Expr,
+			Labels:      rule.Labels,
+			Annotations: rule.Annotations,
+		}
+		if rule.Record != "" {
+			rulerRule.Record = rule.Record
+		} else {
+			rulerRule.Alert = rule.Title
+			rulerRule.For = rule.For
+		}
+
+		rulerGroup.Rules = append(rulerGroup.Rules, rulerRule)
+	}
+
+	return NewRulerClient(ctx, ruler, cfg.Grafana, log).SetRuleGroup(namespace, rulerGroup)
+}
+
+// findRuler returns the ruler with the given name
+func findRuler(rulers []Ruler, name string) (Ruler, bool) {
+	for _, ruler := range rulers {
+		if ruler.Name == name {
+			return ruler, true
+		}
+	}
+	return Ruler{}, false
+}
@@ -0,0 +1,69 @@
+package grafana
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// SafetyLimits are per-run guardrails protecting a production instance against mass changes,
+// e.g. from a truncated config. Zero values disable the corresponding limit.
+type SafetyLimits struct {
+	MaxDeletes          int  // Maximum number of resources deleted in one run
+	MaxOverwritePercent int  // Maximum share of existing managed dashboards overwritten with changed content
+	AllowMassChange     bool // Exceed the limits anyway (--allow-mass-change)
+}
+
+// CheckDeletes refuses to delete more resources than allowed in one run.
+func (limits SafetyLimits) CheckDeletes(count int) error {
+	if limits.MaxDeletes <= 0 || count <= limits.MaxDeletes {
+		return nil
+	}
+	if limits.AllowMassChange {
+		return nil
+	}
+	return fmt.Errorf("refusing to delete %d resources, the limit is %d per run (pass --allow-mass-change to exceed it)", count, limits.MaxDeletes)
+}
+
+// CheckOverwrites refuses to overwrite more than the allowed percentage of existing managed dashboards.
+func (limits SafetyLimits) CheckOverwrites(overwritten int, existing int) error {
+	if limits.MaxOverwritePercent <= 0 || existing == 0 {
+		return nil
+	}
+
+	percent := overwritten * 100 / existing
+	if percent <= limits.MaxOverwritePercent || limits.AllowMassChange {
+		return nil
+	}
+	return fmt.Errorf("refusing to overwrite %d of %d existing managed dashboards (%d%%), the limit is %d%% per run (pass --allow-mass-change to exceed it)",
+		overwritten, existing, percent, limits.MaxOverwritePercent)
+}
+
+// checkDashboardOverwrites counts existing dashboards whose content would change and applies the overwrite limit.
+// Live dashboards are only fetched when the limit is enabled.
+func checkDashboardOverwrites(client GrafanaAPI, limits SafetyLimits, dashboards []*preparedDashboard, log *slog.Logger) error {
+	if limits.MaxOverwritePercent <= 0 {
+		return nil
+	}
+
+	existing := 0
+	overwritten := 0
+	for _, prepared := range dashboards {
+		if prepared.Existing.UID == "" {
+			continue
+		}
+		existing++
+
+		live, err := client.GetDashboardByUID(prepared.Existing.UID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch live dashboard '%s' for the overwrite check: %w", prepared.Config.Name, err)
+		}
+
+		desired := renderDashboardInputs(prepared.Request.Dashboard, prepared.InputValues)
+		if !dashboardContentEqual(live.Dashboard, desired) {
+			overwritten++
+		}
+	}
+
+	log.Info("Dashboard overwrite check", "existing", existing, "changed", overwritten, "limit_percent", limits.MaxOverwritePercent)
+	return limits.CheckOverwrites(overwritten, existing)
+}
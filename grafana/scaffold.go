@@ -0,0 +1,60 @@
+package grafana
+
+// scaffoldSchemaVersion is the dashboard schema version of scaffolded dashboards
+const scaffoldSchemaVersion = 39
+
+// ScaffoldDashboard builds a minimal dashboard with one time series panel querying the data source through a
+// `${DS_NAME}` input, in the format of dashboards exported for sharing externally. The returned import wires the
+// input to the data source in the config.
+func ScaffoldDashboard(title string, dataSource DataSource) (DashboardJSON, DashboardImport) {
+	name := inputName(dataSource)
+	ref := map[string]interface{}{"type": dataSource.Type, "uid": "${" + name + "}"}
+
+	target := map[string]interface{}{"refId": "A", "datasource": ref}
+	switch dataSource.Type {
+	case DataSourceTypePostgres:
+		target["editorMode"] = "code"
+		target["format"] = "time_series"
+		target["rawQuery"] = true
+		target["rawSql"] = "SELECT now() AS time, 1 AS value"
+	case DataSourceTypePrometheus:
+		target["editorMode"] = "code"
+		target["expr"] = "up"
+		target["range"] = true
+	}
+
+	dashboard := DashboardJSON{
+		"__inputs": []interface{}{
+			map[string]interface{}{
+				"name":        name,
+				"label":       dataSource.Name,
+				"description": "",
+				"type":        "datasource",
+				"pluginId":    dataSource.Type,
+				"pluginName":  dataSource.Type,
+			},
+		},
+		"__requires": []interface{}{
+			map[string]interface{}{"type": "datasource", "id": dataSource.Type, "name": dataSource.Type},
+		},
+		"id":            nil,
+		"title":         title,
+		"tags":          []interface{}{},
+		"editable":      true,
+		"schemaVersion": scaffoldSchemaVersion,
+		"time":          map[string]interface{}{"from": "now-6h", "to": "now"},
+		"templating":    map[string]interface{}{"list": []interface{}{}},
+		"annotations":   map[string]interface{}{"list": []interface{}{}},
+		"panels": []interface{}{
+			map[string]interface{}{
+				"id":         1,
+				"type":       "timeseries",
+				"title":      title,
+				"datasource": ref,
+				"gridPos":    map[string]interface{}{"h": 8, "w": 12, "x": 0, "y": 0},
+				"targets":    []interface{}{target},
+			},
+		},
+	}
+	return dashboard, DashboardImport{Name: name, DataSource: dataSource.Name}
+}
@@ -0,0 +1,104 @@
+package grafana
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// roleRank orders the org roles by their permissions
+var roleRank = map[string]int{"Viewer": 1, "Editor": 2, "Admin": 3}
+
+// TokenScope is the org role and server admin permission the provisioning of a config needs
+type TokenScope struct {
+	Role        string
+	ServerAdmin bool
+	Reasons     []string // Parts of the config needing more than the Editor role
+}
+
+// RequiredTokenScope analyzes the actions provisioning the config plans and returns the narrowest permissions
+// they need. Folders, dashboards and annotations need the Editor role, data sources, teams, folder permissions,
+// service accounts and Grafana-managed alerting the Admin role, organizations a server admin.
+func RequiredTokenScope(cfg Config) TokenScope {
+	scope := TokenScope{Role: "Editor"}
+	needAdmin := func(reason string) {
+		scope.Role = "Admin"
+		scope.Reasons = append(scope.Reasons, reason)
+	}
+
+	if len(cfg.Orgs) > 0 {
+		scope.ServerAdmin = true
+		scope.Reasons = append(scope.Reasons, "orgs (server admin)")
+	}
+	if len(cfg.DataSources) > 0 || cfg.Prune {
+		needAdmin("datasources")
+	}
+	if len(cfg.Teams) > 0 {
+		needAdmin("teams")
+	}
+	for _, folder := range cfg.Folders {
+		if folder.OwnerTeam != "" {
+			needAdmin("folder permissions of owner_team")
+			break
+		}
+	}
+	for _, folder := range cfg.Folders {
+		if folder.Permissions != nil {
+			needAdmin("folder permissions")
+			break
+		}
+	}
+	for _, dashboard := range cfg.Dashboards {
+		if dashboard.Permissions != nil {
+			needAdmin("dashboard permissions")
+			break
+		}
+	}
+	for _, folder := range cfg.Folders {
+		if folder.ServiceAccount {
+			needAdmin("folder service accounts")
+			break
+		}
+	}
+	for _, group := range cfg.AlertRuleGroups {
+		if group.Ruler == "" {
+			needAdmin("Grafana-managed alert rules")
+			break
+		}
+	}
+	if len(cfg.ContactPoints) > 0 {
+		needAdmin("contact points")
+	}
+	if cfg.NotificationPolicies != nil {
+		needAdmin("notification policies")
+	}
+	return scope
+}
+
+// checkTokenScope warns, or fails when strict, if the token has more permissions than the config needs
+func checkTokenScope(token *TokenInfo, cfg Config, log *slog.Logger) error {
+	if _, known := roleRank[token.Role]; !known {
+		log.Debug("Role of the token is not known, skipping the token scope check", "role", token.Role)
+		return nil
+	}
+
+	required := RequiredTokenScope(cfg)
+	excess := []string{}
+	if roleRank[token.Role] > roleRank[required.Role] {
+		excess = append(excess, fmt.Sprintf("org role %s where %s is enough", token.Role, required.Role))
+	}
+	if token.IsGrafanaAdmin && !required.ServerAdmin {
+		excess = append(excess, "server admin permissions, which no part of the config needs")
+	}
+	if len(excess) == 0 {
+		return nil
+	}
+
+	suggestion := fmt.Sprintf("use a service account with the %s role", required.Role)
+	if cfg.StrictTokenScope {
+		return fmt.Errorf("the token has more permissions than the config needs (%s), %s", strings.Join(excess, ", "), suggestion)
+	}
+	log.Warn("The token has more permissions than the config needs, "+suggestion,
+		"login", token.Login, "excess", strings.Join(excess, ", "), "needed_for", strings.Join(required.Reasons, ", "))
+	return nil
+}
@@ -0,0 +1,65 @@
+package grafana
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SecretSink stores secrets generated during provisioning, such as service account tokens
+type SecretSink interface {
+	Put(name string, value string) error
+}
+
+// Secret sink types
+const (
+	SecretSinkDir     = "dir"      // One file per secret in a directory
+	SecretSinkEnvFile = "env-file" // NAME=value lines in a dotenv file
+)
+
+// NewSecretSink creates the secret sink of the given type
+func NewSecretSink(sinkType string, path string) (SecretSink, error) {
+	switch sinkType {
+	case SecretSinkDir:
+		return &dirSecretSink{dir: path}, nil
+	case SecretSinkEnvFile:
+		return &envFileSecretSink{path: path}, nil
+	}
+	return nil, fmt.Errorf("unsupported secrets sink type '%s'", sinkType)
+}
+
+// dirSecretSink writes each secret to <dir>/<name>.token
+type dirSecretSink struct {
+	dir string
+}
+
+func (sink *dirSecretSink) Put(name string, value string) error {
+	if err := os.MkdirAll(sink.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create secrets directory: %w", err)
+	}
+	return os.WriteFile(filepath.Join(sink.dir, name+".token"), []byte(value+"\n"), 0o600)
+}
+
+// envFileSecretSink sets NAME=value in a dotenv file, the name upper-cased with dashes replaced
+type envFileSecretSink struct {
+	path string
+}
+
+func (sink *envFileSecretSink) Put(name string, value string) error {
+	key := strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_TOKEN"
+
+	lines := []string{}
+	data, err := os.ReadFile(sink.path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read env file: %w", err)
+	}
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		if line != "" && !strings.HasPrefix(line, key+"=") {
+			lines = append(lines, line)
+		}
+	}
+	lines = append(lines, key+"="+value)
+
+	return os.WriteFile(sink.path, []byte(strings.Join(lines, "\n")+"\n"), 0o600)
+}
@@ -0,0 +1,159 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// folderTokenName is the name of the tokens created for folder service accounts
+const folderTokenName = "grafana-provisioner"
+
+// FindServiceAccountByName looks up a service account by its exact name. Returns nil if it doesn't exist.
+func (client *ApiClient) FindServiceAccountByName(name string) (*ServiceAccountResponse, error) {
+	resp, err := client.doRequest("GET", fmt.Sprintf("%s/api/serviceaccounts/search?query=%s", client.URL, url.QueryEscape(name)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search service account '%s': %w", name, err)
+	}
+
+	var result struct {
+		ServiceAccounts []ServiceAccountResponse `json:"serviceAccounts"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode service account search response: %w", err)
+	}
+
+	for _, serviceAccount := range result.ServiceAccounts {
+		if serviceAccount.Name == name {
+			return &serviceAccount, nil
+		}
+	}
+	return nil, nil
+}
+
+// CreateServiceAccount creates a service account with the given basic role
+func (client *ApiClient) CreateServiceAccount(name string, role string) (*ServiceAccountResponse, error) {
+	client.Logger.Info("Creating new service account", "name", name, "role", role)
+
+	data, err := json.Marshal(map[string]interface{}{"name": name, "role": role, "isDisabled": false})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal service account model: %w", err)
+	}
+
+	resp, err := client.doRequest("POST", client.URL+"/api/serviceaccounts", data)
+	if err != nil {
+		return nil, fmt.Errorf("service account creation failed: %w", err)
+	}
+
+	var response ServiceAccountResponse
+	if err := json.Unmarshal(resp, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal service account creation response: %w", err)
+	}
+
+	client.Logger.Info("Service account successfully created", "name", name, "id", response.ID)
+	return &response, nil
+}
+
+// GetServiceAccountTokenNames returns the names of the tokens of the service account
+func (client *ApiClient) GetServiceAccountTokenNames(serviceAccountID int) ([]string, error) {
+	resp, err := client.doRequest("GET", fmt.Sprintf("%s/api/serviceaccounts/%d/tokens", client.URL, serviceAccountID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service account tokens: %w", err)
+	}
+
+	var tokens []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(resp, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to decode service account tokens: %w", err)
+	}
+
+	names := []string{}
+	for _, token := range tokens {
+		names = append(names, token.Name)
+	}
+	return names, nil
+}
+
+// CreateServiceAccountToken creates a token and returns its key, which Grafana never shows again
+func (client *ApiClient) CreateServiceAccountToken(serviceAccountID int, name string) (string, error) {
+	data, err := json.Marshal(map[string]string{"name": name})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal service account token model: %w", err)
+	}
+
+	resp, err := client.doRequest("POST", fmt.Sprintf("%s/api/serviceaccounts/%d/tokens", client.URL, serviceAccountID), data)
+	if err != nil {
+		return "", fmt.Errorf("service account token creation failed: %w", err)
+	}
+
+	var response struct {
+		Key string `json:"key"`
+	}
+	if err := json.Unmarshal(resp, &response); err != nil {
+		return "", fmt.Errorf("failed to unmarshal service account token response: %w", err)
+	}
+
+	client.Logger.Info("Service account token created", "service_account_id", serviceAccountID, "token", name)
+	return response.Key, nil
+}
+
+// folderServiceAccountName returns the service account name of a folder, e.g. "folder-team-a"
+func folderServiceAccountName(folder string) string {
+	return "folder-" + strings.Trim(unsafeFileNameChars.ReplaceAllString(strings.ToLower(folder), "-"), "-")
+}
+
+// ensureFolderServiceAccount creates the service account of the folder with no org role, so it can only
+// act through the folder permissions, and stores a new token in the secrets sink.
+// Returns the service account user ID used in permissions.
+func ensureFolderServiceAccount(client GrafanaAPI, cfg *Config, folder string, report *Report, log *slog.Logger) (int, error) {
+	name := folderServiceAccountName(folder)
+
+	action := ActionUnchanged
+	serviceAccount, err := client.FindServiceAccountByName(name)
+	if err != nil {
+		return 0, err
+	}
+	if serviceAccount == nil {
+		serviceAccount, err = client.CreateServiceAccount(name, "None")
+		if err != nil {
+			return 0, err
+		}
+		action = ActionCreated
+	}
+
+	// A token is only created once: its key can't be read back, the sink keeps it
+	tokenNames, err := client.GetServiceAccountTokenNames(serviceAccount.ID)
+	if err != nil {
+		return 0, err
+	}
+	if !slices.Contains(tokenNames, folderTokenName) {
+		if cfg.SecretSink == nil {
+			return 0, fmt.Errorf("service account '%s' needs a token but no 'secrets_sink' is configured", name)
+		}
+
+		key, err := client.CreateServiceAccountToken(serviceAccount.ID, folderTokenName)
+		if err != nil {
+			return 0, err
+		}
+		if err := cfg.SecretSink.Put(name, key); err != nil {
+			return 0, fmt.Errorf("failed to store the token of service account '%s': %w", name, err)
+		}
+		log.Info("Service account token stored in the secrets sink", "service_account", name)
+		if action == ActionUnchanged {
+			action = ActionUpdated
+		}
+	}
+
+	report.add(ResourceResult{
+		Kind:   KindServiceAccount,
+		Name:   name,
+		Action: action,
+		UID:    strconv.Itoa(serviceAccount.ID),
+	})
+	return serviceAccount.ID, nil
+}
@@ -0,0 +1,101 @@
+package grafana
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// Shard is one of the disjoint parts of the resources of a config that CI jobs apply in parallel, e.g. 2/5.
+// Folders go to a shard by the hash of their name, together with their dashboards and alert rule groups
+// in every organization. The organizations, teams, data sources, contact points, notification policies and the
+// status dashboard the other shards depend on go to the first shard.
+type Shard struct {
+	Index int // 1-based
+	Count int
+}
+
+// ParseShard parses a shard of the form <index>/<count>, e.g. 2/5
+func ParseShard(value string) (*Shard, error) {
+	index, count, ok := strings.Cut(value, "/")
+	shard := &Shard{}
+	var indexErr, countErr error
+	shard.Index, indexErr = strconv.Atoi(strings.TrimSpace(index))
+	shard.Count, countErr = strconv.Atoi(strings.TrimSpace(count))
+	if !ok || indexErr != nil || countErr != nil || shard.Count < 1 || shard.Index < 1 || shard.Index > shard.Count {
+		return nil, fmt.Errorf("invalid shard '%s', expected <index>/<count> with 1 <= index <= count, e.g. 2/5", value)
+	}
+	return shard, nil
+}
+
+// String returns the shard as <index>/<count>
+func (shard *Shard) String() string {
+	return fmt.Sprintf("%d/%d", shard.Index, shard.Count)
+}
+
+// owns reports whether the resources of the folder belong to the shard. A nil shard owns everything.
+func (shard *Shard) owns(folder string) bool {
+	if shard == nil {
+		return true
+	}
+	if folder == "" {
+		folder = "General"
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(strings.ToLower(folder)))
+	return int(hash.Sum32()%uint32(shard.Count)) == shard.Index-1
+}
+
+// shared reports whether the shard provisions the resources the other shards depend on
+func (shard *Shard) shared() bool {
+	return shard == nil || shard.Index == 1
+}
+
+// apply returns the config with only the resources of the shard
+func (shard *Shard) apply(cfg Config) Config {
+	if shard == nil {
+		return cfg
+	}
+
+	folders := []Folder{}
+	for _, folder := range cfg.Folders {
+		if shard.owns(folder.Name) {
+			folders = append(folders, folder)
+		}
+	}
+	cfg.Folders = folders
+
+	dashboards := []Dashboard{}
+	for _, dashboard := range cfg.Dashboards {
+		if shard.owns(dashboard.Folder) {
+			dashboards = append(dashboards, dashboard)
+		}
+	}
+	cfg.Dashboards = dashboards
+
+	groups := []AlertRuleGroup{}
+	for _, group := range cfg.AlertRuleGroups {
+		if shard.owns(alertGroupFolder(group)) {
+			groups = append(groups, group)
+		}
+	}
+	cfg.AlertRuleGroups = groups
+
+	if !shard.shared() {
+		cfg.DataSources = []DataSource{}
+		cfg.Teams = []Team{}
+		cfg.ContactPoints = []ContactPoint{}
+		cfg.NotificationPolicies = nil
+		cfg.StatusDashboard.Enabled = false
+	}
+	return cfg
+}
+
+// alertGroupFolder returns the folder of a Grafana-managed group or the namespace of a ruler group
+func alertGroupFolder(group AlertRuleGroup) string {
+	if group.Folder == "" {
+		return group.Namespace
+	}
+	return group.Folder
+}
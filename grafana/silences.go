@@ -0,0 +1,116 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// silenceRunLimit bounds the silence of a run, so a crashed run doesn't silence the alerts for good
+const silenceRunLimit = time.Hour
+
+// ruleUIDLabel is the label Grafana sets on the alerts of its managed rules
+const ruleUIDLabel = "__alert_rule_uid__"
+
+// Silence is an Alertmanager silence of the alerts matching all matchers
+type Silence struct {
+	ID        string           `json:"id,omitempty"`
+	Matchers  []SilenceMatcher `json:"matchers"`
+	StartsAt  time.Time        `json:"startsAt"`
+	EndsAt    time.Time        `json:"endsAt"`
+	CreatedBy string           `json:"createdBy"`
+	Comment   string           `json:"comment"`
+}
+
+// SilenceMatcher matches the value of an alert label
+type SilenceMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+// CreateSilence creates the silence in the Grafana Alertmanager, or updates it when it has an ID, and returns its ID
+func (client *ApiClient) CreateSilence(silence *Silence) (string, error) {
+	data, err := json.Marshal(silence)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal silence: %w", err)
+	}
+
+	resp, err := client.doRequest("POST", client.URL+"/api/alertmanager/grafana/api/v2/silences", data)
+	if err != nil {
+		return "", fmt.Errorf("failed to create silence: %w", err)
+	}
+
+	var response struct {
+		SilenceID string `json:"silenceID"`
+	}
+	if err := json.Unmarshal(resp, &response); err != nil {
+		return "", fmt.Errorf("failed to decode silence response: %w", err)
+	}
+
+	client.Logger.Info("Silence saved", "id", response.SilenceID, "ends_at", silence.EndsAt.Format(time.RFC3339))
+	return response.SilenceID, nil
+}
+
+// ExpireSilence expires the silence in the Grafana Alertmanager
+func (client *ApiClient) ExpireSilence(id string) error {
+	if _, err := client.doRequest("DELETE", client.URL+"/api/alertmanager/grafana/api/v2/silence/"+url.PathEscape(id), nil); err != nil {
+		return fmt.Errorf("failed to expire silence '%s': %w", id, err)
+	}
+
+	client.Logger.Info("Silence expired", "id", id)
+	return nil
+}
+
+// silenceManagedAlerts silences the alerts of the managed rules for the duration of the run, at most
+// silenceRunLimit. Returns nil when there are no managed rules to silence.
+func silenceManagedAlerts(client GrafanaAPI, cfg Config, log *slog.Logger) (*Silence, error) {
+	rules, err := managedAlertRules(client, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		log.Info("No managed alert rules to silence")
+		return nil, nil
+	}
+
+	uids := []string{}
+	for _, rule := range rules {
+		uids = append(uids, regexp.QuoteMeta(rule.UID))
+	}
+	now := time.Now()
+	silence := &Silence{
+		Matchers:  []SilenceMatcher{{Name: ruleUIDLabel, Value: strings.Join(uids, "|"), IsRegex: true, IsEqual: true}},
+		StartsAt:  now,
+		EndsAt:    now.Add(silenceRunLimit),
+		CreatedBy: "grafana-provisioner",
+		Comment:   "Provisioning run in progress",
+	}
+	silence.ID, err = client.CreateSilence(silence)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("Managed alert rules silenced for the run", "silence", silence.ID, "rules", len(rules))
+	return silence, nil
+}
+
+// endSilence shortens the silence of the run to the grace period from now, or expires it without one
+func endSilence(client GrafanaAPI, silence *Silence, grace time.Duration, log *slog.Logger) error {
+	if grace <= 0 {
+		return client.ExpireSilence(silence.ID)
+	}
+
+	silence.EndsAt = time.Now().Add(grace)
+	silence.Comment = "Grace period after a provisioning run"
+	if _, err := client.CreateSilence(silence); err != nil {
+		return err
+	}
+	log.Info("Silence of the run ends after the grace period", "silence", silence.ID, "grace", grace)
+	return nil
+}
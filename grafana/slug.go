@@ -0,0 +1,64 @@
+package grafana
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Slug returns the URL slug Grafana generates for a dashboard or folder title: lowercase letters and digits,
+// the runs of other characters replaced with single dashes
+func Slug(title string) string {
+	var slug strings.Builder
+	separate := false
+	for _, r := range strings.ToLower(title) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			separate = true
+			continue
+		}
+		if separate && slug.Len() > 0 {
+			slug.WriteByte('-')
+		}
+		separate = false
+		slug.WriteRune(r)
+	}
+	return slug.String()
+}
+
+// CheckSlugCollisions fails for folders, and for dashboards of the same folder and organization, whose names
+// differ but generate the same slug, e.g. "CPU Usage" and "CPU-Usage". Their URLs and search results are
+// indistinguishable, and one is easily mistaken for, or overwritten with, the other.
+func CheckSlugCollisions(folders []Folder, dashboards []Dashboard) error {
+	collisions := []string{}
+	seen := map[string]string{}
+	check := func(scope string, kind string, where string, name string) {
+		if name == "" {
+			return
+		}
+		key := scope + "\x00" + Slug(name)
+		other, found := seen[key]
+		if !found {
+			seen[key] = name
+			return
+		}
+		if other != name {
+			collisions = append(collisions, fmt.Sprintf("%s '%s' and '%s'%s both have the slug '%s'", kind, other, name, where, Slug(name)))
+		}
+	}
+
+	for _, folder := range folders {
+		check("folders", "folders", "", folder.Name)
+	}
+	for _, dashboard := range dashboards {
+		folder := dashboard.Folder
+		if folder == "" {
+			folder = "General"
+		}
+		check(dashboard.Org+"/"+strings.ToLower(folder), "dashboards", fmt.Sprintf(" in folder '%s'", folder), dashboard.Name)
+	}
+
+	if len(collisions) > 0 {
+		return fmt.Errorf("names generating the same Grafana slug, rename one of each: %s", strings.Join(collisions, "; "))
+	}
+	return nil
+}
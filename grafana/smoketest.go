@@ -0,0 +1,365 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// KindPanelQuery is the check kind of the panel assertions
+const KindPanelQuery = "panel-query"
+
+// defaultAssertionFrom is the start of the queried range when the assertion doesn't set one
+const defaultAssertionFrom = "now-1h"
+
+// dashboardVariablePattern matches $var, ${var} and ${var:format} references in query text
+var dashboardVariablePattern = regexp.MustCompile(`\$\{(\w+)(?::\w+)?\}|\$(\w+)`)
+
+// DataSourceQueryRequest is the body of POST /api/ds/query
+type DataSourceQueryRequest struct {
+	Queries []map[string]interface{} `json:"queries"`
+	From    string                   `json:"from"`
+	To      string                   `json:"to"`
+}
+
+// DataSourceQueryResponse holds the data frames returned per query refId
+type DataSourceQueryResponse struct {
+	Results map[string]DataSourceQueryResult `json:"results"`
+}
+
+// DataSourceQueryResult is the result of a single query
+type DataSourceQueryResult struct {
+	Status int         `json:"status"`
+	Error  string      `json:"error"`
+	Frames []DataFrame `json:"frames"`
+}
+
+// DataFrame is a data frame in the JSON wire format: the field types and one array of values per field
+type DataFrame struct {
+	Schema struct {
+		Fields []struct {
+			Name   string            `json:"name"`
+			Type   string            `json:"type"`
+			Labels map[string]string `json:"labels"`
+		} `json:"fields"`
+	} `json:"schema"`
+	Data struct {
+		Values [][]interface{} `json:"values"`
+	} `json:"data"`
+}
+
+// QueryDataSources runs queries through the data source proxy the way panels do.
+func (client *ApiClient) QueryDataSources(request *DataSourceQueryRequest) (*DataSourceQueryResponse, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal data source query: %w", err)
+	}
+
+	body, err := client.doRequest("POST", client.URL+"/api/ds/query", data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query data sources: %w", err)
+	}
+
+	var response DataSourceQueryResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal data source query response: %w", err)
+	}
+	return &response, nil
+}
+
+// TestDashboards runs the queries of the panels with assertions against the live dashboards and checks their
+// results, catching dashboards that render but show no data, e.g. after a data source moved. Each assertion
+// produces one check; an error is returned only if the live state can't be read.
+func TestDashboards(client GrafanaAPI, cfg Config, log *slog.Logger) ([]Check, error) {
+	log.Info("Testing dashboard panel queries")
+	checks := []Check{}
+
+	dataSources, err := client.GetDataSources()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list data sources: %w", err)
+	}
+
+	for _, dashboard := range cfg.Dashboards {
+		if len(dashboard.Assertions) == 0 {
+			continue
+		}
+		dashboardLog := log.With("dashboard", dashboard.Name)
+
+		live, err := findLiveDashboard(client, dashboard)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, assertion := range dashboard.Assertions {
+			check := Check{Kind: KindPanelQuery, Name: dashboard.Name + "/" + assertion.Panel}
+			if assertion.RefID != "" {
+				check.Name += "/" + assertion.RefID
+			}
+
+			if live == nil {
+				check.Message = "dashboard is missing"
+			} else {
+				check.Message, check.Passed = testPanel(client, live, dataSources, assertion)
+			}
+
+			dashboardLog.Debug("Panel assertion checked", "panel", assertion.Panel, "passed", check.Passed, "message", check.Message)
+			checks = append(checks, check)
+		}
+	}
+
+	log.Info("Dashboard panel queries tested", "checks", len(checks))
+	return checks, nil
+}
+
+// findLiveDashboard returns the dashboard in its configured folder, nil when it is missing
+func findLiveDashboard(client GrafanaAPI, dashboard Dashboard) (*DashboardGetResponse, error) {
+	candidates, err := client.FindDashboardsByName(dashboard.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search dashboard '%s': %w", dashboard.Name, err)
+	}
+
+	for _, candidate := range candidates {
+		if isSameFolder(dashboard.Folder, candidate.FolderTitle) {
+			live, err := client.GetDashboardByUID(candidate.UID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch dashboard '%s': %w", dashboard.Name, err)
+			}
+			return live, nil
+		}
+	}
+	return nil, nil
+}
+
+// testPanel runs the queries of the asserted panel and returns the check message and outcome. Failing queries,
+// e.g. on a dropped table, fail the check.
+func testPanel(client GrafanaAPI, live *DashboardGetResponse, dataSources []DataSource, assertion PanelAssertion) (string, bool) {
+	panel := findPanelByTitle(live.Dashboard, assertion.Panel)
+	if panel == nil {
+		return "panel is missing", false
+	}
+
+	variables := dashboardVariableValues(live.Dashboard)
+	targets, _ := panel["targets"].([]interface{})
+	queries := []map[string]interface{}{}
+	for _, item := range targets {
+		target, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		refID, _ := target["refId"].(string)
+		if assertion.RefID != "" && refID != assertion.RefID {
+			continue
+		}
+		if hide, _ := target["hide"].(bool); hide && assertion.RefID == "" {
+			continue
+		}
+
+		ref := target["datasource"]
+		if ref == nil {
+			ref = panel["datasource"]
+		}
+		dataSourceUID, err := resolveQueryDataSource(ref, variables, dataSources)
+		if err != nil {
+			return fmt.Sprintf("query '%s': %v", refID, err), false
+		}
+
+		query := interpolateQuery(target, variables)
+		query["datasource"] = map[string]interface{}{"uid": dataSourceUID}
+		query["refId"] = refID
+		if _, ok := query["maxDataPoints"]; !ok {
+			query["maxDataPoints"] = 100
+		}
+		queries = append(queries, query)
+	}
+	if len(queries) == 0 {
+		if assertion.RefID != "" {
+			return fmt.Sprintf("panel has no query '%s'", assertion.RefID), false
+		}
+		return "panel has no queries", false
+	}
+
+	response, err := client.QueryDataSources(&DataSourceQueryRequest{Queries: queries, From: assertionFrom(assertion), To: "now"})
+	if err != nil {
+		return err.Error(), false
+	}
+
+	rows, values := 0, []float64{}
+	for _, query := range queries {
+		refID := query["refId"].(string)
+		result := response.Results[refID]
+		if result.Error != "" {
+			return fmt.Sprintf("query '%s' failed: %s", refID, result.Error), false
+		}
+		for _, frame := range result.Frames {
+			rows += frameRows(frame)
+			values = append(values, frameNumbers(frame)...)
+		}
+	}
+	return checkPanelAssertion(assertion, rows, values)
+}
+
+// checkPanelAssertion compares the returned rows and numeric values to the assertion
+func checkPanelAssertion(assertion PanelAssertion, rows int, values []float64) (string, bool) {
+	if assertion.NonEmpty && rows == 0 {
+		return fmt.Sprintf("no data returned since %s", assertionFrom(assertion)), false
+	}
+
+	if assertion.Min != nil || assertion.Max != nil {
+		if len(values) == 0 {
+			return "no numeric values returned to check the range", false
+		}
+		for _, value := range values {
+			if assertion.Min != nil && value < *assertion.Min {
+				return fmt.Sprintf("value %g is below the minimum %g", value, *assertion.Min), false
+			}
+			if assertion.Max != nil && value > *assertion.Max {
+				return fmt.Sprintf("value %g is above the maximum %g", value, *assertion.Max), false
+			}
+		}
+	}
+
+	return fmt.Sprintf("%d rows, %d numeric values", rows, len(values)), true
+}
+
+// assertionFrom returns the start of the queried range
+func assertionFrom(assertion PanelAssertion) string {
+	if assertion.From == "" {
+		return defaultAssertionFrom
+	}
+	return assertion.From
+}
+
+// findPanelByTitle finds a panel, nested in collapsed rows included
+func findPanelByTitle(dashboard DashboardJSON, title string) map[string]interface{} {
+	var find func(panels []interface{}) map[string]interface{}
+	find = func(panels []interface{}) map[string]interface{} {
+		for _, item := range panels {
+			panel, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if panelTitle, _ := panel["title"].(string); panelTitle == title {
+				return panel
+			}
+			nested, _ := panel["panels"].([]interface{})
+			if found := find(nested); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	panels, _ := dashboard["panels"].([]interface{})
+	return find(panels)
+}
+
+// dashboardVariableValues returns the current values of the dashboard template variables, multi-value
+// variables joined with commas
+func dashboardVariableValues(dashboard DashboardJSON) map[string]string {
+	values := map[string]string{}
+	templating, _ := dashboard["templating"].(map[string]interface{})
+	list, _ := templating["list"].([]interface{})
+	for _, item := range list {
+		variable, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := variable["name"].(string)
+		current, _ := variable["current"].(map[string]interface{})
+		switch value := current["value"].(type) {
+		case string:
+			values[name] = value
+		case []interface{}:
+			parts := []string{}
+			for _, part := range value {
+				parts = append(parts, fmt.Sprint(part))
+			}
+			values[name] = strings.Join(parts, ",")
+		}
+	}
+	return values
+}
+
+// resolveQueryDataSource returns the UID of the data source a query runs against. Empty references use the
+// default data source, variable references their current value.
+func resolveQueryDataSource(ref interface{}, variables map[string]string, dataSources []DataSource) (string, error) {
+	var value string
+	switch typed := ref.(type) {
+	case map[string]interface{}:
+		value, _ = typed["uid"].(string)
+	case string:
+		value = typed
+	}
+
+	if match := dashboardVariablePattern.FindStringSubmatch(value); match != nil && match[0] == value {
+		name := match[1] + match[2]
+		resolved, ok := variables[name]
+		if !ok {
+			return "", fmt.Errorf("data source variable '%s' has no current value", name)
+		}
+		value = resolved
+	}
+
+	if value == expressionDataSourceUID {
+		return value, nil
+	}
+	if builtinDataSourceUIDs[value] {
+		return "", fmt.Errorf("queries of the built-in data source '%s' can't be tested", value)
+	}
+	for _, dataSource := range dataSources {
+		if (value == "" && dataSource.IsDefault) || (value != "" && (dataSource.UID == value || dataSource.Name == value)) {
+			return dataSource.UID, nil
+		}
+	}
+	if value == "" {
+		return "", fmt.Errorf("no default data source")
+	}
+	return "", fmt.Errorf("data source '%s' is missing", value)
+}
+
+// interpolateQuery copies the query replacing the dashboard variables in its string fields with their current
+// values, the query API doesn't know the dashboard
+func interpolateQuery(target map[string]interface{}, variables map[string]string) map[string]interface{} {
+	query := map[string]interface{}{}
+	for key, value := range target {
+		if text, ok := value.(string); ok {
+			value = dashboardVariablePattern.ReplaceAllStringFunc(text, func(reference string) string {
+				match := dashboardVariablePattern.FindStringSubmatch(reference)
+				if resolved, ok := variables[match[1]+match[2]]; ok {
+					return resolved
+				}
+				return reference
+			})
+		}
+		query[key] = value
+	}
+	return query
+}
+
+// frameRows returns the number of rows of the frame
+func frameRows(frame DataFrame) int {
+	rows := 0
+	for _, values := range frame.Data.Values {
+		if len(values) > rows {
+			rows = len(values)
+		}
+	}
+	return rows
+}
+
+// frameNumbers returns the non-null values of the numeric fields of the frame
+func frameNumbers(frame DataFrame) []float64 {
+	numbers := []float64{}
+	for i, field := range frame.Schema.Fields {
+		if field.Type != "number" || i >= len(frame.Data.Values) {
+			continue
+		}
+		for _, value := range frame.Data.Values[i] {
+			if number, ok := value.(float64); ok {
+				numbers = append(numbers, number)
+			}
+		}
+	}
+	return numbers
+}
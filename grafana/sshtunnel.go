@@ -0,0 +1,143 @@
+package grafana
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// defaultSSHPort is used when the bastion host has no port
+const defaultSSHPort = "22"
+
+// DialFunc opens the network connections of the HTTP transport
+type DialFunc func(ctx context.Context, network string, addr string) (net.Conn, error)
+
+// OpenSSHTunnel connects to the bastion host and returns a dialer forwarding all connections through it.
+// Connections to the Grafana URL go to the tunnel's Remote address when it is set, as seen from the bastion.
+func OpenSSHTunnel(tunnel SSHTunnel, grafanaURL string, log *slog.Logger) (DialFunc, func() error, error) {
+	host := tunnel.Host
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, defaultSSHPort)
+	}
+
+	auth, err := sshAuthMethods(tunnel)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hostKeyCallback, err := sshHostKeyCallback(tunnel)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	timeout := tunnel.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	sshClient, err := ssh.Dial("tcp", host, &ssh.ClientConfig{
+		User:            tunnel.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         timeout,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to SSH bastion '%s': %w", host, err)
+	}
+	log.Info("SSH tunnel established", "bastion", host, "user", tunnel.User, "remote", tunnel.Remote)
+
+	grafanaAddr := ""
+	if parsed, err := url.Parse(grafanaURL); err == nil {
+		grafanaAddr = hostWithPort(parsed)
+	}
+
+	dial := func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		if tunnel.Remote != "" && addr == grafanaAddr {
+			addr = tunnel.Remote
+		}
+		conn, err := sshClient.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial '%s' through the SSH tunnel: %w", addr, err)
+		}
+		return conn, nil
+	}
+	return dial, sshClient.Close, nil
+}
+
+// sshAuthMethods returns the private key authentication of the tunnel, falling back to the SSH agent
+func sshAuthMethods(tunnel SSHTunnel) ([]ssh.AuthMethod, error) {
+	key := []byte(tunnel.Key)
+	if len(key) == 0 && tunnel.KeyFile != "" {
+		content, err := os.ReadFile(tunnel.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SSH key '%s': %w", tunnel.KeyFile, err)
+		}
+		key = content
+	}
+
+	if len(key) > 0 {
+		var signer ssh.Signer
+		var err error
+		if tunnel.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(tunnel.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SSH key: %w", err)
+		}
+		return []ssh.AuthMethod{ssh.PublicKeys(signer)}, nil
+	}
+
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil, fmt.Errorf("no SSH key configured and SSH_AUTH_SOCK is not set")
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the SSH agent: %w", err)
+	}
+	return []ssh.AuthMethod{ssh.PublicKeysCallback(agent.NewClient(conn).Signers)}, nil
+}
+
+// sshHostKeyCallback verifies the bastion host key against the known_hosts file
+func sshHostKeyCallback(tunnel SSHTunnel) (ssh.HostKeyCallback, error) {
+	if tunnel.InsecureIgnoreHostKey {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+
+	knownHostsFile := tunnel.KnownHostsFile
+	if knownHostsFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate the known_hosts file: %w", err)
+		}
+		knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+
+	callback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known_hosts '%s': %w", knownHostsFile, err)
+	}
+	return callback, nil
+}
+
+// hostWithPort returns the host:port the HTTP transport dials for the URL
+func hostWithPort(parsed *url.URL) string {
+	if parsed.Port() != "" {
+		return parsed.Host
+	}
+	if parsed.Scheme == "https" {
+		return net.JoinHostPort(parsed.Hostname(), "443")
+	}
+	return net.JoinHostPort(parsed.Hostname(), "80")
+}
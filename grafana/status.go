@@ -0,0 +1,129 @@
+package grafana
+
+import (
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/buildinfo"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// statusDashboardUID keeps the status dashboard the same object across runs
+	statusDashboardUID = "grafana-provisioner-status"
+	// defaultStatusDashboardTitle is the title used when none is configured
+	defaultStatusDashboardTitle = "Provisioning Status"
+)
+
+// StatusDashboard configures the dashboard showing the outcome of the last provisioning run
+type StatusDashboard struct {
+	Enabled bool
+	Title   string
+	Folder  string // Must be provisioned in folders, empty or "General" for the General folder
+}
+
+// publishStatusDashboard saves the status dashboard describing the run
+func publishStatusDashboard(client GrafanaAPI, cfg Config, report *Report, runErr error, log *slog.Logger) error {
+	title := cfg.StatusDashboard.Title
+	if title == "" {
+		title = defaultStatusDashboardTitle
+	}
+
+	folderUID := ""
+	if cfg.StatusDashboard.Folder != "" && !strings.EqualFold(cfg.StatusDashboard.Folder, "General") {
+		mapping, ok := cfg.FoldersMapping[cfg.StatusDashboard.Folder]
+		if !ok {
+			return fmt.Errorf("status dashboard folder '%s' was not provisioned", cfg.StatusDashboard.Folder)
+		}
+		folderUID = mapping.UID
+	}
+
+	response, err := client.SaveDashboard(&DashboardSaveRequest{
+		Dashboard: buildStatusDashboard(title, report, runErr),
+		FolderUID: folderUID,
+		Overwrite: true,
+		Message:   "Provisioning status updated by " + buildinfo.UserAgent(),
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Info("Provisioning status dashboard updated", "url", joinResourceURL(client.BaseURL(), response.URL))
+	return nil
+}
+
+// buildStatusDashboard renders the status dashboard model with text panels
+func buildStatusDashboard(title string, report *Report, runErr error) DashboardJSON {
+	status := "✅ Succeeded"
+	failure := "No failures."
+	if runErr != nil {
+		status = "❌ Failed"
+		failure = "```\n" + runErr.Error() + "\n```"
+	}
+
+	summary := fmt.Sprintf("## %s\n\n| | |\n| :--- | :--- |\n| Last run | %s |\n| Duration | %s |\n| Version | `%s` |\n",
+		status,
+		report.FinishedAt.UTC().Format(time.RFC3339),
+		report.FinishedAt.Sub(report.StartedAt).Round(time.Millisecond),
+		report.ToolVersion)
+
+	return DashboardJSON{
+		"uid":           statusDashboardUID,
+		"title":         title,
+		"tags":          []string{"grafana-provisioner"},
+		"editable":      false,
+		"schemaVersion": 39,
+		"time":          map[string]interface{}{"from": "now-6h", "to": "now"},
+		"panels": []interface{}{
+			statusTextPanel(1, "Last run", summary, 0, 0, 8, 8),
+			statusTextPanel(2, "Resources", statusResourceCounts(report), 8, 0, 16, 8),
+			statusTextPanel(3, "Failures", failure, 0, 8, 24, 6),
+		},
+	}
+}
+
+// statusResourceCounts renders a markdown table of the resource counts by kind and action
+func statusResourceCounts(report *Report) string {
+	if len(report.Resources) == 0 {
+		return "No resources provisioned."
+	}
+
+	counts := map[string]map[string]int{}
+	for _, resource := range report.Resources {
+		if counts[resource.Kind] == nil {
+			counts[resource.Kind] = map[string]int{}
+		}
+		counts[resource.Kind][resource.Action]++
+	}
+
+	kinds := []string{}
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	actions := []string{ActionCreated, ActionUpdated, ActionUnchanged, ActionProvisioned, ActionSkipped}
+
+	var table strings.Builder
+	table.WriteString("| Kind | " + strings.Join(actions, " | ") + " |\n| :--- |" + strings.Repeat(" ---: |", len(actions)) + "\n")
+	for _, kind := range kinds {
+		table.WriteString("| " + kind + " |")
+		for _, action := range actions {
+			fmt.Fprintf(&table, " %d |", counts[kind][action])
+		}
+		table.WriteString("\n")
+	}
+	return table.String()
+}
+
+// statusTextPanel returns a markdown text panel at the given grid position
+func statusTextPanel(id int, title string, content string, x, y, width, height int) map[string]interface{} {
+	return map[string]interface{}{
+		"id":      id,
+		"type":    "text",
+		"title":   title,
+		"gridPos": map[string]interface{}{"x": x, "y": y, "w": width, "h": height},
+		"options": map[string]interface{}{"mode": "markdown", "content": content},
+	}
+}
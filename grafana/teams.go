@@ -0,0 +1,317 @@
+package grafana
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FindTeamByName looks up a team by its exact name. Returns nil if it doesn't exist.
+func (client *ApiClient) FindTeamByName(name string) (*TeamResponse, error) {
+	resp, err := client.doRequest("GET", fmt.Sprintf("%s/api/teams/search?name=%s", client.URL, url.QueryEscape(name)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search team '%s': %w", name, err)
+	}
+
+	var result struct {
+		Teams []TeamResponse `json:"teams"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode team search response: %w", err)
+	}
+
+	for _, team := range result.Teams {
+		if team.Name == name {
+			return &team, nil
+		}
+	}
+	return nil, nil
+}
+
+// CreateTeam creates a new team and returns its ID
+func (client *ApiClient) CreateTeam(name string, email string) (int, error) {
+	client.Logger.Info("Creating new team", "name", name)
+
+	data, err := json.Marshal(map[string]string{"name": name, "email": email})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal team model: %w", err)
+	}
+
+	resp, err := client.doRequest("POST", client.URL+"/api/teams", data)
+	if err != nil {
+		return 0, fmt.Errorf("team creation failed: %w", err)
+	}
+
+	var response struct {
+		TeamID int `json:"teamId"`
+	}
+	if err := json.Unmarshal(resp, &response); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal team creation response: %w", err)
+	}
+
+	client.Logger.Info("Team successfully created", "name", name, "id", response.TeamID)
+	return response.TeamID, nil
+}
+
+// GetTeamGroups returns the external groups synced to the team (Enterprise team sync)
+func (client *ApiClient) GetTeamGroups(teamID int) ([]string, error) {
+	resp, err := client.doRequest("GET", fmt.Sprintf("%s/api/teams/%d/groups", client.URL, teamID), nil)
+	if err != nil {
+		return nil, teamSyncError(err)
+	}
+
+	var groups []struct {
+		GroupID string `json:"groupId"`
+	}
+	if err := json.Unmarshal(resp, &groups); err != nil {
+		return nil, fmt.Errorf("failed to decode team groups: %w", err)
+	}
+
+	groupIDs := []string{}
+	for _, group := range groups {
+		groupIDs = append(groupIDs, group.GroupID)
+	}
+	return groupIDs, nil
+}
+
+// AddTeamGroup maps an external group (LDAP DN, OAuth group) to the team
+func (client *ApiClient) AddTeamGroup(teamID int, groupID string) error {
+	data, err := json.Marshal(map[string]string{"groupId": groupID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal team group model: %w", err)
+	}
+
+	if _, err := client.doRequest("POST", fmt.Sprintf("%s/api/teams/%d/groups", client.URL, teamID), data); err != nil {
+		return teamSyncError(err)
+	}
+
+	client.Logger.Info("External group mapped to team", "team_id", teamID, "group", groupID)
+	return nil
+}
+
+// RemoveTeamGroup removes an external group mapping from the team
+func (client *ApiClient) RemoveTeamGroup(teamID int, groupID string) error {
+	endpoint := fmt.Sprintf("%s/api/teams/%d/groups?groupId=%s", client.URL, teamID, url.QueryEscape(groupID))
+	if _, err := client.doRequest("DELETE", endpoint, nil); err != nil {
+		return teamSyncError(err)
+	}
+
+	client.Logger.Info("External group unmapped from team", "team_id", teamID, "group", groupID)
+	return nil
+}
+
+// GetTeamMembers returns the members of the team
+func (client *ApiClient) GetTeamMembers(teamID int) ([]OrgUser, error) {
+	resp, err := client.doRequest("GET", fmt.Sprintf("%s/api/teams/%d/members", client.URL, teamID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get members of team %d: %w", teamID, err)
+	}
+
+	var members []OrgUser
+	if err := json.Unmarshal(resp, &members); err != nil {
+		return nil, fmt.Errorf("failed to decode team members: %w", err)
+	}
+	return members, nil
+}
+
+// AddTeamMember adds the user to the team
+func (client *ApiClient) AddTeamMember(teamID int, userID int) error {
+	data, err := json.Marshal(map[string]int{"userId": userID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal team member model: %w", err)
+	}
+
+	if _, err := client.doRequest("POST", fmt.Sprintf("%s/api/teams/%d/members", client.URL, teamID), data); err != nil {
+		return fmt.Errorf("failed to add user %d to team %d: %w", userID, teamID, err)
+	}
+
+	client.Logger.Info("User added to team", "team_id", teamID, "user_id", userID)
+	return nil
+}
+
+// RemoveTeamMember removes the user from the team
+func (client *ApiClient) RemoveTeamMember(teamID int, userID int) error {
+	if _, err := client.doRequest("DELETE", fmt.Sprintf("%s/api/teams/%d/members/%d", client.URL, teamID, userID), nil); err != nil {
+		return fmt.Errorf("failed to remove user %d from team %d: %w", userID, teamID, err)
+	}
+
+	client.Logger.Info("User removed from team", "team_id", teamID, "user_id", userID)
+	return nil
+}
+
+// FindOrgUser looks up a user of the current organization by the exact login or email. Returns nil if there is none.
+func (client *ApiClient) FindOrgUser(loginOrEmail string) (*OrgUser, error) {
+	resp, err := client.doRequest("GET", fmt.Sprintf("%s/api/org/users/lookup?query=%s&limit=100", client.URL, url.QueryEscape(loginOrEmail)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user '%s': %w", loginOrEmail, err)
+	}
+
+	var users []OrgUser
+	if err := json.Unmarshal(resp, &users); err != nil {
+		return nil, fmt.Errorf("failed to decode user lookup response: %w", err)
+	}
+
+	for _, user := range users {
+		if user.matches(loginOrEmail) {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+// matches reports whether the login or email of the user is the given one, emails are case-insensitive
+func (user OrgUser) matches(loginOrEmail string) bool {
+	return user.Login == loginOrEmail || (user.Email != "" && strings.EqualFold(user.Email, loginOrEmail))
+}
+
+// teamSyncError explains the failure when team sync isn't available
+func teamSyncError(err error) error {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("team sync API not found, it requires Grafana Enterprise or Grafana Cloud: %w", err)
+	}
+	return fmt.Errorf("team sync request failed: %w", err)
+}
+
+// provisionTeams creates the configured teams and syncs their members and external group mappings.
+// Members and groups of a team that are not in the config are removed.
+func provisionTeams(client GrafanaAPI, teams []Team, report *Report, log *slog.Logger) error {
+	if len(teams) == 0 {
+		return nil
+	}
+
+	log.Info("Provisioning Grafana teams")
+	for _, team := range teams {
+		action := ActionUnchanged
+		teamID, created, err := ensureTeam(client, team)
+		if err != nil {
+			return report.fail(KindTeam, team.Name, fmt.Errorf("failed to provision team '%s': %w", team.Name, err))
+		}
+		if created {
+			action = ActionCreated
+		}
+
+		// Team sync mappings are only touched when declared, so OSS instances can still provision teams
+		if team.Groups != nil {
+			changed, err := syncTeamGroups(client, teamID, team.Groups, log)
+			if err != nil {
+				return report.fail(KindTeam, team.Name, fmt.Errorf("failed to sync groups of team '%s': %w", team.Name, err))
+			}
+			if changed && action == ActionUnchanged {
+				action = ActionUpdated
+			}
+		}
+
+		if team.Members != nil {
+			changed, err := syncTeamMembers(client, teamID, team.Members, log)
+			if err != nil {
+				return report.fail(KindTeam, team.Name, fmt.Errorf("failed to sync members of team '%s': %w", team.Name, err))
+			}
+			if changed && action == ActionUnchanged {
+				action = ActionUpdated
+			}
+		}
+
+		report.add(ResourceResult{
+			Kind:   KindTeam,
+			Name:   team.Name,
+			Action: action,
+			UID:    strconv.Itoa(teamID),
+		})
+	}
+
+	log.Info("All configured teams provisioned.")
+	return nil
+}
+
+// ensureTeam returns the ID of the team, creating it if it doesn't exist yet
+func ensureTeam(client GrafanaAPI, team Team) (int, bool, error) {
+	existing, err := client.FindTeamByName(team.Name)
+	if err != nil {
+		return 0, false, err
+	}
+	if existing != nil {
+		return existing.ID, false, nil
+	}
+
+	teamID, err := client.CreateTeam(team.Name, team.Email)
+	if err != nil {
+		return 0, false, err
+	}
+	return teamID, true, nil
+}
+
+// syncTeamGroups makes the team's external groups match the desired list, reports whether anything changed
+func syncTeamGroups(client GrafanaAPI, teamID int, desired []string, log *slog.Logger) (bool, error) {
+	current, err := client.GetTeamGroups(teamID)
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	for _, groupID := range desired {
+		if !slices.Contains(current, groupID) {
+			if err := client.AddTeamGroup(teamID, groupID); err != nil {
+				return false, err
+			}
+			changed = true
+		}
+	}
+
+	for _, groupID := range current {
+		if !slices.Contains(desired, groupID) {
+			if err := client.RemoveTeamGroup(teamID, groupID); err != nil {
+				return false, err
+			}
+			changed = true
+		}
+	}
+
+	log.Debug("Team groups synced", "team_id", teamID, "groups", len(desired), "changed", changed)
+	return changed, nil
+}
+
+// syncTeamMembers makes the team's members match the desired logins or emails, reports whether anything changed.
+// Every desired member must be a user of the organization.
+func syncTeamMembers(client GrafanaAPI, teamID int, desired []string, log *slog.Logger) (bool, error) {
+	current, err := client.GetTeamMembers(teamID)
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	for _, loginOrEmail := range desired {
+		if slices.ContainsFunc(current, func(member OrgUser) bool { return member.matches(loginOrEmail) }) {
+			continue
+		}
+		user, err := client.FindOrgUser(loginOrEmail)
+		if err != nil {
+			return false, err
+		}
+		if user == nil {
+			return false, fmt.Errorf("user '%s' is not a member of the organization", loginOrEmail)
+		}
+		if err := client.AddTeamMember(teamID, user.UserID); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+
+	for _, member := range current {
+		if !slices.ContainsFunc(desired, member.matches) {
+			if err := client.RemoveTeamMember(teamID, member.UserID); err != nil {
+				return false, err
+			}
+			changed = true
+		}
+	}
+
+	log.Debug("Team members synced", "team_id", teamID, "members", len(desired), "changed", changed)
+	return changed, nil
+}
@@ -0,0 +1,67 @@
+package grafana
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// TLSParams defines the TLS of the connections to a Grafana behind an internal PKI
+type TLSParams struct {
+	CAFile             string // PEM bundle of the CAs trusted in addition to the system ones
+	CertFile           string // PEM client certificate for mutual TLS, with KeyFile
+	KeyFile            string
+	InsecureSkipVerify bool // Accepts any server certificate, for test setups only
+}
+
+// configured reports whether any TLS setting differs from the Go defaults
+func (params TLSParams) configured() bool {
+	return params.CAFile != "" || params.CertFile != "" || params.KeyFile != "" || params.InsecureSkipVerify
+}
+
+// Config loads the CA bundle and the client certificate into a TLS config, nil when nothing is configured
+func (params TLSParams) Config() (*tls.Config, error) {
+	if !params.configured() {
+		return nil, nil
+	}
+
+	config := &tls.Config{InsecureSkipVerify: params.InsecureSkipVerify}
+	if params.CAFile != "" {
+		pem, err := os.ReadFile(params.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("failed to load CA file %s: no PEM certificates found", params.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	if params.CertFile != "" || params.KeyFile != "" {
+		if params.CertFile == "" || params.KeyFile == "" {
+			return nil, fmt.Errorf("the client certificate and its key must be set together")
+		}
+		certificate, err := tls.LoadX509KeyPair(params.CertFile, params.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+	return config, nil
+}
+
+// tlsErrorTransport fails all requests of a client whose TLS settings couldn't be loaded, rather than
+// connecting without them
+type tlsErrorTransport struct {
+	err error
+}
+
+// RoundTrip returns the error loading the TLS settings
+func (transport *tlsErrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("failed to load the TLS settings: %w", transport.err)
+}
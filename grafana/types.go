@@ -8,23 +8,116 @@ import (
 type ClientParams struct {
 	URL        string
 	Token      string
-	Timeout    time.Duration
+	APIKey     string        // Legacy API key, sent like a token
+	Username   string        // Basic auth instead of the token, with Password
+	Password   string
+	Timeout    time.Duration // Overall timeout of a request, including reading the response body
 	Retries    int
 	RetryDelay time.Duration
+	API        string // Dashboard and folder API backend: auto, legacy or k8s
+	Org        string // Organization to provision into, empty for the token's org
+	UserAgent  string // Defaults to grafana-provisioner/<version>
+
+	DialTimeout           time.Duration // Establishing the TCP connection
+	TLSTimeout            time.Duration // TLS handshake
+	ResponseHeaderTimeout time.Duration // Waiting for the response headers after the request is sent, 0 for no limit
+
+	DialContext DialFunc // Opens the connections, e.g. through an SSH tunnel, nil for direct connections
+	TLS         TLSParams
+	Inject      FailureInjection
+	LiveTail    time.Duration // Poll interval of the server monitor during RunProvisioning, 0 to disable
+
+	ConsistencyWait time.Duration // How long to poll for created dashboards and data sources until lookups find them, 0 to disable
+}
+
+// KubeTarget defines the Kubernetes service of a cluster-internal Grafana reached through a port-forward
+type KubeTarget struct {
+	Kubeconfig string // Defaults to KUBECONFIG or ~/.kube/config
+	Context    string // Defaults to the current context
+	Namespace  string // Defaults to the context namespace
+	Service    string
+	Port       int // Service port, defaults to the first one
+}
+
+// SSHTunnel defines the bastion host connections to a Grafana on a private network go through
+type SSHTunnel struct {
+	Host                  string // Bastion host[:port]
+	User                  string
+	Key                   string // PEM private key, takes precedence over KeyFile
+	KeyFile               string
+	Passphrase            string
+	KnownHostsFile        string // Defaults to ~/.ssh/known_hosts
+	InsecureIgnoreHostKey bool
+	Remote                string // Grafana host:port as seen from the bastion, defaults to the Grafana URL
+	Timeout               time.Duration // Connecting to the bastion, defaults to 10s
+}
+
+// HealthResponse is the structure of the response from the /api/health endpoint
+type HealthResponse struct {
+	Database string `json:"database"`
+	Version  string `json:"version"`
+	Commit   string `json:"commit"`
+}
+
+// K8sAPIGroup is the discovery document of a k8s-style API group served under /apis
+type K8sAPIGroup struct {
+	Name             string `json:"name"`
+	PreferredVersion struct {
+		GroupVersion string `json:"groupVersion"` // e.g. "dashboard.grafana.app/v1beta1"
+		Version      string `json:"version"`
+	} `json:"preferredVersion"`
+}
+
+// K8sObject is a dashboard or folder resource of the k8s-style APIs
+type K8sObject struct {
+	APIVersion string                 `json:"apiVersion"`
+	Kind       string                 `json:"kind"`
+	Metadata   K8sObjectMeta          `json:"metadata"`
+	Spec       map[string]interface{} `json:"spec"`
 }
 
-// PostgreSQLDataSourceModel defines the JSON structure required by Grafana
-// to create a new PostgreSQL data source.
-type PostgreSQLDataSourceModel struct {
+// K8sObjectMeta is the object metadata of a k8s-style resource
+type K8sObjectMeta struct {
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace,omitempty"`
+	Annotations     map[string]string `json:"annotations,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	ResourceVersion string            `json:"resourceVersion,omitempty"`
+	Generation      int64             `json:"generation,omitempty"`
+}
+
+// TokenInfo describes the identity behind the configured token
+type TokenInfo struct {
+	OrgID          int
+	OrgName        string
+	Login          string // Empty for legacy API keys
+	Role           string // Viewer, Editor, Admin or "unknown" when it can't be determined
+	IsGrafanaAdmin bool
+}
+
+// DataSourceModel defines the JSON structure required by Grafana to create a data source of any plugin type.
+// Settings without a dedicated field are passed in JSONData and SecureJSONData.
+type DataSourceModel struct {
 	Name      string `json:"name"`
-	Type      string `json:"type"` // Must be "postgres"
+	UID       string `json:"uid,omitempty"` // Generated by Grafana if empty
+	Type      string `json:"type"` // Plugin ID, e.g. DataSourceTypePostgres, DataSourceTypePrometheus or loki
 	Access    string `json:"access"`
-	URL       string `json:"url"`  // Host:Port, e.g., "127.0.0.1:5432"
+	URL       string `json:"url"`  // Host:Port for PostgreSQL, e.g., "127.0.0.1:5432"
 	Database  string `json:"database"`
-	User      string `json:"user"`
+	User      string `json:"user"` // Basic auth user of the HTTP-based types and with BasicAuth
 	Password  string `json:"password"`
 	SSLMode   string `json:"sslmode"` // e.g., "disable", "require"
 	IsDefault bool   `json:"isDefault"`
+
+	// Rendered into jsonData and secureJsonData by dataSourceRequestData
+	Headers              []DataSourceHeader     `json:"-"`
+	ForwardOAuthIdentity bool                   `json:"-"`
+	KeepCookies          []string               `json:"-"`
+	JSONData             map[string]interface{} `json:"-"`
+	SecureJSONData       map[string]string      `json:"-"`
+	Prometheus           *PrometheusSettings    `json:"-"`
+	BasicAuth            bool                   `json:"-"` // Send User and Password as basic auth whatever the type
+	KeepJSONData         map[string]interface{} `json:"-"` // Live jsonData kept under the rendered settings on updates
 }
 
 type CreateDataSourceResponseDatasource struct {  
@@ -42,18 +135,68 @@ type CreateDataSourceResponse struct {
 
 // DataSource defines the parameters for a data source provisioned by this tool.
 type DataSource struct {
-	ID         int
-	UID        string
-	Name       string
-	Type       string
-	URL        string
-	User	   string
-	Password   string
-	SSLMode    string
-	IsDefault  bool
-	Database   string
+	ID                   int
+	UID                  string
+	Name                 string
+	Type                 string
+	URL                  string
+	User                 string
+	Password             string
+	SSLMode              string
+	IsDefault            bool
+	Database             string
+	Match                string                 // Identity key used to find the existing data source, see MatchBy*
+	Headers              []DataSourceHeader     // Custom HTTP headers sent with every query
+	ForwardOAuthIdentity bool                   // Forward the user's OAuth identity
+	KeepCookies          []string               // Browser cookies forwarded to the data source
+	JSONData             map[string]interface{} // Merged into jsonData
+	SecureJSONData       map[string]string      // Merged into secureJsonData
+	ReadOnly             bool                   // Managed by the file provisioning of Grafana, set on live data sources only
+	BasicAuth            bool                   // Send User and Password as basic auth, implied by the HTTP-based types
+	Access               string                 // Set on live data sources only, like the fields below
+	BasicAuthUser        string
+	SecureJSONFields     map[string]bool        // Secrets that are set, their values are never returned
+	Labels               map[string]string      // Freeform metadata used by --select and the report grouping
+	Prometheus           *PrometheusSettings    // Settings of DataSourceTypePrometheus data sources
+	TestQuery            string                 // Run after provisioning, a failure or no data marks the data source broken
+	Org                  string                 // Organization to create the data source in, empty for the one of the run
+}
+
+// PrometheusSettings are the jsonData settings of a Prometheus data source, empty ones keep Grafana's defaults
+type PrometheusSettings struct {
+	ScrapeInterval string // jsonData.timeInterval
+	QueryTimeout   string
+	HTTPMethod     string // GET or POST
+	Exemplars      []PrometheusExemplar
+}
+
+// PrometheusExemplar links the trace ID label of exemplars to a tracing data source or a URL
+type PrometheusExemplar struct {
+	Name          string // Label holding the trace ID
+	DataSourceUID string
+	URL           string
+}
+
+// DataSourceHeader is a custom HTTP header of a data source, e.g. X-Scope-OrgID selecting the Mimir/Loki tenant.
+type DataSourceHeader struct {
+	Name  string
+	Value string // Stored encrypted in secureJsonData
 }
 
+// Data source types with dedicated settings, any other plugin ID is provisioned from the generic settings
+const (
+	DataSourceTypePostgres   = "grafana-postgresql-datasource"
+	DataSourceTypePrometheus = "prometheus"
+)
+
+// Data source identity keys
+const (
+	MatchByTypeURLDatabase     = "type+url+database" // Default
+	MatchByTypeURLDatabaseUser = "type+url+database+user"
+	MatchByName                = "name"
+	MatchByUID                 = "uid"
+)
+
 // DashboardImport defines a single variable mapping for data source injection.
 type DashboardImport struct {
 	Name       string // The name of the dashboard variable (e.g., DS_ELMON_METRICS)
@@ -62,18 +205,170 @@ type DashboardImport struct {
 
 // Dashboard defines parameters of a Grafana dashboard.
 type Dashboard struct {
-	Name       string 
-	Folder     string 
-	File       string 
-	DataSource string 
-	ImportVar  string
-	Imports    []DashboardImport 
+	Name         string
+	Folder       string
+	File         string
+	GnetID       int               // grafana.com dashboard ID, downloaded instead of reading File
+	Revision     int               // grafana.com revision, 0 means the latest one
+	Mode         string            // DashboardModeImport or DashboardModeDB, empty means import
+	Overwrite    bool              // Overwrite the live dashboard regardless of its version
+	OnConflict   string            // ConflictFail, ConflictMerge or ConflictPrompt when not overwriting
+	UIDCollision string            // UIDCollisionRegenerate, UIDCollisionFail or UIDCollisionAdopt, empty means regenerate
+	Imports      []DashboardImport // One data source per `__inputs` variable
+	Assertions   []PanelAssertion  // Expected query results checked by the test command
+	Labels       map[string]string // Merged over the labels of the folder
+	Permissions  []Permission      // Replace the permissions of the dashboard, nil inherits those of the folder
+
+	RequiresDataSourceType string // Plugin type the imported data sources must have, e.g. prometheus, empty for any
+	MigrateGraphPanels     bool   // Convert the deprecated graph panels to timeseries panels before the import
+	Org                    string // Organization to import into, empty for the one of the run
+}
+
+// PanelAssertion is an expected property of the query results of a dashboard panel
+type PanelAssertion struct {
+	Panel    string   // Panel title
+	RefID    string   // Query of the panel, empty means all its visible queries
+	NonEmpty bool     // At least one row is returned
+	Min      *float64 // Every numeric value is at least Min
+	Max      *float64 // Every numeric value is at most Max
+	From     string   // Start of the queried range, e.g. now-6h, defaults to now-1h
+}
+
+// Dashboard save modes
+const (
+	DashboardModeImport = "import" // POST /api/dashboards/import, Grafana substitutes __inputs
+	DashboardModeDB     = "db"     // POST /api/dashboards/db with the data source references rewritten by the provisioner
+)
+
+// Annotation defines an annotation query injected into the dashboards' annotations.list.
+type Annotation struct {
+	Name       string
+	DataSource string   // The name of the data source from the config
+	Query      string   // Raw SQL for SQL data sources, expression for the others
+	IconColor  string
+	Hide       bool
+	Dashboards []string // Dashboard names the annotation applies to, empty means all
+}
+
+// Ruler defines a Cortex-compatible ruler (Mimir or Loki) that rule groups can target.
+type Ruler struct {
+	Name   string
+	Type   string // "mimir" or "loki"
+	URL    string
+	Tenant string // Sent as X-Scope-OrgID
+	Token  string
+}
+
+// AlertRuleGroup defines a group of alert or recording rules evaluated together.
+type AlertRuleGroup struct {
+	Name      string
+	Folder    string        // Grafana folder of Grafana-managed rules
+	Interval  time.Duration // Evaluation interval
+	Ruler     string        // Name of the ruler to push the group to, empty means Grafana-managed alerting
+	Namespace string        // Ruler namespace, defaults to the folder name
+	Rules     []AlertRule
+}
+
+// AlertRule defines a single alert or recording rule.
+type AlertRule struct {
+	UID         string // Grafana-managed only, defaults to the live rule with the same title or a UID derived from the names
+	Title       string
+	Record      string // Recording rule metric name, ruler targets only
+	Expr        string // Rule expression, ruler targets only
+	Condition   string // RefID of the query or expression used as condition, Grafana-managed only
+	For         string
+	Labels      map[string]string
+	Annotations map[string]string
+	Queries     []AlertQuery
+	Expressions []AlertExpression
+}
+
+// AlertQuery defines a data source query of a Grafana-managed rule.
+type AlertQuery struct {
+	RefID        string
+	DataSource   string // The name of the data source from the config
+	Expr         string
+	RelativeTime time.Duration
+}
+
+// AlertExpression defines a server-side expression (math, reduce) of a Grafana-managed rule.
+type AlertExpression struct {
+	RefID      string
+	Type       string
+	Expression string
+	Reducer    string
+}
+
+// Org defines an organization to create if it is missing
+type Org struct {
+	Name              string
+	Role              string            // Role of the provisioning user in the org, defaults to Admin
+	Dashboards        bool              // Import the configured dashboards into the org too
+	DataSourceAliases map[string]string // Org-local data source names by the config names used in imports
+}
+
+// OrgResponse is the structure for an existing Grafana organization
+type OrgResponse struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// Team defines a team and its team sync mappings
+type Team struct {
+	Name    string
+	Email   string
+	Groups  []string // External group IDs (LDAP DN, OAuth group), nil to leave team sync untouched
+	Members []string // Logins or emails of the org users in the team, nil to leave the members untouched
+}
+
+// OrgUser is a user of the current organization, as listed in team members and user lookups
+type OrgUser struct {
+	UserID int    `json:"userId"`
+	Login  string `json:"login"`
+	Email  string `json:"email"`
+}
+
+// TeamResponse is the structure for a team returned by /api/teams/search
+type TeamResponse struct {
+	ID          int    `json:"id"`
+	UID         string `json:"uid"`
+	Name        string `json:"name"`
+	Email       string `json:"email"`
+	MemberCount int    `json:"memberCount"`
 }
 
 // Folder defines parameters of a Grafana folder from config.
 // NOTE: This structure was moved from the config package to decouple grafana package.
 type Folder struct {
-	Name string 
+	Name           string
+	OwnerTeam      string            // Team granted Edit while everyone else can only view
+	ServiceAccount bool              // Create a service account limited to this folder
+	Permissions    []Permission      // Replace the Grafana default permissions, nil keeps them
+	Labels         map[string]string // Inherited by the dashboards of the folder
+}
+
+// ServiceAccountResponse is the structure for a Grafana service account
+type ServiceAccountResponse struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Login string `json:"login"`
+	Role  string `json:"role"`
+}
+
+// Permission grants a team, a user or a role access to a folder or dashboard, exactly one of them is set
+type Permission struct {
+	Team  string
+	User  string // Login or email of an org user
+	Role  string // Viewer, Editor or Admin
+	Level string // PermissionLevelView, PermissionLevelEdit or PermissionLevelAdmin
+}
+
+// PermissionItem is a single folder or dashboard permission, for a role, a team or a user
+type PermissionItem struct {
+	Role       string `json:"role,omitempty"`
+	TeamID     int    `json:"teamId,omitempty"`
+	UserID     int    `json:"userId,omitempty"`
+	Permission int    `json:"permission"`
 }
 
 // FolderMapping holds the runtime information about a provisioned folder.
@@ -85,11 +380,41 @@ type FolderMapping struct {
 
 // Config defines the configuration subset needed for provisioning
 type Config struct {
-	Grafana        ClientParams
-	Dashboards     []Dashboard
-	DataSources    []DataSource
-	Folders        []Folder
-	FoldersMapping map[string]FolderMapping
+	Grafana              ClientParams
+	Dashboards           []Dashboard
+	DataSources          []DataSource
+	Orgs                 []Org
+	Teams                []Team
+	Folders              []Folder
+	Annotations          []Annotation
+	Rulers               []Ruler
+	AlertRuleGroups      []AlertRuleGroup
+	ContactPoints        []ContactPoint
+	NotificationPolicies *NotificationPolicies // Routes put into the notification policy tree, nil leaves it untouched
+	Safety               SafetyLimits
+	FolderCapacity       FolderCapacity // Usability guideline on the dashboards per folder
+	ChangeWindow         *ChangeWindow // Runs are refused outside the window, nil to allow every run
+	StatusDashboard      StatusDashboard
+	Metrics              MetricsPush // Run metrics pushed at the end of the run, failed runs included
+	SecretSink           SecretSink // Receives generated service account tokens
+	DumpDir              string // Directory to dump rejected dashboard import payloads into, empty to disable
+	PauseAlerts          bool   // Pause the managed alert rules while provisioning
+	SilenceAlerts        bool   // Silence the alerts of the managed rules while provisioning
+	SilenceGrace         time.Duration // Time the silence lasts after the run, 0 expires it right away
+	StrictTokenScope     bool   // Fail instead of warning when the token has more permissions than the config needs
+	FileProvisioned      string // FileProvisionedFail or FileProvisionedSkip, empty means fail
+	UpdateSecrets        bool   // Send the secrets of every matched data source, not only the ones missing
+	DryRun               bool   // Only plan the changes into Report.Plan, Grafana is read but not changed
+	Prune                bool   // Delete the tagged dashboards and data sources not in the config, see pruneResources
+	PruneTag             string // Marks the resources owned by pruning runs, defaults to grafana-provisioner
+	Values               map[string]interface{} // Substituted into `${values.NAME}` dashboard placeholders
+	ValueSources         []ValueSource          // Fetched for the `${values.SOURCE.KEY}` placeholders missing from Values
+	OnEvent              func(Event)            // Receives the progress events of the run, nil to disable
+	Git                  *GitMetadata           // Tagged onto the dashboards and their version messages, nil to disable
+	ConfirmConflict      func(dashboard string, liveVersion int) bool // Asks for the prompt conflict policy, nil keeps the live dashboard
+	Shard                *Shard // Only the resources of the shard are provisioned, nil for all
+	FoldersMapping       map[string]FolderMapping
+	k8s                  *k8sBackend // Set when dashboards and folders go through the k8s-style APIs
 }
 
 // FolderResponse is the structure for an existing Grafana folder
@@ -106,6 +431,13 @@ type CreateFolderRequest struct {
 	Title string `json:"title"`
 }
 
+// UpdateFolderRequest is the structure for updating a folder via API.
+type UpdateFolderRequest struct {
+	Title     string `json:"title"`
+	Version   int    `json:"version,omitempty"`
+	Overwrite bool   `json:"overwrite"`
+}
+
 // DashboardSearchResponse is the structure for a dashboard returned by the /api/search endpoint
 type DashboardSearchResponse struct {
 	ID          int    `json:"id"`
@@ -133,4 +465,109 @@ type DashboardImportRequest struct {
 	FolderUID string        `json:"folderUid"`
 	Overwrite bool          `json:"overwrite"`
 	Message   string        `json:"message"`
-}
\ No newline at end of file
+}
+
+// DashboardImportResponse is the structure of the response from the /api/dashboards/import endpoint
+type DashboardImportResponse struct {
+	UID         string `json:"uid"`
+	Title       string `json:"title"`
+	Imported    bool   `json:"imported"`
+	ImportedURI string `json:"importedUri"`
+	ImportedURL string `json:"importedUrl"` // Relative URL, e.g. "/d/abc123/my-dashboard"
+	Slug        string `json:"slug"`
+	DashboardID int    `json:"dashboardId"`
+	FolderUID   string `json:"folderUid"`
+}
+
+// DashboardMeta is the metadata returned together with a dashboard by /api/dashboards/uid/:uid
+type DashboardMeta struct {
+	Slug        string `json:"slug"`
+	URL         string `json:"url"`
+	FolderID    int    `json:"folderId"`
+	FolderUID   string `json:"folderUid"`
+	FolderTitle string `json:"folderTitle"`
+	Version     int    `json:"version"`
+	Provisioned bool   `json:"provisioned"`
+	CanEdit     bool   `json:"canEdit"`
+	Created     string `json:"created"`
+	Updated     string `json:"updated"`
+}
+
+// DashboardGetResponse is the structure of the response from the /api/dashboards/uid/:uid endpoint
+type DashboardGetResponse struct {
+	Dashboard DashboardJSON `json:"dashboard"`
+	Meta      DashboardMeta `json:"meta"`
+}
+
+// DashboardSaveRequest is the structure for creating or updating a dashboard via /api/dashboards/db.
+type DashboardSaveRequest struct {
+	Dashboard DashboardJSON `json:"dashboard"`
+	FolderUID string        `json:"folderUid"`
+	Overwrite bool          `json:"overwrite"`
+	Message   string        `json:"message"`
+}
+
+// DashboardSaveResponse is the structure of the response from the /api/dashboards/db endpoint
+type DashboardSaveResponse struct {
+	ID      int    `json:"id"`
+	UID     string `json:"uid"`
+	URL     string `json:"url"`
+	Status  string `json:"status"`
+	Version int    `json:"version"`
+	Slug    string `json:"slug"`
+}
+
+// ProvisionedRuleGroup is the structure of a rule group in the alerting provisioning API.
+type ProvisionedRuleGroup struct {
+	Title     string                 `json:"title"`
+	FolderUID string                 `json:"folderUid"`
+	Interval  int64                  `json:"interval"` // Seconds
+	Rules     []ProvisionedAlertRule `json:"rules"`
+}
+
+// ProvisionedAlertRule is the structure of a Grafana-managed alert rule in the alerting provisioning API.
+type ProvisionedAlertRule struct {
+	UID          string            `json:"uid,omitempty"`
+	Title        string            `json:"title"`
+	Condition    string            `json:"condition"`
+	Data         []AlertQueryModel `json:"data"`
+	NoDataState  string            `json:"noDataState"`
+	ExecErrState string            `json:"execErrState"`
+	For          string            `json:"for"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	FolderUID    string            `json:"folderUID"`
+	RuleGroup    string            `json:"ruleGroup"`
+	IsPaused     bool              `json:"isPaused"`
+}
+
+// AlertQueryModel is a single query or expression of a Grafana-managed alert rule.
+type AlertQueryModel struct {
+	RefID             string                 `json:"refId"`
+	RelativeTimeRange RelativeTimeRange      `json:"relativeTimeRange"`
+	DatasourceUID     string                 `json:"datasourceUid"`
+	Model             map[string]interface{} `json:"model"`
+}
+
+// RelativeTimeRange is the query time range in seconds relative to the evaluation time.
+type RelativeTimeRange struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+}
+
+// RulerRuleGroup is a rule group in the Prometheus rule file format used by Cortex-compatible rulers.
+type RulerRuleGroup struct {
+	Name     string      `yaml:"name"`
+	Interval string      `yaml:"interval,omitempty"`
+	Rules    []RulerRule `yaml:"rules"`
+}
+
+// RulerRule is a single alerting or recording rule in the Prometheus rule file format.
+type RulerRule struct {
+	Alert       string            `yaml:"alert,omitempty"`
+	Record      string            `yaml:"record,omitempty"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
@@ -0,0 +1,54 @@
+package grafana
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// Policies for a dashboard JSON UID already used by another dashboard on the instance
+const (
+	UIDCollisionRegenerate = "regenerate" // Drop the UID, Grafana generates a new one
+	UIDCollisionFail       = "fail"       // Stop the run
+	UIDCollisionAdopt      = "adopt"      // Take over the other dashboard
+)
+
+// resolveLocalDashboardUID decides the UID of a dashboard that doesn't exist under its name yet. The UID of the
+// JSON is kept when it is free; when it belongs to another, unmanaged dashboard the collision policy applies.
+// The dashboard taken over is returned with the adopt policy.
+func resolveLocalDashboardUID(client GrafanaAPI, cfg Dashboard, rawDashboard DashboardJSON, log *slog.Logger) (string, *DashboardSearchResponse, error) {
+	localUID, _ := rawDashboard["uid"].(string)
+	if localUID == "" {
+		return "", nil, nil
+	}
+
+	live, err := client.GetDashboardByUID(localUID)
+	if errors.Is(err, ErrNotFound) {
+		return localUID, nil, nil
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to check the dashboard UID '%s': %w", localUID, err)
+	}
+
+	liveTitle, _ := live.Dashboard["title"].(string)
+	location := fmt.Sprintf("'%s' in folder '%s'", liveTitle, live.Meta.FolderTitle)
+
+	switch cfg.UIDCollision {
+	case UIDCollisionFail:
+		return "", nil, fmt.Errorf("dashboard UID '%s' of '%s' already belongs to dashboard %s, change the UID in the JSON or set uid_collision to regenerate or adopt", localUID, cfg.Name, location)
+	case UIDCollisionAdopt:
+		log.Warn("Dashboard UID belongs to another dashboard, adopting it", "uid", localUID, "other", location)
+		liveID, _ := live.Dashboard["id"].(float64)
+		return localUID, &DashboardSearchResponse{
+			ID:          int(liveID),
+			UID:         localUID,
+			Title:       liveTitle,
+			URL:         live.Meta.URL,
+			FolderUID:   live.Meta.FolderUID,
+			FolderTitle: live.Meta.FolderTitle,
+		}, nil
+	default:
+		log.Warn("Dashboard UID belongs to another dashboard, generating a new one", "uid", localUID, "other", location)
+		return "", nil, nil
+	}
+}
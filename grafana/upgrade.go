@@ -0,0 +1,159 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+)
+
+// UpgradeReport is the migration report of the managed dashboards and the alerting before a Grafana upgrade
+type UpgradeReport struct {
+	CurrentVersion string
+	TargetVersion  string
+	Issues         []ValidationIssue // Errors break on the target version, warnings are deprecations to plan for
+}
+
+// migratedPanels are the AngularJS panels Grafana 11 migrates on load to the React panels replacing them
+var migratedPanels = map[string]string{
+	"graph":                    "timeseries",
+	"table-old":                "table",
+	"singlestat":               "stat",
+	"grafana-singlestat-panel": "stat",
+	"grafana-piechart-panel":   "piechart",
+	"grafana-worldmap-panel":   "geomap",
+}
+
+// angularPanels are known AngularJS panel plugins without an automatic migration and their core replacements
+var angularPanels = map[string]string{
+	"natel-discrete-panel":          "state-timeline",
+	"flant-statusmap-panel":         "status-history",
+	"briangann-gauge-panel":         "gauge",
+	"michaeldmoore-multistat-panel": "bargauge",
+}
+
+// Major versions changing the support of AngularJS and the legacy alerting
+const (
+	angularDisabledVersion = 11 // angular_support_enabled defaults to false, legacy alerting is removed
+	angularRemovedVersion  = 12
+)
+
+// CheckUpgrade analyzes the live versions of the managed dashboards, or their files when they don't exist yet,
+// and the installed plugins for deprecated panel types, AngularJS plugins and legacy alerting. Issues are errors
+// when they break on the target version, e.g. `11.x`.
+func CheckUpgrade(client GrafanaAPI, cfg Config, target string, log *slog.Logger) (*UpgradeReport, error) {
+	targetMajor := parseMajorVersion(target)
+	if targetMajor == 0 {
+		return nil, fmt.Errorf("invalid target version '%s', expected e.g. 11.x or 11.2.0", target)
+	}
+
+	settings, err := client.GetFrontendSettings()
+	if err != nil {
+		return nil, err
+	}
+	plugins, err := client.GetPlugins()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &UpgradeReport{CurrentVersion: settings.BuildInfo.Version, TargetVersion: target}
+	add := func(breaks bool, resource string, format string, args ...interface{}) {
+		severity := SeverityWarning
+		if breaks {
+			severity = SeverityError
+		}
+		report.Issues = append(report.Issues, ValidationIssue{Severity: severity, Resource: resource, Message: fmt.Sprintf(format, args...)})
+	}
+	angularBreaks := targetMajor >= angularDisabledVersion
+	angularSupport := fmt.Sprintf("AngularJS support is disabled by default in Grafana %d and removed in %d", angularDisabledVersion, angularRemovedVersion)
+
+	angular := map[string]bool{}
+	for _, plugin := range plugins {
+		if !plugin.IsAngular() {
+			continue
+		}
+		angular[plugin.ID] = true
+		add(angularBreaks, fmt.Sprintf("%s plugin '%s'", plugin.Type, plugin.ID), "version %s uses AngularJS, %s, update it or replace it", plugin.Info.Version, angularSupport)
+	}
+
+	for _, dashboard := range cfg.Dashboards {
+		resource := fmt.Sprintf("dashboard '%s'", dashboard.Name)
+		model, err := upgradeDashboardModel(client, dashboard, log)
+		if err != nil {
+			return nil, err
+		}
+		walkDashboardPanels(model, func(panel map[string]interface{}) {
+			title, _ := panel["title"].(string)
+			panelType, _ := panel["type"].(string)
+			if replacement, ok := migratedPanels[panelType]; ok {
+				add(false, resource, "panel '%s' uses the deprecated %s panel, Grafana %d migrates it to %s on load but the provisioned JSON keeps it, replace it with %s in the file", title, panelType, angularDisabledVersion, replacement, replacement)
+			} else if replacement, known := angularPanels[panelType]; known || angular[panelType] {
+				suggestion := "update or replace the plugin"
+				if replacement != "" {
+					suggestion = "replace it with the " + replacement + " panel"
+				}
+				add(angularBreaks, resource, "panel '%s' uses the AngularJS %s panel, %s, %s", title, panelType, angularSupport, suggestion)
+			}
+			if _, legacy := panel["alert"]; legacy {
+				add(angularBreaks, resource, "panel '%s' has a legacy dashboard alert, legacy alerting is removed in Grafana %d, define it in alerting.rule_groups", title, angularDisabledVersion)
+			}
+		})
+	}
+
+	if !settings.UnifiedAlertingEnabled {
+		add(angularBreaks, "alerting", "legacy alerting is enabled, Grafana %d removes it, enable unified alerting first", angularDisabledVersion)
+		channels, err := client.GetLegacyNotificationChannels()
+		if err != nil {
+			log.Warn("Failed to list the legacy notification channels", "error", err)
+		} else if len(channels) > 0 {
+			add(angularBreaks, "alerting", "%d legacy notification channels, convert them with `migrate notification-channels`", len(channels))
+		}
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool { return report.Issues[i].Severity < report.Issues[j].Severity })
+	log.Info("Upgrade compatibility checked", "current", report.CurrentVersion, "target", target, "issues", len(report.Issues))
+	return report, nil
+}
+
+// upgradeDashboardModel returns the live model of the managed dashboard, or its file when it doesn't exist yet
+func upgradeDashboardModel(client GrafanaAPI, dashboard Dashboard, log *slog.Logger) (DashboardJSON, error) {
+	live, found, err := client.FindFirstDashboardByFolderAndName(dashboard.Name, dashboard.Folder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find dashboard '%s': %w", dashboard.Name, err)
+	}
+	if found {
+		response, err := client.GetDashboardByUID(live.UID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get dashboard '%s': %w", dashboard.Name, err)
+		}
+		return response.Dashboard, nil
+	}
+
+	data, err := loadDashboardJSON(dashboard, log)
+	if err != nil {
+		return nil, err
+	}
+	var model DashboardJSON
+	if err := json.Unmarshal(data, &model); err != nil {
+		return nil, fmt.Errorf("failed to parse dashboard file %s: %w", dashboard.File, err)
+	}
+	return model, nil
+}
+
+// walkDashboardPanels calls visit for every panel of the dashboard, the panels of collapsed rows included
+func walkDashboardPanels(dashboard DashboardJSON, visit func(panel map[string]interface{})) {
+	var walk func(panels []interface{})
+	walk = func(panels []interface{}) {
+		for _, item := range panels {
+			panel, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			visit(panel)
+			nested, _ := panel["panels"].([]interface{})
+			walk(nested)
+		}
+	}
+	panels, _ := dashboard["panels"].([]interface{})
+	walk(panels)
+}
@@ -0,0 +1,233 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// Severities of the config validation issues
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// ValidationIssue is a problem found in the config without contacting Grafana
+type ValidationIssue struct {
+	Severity string // SeverityError fails the validation, SeverityWarning may be fine depending on the live state
+	Resource string // e.g. "dashboard 'Overview'"
+	Message  string
+}
+
+// String renders the issue with its severity and resource
+func (issue ValidationIssue) String() string {
+	return fmt.Sprintf("%s: %s: %s", issue.Severity, issue.Resource, issue.Message)
+}
+
+// ValidateConfig checks the config without contacting Grafana: unique names, dashboards in configured folders,
+// dashboard files that parse with their values placeholders, `__inputs` and links covered by the config, and
+// references to data sources, teams and rulers, and the folder capacity. References to resources that may already
+// exist in Grafana are warnings. grafana.com dashboards are not downloaded.
+func ValidateConfig(cfg Config) []ValidationIssue {
+	issues := []ValidationIssue{}
+	add := func(severity string, resource string, format string, args ...interface{}) {
+		issues = append(issues, ValidationIssue{Severity: severity, Resource: resource, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// Resources without an org are provisioned into the one of the run
+	orgOf := func(org string) string {
+		if org == "" {
+			return cfg.Grafana.Org
+		}
+		return org
+	}
+
+	dataSources := map[string]bool{}
+	dataSourceTypes := map[string]string{}
+	dataSourceOrgs := map[string]string{}
+	for _, dataSource := range cfg.DataSources {
+		if dataSources[dataSource.Name] {
+			add(SeverityError, fmt.Sprintf("data source '%s'", dataSource.Name), "configured more than once")
+		}
+		dataSources[dataSource.Name] = true
+		dataSourceTypes[dataSource.Name] = dataSource.Type
+		dataSourceOrgs[dataSource.Name] = orgOf(dataSource.Org)
+	}
+
+	teams := map[string]bool{}
+	for _, team := range cfg.Teams {
+		if teams[team.Name] {
+			add(SeverityError, fmt.Sprintf("team '%s'", team.Name), "configured more than once")
+		}
+		teams[team.Name] = true
+	}
+
+	folders := map[string]bool{}
+	for _, folder := range cfg.Folders {
+		resource := fmt.Sprintf("folder '%s'", folder.Name)
+		if folders[folder.Name] {
+			add(SeverityError, resource, "configured more than once")
+		}
+		folders[folder.Name] = true
+		if folder.OwnerTeam != "" {
+			teams[folder.OwnerTeam] = true // Created when missing
+		}
+	}
+	checkPermissions := func(resource string, permissions []Permission) {
+		for _, permission := range permissions {
+			if permission.Team != "" && !teams[permission.Team] {
+				add(SeverityWarning, resource, "permission team '%s' is not in teams, it must already exist in Grafana", permission.Team)
+			}
+		}
+	}
+	for _, folder := range cfg.Folders {
+		checkPermissions(fmt.Sprintf("folder '%s'", folder.Name), folder.Permissions)
+	}
+
+	dashboards := map[string]bool{}
+	for _, dashboard := range cfg.Dashboards {
+		dashboards[dashboard.Name] = true
+	}
+	seen := map[string]bool{}
+	for _, dashboard := range cfg.Dashboards {
+		resource := fmt.Sprintf("dashboard '%s'", dashboard.Name)
+		key := strings.ToLower(dashboard.Folder) + "/" + dashboard.Name
+		if seen[key] {
+			add(SeverityError, resource, "configured more than once in folder '%s'", dashboard.Folder)
+		}
+		seen[key] = true
+
+		if dashboard.Folder != "" && !strings.EqualFold(dashboard.Folder, "General") && !folders[dashboard.Folder] {
+			add(SeverityError, resource, "folder '%s' is not in folders", dashboard.Folder)
+		}
+		importNames := map[string]bool{}
+		for _, dashboardImport := range dashboard.Imports {
+			if importNames[dashboardImport.Name] {
+				add(SeverityError, resource, "import '%s' is mapped more than once", dashboardImport.Name)
+			}
+			importNames[dashboardImport.Name] = true
+			if !dataSources[dashboardImport.DataSource] {
+				add(SeverityWarning, resource, "import '%s' uses data source '%s', which is not in datasources, it must already exist in Grafana", dashboardImport.Name, dashboardImport.DataSource)
+				continue
+			}
+			if dataSourceOrgs[dashboardImport.DataSource] != orgOf(dashboard.Org) {
+				add(SeverityError, resource, "import '%s' uses data source '%s' of another organization, data sources are only visible in their own", dashboardImport.Name, dashboardImport.DataSource)
+			}
+			if dashboard.RequiresDataSourceType != "" && !isDataSourceType(dataSourceTypes[dashboardImport.DataSource], dashboard.RequiresDataSourceType) {
+				add(SeverityError, resource, "requires %s data sources, but import '%s' uses '%s' of type '%s'", dashboard.RequiresDataSourceType, dashboardImport.Name, dashboardImport.DataSource, dataSourceTypes[dashboardImport.DataSource])
+			}
+		}
+		checkPermissions(resource, dashboard.Permissions)
+
+		if dashboard.GnetID != 0 {
+			continue
+		}
+		for _, problem := range validateDashboardFile(dashboard, cfg.Values, cfg.ValueSources, dashboards, dataSourceTypes) {
+			add(SeverityError, resource, "%s", problem)
+		}
+	}
+
+	for _, stat := range FolderDashboardCounts(nil, cfg.Dashboards, cfg.FolderCapacity) {
+		if !stat.OverLimit {
+			continue
+		}
+		severity := SeverityWarning
+		if cfg.FolderCapacity.Enforce {
+			severity = SeverityError
+		}
+		add(severity, fmt.Sprintf("folder '%s'", stat.Folder), "holds %d configured dashboards, more than the %d of folder_capacity, split it into subfolders", stat.Configured, cfg.FolderCapacity.MaxDashboards)
+	}
+
+	for _, annotation := range cfg.Annotations {
+		resource := fmt.Sprintf("annotation '%s'", annotation.Name)
+		if !dataSources[annotation.DataSource] {
+			add(SeverityWarning, resource, "data source '%s' is not in datasources, it must already exist in Grafana", annotation.DataSource)
+		}
+		for _, name := range annotation.Dashboards {
+			if !dashboards[name] {
+				add(SeverityError, resource, "dashboard '%s' is not in dashboards", name)
+			}
+		}
+	}
+
+	rulers := map[string]bool{}
+	for _, ruler := range cfg.Rulers {
+		rulers[ruler.Name] = true
+	}
+	for _, group := range cfg.AlertRuleGroups {
+		resource := fmt.Sprintf("rule group '%s'", group.Name)
+		if group.Ruler != "" && !rulers[group.Ruler] {
+			add(SeverityError, resource, "ruler '%s' is not in rulers", group.Ruler)
+		}
+		if group.Ruler == "" && !folders[group.Folder] {
+			add(SeverityWarning, resource, "folder '%s' is not in folders, it must already exist in Grafana", group.Folder)
+		}
+	}
+
+	return issues
+}
+
+// validateDashboardFile checks that the dashboard file parses and that its values placeholders, `__inputs` and
+// `${dashboard:NAME}` links are covered by the config. Inputs mapped to configured data sources must match
+// the plugin of the input.
+func validateDashboardFile(dashboard Dashboard, values map[string]interface{}, sources []ValueSource, dashboards map[string]bool, dataSourceTypes map[string]string) []string {
+	data, err := loadDashboardJSON(dashboard, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		return []string{err.Error()}
+	}
+	var model DashboardJSON
+	if err := json.Unmarshal(data, &model); err != nil {
+		return []string{fmt.Sprintf("failed to parse dashboard file %s: %v", dashboard.File, err)}
+	}
+
+	// The values of the value sources are only fetched when provisioning, their placeholders pass
+	offline := map[string]interface{}{}
+	for name, value := range values {
+		offline[name] = value
+	}
+	missing := map[string]bool{}
+	renderValuePlaceholders(map[string]interface{}(model), values, missing)
+	for _, source := range sources {
+		for name := range missing {
+			if strings.HasPrefix(name, source.Name+".") {
+				offline[name] = ""
+			}
+		}
+	}
+
+	problems := []string{}
+	if _, err := renderDashboardValues(model, offline); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	imported := map[string]string{}
+	for _, dashboardImport := range dashboard.Imports {
+		imported[dashboardImport.Name] = dashboardImport.DataSource
+	}
+	inputs := dataSourceInputs(model)
+	names := []string{}
+	for name := range inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		dataSource, mapped := imported[name]
+		if !mapped {
+			problems = append(problems, fmt.Sprintf("input '%s' of the dashboard file is not mapped in imports", name))
+			continue
+		}
+		if dataSourceType, configured := dataSourceTypes[dataSource]; configured && inputs[name] != "" && !isDataSourceType(dataSourceType, inputs[name]) {
+			problems = append(problems, fmt.Sprintf("input '%s' expects a %s data source, but '%s' is of type '%s'", name, inputs[name], dataSource, dataSourceType))
+		}
+	}
+
+	for _, target := range dashboardLinkTargets(model) {
+		if !dashboards[target] {
+			problems = append(problems, fmt.Sprintf("links to dashboard '%s', which is not in dashboards", target))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,311 @@
+package grafana
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ilya-pishchalnikov/grafana-provisioner/buildinfo"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Value source types
+const (
+	ValueSourceConsul = "consul"
+	ValueSourceEtcd   = "etcd"
+	ValueSourceHTTP   = "http"
+)
+
+// defaultValueSourceTTL is how long fetched values are reused when the source sets no cache TTL
+const defaultValueSourceTTL = time.Minute
+
+// ValueSource is an external store of dashboard values, e.g. the addresses of services discovered by Consul.
+// Its values are substituted into `${values.NAME.KEY}` placeholders, fetched when the first dashboard using
+// them is rendered.
+type ValueSource struct {
+	Name     string        // First segment of the placeholders taking values from the source
+	Type     string        // ValueSourceConsul, ValueSourceEtcd or ValueSourceHTTP
+	URL      string        // Consul or etcd address, or the JSON endpoint
+	Prefix   string        // Consul or etcd key prefix, the keys below it become dotted names
+	Token    string        // Consul ACL token, etcd auth token or bearer token of the endpoint
+	CacheTTL time.Duration // Fetched values are reused this long, also across the runs of a daemon
+}
+
+// cachedValues are the values of a source and when they were fetched
+type cachedValues struct {
+	values    map[string]interface{}
+	fetchedAt time.Time
+}
+
+// valueSourceCache keeps the fetched values per source for the lifetime of the process
+var valueSourceCache = struct {
+	sync.Mutex
+	entries map[string]cachedValues
+}{entries: map[string]cachedValues{}}
+
+// Values returns the values of the source by their dotted names, from the cache while it is fresh. A source
+// that can't be reached falls back to the values fetched last, if any.
+func (source ValueSource) Values(log *slog.Logger) (map[string]interface{}, error) {
+	ttl := source.CacheTTL
+	if ttl <= 0 {
+		ttl = defaultValueSourceTTL
+	}
+	key := source.Type + " " + source.URL + " " + source.Prefix
+
+	valueSourceCache.Lock()
+	defer valueSourceCache.Unlock()
+	cached, found := valueSourceCache.entries[key]
+	if found && time.Since(cached.fetchedAt) < ttl {
+		return cached.values, nil
+	}
+
+	values, err := source.fetch()
+	if err != nil {
+		if found {
+			log.Warn("Value source unavailable, using the values fetched before", "source", source.Name, "fetched_at", cached.fetchedAt.Format(time.RFC3339), "error", err)
+			return cached.values, nil
+		}
+		return nil, fmt.Errorf("failed to fetch the values of source '%s': %w", source.Name, err)
+	}
+
+	log.Info("Values fetched from the value source", "source", source.Name, "type", source.Type, "count", len(values))
+	valueSourceCache.entries[key] = cachedValues{values: values, fetchedAt: time.Now()}
+	return values, nil
+}
+
+// fetch reads all values of the source
+func (source ValueSource) fetch() (map[string]interface{}, error) {
+	baseURL := strings.TrimRight(source.URL, "/")
+	switch source.Type {
+	case ValueSourceConsul:
+		return source.fetchConsul(baseURL)
+	case ValueSourceEtcd:
+		return source.fetchEtcd(baseURL)
+	case ValueSourceHTTP:
+		body, err := source.request("GET", source.URL, nil, map[string]string{"Authorization": bearer(source.Token)})
+		if err != nil {
+			return nil, err
+		}
+		var document map[string]interface{}
+		if err := json.Unmarshal(body, &document); err != nil {
+			return nil, fmt.Errorf("failed to decode JSON object: %w", err)
+		}
+		values := map[string]interface{}{}
+		flattenSourceValues("", document, values)
+		return values, nil
+	default:
+		return nil, fmt.Errorf("unsupported value source type '%s', expected consul, etcd or http", source.Type)
+	}
+}
+
+// fetchConsul reads the keys below the prefix from the Consul KV store
+func (source ValueSource) fetchConsul(baseURL string) (map[string]interface{}, error) {
+	prefix := strings.Trim(source.Prefix, "/")
+	endpoint := baseURL + "/v1/kv/" + (&url.URL{Path: prefix}).EscapedPath() + "?recurse=true"
+	body, err := source.request("GET", endpoint, nil, map[string]string{"X-Consul-Token": source.Token})
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return map[string]interface{}{}, nil // No keys below the prefix
+		}
+		return nil, err
+	}
+
+	var pairs []struct {
+		Key   string  `json:"Key"`
+		Value *string `json:"Value"` // Base64, null for folders
+	}
+	if err := json.Unmarshal(body, &pairs); err != nil {
+		return nil, fmt.Errorf("failed to decode Consul KV response: %w", err)
+	}
+
+	values := map[string]interface{}{}
+	for _, pair := range pairs {
+		if pair.Value == nil {
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(*pair.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode the value of Consul key '%s': %w", pair.Key, err)
+		}
+		if name := dottedKey(pair.Key, prefix); name != "" {
+			values[name] = decodeSourceValue(string(value))
+		}
+	}
+	return values, nil
+}
+
+// fetchEtcd reads the keys below the prefix through the etcd v3 JSON gateway
+func (source ValueSource) fetchEtcd(baseURL string) (map[string]interface{}, error) {
+	start, end := etcdPrefixRange(source.Prefix)
+	request, err := json.Marshal(map[string]string{
+		"key":       base64.StdEncoding.EncodeToString(start),
+		"range_end": base64.StdEncoding.EncodeToString(end),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal etcd range request: %w", err)
+	}
+	body, err := source.request("POST", baseURL+"/v3/kv/range", bytes.NewReader(request), map[string]string{"Authorization": source.Token})
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		KVs []struct {
+			Key   string `json:"key"`
+			Value string `json:"value"`
+		} `json:"kvs"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode etcd range response: %w", err)
+	}
+
+	values := map[string]interface{}{}
+	for _, kv := range response.KVs {
+		key, keyErr := base64.StdEncoding.DecodeString(kv.Key)
+		value, valueErr := base64.StdEncoding.DecodeString(kv.Value)
+		if keyErr != nil || valueErr != nil {
+			return nil, fmt.Errorf("failed to decode etcd key-value pair '%s'", kv.Key)
+		}
+		if name := dottedKey(string(key), strings.Trim(source.Prefix, "/")); name != "" {
+			values[name] = decodeSourceValue(string(value))
+		}
+	}
+	return values, nil
+}
+
+// request sends a request to the source and returns the body of a successful response
+func (source ValueSource) request(method string, target string, body io.Reader, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", buildinfo.UserAgent())
+	for key, value := range headers {
+		if value != "" {
+			req.Header.Set(key, value)
+		}
+	}
+
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", target, ErrNotFound)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s returned status %d: %s", target, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return respBody, nil
+}
+
+// bearer returns the Authorization header value of the token, empty without one
+func bearer(token string) string {
+	if token == "" {
+		return ""
+	}
+	return "Bearer " + token
+}
+
+// dottedKey converts a KV store key below the prefix into a dotted value name, e.g. services/api/host
+// below services becomes api.host
+func dottedKey(key string, prefix string) string {
+	key = strings.Trim(strings.TrimPrefix(strings.Trim(key, "/"), prefix), "/")
+	return strings.ReplaceAll(key, "/", ".")
+}
+
+// etcdPrefixRange returns the key range of all etcd keys starting with the prefix, all keys for an empty one
+func etcdPrefixRange(prefix string) ([]byte, []byte) {
+	if prefix == "" {
+		return []byte{0}, []byte{0}
+	}
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return []byte(prefix), end[:i+1]
+		}
+	}
+	return []byte(prefix), []byte{0}
+}
+
+// decodeSourceValue keeps numbers and booleans stored as text typed, so thresholds stay numbers
+func decodeSourceValue(value string) interface{} {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(value), &decoded); err == nil {
+		switch decoded.(type) {
+		case float64, bool:
+			return decoded
+		}
+	}
+	return value
+}
+
+// flattenSourceValues copies the nested JSON values into the flat map under dotted names
+func flattenSourceValues(prefix string, nested map[string]interface{}, values map[string]interface{}) {
+	for key, value := range nested {
+		name := key
+		if prefix != "" {
+			name = prefix + "." + key
+		}
+		if child, ok := value.(map[string]interface{}); ok {
+			flattenSourceValues(name, child, values)
+			continue
+		}
+		values[name] = value
+	}
+}
+
+// renderDashboardSourcedValues is renderDashboardValues with the placeholders missing from the values file
+// taken from the value source named by their first segment, e.g. `${values.consul.api.host}`. Only the
+// sources the dashboard uses are fetched.
+func renderDashboardSourcedValues(dashboard DashboardJSON, values map[string]interface{}, sources []ValueSource, log *slog.Logger) (DashboardJSON, error) {
+	missing := map[string]bool{}
+	renderValuePlaceholders(map[string]interface{}(dashboard), values, missing)
+	if len(missing) == 0 || len(sources) == 0 {
+		return renderDashboardValues(dashboard, values)
+	}
+
+	merged := map[string]interface{}{}
+	for name, value := range values {
+		merged[name] = value
+	}
+	for _, source := range sources {
+		if !usesValueSource(missing, source) {
+			continue
+		}
+		fetched, err := source.Values(log)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range fetched {
+			merged[source.Name+"."+name] = value
+		}
+	}
+	return renderDashboardValues(dashboard, merged)
+}
+
+// usesValueSource reports whether any of the placeholder names takes its value from the source
+func usesValueSource(names map[string]bool, source ValueSource) bool {
+	for name := range names {
+		if strings.HasPrefix(name, source.Name+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package grafana
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// Check is the outcome of verifying a single configured resource against the live instance.
+type Check struct {
+	Kind    string
+	Name    string
+	Passed  bool
+	Skipped bool // The resource can't be verified through the Grafana API
+	Message string
+}
+
+// Verify asserts that the live Grafana instance matches the config without changing anything.
+// Each configured resource produces one check; an error is returned only if the live state can't be read.
+func Verify(client GrafanaAPI, cfg Config, log *slog.Logger) ([]Check, error) {
+	log.Info("Verifying Grafana instance against config")
+	checks := []Check{}
+
+	// 1. Folders
+	liveFolders, err := client.GetFolders()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+	for _, folder := range cfg.Folders {
+		check := Check{Kind: KindFolder, Name: folder.Name, Message: "folder is missing"}
+		for _, liveFolder := range liveFolders {
+			if liveFolder.Title == folder.Name {
+				check.Passed = true
+				check.Message = fmt.Sprintf("folder exists (uid %s)", liveFolder.UID)
+				break
+			}
+		}
+		checks = append(checks, check)
+	}
+
+	// 2. Data sources
+	liveSources, err := client.GetDataSources()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list data sources: %w", err)
+	}
+	for _, dataSource := range cfg.DataSources {
+		check := Check{
+			Kind:    KindDataSource,
+			Name:    dataSource.Name,
+			Message: fmt.Sprintf("no data source matching %s", describeDataSourceIdentity(dataSource)),
+		}
+		for _, source := range liveSources {
+			if dataSourceMatches(source, dataSource) {
+				check.Passed = true
+				check.Message = fmt.Sprintf("data source exists as '%s' (uid %s)", source.Name, source.UID)
+				break
+			}
+		}
+		checks = append(checks, check)
+	}
+
+	// 3. Dashboards
+	for _, dashboard := range cfg.Dashboards {
+		check, err := verifyDashboard(client, dashboard, log)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, check)
+	}
+
+	// 4. Alert rule groups
+	alertChecks, err := verifyAlertRuleGroups(client, cfg, liveFolders)
+	if err != nil {
+		return nil, err
+	}
+	checks = append(checks, alertChecks...)
+
+	log.Info("Verification completed", "checks", len(checks))
+	return checks, nil
+}
+
+// verifyDashboard checks that the dashboard exists in its configured folder
+func verifyDashboard(client GrafanaAPI, dashboard Dashboard, log *slog.Logger) (Check, error) {
+	check := Check{Kind: KindDashboard, Name: dashboard.Name}
+
+	candidates, err := client.FindDashboardsByName(dashboard.Name)
+	if err != nil {
+		return check, fmt.Errorf("failed to search dashboard '%s': %w", dashboard.Name, err)
+	}
+
+	for _, candidate := range candidates {
+		if isSameFolder(dashboard.Folder, candidate.FolderTitle) {
+			check.Passed = true
+			check.Message = fmt.Sprintf("dashboard exists (uid %s)", candidate.UID)
+			return check, nil
+		}
+	}
+
+	check.Message = "dashboard is missing"
+	if len(candidates) > 0 {
+		check.Message = fmt.Sprintf("dashboard is in folder '%s' instead of '%s'", candidates[0].FolderTitle, dashboard.Folder)
+	}
+	return check, nil
+}
+
+// verifyAlertRuleGroups checks that every Grafana-managed rule exists in its folder and group.
+// Groups pushed to Mimir/Loki rulers are reported as skipped.
+func verifyAlertRuleGroups(client GrafanaAPI, cfg Config, liveFolders []FolderResponse) ([]Check, error) {
+	checks := []Check{}
+	if len(cfg.AlertRuleGroups) == 0 {
+		return checks, nil
+	}
+
+	liveRules, err := client.GetAlertRules()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list alert rules: %w", err)
+	}
+
+	folderUIDs := map[string]string{}
+	for _, folder := range liveFolders {
+		folderUIDs[folder.Title] = folder.UID
+	}
+
+	for _, group := range cfg.AlertRuleGroups {
+		check := Check{Kind: KindAlertRuleGroup, Name: group.Name}
+
+		if group.Ruler != "" {
+			check.Skipped = true
+			check.Message = fmt.Sprintf("rule group is managed by ruler '%s'", group.Ruler)
+			checks = append(checks, check)
+			continue
+		}
+
+		missing := []string{}
+		for _, rule := range group.Rules {
+			if findExistingRuleUID(liveRules, folderUIDs[group.Folder], group.Name, rule.Title) == "" {
+				missing = append(missing, rule.Title)
+			}
+		}
+
+		check.Passed = len(missing) == 0
+		check.Message = fmt.Sprintf("all %d rules exist", len(group.Rules))
+		if !check.Passed {
+			check.Message = fmt.Sprintf("missing rules: %v", missing)
+		}
+		checks = append(checks, check)
+	}
+
+	return checks, nil
+}
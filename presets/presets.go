@@ -0,0 +1,65 @@
+// Package presets contains built-in bundles of curated grafana.com dashboards
+// together with the data source inputs they need.
+package presets
+
+import "sort"
+
+// Dashboard is a grafana.com dashboard included in a preset.
+type Dashboard struct {
+	Name     string   // Dashboard title in Grafana
+	GnetID   int      // grafana.com dashboard ID
+	Revision int      // grafana.com revision, 0 means the latest one
+	Inputs   []string // Dashboard __inputs wired to the preset data source
+}
+
+// Preset bundles dashboards provisioned into a single folder and wired to a single data source.
+type Preset struct {
+	Name        string
+	Description string
+	Folder      string // Default folder, can be overridden in config
+	Dashboards  []Dashboard
+}
+
+// builtIn is the registry of all presets shipped with the provisioner
+var builtIn = map[string]Preset{
+	"postgres-observability": {
+		Name:        "postgres-observability",
+		Description: "PostgreSQL server metrics from postgres_exporter scraped by Prometheus",
+		Folder:      "PostgreSQL",
+		Dashboards: []Dashboard{
+			{Name: "PostgreSQL Database", GnetID: 9628, Inputs: []string{"DS_PROMETHEUS"}},
+		},
+	},
+	"kubernetes-cluster": {
+		Name:        "kubernetes-cluster",
+		Description: "Kubernetes cluster resource usage from cAdvisor and kube-state-metrics scraped by Prometheus",
+		Folder:      "Kubernetes",
+		Dashboards: []Dashboard{
+			{Name: "Kubernetes Cluster Monitoring", GnetID: 315, Inputs: []string{"DS_PROMETHEUS"}},
+		},
+	},
+	"nginx": {
+		Name:        "nginx",
+		Description: "NGINX connections and requests from nginx-prometheus-exporter scraped by Prometheus",
+		Folder:      "NGINX",
+		Dashboards: []Dashboard{
+			{Name: "NGINX Exporter", GnetID: 12708, Inputs: []string{"DS_PROMETHEUS"}},
+		},
+	},
+}
+
+// Get returns the built-in preset with the given name
+func Get(name string) (Preset, bool) {
+	preset, ok := builtIn[name]
+	return preset, ok
+}
+
+// Names returns the names of all built-in presets in alphabetical order
+func Names() []string {
+	names := make([]string, 0, len(builtIn))
+	for name := range builtIn {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}